@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/diother/go-invoices/config"
+	"github.com/diother/go-invoices/database"
+	"github.com/diother/go-invoices/internal/gateway"
+	"github.com/diother/go-invoices/internal/repository"
+	"github.com/diother/go-invoices/internal/services"
+)
+
+const dateLayout = "2006-01-02"
+
+func main() {
+	fromFlag := flag.String("from", "", "Import charges and payouts created on or after this date (YYYY-MM-DD)")
+	toFlag := flag.String("to", "", "Import charges and payouts created on or before this date (YYYY-MM-DD)")
+	dryRun := flag.Bool("dry-run", false, "Report what would be imported without writing to the database")
+
+	flag.Parse()
+
+	stripeKey, _, dsn, err := config.LoadEnv()
+	if err != nil {
+		log.Fatalf("Environment variable is missing: %v", err)
+	}
+	location, err := config.LoadTimezone()
+	if err != nil {
+		log.Fatalf("Environment variable is invalid: %v", err)
+	}
+	defaultSeries, currencySeries, err := config.LoadInvoiceSeries()
+	if err != nil {
+		log.Fatalf("Environment variable is invalid: %v", err)
+	}
+	from, to := parseFlags(*fromFlag, *toFlag, location)
+	db, err := database.InitDB(dsn)
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %v", err)
+	}
+	if err = database.ApplyMigrations(dsn); err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	}
+	webhookRepo := repository.NewWebhookRepository(db)
+	pwaRepo := repository.NewPWARepository(db)
+
+	stripeGateway := gateway.NewStripeGateway(stripeKey)
+	invoiceNumbering := services.NewInvoiceNumbering(defaultSeries, currencySeries, location)
+
+	donationService := services.NewDonationService(webhookRepo, stripeGateway, invoiceNumbering)
+	payoutService := services.NewPayoutService(webhookRepo, stripeGateway, invoiceNumbering)
+	backfillService := services.NewBackfillService(pwaRepo, stripeGateway, donationService, payoutService)
+
+	summary, err := backfillService.Backfill(from, to, *dryRun)
+	if err != nil {
+		log.Fatalf("Backfill failed: %v", err)
+	}
+	printSummary(summary, *dryRun)
+}
+
+// parseFlags returns the range as [from, to), so the to date is included in full.
+// Dates are local days in the organisation timezone.
+func parseFlags(fromFlag, toFlag string, location *time.Location) (from, to time.Time) {
+	var err error
+	if fromFlag != "" {
+		if from, err = time.ParseInLocation(dateLayout, fromFlag, location); err != nil {
+			log.Fatalf("Invalid --from date: %v", err)
+		}
+	}
+	if toFlag != "" {
+		if to, err = time.ParseInLocation(dateLayout, toFlag, location); err != nil {
+			log.Fatalf("Invalid --to date: %v", err)
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		log.Fatal("The --from date must not be after the --to date")
+	}
+	return
+}
+
+func printSummary(summary *services.BackfillSummary, dryRun bool) {
+	inserted := "inserted"
+	if dryRun {
+		inserted = "to insert"
+	}
+	fmt.Printf("Donations: %d %s, %d skipped, %d failed\n", summary.DonationsInserted, inserted, summary.DonationsSkipped, summary.DonationsFailed)
+	fmt.Printf("Payouts: %d %s, %d skipped, %d failed\n", summary.PayoutsInserted, inserted, summary.PayoutsSkipped, summary.PayoutsFailed)
+	for _, failure := range summary.Failures {
+		fmt.Printf("Failed %s\n", failure)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+
+	"github.com/diother/go-invoices/config"
+	"github.com/diother/go-invoices/database"
+	"github.com/diother/go-invoices/internal/documents"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/repository"
+	"github.com/diother/go-invoices/internal/services"
+	"github.com/diother/go-invoices/internal/storage"
+)
+
+// exportSystemUser is recorded as the issuer of documents first archived by an export.
+const exportSystemUser = "export"
+
+func main() {
+	monthFlag := flag.String("month", "", "Export the documents of this month (YYYY-MM)")
+	fromFlag := flag.String("from", "", "Export the documents created on or after this date (YYYY-MM-DD)")
+	toFlag := flag.String("to", "", "Export the documents created on or before this date (YYYY-MM-DD)")
+	statementsFlag := flag.String("statements", "", "Export the annual statements of every donor of this year (YYYY) instead")
+	langFlag := flag.String("lang", "", "Export the documents in this language instead of each document's own (ro, en)")
+	outFlag := flag.String("out", "", "Write the ZIP to this file (default export-<period>.zip)")
+	workersFlag := flag.Int("workers", runtime.NumCPU(), "Read this many documents at a time")
+
+	flag.Parse()
+
+	if *langFlag != "" && i18n.Language(*langFlag) == "" {
+		log.Fatalf("Unsupported --lang %s", *langFlag)
+	}
+
+	_, _, dsn, err := config.LoadEnv()
+	if err != nil {
+		log.Fatalf("Environment variable is missing: %v", err)
+	}
+	location, err := config.LoadTimezone()
+	if err != nil {
+		log.Fatalf("Environment variable is invalid: %v", err)
+	}
+	db, err := database.InitDB(dsn)
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %v", err)
+	}
+	if err = database.ApplyMigrations(dsn); err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	}
+	pwaRepo := repository.NewPWARepository(db)
+
+	accountingService := services.NewAccountingService(pwaRepo, documents.NewDocumentService(), location)
+	archiveService := services.NewArchiveService(pwaRepo, storage.NewFileStorage(config.LoadArchiveDir()), accountingService, location)
+	exportService := services.NewExportService(pwaRepo, archiveService, accountingService, location, *workersFlag)
+
+	path := *outFlag
+	if path == "" {
+		path = defaultFileName(*monthFlag, *fromFlag, *toFlag, *statementsFlag)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", path, err)
+	}
+
+	var summary *dto.ExportSummary
+	if *statementsFlag != "" {
+		summary, err = exportService.ExportDonorStatements(file, *statementsFlag, *langFlag)
+	} else {
+		summary, err = exportService.Export(file, *monthFlag, *fromFlag, *toFlag, *langFlag, exportSystemUser)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	fmt.Printf("Exported %d documents to %s\n", summary.Documents, path)
+	for _, failure := range summary.Failures {
+		fmt.Printf("Failed %s\n", failure)
+	}
+	if summary.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func defaultFileName(month, from, to, statements string) string {
+	if statements != "" {
+		return "statements-" + statements + ".zip"
+	}
+	if month != "" {
+		return "export-" + month + ".zip"
+	}
+	return "export-" + from + "_" + to + ".zip"
+}
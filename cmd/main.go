@@ -1,19 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"runtime"
 
 	"github.com/diother/go-invoices/config"
 	"github.com/diother/go-invoices/database"
 	"github.com/gorilla/mux"
 
 	"github.com/diother/go-invoices/internal/documents"
+	"github.com/diother/go-invoices/internal/gateway"
 	"github.com/diother/go-invoices/internal/handlers"
+	"github.com/diother/go-invoices/internal/mailer"
 	"github.com/diother/go-invoices/internal/middleware"
 	"github.com/diother/go-invoices/internal/repository"
 	"github.com/diother/go-invoices/internal/services"
-	"github.com/stripe/stripe-go/v79"
+	"github.com/diother/go-invoices/internal/storage"
 )
 
 func main() {
@@ -21,6 +25,30 @@ func main() {
 	if err != nil {
 		log.Fatalf("Environment variable is missing: %v", err)
 	}
+	workers, maxAttempts, err := config.LoadWorkerEnv()
+	if err != nil {
+		log.Fatalf("Environment variable is invalid: %v", err)
+	}
+	location, err := config.LoadTimezone()
+	if err != nil {
+		log.Fatalf("Environment variable is invalid: %v", err)
+	}
+	defaultSeries, currencySeries, err := config.LoadInvoiceSeries()
+	if err != nil {
+		log.Fatalf("Environment variable is invalid: %v", err)
+	}
+	smtpHost, smtpPort, smtpUsername, smtpPassword, smtpFrom, err := config.LoadSMTPEnv()
+	if err != nil {
+		log.Fatalf("Environment variable is invalid: %v", err)
+	}
+	portalSecret, portalURL, portalTTL, err := config.LoadPortalEnv()
+	if err != nil {
+		log.Fatalf("Environment variable is invalid: %v", err)
+	}
+	trustedProxies, err := config.LoadTrustedProxies()
+	if err != nil {
+		log.Fatalf("Environment variable is invalid: %v", err)
+	}
 	db, err := database.InitDB(dsn)
 	if err != nil {
 		log.Fatalf("Failed to connect to the database: %v", err)
@@ -28,34 +56,60 @@ func main() {
 	if err = database.ApplyMigrations(dsn); err != nil {
 		log.Fatalf("Failed to apply migrations: %v", err)
 	}
-	stripe.Key = stripeKey
-
-	webhookRepo := repository.NewWebhookRepository(db)
+	eventRepo := repository.NewWebhookRepository(db)
 	pwaRepo := repository.NewPWARepository(db)
 	authRepo := repository.NewAuthRepository(db)
 
-	donationService := services.NewDonationService(webhookRepo)
-	payoutService := services.NewPayoutService(webhookRepo)
+	stripeGateway := gateway.NewStripeGateway(stripeKey)
+	invoiceNumbering := services.NewInvoiceNumbering(defaultSeries, currencySeries, location)
+
+	eventService := services.NewEventService(eventRepo, maxAttempts, location)
 	documentService := documents.NewDocumentService()
-	accountingService := services.NewAccountingService(pwaRepo, documentService)
+	accountingService := services.NewAccountingService(pwaRepo, documentService, location)
 	authService := services.NewAuthService(authRepo)
+	organisationService := services.NewOrganisationService(pwaRepo, config.LoadLogoDir())
+	layoutService := services.NewLayoutService(pwaRepo, documentService)
+	archiveService := services.NewArchiveService(pwaRepo, storage.NewFileStorage(config.LoadArchiveDir()), accountingService, location)
+	exportService := services.NewExportService(pwaRepo, archiveService, accountingService, location, runtime.NumCPU())
+	// Emails are retried with the same backoff and attempt limit as webhook events.
+	smtpMailer := mailer.NewSMTPMailer(smtpHost, smtpPort, smtpUsername, smtpPassword, smtpFrom)
+	emailService := services.NewEmailService(pwaRepo, archiveService, smtpMailer, maxAttempts, location)
+	donorService := services.NewDonorService(pwaRepo, location)
+	searchService := services.NewSearchService(pwaRepo, location)
+	auditService := services.NewAuditService(pwaRepo, location)
+	portalService := services.NewPortalService(pwaRepo, accountingService, smtpMailer, portalSecret, portalURL, portalTTL, location)
 
-	// payouts := []*stripe.Payout{
-	// 	{ID: "po_1PkFJUDXCtuWOFq8DYodF1nZ", Status: "paid"},
-	// 	{ID: "po_1PZ0YuDXCtuWOFq8wiLw72fu", Status: "paid"},
-	// 	{ID: "po_1Pj9wxDXCtuWOFq8lSKRH9Jx", Status: "paid"},
-	// }
-	// for _, payout := range payouts {
-	// 	if err = payoutService.ProcessPayout(payout); err != nil {
-	// 		return
-	// 	}
-	// }
+	// Every worker gets its own repository, since a repository holds the open transaction.
+	eventWorker := services.NewEventWorker(eventService, func() services.EventProcessor {
+		return services.NewWebhookEventProcessor(repository.NewWebhookRepository(db), stripeGateway, invoiceNumbering)
+	}, workers)
+	go func() {
+		if err := eventWorker.Run(context.Background()); err != nil {
+			log.Fatalf("Event worker stopped: %v", err)
+		}
+	}()
 
+	if smtpHost != "" {
+		go services.NewEmailWorker(emailService).Run(context.Background())
+	} else {
+		log.Println("SMTP_HOST is not set, invoice emails stay queued")
+	}
+
+	handlers.TrustProxies(trustedProxies)
 	m := middleware.NewMiddleware(authService)
 
-	webhookHandler := handlers.NewWebhookHandler(donationService, payoutService, stripeEndpointSecret)
-	pwaHandler := handlers.NewPWAHandler(accountingService)
-	authHandler := handlers.NewAuthHandler(authService)
+	webhookHandler := handlers.NewWebhookHandler(eventService, stripeEndpointSecret)
+	pwaHandler := handlers.NewPWAHandler(accountingService, archiveService, eventService, auditService, location)
+	authHandler := handlers.NewAuthHandler(authService, auditService)
+	settingsHandler := handlers.NewSettingsHandler(organisationService)
+	layoutHandler := handlers.NewLayoutHandler(layoutService)
+	exportHandler := handlers.NewExportHandler(exportService, auditService)
+	statementHandler := handlers.NewStatementHandler(accountingService, exportService, auditService)
+	emailHandler := handlers.NewEmailHandler(emailService, auditService)
+	donorHandler := handlers.NewDonorHandler(donorService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	portalHandler := handlers.NewPortalHandler(portalService)
+	auditHandler := handlers.NewAuditHandler(auditService)
 
 	router := mux.NewRouter()
 
@@ -65,7 +119,36 @@ func main() {
 
 	router.Handle("/", m.HandleSessions(http.HandlerFunc(pwaHandler.HandleDashboard))).Methods("GET")
 	router.Handle("/document", m.HandleSessions(http.HandlerFunc(pwaHandler.HandleDocuments))).Methods("GET")
+	router.Handle("/archive", m.HandleSessions(http.HandlerFunc(pwaHandler.HandleArchive))).Methods("GET", "POST")
+	router.Handle("/archive/document", m.HandleSessions(http.HandlerFunc(pwaHandler.HandleArchivedDocument))).Methods("GET")
+	router.Handle("/export", m.HandleSessions(http.HandlerFunc(exportHandler.HandleExport))).Methods("GET")
+	router.Handle("/statement", m.HandleSessions(http.HandlerFunc(statementHandler.HandleStatement))).Methods("GET")
+	router.Handle("/statements", m.HandleSessions(http.HandlerFunc(statementHandler.HandleStatements))).Methods("GET")
 	router.Handle("/monthly", m.HandleSessions(http.HandlerFunc(pwaHandler.HandleMonthly))).Methods("GET")
+	router.Handle("/events", m.HandleSessions(http.HandlerFunc(pwaHandler.HandleEvents))).Methods("GET")
+	router.Handle("/events/retry", m.HandleSessions(http.HandlerFunc(pwaHandler.HandleEventRetry))).Methods("POST")
+	router.Handle("/emails", m.HandleSessions(http.HandlerFunc(emailHandler.HandleEmails))).Methods("GET")
+	router.Handle("/emails/resend", m.HandleSessions(http.HandlerFunc(emailHandler.HandleEmailResend))).Methods("POST")
+	router.Handle("/search", m.HandleSessions(http.HandlerFunc(searchHandler.HandleSearch))).Methods("GET")
+	router.Handle("/donors", m.HandleSessions(http.HandlerFunc(donorHandler.HandleDonors))).Methods("GET")
+	router.Handle("/donor", m.HandleSessions(http.HandlerFunc(donorHandler.HandleDonor))).Methods("GET", "POST")
+	router.Handle("/donor/merge", m.HandleSessions(http.HandlerFunc(donorHandler.HandleDonorMerge))).Methods("POST")
+	router.Handle("/audit", m.HandleSessions(http.HandlerFunc(auditHandler.HandleAudit))).Methods("GET")
+	router.Handle("/settings", m.HandleSessions(http.HandlerFunc(settingsHandler.HandleSettings))).Methods("GET", "POST")
+	router.Handle("/layouts", m.HandleSessions(http.HandlerFunc(layoutHandler.HandleLayouts))).Methods("GET", "POST")
+
+	// The donor portal is public; its links are signed instead.
+	if portalSecret != nil {
+		if smtpHost == "" {
+			log.Println("SMTP_HOST is not set, donor portal links cannot be sent")
+		}
+		router.HandleFunc("/portal", portalHandler.HandlePortal).Methods("GET", "POST")
+		router.HandleFunc("/portal/documents", portalHandler.HandlePortalDocuments).Methods("GET")
+		router.HandleFunc("/portal/invoice", portalHandler.HandlePortalInvoice).Methods("GET")
+		router.HandleFunc("/portal/statement", portalHandler.HandlePortalStatement).Methods("GET")
+	} else {
+		log.Println("PORTAL_SECRET is not set, the donor portal is off")
+	}
 
 	log.Println("Server listening at port 8080")
 	if err := http.ListenAndServe(":8080", router); err != nil {
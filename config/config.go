@@ -2,7 +2,15 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
 )
 
 func LoadEnv() (string, string, string, error) {
@@ -22,3 +30,165 @@ func LoadEnv() (string, string, string, error) {
 
 	return stripeKey, stripeEndpointSecret, dsn, nil
 }
+
+// LoadWorkerEnv reads the optional webhook worker settings, falling back to the defaults.
+func LoadWorkerEnv() (int, uint32, error) {
+	workers := 4
+	maxAttempts := uint32(8)
+
+	if value := os.Getenv("WEBHOOK_WORKERS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, fmt.Errorf("Webhook workers must be a positive number")
+		}
+		workers = parsed
+	}
+	if value := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || parsed < 1 {
+			return 0, 0, fmt.Errorf("Webhook max attempts must be a positive number")
+		}
+		maxAttempts = uint32(parsed)
+	}
+
+	return workers, maxAttempts, nil
+}
+
+// LoadTimezone returns the organisation timezone used for accounting periods and
+// document dates. It defaults to Europe/Bucharest and is embedded, so hosts without
+// a zoneinfo database still resolve it.
+func LoadTimezone() (*time.Location, error) {
+	name := os.Getenv("ORG_TIMEZONE")
+	if name == "" {
+		name = "Europe/Bucharest"
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Organisation timezone is invalid: %w", err)
+	}
+	return location, nil
+}
+
+// LoadLogoDir returns where logos uploaded from the settings page are stored. It
+// defaults to ./uploads, relative to the working directory like the PDF assets.
+func LoadLogoDir() string {
+	if dir := os.Getenv("LOGO_DIR"); dir != "" {
+		return dir
+	}
+	return "./uploads"
+}
+
+// LoadArchiveDir returns where issued documents are stored. It defaults to ./archive;
+// the directory must be kept and backed up, since documents are served from it.
+func LoadArchiveDir() string {
+	if dir := os.Getenv("ARCHIVE_DIR"); dir != "" {
+		return dir
+	}
+	return "./archive"
+}
+
+var seriesPattern = regexp.MustCompile(`^[A-Z0-9]+$`)
+
+// LoadInvoiceSeries reads INVOICE_SERIES as a default series followed by optional
+// per-currency series, for example "HNT,eur=HNTE". The default series is HNT.
+func LoadInvoiceSeries() (string, map[string]string, error) {
+	defaultSeries := "HNT"
+	currencySeries := make(map[string]string)
+
+	value := os.Getenv("INVOICE_SERIES")
+	if value == "" {
+		return defaultSeries, currencySeries, nil
+	}
+	for i, entry := range strings.Split(value, ",") {
+		currency, series, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			series, currency = currency, ""
+		}
+		if !seriesPattern.MatchString(series) {
+			return "", nil, fmt.Errorf("Invoice series %q must be upper case letters and digits", series)
+		}
+		switch {
+		case currency != "":
+			currencySeries[strings.ToLower(currency)] = series
+		case i == 0:
+			defaultSeries = series
+		default:
+			return "", nil, fmt.Errorf("Invoice series %q has no currency", series)
+		}
+	}
+	return defaultSeries, currencySeries, nil
+}
+
+// LoadSMTPEnv reads the SMTP server invoices are emailed through. An empty host turns
+// sending off; emails then stay queued. SMTP_PORT defaults to 587, and a local MailHog
+// needs SMTP_HOST=localhost, SMTP_PORT=1025 and no username.
+func LoadSMTPEnv() (host string, port int, username, password, from string, err error) {
+	host = os.Getenv("SMTP_HOST")
+	if host == "" {
+		return "", 0, "", "", "", nil
+	}
+
+	port = 587
+	if value := os.Getenv("SMTP_PORT"); value != "" {
+		if port, err = strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+			return "", 0, "", "", "", fmt.Errorf("SMTP port must be a port number")
+		}
+	}
+	from = os.Getenv("SMTP_FROM")
+	if _, err = mail.ParseAddress(from); err != nil {
+		return "", 0, "", "", "", fmt.Errorf("SMTP sender address is invalid: %w", err)
+	}
+	return host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from, nil
+}
+
+// LoadPortalEnv reads the donor portal settings. An empty PORTAL_SECRET turns the portal
+// off. PORTAL_URL is the public address magic links point to, and PORTAL_LINK_TTL how
+// long a link stays valid, 24h by default.
+func LoadPortalEnv() (secret []byte, baseURL string, ttl time.Duration, err error) {
+	secret = []byte(os.Getenv("PORTAL_SECRET"))
+	if len(secret) == 0 {
+		return nil, "", 0, nil
+	}
+	if len(secret) < 32 {
+		return nil, "", 0, fmt.Errorf("Portal secret must be at least 32 characters")
+	}
+
+	baseURL = strings.TrimSuffix(os.Getenv("PORTAL_URL"), "/")
+	if parsed, err := url.Parse(baseURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, "", 0, fmt.Errorf("Portal URL must be an absolute http or https address")
+	}
+	ttl = 24 * time.Hour
+	if value := os.Getenv("PORTAL_LINK_TTL"); value != "" {
+		if ttl, err = time.ParseDuration(value); err != nil || ttl <= 0 {
+			return nil, "", 0, fmt.Errorf("Portal link TTL must be a positive duration")
+		}
+	}
+	return secret, baseURL, ttl, nil
+}
+
+// LoadTrustedProxies reads TRUSTED_PROXIES, the comma-separated addresses or CIDR ranges
+// of the proxies in front of the app, such as nginx. Only requests coming from them may
+// name the client in X-Real-IP. Nothing is trusted by default.
+func LoadTrustedProxies() ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, value := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("Trusted proxy %q is not an address", value)
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("Trusted proxy %q is not a CIDR range", value)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
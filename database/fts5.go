@@ -0,0 +1,6 @@
+//go:build sqlite_fts5 || fts5
+
+package database
+
+// sqliteFTS5 reports whether go-sqlite3 was built with FTS5, which the search index needs.
+const sqliteFTS5 = true
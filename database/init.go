@@ -1,15 +1,50 @@
 package database
 
 import (
+	"errors"
+	"net/url"
+	"strings"
+
 	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+const (
+	SQLite   = "sqlite3"
+	Postgres = "postgres"
+)
+
+// Driver picks the database from the DSN: postgres:// and postgresql:// URLs are
+// PostgreSQL, anything else is the path of an SQLite database.
+func Driver(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		return Postgres
+	}
+	return SQLite
+}
+
+// sqliteOptions let the webhook workers write concurrently: WAL keeps readers off the
+// writer's lock, immediate transactions take the write lock at BEGIN instead of failing
+// with SQLITE_BUSY on their first write, and the busy timeout makes them wait for it.
+var sqliteOptions = [][2]string{
+	{"_journal_mode", "WAL"},
+	{"_txlock", "immediate"},
+	{"_busy_timeout", "5000"},
+}
+
+// InitDB connects with the driver the DSN selects. Repositories write their queries with
+// ? placeholders and rebind them for the driver.
 func InitDB(dsn string) (*sqlx.DB, error) {
-	db, err := sqlx.Connect("sqlite3", dsn)
+	driver := Driver(dsn)
+	if driver == SQLite {
+		dsn = sqliteDSN(dsn)
+	}
+	db, err := sqlx.Connect(driver, dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -19,11 +54,37 @@ func InitDB(dsn string) (*sqlx.DB, error) {
 	return db, nil
 }
 
+// sqliteDSN adds the sqliteOptions the DSN does not set itself.
+func sqliteDSN(dsn string) string {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return dsn
+	}
+	for _, option := range sqliteOptions {
+		if !query.Has(option[0]) {
+			query.Set(option[0], option[1])
+		}
+	}
+	return path + "?" + query.Encode()
+}
+
+// ErrSQLiteWithoutFTS5 is returned instead of an opaque "no such module: fts5" from the
+// migration that creates the search index.
+var ErrSQLiteWithoutFTS5 = errors.New("SQLite needs FTS5 for the search index: build with -tags sqlite_fts5, as make does")
+
+// ApplyMigrations runs the migration set of the DSN's database. The two sets share their
+// versions, so a change is made to both.
 func ApplyMigrations(dsn string) error {
-	m, err := migrate.New(
-		"file://database/migrations",
-		"sqlite3://"+dsn,
-	)
+	if Driver(dsn) == SQLite && !sqliteFTS5 {
+		return ErrSQLiteWithoutFTS5
+	}
+	source, databaseURL := "file://database/migrations/sqlite", "sqlite3://"+dsn
+	if Driver(dsn) == Postgres {
+		source, databaseURL = "file://database/migrations/postgres", dsn
+	}
+
+	m, err := migrate.New(source, databaseURL)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSQLiteDSN(t *testing.T) {
+	testCases := map[string]struct {
+		dsn      string
+		expected string
+	}{
+		"path":       {dsn: "/data/app.db", expected: "/data/app.db?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"},
+		"ownOptions": {dsn: "file:app.db?_busy_timeout=100&cache=shared", expected: "file:app.db?_busy_timeout=100&_journal_mode=WAL&_txlock=immediate&cache=shared"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := sqliteDSN(tc.dsn); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestInitDBConcurrentWrites(t *testing.T) {
+	db, err := InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to connect to the database: %v", err)
+	}
+	defer db.Close()
+
+	var journalMode string
+	if err = db.Get(&journalMode, "PRAGMA journal_mode"); err != nil || journalMode != "wal" {
+		t.Fatalf("Expected the WAL journal, got %q and %v", journalMode, err)
+	}
+	if _, err = db.Exec("CREATE TABLE counter (value INTEGER)"); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if _, err = db.Exec("INSERT INTO counter (value) VALUES (0)"); err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	// Read-then-write transactions deadlock into SQLITE_BUSY under deferred BEGIN.
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx, err := db.Beginx()
+			if err != nil {
+				errs <- err
+				return
+			}
+			var value int
+			if err = tx.Get(&value, "SELECT value FROM counter"); err == nil {
+				_, err = tx.Exec("UPDATE counter SET value = ?", value+1)
+			}
+			if err != nil {
+				tx.Rollback()
+				errs <- err
+				return
+			}
+			errs <- tx.Commit()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected concurrent writers to wait for each other, got: %v", err)
+		}
+	}
+
+	var value int
+	if err = db.Get(&value, "SELECT value FROM counter"); err != nil || value != writers {
+		t.Errorf("Expected %d increments, got %d and %v", writers, value, err)
+	}
+}
@@ -0,0 +1,9 @@
+CREATE TABLE payouts (
+    id TEXT PRIMARY KEY,
+    created BIGINT NOT NULL,
+    gross BIGINT NOT NULL,
+    fee BIGINT NOT NULL,
+    net BIGINT NOT NULL
+);
+
+CREATE INDEX idx_created ON payouts (created);
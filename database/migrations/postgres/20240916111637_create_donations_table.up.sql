@@ -0,0 +1,13 @@
+CREATE TABLE donations (
+    id TEXT PRIMARY KEY,
+    created BIGINT NOT NULL,
+    gross BIGINT NOT NULL,
+    fee BIGINT NOT NULL,
+    net BIGINT NOT NULL,
+    client_name TEXT NOT NULL,
+    client_email TEXT NOT NULL,
+    payout_id TEXT,
+    FOREIGN KEY (payout_id) REFERENCES payouts(id)
+);
+
+CREATE INDEX idx_donations_payout_id ON donations (payout_id);
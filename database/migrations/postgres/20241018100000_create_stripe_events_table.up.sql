@@ -0,0 +1,10 @@
+CREATE TABLE stripe_events (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    received BIGINT NOT NULL,
+    processed BIGINT,
+    status TEXT NOT NULL,
+    error TEXT
+);
+
+CREATE INDEX idx_stripe_events_status ON stripe_events (status);
@@ -0,0 +1,7 @@
+DROP INDEX idx_fees_event_id;
+DROP INDEX idx_donations_event_id;
+DROP INDEX idx_payouts_event_id;
+
+ALTER TABLE fees DROP COLUMN event_id;
+ALTER TABLE donations DROP COLUMN event_id;
+ALTER TABLE payouts DROP COLUMN event_id;
@@ -0,0 +1,7 @@
+ALTER TABLE payouts ADD COLUMN event_id TEXT REFERENCES stripe_events(id);
+ALTER TABLE donations ADD COLUMN event_id TEXT REFERENCES stripe_events(id);
+ALTER TABLE fees ADD COLUMN event_id TEXT REFERENCES stripe_events(id);
+
+CREATE INDEX idx_payouts_event_id ON payouts (event_id);
+CREATE INDEX idx_donations_event_id ON donations (event_id);
+CREATE INDEX idx_fees_event_id ON fees (event_id);
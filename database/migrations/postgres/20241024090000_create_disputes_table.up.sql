@@ -0,0 +1,13 @@
+CREATE TABLE disputes (
+    id TEXT PRIMARY KEY,
+    created BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL,
+    donation_id TEXT NOT NULL,
+    event_id TEXT,
+    FOREIGN KEY (donation_id) REFERENCES donations(id),
+    FOREIGN KEY (event_id) REFERENCES stripe_events(id)
+);
+
+CREATE INDEX idx_disputes_donation_id ON disputes (donation_id);
@@ -0,0 +1 @@
+DROP TABLE dispute_adjustments;
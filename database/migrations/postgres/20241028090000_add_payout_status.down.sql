@@ -0,0 +1,6 @@
+DROP TABLE payout_status_history;
+
+DROP INDEX idx_payouts_failure_transaction_id;
+
+ALTER TABLE payouts DROP COLUMN failure_transaction_id;
+ALTER TABLE payouts DROP COLUMN status;
@@ -0,0 +1,9 @@
+ALTER TABLE donations ADD COLUMN failed_payout_id TEXT REFERENCES payouts(id);
+ALTER TABLE fees ADD COLUMN failed_payout_id TEXT REFERENCES payouts(id);
+ALTER TABLE refunds ADD COLUMN failed_payout_id TEXT REFERENCES payouts(id);
+ALTER TABLE dispute_adjustments ADD COLUMN failed_payout_id TEXT REFERENCES payouts(id);
+
+CREATE INDEX idx_donations_failed_payout_id ON donations (failed_payout_id);
+CREATE INDEX idx_fees_failed_payout_id ON fees (failed_payout_id);
+CREATE INDEX idx_refunds_failed_payout_id ON refunds (failed_payout_id);
+CREATE INDEX idx_dispute_adjustments_failed_payout_id ON dispute_adjustments (failed_payout_id);
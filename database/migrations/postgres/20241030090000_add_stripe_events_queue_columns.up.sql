@@ -0,0 +1,5 @@
+ALTER TABLE stripe_events ADD COLUMN payload TEXT NOT NULL DEFAULT '';
+ALTER TABLE stripe_events ADD COLUMN attempts BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE stripe_events ADD COLUMN next_attempt BIGINT;
+
+CREATE INDEX idx_stripe_events_next_attempt ON stripe_events (status, next_attempt);
@@ -0,0 +1,9 @@
+ALTER TABLE donations ADD COLUMN currency TEXT NOT NULL DEFAULT 'ron';
+ALTER TABLE donations ADD COLUMN original_amount BIGINT;
+ALTER TABLE donations ADD COLUMN original_currency TEXT;
+ALTER TABLE donations ADD COLUMN exchange_rate DOUBLE PRECISION;
+
+ALTER TABLE fees ADD COLUMN currency TEXT NOT NULL DEFAULT 'ron';
+ALTER TABLE payouts ADD COLUMN currency TEXT NOT NULL DEFAULT 'ron';
+ALTER TABLE refunds ADD COLUMN currency TEXT NOT NULL DEFAULT 'ron';
+ALTER TABLE dispute_adjustments ADD COLUMN currency TEXT NOT NULL DEFAULT 'ron';
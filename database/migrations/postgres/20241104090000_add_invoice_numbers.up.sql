@@ -0,0 +1,12 @@
+CREATE TABLE invoice_sequences (
+    series TEXT NOT NULL,
+    year BIGINT NOT NULL,
+    last_number BIGINT NOT NULL,
+    PRIMARY KEY (series, year)
+);
+
+ALTER TABLE donations ADD COLUMN invoice_series TEXT;
+ALTER TABLE donations ADD COLUMN invoice_year BIGINT;
+ALTER TABLE donations ADD COLUMN invoice_number BIGINT;
+
+CREATE UNIQUE INDEX idx_donations_invoice ON donations (invoice_series, invoice_year, invoice_number);
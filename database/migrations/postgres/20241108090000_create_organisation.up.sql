@@ -0,0 +1,23 @@
+CREATE TABLE organisation (
+    id BIGINT PRIMARY KEY CHECK (id = 1),
+    name TEXT NOT NULL,
+    tax_id TEXT NOT NULL DEFAULT '',
+    registration_number TEXT NOT NULL DEFAULT '',
+    street TEXT NOT NULL,
+    additional_street TEXT NOT NULL DEFAULT '',
+    city TEXT NOT NULL,
+    county TEXT NOT NULL DEFAULT '',
+    postal_code TEXT NOT NULL DEFAULT '',
+    country TEXT NOT NULL,
+    iban TEXT NOT NULL DEFAULT '',
+    bank TEXT NOT NULL DEFAULT '',
+    email TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    stripe_account TEXT NOT NULL DEFAULT '',
+    logo_path TEXT NOT NULL,
+    small_logo_path TEXT NOT NULL,
+    updated BIGINT NOT NULL
+);
+
+INSERT INTO organisation (id, name, street, additional_street, city, county, postal_code, country, email, stripe_account, logo_path, small_logo_path, updated)
+VALUES (1, 'Asociația de Caritate Hintermann', 'Strada Spicului, Nr. 12', 'Bl. 40, Sc. A, Ap. 12', 'Brașov', 'RO-BV', '500460', 'RO', 'contact@hintermann.ro', 'acct_1PVfUvDXCtuWOFq8', './static/pdf/hintermann-logo.png', './static/pdf/hintermann-logo-small.png', 0);
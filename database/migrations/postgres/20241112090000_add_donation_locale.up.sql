@@ -0,0 +1 @@
+ALTER TABLE donations ADD COLUMN locale TEXT;
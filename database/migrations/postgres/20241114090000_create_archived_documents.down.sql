@@ -0,0 +1 @@
+DROP TABLE archived_documents;
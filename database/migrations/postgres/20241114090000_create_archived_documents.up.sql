@@ -0,0 +1,14 @@
+CREATE TABLE archived_documents (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    reference TEXT NOT NULL,
+    lang TEXT NOT NULL,
+    version BIGINT NOT NULL,
+    path TEXT NOT NULL,
+    sha256 TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    issued BIGINT NOT NULL,
+    issued_by TEXT NOT NULL,
+    reason TEXT,
+    UNIQUE (type, reference, lang, version)
+);
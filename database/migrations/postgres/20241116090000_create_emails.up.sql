@@ -0,0 +1,15 @@
+CREATE TABLE emails (
+    id BIGSERIAL PRIMARY KEY,
+    donation_id TEXT NOT NULL REFERENCES donations (id),
+    recipient TEXT NOT NULL,
+    created BIGINT NOT NULL,
+    requested_by TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    next_attempt BIGINT,
+    sent BIGINT
+);
+
+CREATE INDEX idx_emails_status ON emails (status, next_attempt);
+CREATE INDEX idx_emails_donation_id ON emails (donation_id);
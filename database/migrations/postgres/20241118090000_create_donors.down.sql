@@ -0,0 +1,6 @@
+DROP INDEX idx_donations_donor_id;
+
+ALTER TABLE donations DROP COLUMN donor_id;
+
+DROP TABLE donor_emails;
+DROP TABLE donors;
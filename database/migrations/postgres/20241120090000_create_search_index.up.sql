@@ -0,0 +1,10 @@
+CREATE VIEW search_index AS
+SELECT 'donation' AS kind, id AS reference, payout_id, created, gross AS amount, currency, client_name AS title,
+    concat_ws(' ', id, client_name, client_email, invoice_series || '-' || invoice_year || '-' || lpad(invoice_number::TEXT, 6, '0')) AS content
+FROM donations
+UNION ALL
+SELECT 'fee', id, payout_id, created, fee, currency, description, concat_ws(' ', id, description)
+FROM fees
+UNION ALL
+SELECT 'payout', id, id, created, net, currency, '', id
+FROM payouts;
@@ -0,0 +1,4 @@
+DROP TRIGGER audit_log_append_only ON audit_log;
+DROP FUNCTION audit_log_append_only();
+
+DROP TABLE audit_log;
@@ -0,0 +1,22 @@
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    created BIGINT NOT NULL,
+    username TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    action TEXT NOT NULL,
+    entity TEXT NOT NULL,
+    reference TEXT NOT NULL,
+    changes TEXT
+);
+
+CREATE INDEX idx_audit_log_created ON audit_log (created);
+CREATE INDEX idx_audit_log_entity ON audit_log (entity, reference);
+
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,2 @@
+ALTER TABLE donations DROP COLUMN invoice_issued;
+ALTER TABLE invoice_sequences DROP COLUMN last_issued;
@@ -0,0 +1,8 @@
+ALTER TABLE invoice_sequences ADD COLUMN last_issued BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE donations ADD COLUMN invoice_issued BIGINT;
+
+UPDATE donations SET invoice_issued = created WHERE invoice_number IS NOT NULL;
+UPDATE invoice_sequences SET last_issued = COALESCE((
+    SELECT MAX(invoice_issued) FROM donations
+    WHERE invoice_series = invoice_sequences.series AND invoice_year = invoice_sequences.year
+), 0);
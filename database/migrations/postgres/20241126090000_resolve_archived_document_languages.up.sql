@@ -0,0 +1,28 @@
+UPDATE archived_documents
+SET lang = 'en'
+WHERE lang = ''
+  AND type IN ('donation', 'refund')
+  AND EXISTS (
+      SELECT 1 FROM donations
+      WHERE (donations.id = archived_documents.reference
+          OR donations.id IN (SELECT donation_id FROM refunds WHERE refunds.id = archived_documents.reference))
+        AND (LOWER(REPLACE(donations.locale, '_', '-')) = 'en' OR LOWER(REPLACE(donations.locale, '_', '-')) LIKE 'en-%')
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM archived_documents resolved
+      WHERE resolved.type = archived_documents.type
+        AND resolved.reference = archived_documents.reference
+        AND resolved.lang = 'en'
+        AND resolved.version = archived_documents.version
+  );
+
+UPDATE archived_documents
+SET lang = 'ro'
+WHERE lang = ''
+  AND NOT EXISTS (
+      SELECT 1 FROM archived_documents resolved
+      WHERE resolved.type = archived_documents.type
+        AND resolved.reference = archived_documents.reference
+        AND resolved.lang = 'ro'
+        AND resolved.version = archived_documents.version
+  );
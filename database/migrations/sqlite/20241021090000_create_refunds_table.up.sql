@@ -0,0 +1,16 @@
+CREATE TABLE refunds (
+    id TEXT PRIMARY KEY,
+    created INTEGER NOT NULL,
+    gross INTEGER NOT NULL,
+    fee INTEGER NOT NULL,
+    net INTEGER NOT NULL,
+    donation_id TEXT NOT NULL,
+    payout_id TEXT,
+    event_id TEXT,
+    FOREIGN KEY (donation_id) REFERENCES donations(id),
+    FOREIGN KEY (payout_id) REFERENCES payouts(id),
+    FOREIGN KEY (event_id) REFERENCES stripe_events(id)
+);
+
+CREATE INDEX idx_refunds_donation_id ON refunds (donation_id);
+CREATE INDEX idx_refunds_payout_id ON refunds (payout_id);
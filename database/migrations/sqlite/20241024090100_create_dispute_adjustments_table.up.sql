@@ -0,0 +1,17 @@
+CREATE TABLE dispute_adjustments (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    created INTEGER NOT NULL,
+    gross INTEGER NOT NULL,
+    fee INTEGER NOT NULL,
+    net INTEGER NOT NULL,
+    dispute_id TEXT NOT NULL,
+    payout_id TEXT,
+    event_id TEXT,
+    FOREIGN KEY (dispute_id) REFERENCES disputes(id),
+    FOREIGN KEY (payout_id) REFERENCES payouts(id),
+    FOREIGN KEY (event_id) REFERENCES stripe_events(id)
+);
+
+CREATE INDEX idx_dispute_adjustments_dispute_id ON dispute_adjustments (dispute_id);
+CREATE INDEX idx_dispute_adjustments_payout_id ON dispute_adjustments (payout_id);
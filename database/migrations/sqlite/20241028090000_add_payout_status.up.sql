@@ -0,0 +1,18 @@
+ALTER TABLE payouts ADD COLUMN status TEXT NOT NULL DEFAULT 'paid';
+ALTER TABLE payouts ADD COLUMN failure_transaction_id TEXT;
+
+CREATE UNIQUE INDEX idx_payouts_failure_transaction_id ON payouts (failure_transaction_id);
+
+CREATE TABLE payout_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    payout_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created INTEGER NOT NULL,
+    failure_code TEXT,
+    failure_message TEXT,
+    event_id TEXT,
+    FOREIGN KEY (payout_id) REFERENCES payouts(id),
+    FOREIGN KEY (event_id) REFERENCES stripe_events(id)
+);
+
+CREATE INDEX idx_payout_status_history_payout_id ON payout_status_history (payout_id);
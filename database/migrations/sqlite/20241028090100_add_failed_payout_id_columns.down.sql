@@ -0,0 +1,9 @@
+DROP INDEX idx_dispute_adjustments_failed_payout_id;
+DROP INDEX idx_refunds_failed_payout_id;
+DROP INDEX idx_fees_failed_payout_id;
+DROP INDEX idx_donations_failed_payout_id;
+
+ALTER TABLE dispute_adjustments DROP COLUMN failed_payout_id;
+ALTER TABLE refunds DROP COLUMN failed_payout_id;
+ALTER TABLE fees DROP COLUMN failed_payout_id;
+ALTER TABLE donations DROP COLUMN failed_payout_id;
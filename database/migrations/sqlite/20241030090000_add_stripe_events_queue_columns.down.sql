@@ -0,0 +1,5 @@
+DROP INDEX idx_stripe_events_next_attempt;
+
+ALTER TABLE stripe_events DROP COLUMN next_attempt;
+ALTER TABLE stripe_events DROP COLUMN attempts;
+ALTER TABLE stripe_events DROP COLUMN payload;
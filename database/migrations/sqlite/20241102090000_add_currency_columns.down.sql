@@ -0,0 +1,9 @@
+ALTER TABLE dispute_adjustments DROP COLUMN currency;
+ALTER TABLE refunds DROP COLUMN currency;
+ALTER TABLE payouts DROP COLUMN currency;
+ALTER TABLE fees DROP COLUMN currency;
+
+ALTER TABLE donations DROP COLUMN exchange_rate;
+ALTER TABLE donations DROP COLUMN original_currency;
+ALTER TABLE donations DROP COLUMN original_amount;
+ALTER TABLE donations DROP COLUMN currency;
@@ -0,0 +1,7 @@
+DROP INDEX idx_donations_invoice;
+
+ALTER TABLE donations DROP COLUMN invoice_number;
+ALTER TABLE donations DROP COLUMN invoice_year;
+ALTER TABLE donations DROP COLUMN invoice_series;
+
+DROP TABLE invoice_sequences;
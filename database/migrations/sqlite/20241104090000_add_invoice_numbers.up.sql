@@ -0,0 +1,12 @@
+CREATE TABLE invoice_sequences (
+    series TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    last_number INTEGER NOT NULL,
+    PRIMARY KEY (series, year)
+);
+
+ALTER TABLE donations ADD COLUMN invoice_series TEXT;
+ALTER TABLE donations ADD COLUMN invoice_year INTEGER;
+ALTER TABLE donations ADD COLUMN invoice_number INTEGER;
+
+CREATE UNIQUE INDEX idx_donations_invoice ON donations (invoice_series, invoice_year, invoice_number);
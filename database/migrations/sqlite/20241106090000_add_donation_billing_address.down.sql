@@ -0,0 +1,6 @@
+ALTER TABLE donations DROP COLUMN client_country;
+ALTER TABLE donations DROP COLUMN client_postal_code;
+ALTER TABLE donations DROP COLUMN client_state;
+ALTER TABLE donations DROP COLUMN client_city;
+ALTER TABLE donations DROP COLUMN client_address_line2;
+ALTER TABLE donations DROP COLUMN client_address_line1;
@@ -0,0 +1,6 @@
+ALTER TABLE donations ADD COLUMN client_address_line1 TEXT;
+ALTER TABLE donations ADD COLUMN client_address_line2 TEXT;
+ALTER TABLE donations ADD COLUMN client_city TEXT;
+ALTER TABLE donations ADD COLUMN client_state TEXT;
+ALTER TABLE donations ADD COLUMN client_postal_code TEXT;
+ALTER TABLE donations ADD COLUMN client_country TEXT;
@@ -0,0 +1 @@
+DROP TABLE document_layouts;
@@ -0,0 +1,5 @@
+CREATE TABLE document_layouts (
+    name TEXT PRIMARY KEY,
+    definition TEXT NOT NULL,
+    updated INTEGER NOT NULL
+);
@@ -0,0 +1,14 @@
+CREATE TABLE archived_documents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL,
+    reference TEXT NOT NULL,
+    lang TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    path TEXT NOT NULL,
+    sha256 TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    issued INTEGER NOT NULL,
+    issued_by TEXT NOT NULL,
+    reason TEXT,
+    UNIQUE (type, reference, lang, version)
+);
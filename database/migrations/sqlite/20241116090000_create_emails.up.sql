@@ -0,0 +1,15 @@
+CREATE TABLE emails (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    donation_id TEXT NOT NULL REFERENCES donations (id),
+    recipient TEXT NOT NULL,
+    created INTEGER NOT NULL,
+    requested_by TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt INTEGER,
+    sent INTEGER
+);
+
+CREATE INDEX idx_emails_status ON emails (status, next_attempt);
+CREATE INDEX idx_emails_donation_id ON emails (donation_id);
@@ -0,0 +1,39 @@
+CREATE TABLE donors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created INTEGER NOT NULL
+);
+
+CREATE TABLE donor_emails (
+    email TEXT PRIMARY KEY,
+    donor_id INTEGER NOT NULL REFERENCES donors (id)
+);
+
+CREATE INDEX idx_donor_emails_donor_id ON donor_emails (donor_id);
+
+ALTER TABLE donations ADD COLUMN donor_id INTEGER REFERENCES donors (id);
+
+CREATE INDEX idx_donations_donor_id ON donations (donor_id);
+
+INSERT INTO donors (name, email, created)
+SELECT (
+    SELECT latest.client_name FROM donations latest
+    WHERE lower(trim(latest.client_email)) = first_gifts.email
+    ORDER BY latest.created DESC, latest.id DESC
+    LIMIT 1
+), email, created
+FROM (
+    SELECT lower(trim(client_email)) AS email, MIN(created) AS created
+    FROM donations
+    WHERE trim(client_email) != ''
+    GROUP BY lower(trim(client_email))
+) first_gifts
+ORDER BY created, email;
+
+INSERT INTO donor_emails (email, donor_id)
+SELECT email, id FROM donors;
+
+UPDATE donations
+SET donor_id = (SELECT donor_id FROM donor_emails WHERE donor_emails.email = lower(trim(donations.client_email)))
+WHERE trim(client_email) != '';
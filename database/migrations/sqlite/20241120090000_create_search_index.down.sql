@@ -0,0 +1,6 @@
+DROP TRIGGER payouts_search_insert;
+DROP TRIGGER fees_search_insert;
+DROP TRIGGER donations_search_update;
+DROP TRIGGER donations_search_insert;
+
+DROP TABLE search_index;
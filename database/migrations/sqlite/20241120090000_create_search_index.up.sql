@@ -0,0 +1,51 @@
+CREATE VIRTUAL TABLE search_index USING fts5 (
+    kind UNINDEXED,
+    reference UNINDEXED,
+    payout_id UNINDEXED,
+    created UNINDEXED,
+    amount UNINDEXED,
+    currency UNINDEXED,
+    title UNINDEXED,
+    content,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER donations_search_insert AFTER INSERT ON donations
+BEGIN
+    INSERT INTO search_index (kind, reference, payout_id, created, amount, currency, title, content)
+    VALUES ('donation', NEW.id, NEW.payout_id, NEW.created, NEW.gross, NEW.currency, NEW.client_name,
+        NEW.id || ' ' || NEW.client_name || ' ' || NEW.client_email || CASE WHEN NEW.invoice_number IS NULL THEN '' ELSE ' ' || NEW.invoice_series || '-' || NEW.invoice_year || '-' || printf('%06d', NEW.invoice_number) END);
+END;
+
+CREATE TRIGGER donations_search_update AFTER UPDATE OF client_name, client_email, payout_id ON donations
+BEGIN
+    DELETE FROM search_index WHERE kind = 'donation' AND reference = OLD.id;
+    INSERT INTO search_index (kind, reference, payout_id, created, amount, currency, title, content)
+    VALUES ('donation', NEW.id, NEW.payout_id, NEW.created, NEW.gross, NEW.currency, NEW.client_name,
+        NEW.id || ' ' || NEW.client_name || ' ' || NEW.client_email || CASE WHEN NEW.invoice_number IS NULL THEN '' ELSE ' ' || NEW.invoice_series || '-' || NEW.invoice_year || '-' || printf('%06d', NEW.invoice_number) END);
+END;
+
+CREATE TRIGGER fees_search_insert AFTER INSERT ON fees
+BEGIN
+    INSERT INTO search_index (kind, reference, payout_id, created, amount, currency, title, content)
+    VALUES ('fee', NEW.id, NEW.payout_id, NEW.created, NEW.fee, NEW.currency, NEW.description, NEW.id || ' ' || NEW.description);
+END;
+
+CREATE TRIGGER payouts_search_insert AFTER INSERT ON payouts
+BEGIN
+    INSERT INTO search_index (kind, reference, payout_id, created, amount, currency, title, content)
+    VALUES ('payout', NEW.id, NEW.id, NEW.created, NEW.net, NEW.currency, '', NEW.id);
+END;
+
+INSERT INTO search_index (kind, reference, payout_id, created, amount, currency, title, content)
+SELECT 'donation', id, payout_id, created, gross, currency, client_name,
+    id || ' ' || client_name || ' ' || client_email || CASE WHEN invoice_number IS NULL THEN '' ELSE ' ' || invoice_series || '-' || invoice_year || '-' || printf('%06d', invoice_number) END
+FROM donations;
+
+INSERT INTO search_index (kind, reference, payout_id, created, amount, currency, title, content)
+SELECT 'fee', id, payout_id, created, fee, currency, description, id || ' ' || description
+FROM fees;
+
+INSERT INTO search_index (kind, reference, payout_id, created, amount, currency, title, content)
+SELECT 'payout', id, id, created, net, currency, '', id
+FROM payouts;
@@ -0,0 +1 @@
+-- Documents archived under an empty language keep their resolved one.
@@ -14,6 +14,9 @@ const (
 	ErrPayoutMissing       = "payout object is nil"
 	ErrPayoutIDMissing     = "payout ID is missing"
 	ErrPayoutStatusInvalid = "payout status is not paid"
+
+	ErrPayoutFailedStatusInvalid       = "payout status is not failed or canceled"
+	ErrPayoutBalanceTransactionMissing = "payout balance transaction is missing"
 )
 
 // Payout list validation errors
@@ -23,6 +26,9 @@ const (
 	ErrPayoutListPayoutTransactionInvalid  = "payout transaction validation failed"
 	ErrPayoutListRelatedTransactionInvalid = "related transaction validation failed"
 	ErrPayoutListUnexpectedTransaction     = "unexpected transaction type"
+	ErrPayoutListFailedPayoutMissing       = "returned payout failure has no recorded failed payout"
+	ErrPayoutListFailedPayoutMismatch      = "returned amount does not match failed payout net"
+	ErrPayoutListCurrencyMismatch          = "transaction currency does not match payout currency"
 )
 
 // General transaction-related errors
@@ -50,6 +56,14 @@ const (
 	ErrPayoutTransactionNetInvalid    = "payout transaction net is missing, zero, or positive"
 )
 
+// Payout failure transaction-related errors
+const (
+	ErrPayoutFailureTransactionTypeInvalid   = "transaction is not of type payout_failure or payout_cancel"
+	ErrPayoutFailureTransactionAmountInvalid = "payout failure transaction amount is missing, zero, or negative"
+	ErrPayoutFailureTransactionFeeInvalid    = "payout failure transaction fee is not zero"
+	ErrPayoutFailureTransactionNetInvalid    = "payout failure transaction net is missing, zero, or negative"
+)
+
 // Fee transaction-related errors
 const (
 	ErrFeeTransactionTypeInvalid        = "transaction is not of type stripe_fee"
@@ -58,3 +72,136 @@ const (
 	ErrFeeTransactionFeeInvalid         = "fee transaction fee is not zero"
 	ErrFeeTransactionNetInvalid         = "fee transaction net is missing, zero, or positive"
 )
+
+// Refund-related errors
+const (
+	ErrRefundedChargeNotRefunded = "charge has no refunded amount"
+	ErrRefundMissing             = "refund object is nil"
+	ErrRefundChargeMissing       = "refund charge object is nil"
+)
+
+// Refund transaction-related errors
+const (
+	ErrRefundTransactionTypeInvalid     = "transaction is not of type refund"
+	ErrRefundTransactionAmountInvalid   = "refund transaction amount is missing, zero, or positive"
+	ErrRefundTransactionFeeInvalid      = "refund transaction fee is positive"
+	ErrRefundTransactionNetInvalid      = "refund transaction net is missing, zero, or positive"
+	ErrRefundTransactionSourceMissing   = "refund transaction source is missing"
+	ErrRefundTransactionSourceIDMissing = "refund transaction source ID is missing"
+)
+
+// Dispute-related errors
+const (
+	ErrDisputeMissing         = "dispute object is nil"
+	ErrDisputeIDMissing       = "dispute ID is missing"
+	ErrDisputeStatusMissing   = "dispute status is missing"
+	ErrDisputeChargeMissing   = "dispute charge object is nil"
+	ErrDisputeChargeIDMissing = "dispute charge ID is missing"
+)
+
+// Dispute transaction-related errors
+const (
+	ErrDisputeTransactionTypeInvalid     = "transaction is not of type adjustment"
+	ErrDisputeTransactionCategoryInvalid = "adjustment transaction is not a dispute withdrawal or reinstatement"
+	ErrDisputeTransactionAmountInvalid   = "dispute transaction amount is missing, zero, or has the wrong sign"
+	ErrDisputeTransactionNetInvalid      = "dispute transaction net is missing, zero, or has the wrong sign"
+	ErrDisputeTransactionSourceMissing   = "dispute transaction source is missing"
+	ErrDisputeTransactionSourceIDMissing = "dispute transaction source ID is missing"
+)
+
+// Money-related errors
+const (
+	ErrMoneyCurrencyMismatch = "amounts have different currencies"
+	ErrMoneyOverflow         = "amount overflows int64"
+)
+
+// e-Factura-related errors
+const (
+	ErrEInvoiceMalformed = "e-invoice is not a UBL invoice"
+	ErrEInvoiceInvalid   = "e-invoice breaks CIUS-RO rules"
+)
+
+// Organisation profile-related errors
+const (
+	ErrOrganisationFieldMissing         = "organisation %s is missing"
+	ErrOrganisationTaxIDInvalid         = "organisation CUI is not valid"
+	ErrOrganisationIBANInvalid          = "organisation IBAN is not valid"
+	ErrOrganisationEmailInvalid         = "organisation email is not valid"
+	ErrOrganisationCountryInvalid       = "organisation country must be a two-letter ISO code"
+	ErrOrganisationStripeAccountInvalid = "organisation Stripe account must be an account ID starting with acct_"
+	ErrOrganisationLogoInvalid          = "organisation logo must be a PNG or JPEG image"
+)
+
+// Document layout-related errors
+const (
+	ErrLayoutUnknown      = "document layout %s does not exist"
+	ErrLayoutMalformed    = "document layout is not valid"
+	ErrLayoutFieldUnknown = "document layout uses unknown field %s"
+)
+
+// Document archive-related errors
+const (
+	ErrStorageExists        = "stored file %s already exists with different content"
+	ErrStorageKeyInvalid    = "storage key %s is not valid"
+	ErrArchiveTypeUnknown   = "document type %s cannot be archived"
+	ErrArchiveReference     = "document reference %q is not valid"
+	ErrArchiveHashMismatch  = "archived document %d does not match its SHA-256 hash"
+	ErrArchiveReasonMissing = "reissue reason is missing"
+	ErrArchivePeriodOpen    = "the report of %s cannot be archived before the period ends"
+)
+
+// Document export-related errors
+const (
+	ErrExportPeriodMissing = "export needs a month or a from and to date"
+	ErrExportMonthInvalid  = "export month %q is not valid, use YYYY-MM"
+	ErrExportDateInvalid   = "export date %q is not valid, use YYYY-MM-DD"
+	ErrExportRangeInvalid  = "export from date must not be after the to date"
+)
+
+// Donor statement-related errors
+const (
+	ErrStatementEmailInvalid = "donor email %q is not valid"
+	ErrStatementYearInvalid  = "statement year %q is not valid, use YYYY"
+	ErrStatementEmpty        = "%s made no donations in %s"
+)
+
+// Report period-related errors
+const (
+	ErrReportPeriodInvalid = "report period %q is not valid, use YYYY-MM, YYYY-Qn, YYYY or YYYY-MM-DD_YYYY-MM-DD"
+	ErrReportRangeInvalid  = "report start date must not be after the end date"
+)
+
+// Email-related errors
+const (
+	ErrEmailRecipientMissing = "donation %s has no email address"
+)
+
+// Donor portal-related errors
+const (
+	ErrPortalEmailInvalid     = "email %q is not valid"
+	ErrPortalLinkInvalid      = "the link is not valid, request a new one"
+	ErrPortalLinkExpired      = "the link has expired, request a new one"
+	ErrPortalDocumentNotFound = "document %s was not found"
+)
+
+// Donor-related errors
+const (
+	ErrDonorNotFound     = "donor %s was not found"
+	ErrDonorNameMissing  = "donor name is missing"
+	ErrDonorEmailUnknown = "no donor uses the email %q"
+	ErrDonorMergeSelf    = "a donor cannot be merged into itself"
+)
+
+// Search-related errors
+const (
+	ErrSearchAmountInvalid = "amount %q is not valid, use e.g. 150 or 150,50"
+	ErrSearchDateInvalid   = "date %q is not valid, use YYYY-MM-DD"
+	ErrSearchRangeInvalid  = "the start of a range must not be after its end"
+)
+
+// Login errors, as keys of the i18n catalogue the login page translates them with
+const (
+	ErrLoginUsernameMissing = "ui.login.usernameMissing"
+	ErrLoginPasswordMissing = "ui.login.passwordMissing"
+	ErrLoginInvalid         = "ui.login.invalid"
+)
@@ -15,3 +15,18 @@ func NewCredentialsError(message string, args ...any) *CredentialsError {
 		Message: fmt.Sprintf(message, args...),
 	}
 }
+
+// ValidationError reports user input that was rejected, so handlers can show it back.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func NewValidationError(message string, args ...any) *ValidationError {
+	return &ValidationError{
+		Message: fmt.Sprintf(message, args...),
+	}
+}
@@ -0,0 +1,40 @@
+package documents
+
+import (
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/money"
+	"github.com/signintech/gopdf"
+)
+
+// GenerateCreditNote renders the credit note of a refund in lang from layout, or from the
+// default credit note layout when none is given.
+func (s *DocumentService) GenerateCreditNote(creditNoteData *dto.CreditNoteData, organisation *dto.Organisation, layout []byte, lang string) (*gopdf.GoPdf, error) {
+	creditNoteLayout, err := loadLayout(CreditNoteLayout, layout)
+	if err != nil {
+		return nil, err
+	}
+	return renderLayout(creditNoteLayout, creditNoteLayoutData(creditNoteData, organisation, lang))
+}
+
+func creditNoteLayoutData(creditNoteData *dto.CreditNoteData, organisation *dto.Organisation, lang string) *layoutData {
+	refund := creditNoteData.Refund
+	donation := creditNoteData.Donation
+
+	data := organisationLayoutData(organisation, lang)
+	for key, value := range map[string]string{
+		"id":             refund.ID,
+		"created":        refund.Created,
+		"gross":          refund.Gross,
+		"invoiceNumber":  donation.InvoiceNumber,
+		"invoiceCreated": donation.Created,
+		"clientName":     donation.ClientName,
+		"clientEmail":    donation.ClientEmail,
+		"reference":      i18n.T(lang, "document.creditNote.reference", donation.InvoiceNumber, donation.Created),
+		"zero":           money.New(0, donation.Currency).String(),
+	} {
+		data.fields[key] = value
+	}
+	data.rows = []map[string]string{{"quantity": "-1"}}
+	return data
+}
@@ -0,0 +1,58 @@
+package documents
+
+import (
+	"strconv"
+
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/signintech/gopdf"
+)
+
+// GenerateDonorStatement renders the annual donor statement in lang from layout, or from
+// the default donor statement layout when none is given.
+func (s *DocumentService) GenerateDonorStatement(statementData *dto.DonorStatementData, organisation *dto.Organisation, layout []byte, lang string) (*gopdf.GoPdf, error) {
+	statementLayout, err := loadLayout(DonorStatementLayout, layout)
+	if err != nil {
+		return nil, err
+	}
+	return renderLayout(statementLayout, donorStatementLayoutData(statementData, organisation, lang))
+}
+
+func donorStatementLayoutData(statementData *dto.DonorStatementData, organisation *dto.Organisation, lang string) *layoutData {
+	data := organisationLayoutData(organisation, lang)
+	for key, value := range map[string]string{
+		"year":        statementData.Year,
+		"clientName":  statementData.ClientName,
+		"clientEmail": statementData.ClientEmail,
+		"periodStart": statementData.PeriodStart,
+		"periodEnd":   statementData.PeriodEnd,
+		"created":     statementData.EmissionDate,
+		"count":       strconv.Itoa(statementData.Count),
+		"gross":       statementData.Gross,
+		"refunded":    statementData.Refunded,
+		"net":         statementData.Net,
+	} {
+		data.fields[key] = value
+	}
+	for _, item := range statementData.Items {
+		data.rows = append(data.rows, donorStatementRow(item, lang))
+	}
+	return data
+}
+
+// donorStatementRow prints refunds as negative amounts against the invoice they credit.
+func donorStatementRow(item *dto.DonorStatementItem, lang string) map[string]string {
+	row := map[string]string{
+		"id":            item.ID,
+		"description":   i18n.T(lang, "document.donation") + " " + item.Amount,
+		"invoiceNumber": item.InvoiceNumber,
+		"created":       item.Created,
+		"conversion":    item.Conversion,
+		"amount":        item.Amount,
+	}
+	if item.Type == "refund" {
+		row["description"] = i18n.T(lang, "document.statement.refund")
+		row["amount"] = "-" + item.Amount
+	}
+	return row
+}
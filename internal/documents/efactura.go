@@ -0,0 +1,244 @@
+package documents
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/diother/go-invoices/internal/dto"
+)
+
+const (
+	ublInvoiceNamespace = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	ublCACNamespace     = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	ublCBCNamespace     = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+
+	ciusROCustomizationID = "urn:cen.eu:en16931:2017#compliant#urn:efactura.mfinante.ro:CIUS-RO:1.0.1"
+	commercialInvoiceCode = "380"
+	ciusROTaxCurrency     = "RON"
+
+	// Donations are outside the scope of VAT, so every invoice uses category O.
+	outOfScopeTaxCategory  = "O"
+	outOfScopeExemption    = "VATEX-EU-O"
+	donationItemName       = "Donație"
+	donationQuantityUnit   = "C62"
+	donationQuantity       = "1"
+	invoiceLineID          = "1"
+	vatTaxScheme           = "VAT"
+	bucharestCountySubunit = "RO-B"
+)
+
+type ublInvoice struct {
+	XMLName              xml.Name         `xml:"Invoice"`
+	Namespace            string           `xml:"xmlns,attr"`
+	CACNamespace         string           `xml:"xmlns:cac,attr"`
+	CBCNamespace         string           `xml:"xmlns:cbc,attr"`
+	CustomizationID      string           `xml:"cbc:CustomizationID"`
+	ID                   string           `xml:"cbc:ID"`
+	IssueDate            string           `xml:"cbc:IssueDate"`
+	InvoiceTypeCode      string           `xml:"cbc:InvoiceTypeCode"`
+	Note                 string           `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode string           `xml:"cbc:DocumentCurrencyCode"`
+	TaxCurrencyCode      string           `xml:"cbc:TaxCurrencyCode,omitempty"`
+	Supplier             ublParty         `xml:"cac:AccountingSupplierParty>cac:Party"`
+	Customer             ublParty         `xml:"cac:AccountingCustomerParty>cac:Party"`
+	TaxTotals            []ublTaxTotal    `xml:"cac:TaxTotal"`
+	MonetaryTotal        ublMonetaryTotal `xml:"cac:LegalMonetaryTotal"`
+	Lines                []ublInvoiceLine `xml:"cac:InvoiceLine"`
+}
+
+type ublParty struct {
+	PostalAddress ublAddress     `xml:"cac:PostalAddress"`
+	LegalEntity   ublLegalEntity `xml:"cac:PartyLegalEntity"`
+	Contact       *ublContact    `xml:"cac:Contact,omitempty"`
+}
+
+type ublAddress struct {
+	StreetName           string `xml:"cbc:StreetName,omitempty"`
+	AdditionalStreetName string `xml:"cbc:AdditionalStreetName,omitempty"`
+	CityName             string `xml:"cbc:CityName,omitempty"`
+	PostalZone           string `xml:"cbc:PostalZone,omitempty"`
+	CountrySubentity     string `xml:"cbc:CountrySubentity,omitempty"`
+	CountryCode          string `xml:"cac:Country>cbc:IdentificationCode"`
+}
+
+type ublLegalEntity struct {
+	RegistrationName string `xml:"cbc:RegistrationName"`
+	CompanyID        string `xml:"cbc:CompanyID,omitempty"`
+}
+
+type ublContact struct {
+	ElectronicMail string `xml:"cbc:ElectronicMail"`
+}
+
+type ublAmount struct {
+	Value      string `xml:",chardata"`
+	CurrencyID string `xml:"currencyID,attr"`
+}
+
+type ublTaxTotal struct {
+	TaxAmount    ublAmount        `xml:"cbc:TaxAmount"`
+	TaxSubtotals []ublTaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type ublTaxSubtotal struct {
+	TaxableAmount ublAmount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     ublAmount      `xml:"cbc:TaxAmount"`
+	TaxCategory   ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublTaxCategory struct {
+	ID                     string `xml:"cbc:ID"`
+	TaxExemptionReasonCode string `xml:"cbc:TaxExemptionReasonCode,omitempty"`
+	TaxSchemeID            string `xml:"cac:TaxScheme>cbc:ID"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtensionAmount ublAmount `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount  ublAmount `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount  ublAmount `xml:"cbc:TaxInclusiveAmount"`
+	PayableAmount       ublAmount `xml:"cbc:PayableAmount"`
+}
+
+type ublQuantity struct {
+	Value    string `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr"`
+}
+
+type ublInvoiceLine struct {
+	ID                  string      `xml:"cbc:ID"`
+	InvoicedQuantity    ublQuantity `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount ublAmount   `xml:"cbc:LineExtensionAmount"`
+	Item                ublItem     `xml:"cac:Item"`
+	PriceAmount         ublAmount   `xml:"cac:Price>cbc:PriceAmount"`
+}
+
+type ublItem struct {
+	Description string         `xml:"cbc:Description,omitempty"`
+	Name        string         `xml:"cbc:Name"`
+	TaxCategory ublTaxCategory `xml:"cac:ClassifiedTaxCategory"`
+}
+
+// GenerateEInvoice renders the invoice as UBL 2.1 following CIUS-RO, for upload to
+// e-Factura. The document is validated before it is returned.
+func (s *DocumentService) GenerateEInvoice(data *dto.EInvoiceData, organisation *dto.Organisation) ([]byte, error) {
+	invoice := newUBLInvoice(data, organisation)
+
+	body, err := xml.MarshalIndent(invoice, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed marshalling invoice: %w", err)
+	}
+	document := append([]byte(xml.Header), body...)
+
+	if err = ValidateEInvoice(document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+func newUBLInvoice(data *dto.EInvoiceData, seller *dto.Organisation) *ublInvoice {
+	donation := data.Donation
+	currency := data.CurrencyCode
+	amount := ublAmount{Value: data.Amount, CurrencyID: currency}
+	zero := ublAmount{Value: "0.00", CurrencyID: currency}
+	category := ublTaxCategory{
+		ID:                     outOfScopeTaxCategory,
+		TaxExemptionReasonCode: outOfScopeExemption,
+		TaxSchemeID:            vatTaxScheme,
+	}
+
+	invoice := &ublInvoice{
+		Namespace:            ublInvoiceNamespace,
+		CACNamespace:         ublCACNamespace,
+		CBCNamespace:         ublCBCNamespace,
+		CustomizationID:      ciusROCustomizationID,
+		ID:                   donation.InvoiceNumber,
+		IssueDate:            data.IssueDate,
+		InvoiceTypeCode:      commercialInvoiceCode,
+		Note:                 "ID tranzacție: " + donation.ID,
+		DocumentCurrencyCode: currency,
+		Supplier: ublParty{
+			PostalAddress: newUBLAddress(seller.Address),
+			LegalEntity: ublLegalEntity{
+				RegistrationName: seller.Name,
+				CompanyID:        seller.TaxID,
+			},
+			Contact: &ublContact{ElectronicMail: seller.Email},
+		},
+		Customer: ublParty{
+			PostalAddress: newUBLAddress(data.Buyer),
+			LegalEntity:   ublLegalEntity{RegistrationName: donation.ClientName},
+		},
+		TaxTotals: []ublTaxTotal{{
+			TaxAmount: zero,
+			TaxSubtotals: []ublTaxSubtotal{{
+				TaxableAmount: amount,
+				TaxAmount:     zero,
+				TaxCategory:   category,
+			}},
+		}},
+		MonetaryTotal: ublMonetaryTotal{
+			LineExtensionAmount: amount,
+			TaxExclusiveAmount:  amount,
+			TaxInclusiveAmount:  amount,
+			PayableAmount:       amount,
+		},
+		Lines: []ublInvoiceLine{{
+			ID:                  invoiceLineID,
+			InvoicedQuantity:    ublQuantity{Value: donationQuantity, UnitCode: donationQuantityUnit},
+			LineExtensionAmount: amount,
+			Item: ublItem{
+				Description: donation.Conversion,
+				Name:        donationItemName,
+				TaxCategory: ublTaxCategory{ID: outOfScopeTaxCategory, TaxSchemeID: vatTaxScheme},
+			},
+			PriceAmount: amount,
+		}},
+	}
+	if donation.ClientEmail != "" {
+		invoice.Customer.Contact = &ublContact{ElectronicMail: donation.ClientEmail}
+	}
+
+	// CIUS-RO wants the VAT total in lei as well when the invoice is in another currency.
+	if currency != ciusROTaxCurrency {
+		invoice.TaxCurrencyCode = ciusROTaxCurrency
+		invoice.TaxTotals = append(invoice.TaxTotals, ublTaxTotal{
+			TaxAmount: ublAmount{Value: "0.00", CurrencyID: ciusROTaxCurrency},
+		})
+	}
+	return invoice
+}
+
+var bucharestSectorPattern = regexp.MustCompile(`(?i)sector(?:ul)?\s*([1-6])`)
+
+// newUBLAddress maps a postal address to UBL. Romanian counties become ISO 3166-2:RO codes
+// and Bucharest addresses name their sector as the city, as CIUS-RO requires.
+func newUBLAddress(address *dto.Address) ublAddress {
+	if address == nil {
+		return ublAddress{}
+	}
+	ubl := ublAddress{
+		StreetName:           address.Street,
+		AdditionalStreetName: address.AdditionalStreet,
+		CityName:             address.City,
+		PostalZone:           address.PostalCode,
+		CountrySubentity:     address.County,
+		CountryCode:          strings.ToUpper(address.Country),
+	}
+	if ubl.CountryCode != "RO" {
+		return ubl
+	}
+	if code, ok := romanianCountyCode(address.County); ok {
+		ubl.CountrySubentity = code
+	}
+	if ubl.CountrySubentity == bucharestCountySubunit {
+		for _, text := range []string{address.City, address.Street, address.AdditionalStreet} {
+			if match := bucharestSectorPattern.FindStringSubmatch(text); match != nil {
+				ubl.CityName = "SECTOR" + match[1]
+				break
+			}
+		}
+	}
+	return ubl
+}
@@ -0,0 +1,52 @@
+package documents
+
+import "strings"
+
+// romanianCountyNames maps the ISO 3166-2:RO codes CIUS-RO accepts to county names
+// written without diacritics.
+var romanianCountyNames = map[string]string{
+	"RO-AB": "alba", "RO-AR": "arad", "RO-AG": "arges", "RO-BC": "bacau",
+	"RO-BH": "bihor", "RO-BN": "bistrita-nasaud", "RO-BT": "botosani", "RO-BV": "brasov",
+	"RO-BR": "braila", "RO-B": "bucuresti", "RO-BZ": "buzau", "RO-CS": "caras-severin",
+	"RO-CL": "calarasi", "RO-CJ": "cluj", "RO-CT": "constanta", "RO-CV": "covasna",
+	"RO-DB": "dambovita", "RO-DJ": "dolj", "RO-GL": "galati", "RO-GR": "giurgiu",
+	"RO-GJ": "gorj", "RO-HR": "harghita", "RO-HD": "hunedoara", "RO-IL": "ialomita",
+	"RO-IS": "iasi", "RO-IF": "ilfov", "RO-MM": "maramures", "RO-MH": "mehedinti",
+	"RO-MS": "mures", "RO-NT": "neamt", "RO-OT": "olt", "RO-PH": "prahova",
+	"RO-SM": "satu mare", "RO-SJ": "salaj", "RO-SB": "sibiu", "RO-SV": "suceava",
+	"RO-TR": "teleorman", "RO-TM": "timis", "RO-TL": "tulcea", "RO-VS": "vaslui",
+	"RO-VL": "valcea", "RO-VN": "vrancea",
+}
+
+var diacritics = strings.NewReplacer(
+	"ă", "a", "â", "a", "î", "i", "ș", "s", "ş", "s", "ț", "t", "ţ", "t",
+)
+
+// romanianCountyCode accepts a county as an ISO code, with or without the RO- prefix,
+// or by name, as donors type it into the Stripe billing form.
+func romanianCountyCode(county string) (string, bool) {
+	normalized := diacritics.Replace(strings.ToLower(strings.TrimSpace(county)))
+	normalized = strings.TrimPrefix(normalized, "judetul ")
+	normalized = strings.TrimPrefix(normalized, "jud. ")
+	if normalized == "" {
+		return "", false
+	}
+
+	code := strings.ToUpper(normalized)
+	if !strings.HasPrefix(code, "RO-") {
+		code = "RO-" + code
+	}
+	if _, ok := romanianCountyNames[code]; ok {
+		return code, true
+	}
+	for code, name := range romanianCountyNames {
+		if normalized == name || strings.ReplaceAll(normalized, " ", "-") == name {
+			return code, true
+		}
+	}
+	switch normalized {
+	case "bucharest", "municipiul bucuresti":
+		return bucharestCountySubunit, true
+	}
+	return "", false
+}
@@ -0,0 +1,160 @@
+package documents
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/diother/go-invoices/internal/dto"
+)
+
+func newTestEInvoiceData() *dto.EInvoiceData {
+	return dto.NewEInvoiceData(
+		&dto.FormattedDonation{
+			ID:            "txn_123456",
+			InvoiceNumber: "HNT-2024-000001",
+			ClientName:    "Ion Popescu",
+			ClientEmail:   "ion@example.com",
+		},
+		dto.NewAddress("Strada Memorandumului 5", "", "Cluj-Napoca", "Cluj", "400114", "RO"),
+		"2024-09-22",
+		"RON",
+		"100.00",
+	)
+}
+
+func newTestOrganisation() *dto.Organisation {
+	return dto.NewOrganisation(
+		"Asociația de Caritate Hintermann",
+		"12345674",
+		"",
+		"",
+		"",
+		"contact@hintermann.ro",
+		"",
+		"",
+		"",
+		"",
+		dto.NewAddress("Strada Spicului, Nr. 12", "Bl. 40, Sc. A, Ap. 12", "Brașov", "RO-BV", "500460", "RO"),
+	)
+}
+
+func TestGenerateEInvoice(t *testing.T) {
+	testCases := map[string]struct {
+		modify        func(data *dto.EInvoiceData, organisation *dto.Organisation)
+		expectedRules []string
+	}{
+		"valid": {
+			modify: func(data *dto.EInvoiceData, organisation *dto.Organisation) {},
+		},
+		"foreignCurrency": {
+			modify: func(data *dto.EInvoiceData, organisation *dto.Organisation) { data.CurrencyCode = "EUR" },
+		},
+		"foreignBuyer": {
+			modify: func(data *dto.EInvoiceData, organisation *dto.Organisation) {
+				data.Buyer = dto.NewAddress("", "", "Wien", "", "1010", "AT")
+			},
+		},
+		"missingSellerTaxID": {
+			modify:        func(data *dto.EInvoiceData, organisation *dto.Organisation) { organisation.TaxID = "" },
+			expectedRules: []string{"BR-CO-26"},
+		},
+		"missingBuyerAddress": {
+			modify:        func(data *dto.EInvoiceData, organisation *dto.Organisation) { data.Buyer = nil },
+			expectedRules: []string{"BR-11"},
+		},
+		"unknownCounty": {
+			modify:        func(data *dto.EInvoiceData, organisation *dto.Organisation) { data.Buyer.County = "Atlantis" },
+			expectedRules: []string{"CIUS-RO buyer county"},
+		},
+		"bucharestWithoutSector": {
+			modify: func(data *dto.EInvoiceData, organisation *dto.Organisation) {
+				data.Buyer = dto.NewAddress("Strada Lipscani 10", "", "București", "București", "030031", "RO")
+			},
+			expectedRules: []string{"CIUS-RO buyer city in Bucharest"},
+		},
+		"invoiceNumberWithoutDigits": {
+			modify:        func(data *dto.EInvoiceData, organisation *dto.Organisation) { data.Donation.InvoiceNumber = "HNT" },
+			expectedRules: []string{"CIUS-RO invoice number"},
+		},
+		"invalidIssueDate": {
+			modify:        func(data *dto.EInvoiceData, organisation *dto.Organisation) { data.IssueDate = "22 Sep 2024" },
+			expectedRules: []string{"BR-03"},
+		},
+		"tooManyDecimals": {
+			modify:        func(data *dto.EInvoiceData, organisation *dto.Organisation) { data.Amount = "100.001" },
+			expectedRules: []string{"BR-24", "BR-12"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			data := newTestEInvoiceData()
+			organisation := newTestOrganisation()
+			tc.modify(data, organisation)
+
+			document, err := NewDocumentService().GenerateEInvoice(data, organisation)
+
+			if len(tc.expectedRules) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if !strings.HasPrefix(string(document), "<?xml") {
+					t.Errorf("Expected an XML document, got %q", document[:20])
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected rules %v to fail, got no error", tc.expectedRules)
+			}
+			for _, rule := range tc.expectedRules {
+				if !strings.Contains(err.Error(), rule) {
+					t.Errorf("Expected rule %v in %v", rule, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateEInvoiceRejectsOtherDocuments(t *testing.T) {
+	testCases := map[string]string{
+		"notXML":         "invoice",
+		"otherRoot":      `<CreditNote xmlns="urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"/>`,
+		"wrongNamespace": `<Invoice xmlns="urn:example"/>`,
+	}
+
+	for name, document := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := ValidateEInvoice([]byte(document)); err == nil {
+				t.Errorf("Expected error, got none")
+			}
+		})
+	}
+}
+
+func TestNewUBLAddress(t *testing.T) {
+	testCases := map[string]struct {
+		input          *dto.Address
+		expectedCity   string
+		expectedCounty string
+	}{
+		"countyName":      {input: dto.NewAddress("", "", "Brașov", "Brașov", "", "RO"), expectedCity: "Brașov", expectedCounty: "RO-BV"},
+		"countyCode":      {input: dto.NewAddress("", "", "Iași", "IS", "", "ro"), expectedCity: "Iași", expectedCounty: "RO-IS"},
+		"hyphenatedName":  {input: dto.NewAddress("", "", "Reșița", "Caraș Severin", "", "RO"), expectedCity: "Reșița", expectedCounty: "RO-CS"},
+		"judetPrefix":     {input: dto.NewAddress("", "", "Sibiu", "Județul Sibiu", "", "RO"), expectedCity: "Sibiu", expectedCounty: "RO-SB"},
+		"bucharestSector": {input: dto.NewAddress("Bd. Unirii 1, sector 4", "", "Bucharest", "Bucharest", "", "RO"), expectedCity: "SECTOR4", expectedCounty: "RO-B"},
+		"foreignState":    {input: dto.NewAddress("", "", "Wien", "Wien", "", "AT"), expectedCity: "Wien", expectedCounty: "Wien"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := newUBLAddress(tc.input)
+
+			if result.CityName != tc.expectedCity {
+				t.Errorf("Expected city %v, got %v", tc.expectedCity, result.CityName)
+			}
+			if result.CountrySubentity != tc.expectedCounty {
+				t.Errorf("Expected county %v, got %v", tc.expectedCounty, result.CountrySubentity)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package documents
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/diother/go-invoices/internal/constants"
+)
+
+var (
+	isoDatePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	currencyPattern    = regexp.MustCompile(`^[A-Z]{3}$`)
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	amountPattern      = regexp.MustCompile(`^-?\d+(\.\d{1,2})?$`)
+	digitPattern       = regexp.MustCompile(`\d`)
+	ciusROSectorCity   = regexp.MustCompile(`^SECTOR[1-6]$`)
+)
+
+var invoiceTypeCodes = map[string]bool{"380": true, "384": true, "389": true, "751": true}
+
+// ValidateEInvoice checks a UBL invoice against the EN 16931 and CIUS-RO rules below and
+// reports every violation by rule ID.
+func ValidateEInvoice(document []byte) error {
+	root, err := parseXMLTree(document)
+	if err != nil {
+		return fmt.Errorf(constants.ErrEInvoiceMalformed+": %w", err)
+	}
+	if root.name.Space != ublInvoiceNamespace || root.name.Local != "Invoice" {
+		return fmt.Errorf(constants.ErrEInvoiceMalformed+": root element %s %s", root.name.Space, root.name.Local)
+	}
+
+	var violations []string
+	check := func(ok bool, rule, message string) {
+		if !ok {
+			violations = append(violations, rule+" "+message)
+		}
+	}
+
+	check(root.value("cbc:CustomizationID") == ciusROCustomizationID, "BR-01", "specification identifier must be CIUS-RO")
+	invoiceID := root.value("cbc:ID")
+	check(invoiceID != "", "BR-02", "invoice number is missing")
+	check(digitPattern.MatchString(invoiceID), "CIUS-RO", "invoice number must contain a digit")
+	check(isoDatePattern.MatchString(root.value("cbc:IssueDate")), "BR-03", "issue date must be YYYY-MM-DD")
+	check(invoiceTypeCodes[root.value("cbc:InvoiceTypeCode")], "BR-04", "invoice type code is not allowed")
+
+	currency := root.value("cbc:DocumentCurrencyCode")
+	check(currencyPattern.MatchString(currency), "BR-05", "document currency must be an ISO 4217 code")
+	if currency != ciusROTaxCurrency {
+		check(root.value("cbc:TaxCurrencyCode") == ciusROTaxCurrency, "CIUS-RO", "VAT accounting currency must be RON")
+	}
+
+	seller := root.child("cac:AccountingSupplierParty", "cac:Party")
+	buyer := root.child("cac:AccountingCustomerParty", "cac:Party")
+	check(seller.value("cac:PartyLegalEntity", "cbc:RegistrationName") != "", "BR-06", "seller name is missing")
+	check(buyer.value("cac:PartyLegalEntity", "cbc:RegistrationName") != "", "BR-07", "buyer name is missing")
+	check(seller.child("cac:PostalAddress") != nil, "BR-08", "seller postal address is missing")
+	check(buyer.child("cac:PostalAddress") != nil, "BR-10", "buyer postal address is missing")
+	check(seller.value("cac:PartyLegalEntity", "cbc:CompanyID") != "", "BR-CO-26", "seller fiscal code is missing")
+	violations = append(violations, validateUBLAddress(seller.child("cac:PostalAddress"), "seller", "BR-09")...)
+	violations = append(violations, validateUBLAddress(buyer.child("cac:PostalAddress"), "buyer", "BR-11")...)
+
+	lines := root.all("cac:InvoiceLine")
+	check(len(lines) > 0, "BR-16", "invoice has no lines")
+	var lineSum int64
+	for _, line := range lines {
+		check(line.value("cbc:ID") != "", "BR-21", "line identifier is missing")
+		check(line.value("cbc:InvoicedQuantity") != "", "BR-22", "line quantity is missing")
+		check(line.child("cbc:InvoicedQuantity").attr("unitCode") != "", "BR-23", "line unit of measure is missing")
+		check(line.value("cac:Item", "cbc:Name") != "", "BR-25", "item name is missing")
+		check(line.value("cac:Price", "cbc:PriceAmount") != "", "BR-26", "item price is missing")
+		check(line.value("cac:Item", "cac:ClassifiedTaxCategory", "cbc:ID") != "", "BR-CO-04", "line VAT category is missing")
+
+		amount, ok := parseUBLAmount(line.child("cbc:LineExtensionAmount"), currency)
+		check(ok, "BR-24", "line net amount is missing or invalid")
+		lineSum += amount
+	}
+
+	totals := root.child("cac:LegalMonetaryTotal")
+	lineTotal, ok := parseUBLAmount(totals.child("cbc:LineExtensionAmount"), currency)
+	check(ok, "BR-12", "sum of line net amounts is missing or invalid")
+	check(!ok || lineTotal == lineSum, "BR-CO-10", "sum of line net amounts does not match the lines")
+	taxExclusive, ok := parseUBLAmount(totals.child("cbc:TaxExclusiveAmount"), currency)
+	check(ok, "BR-13", "total without VAT is missing or invalid")
+	check(!ok || taxExclusive == lineTotal, "BR-CO-13", "total without VAT does not match the lines")
+	taxInclusive, ok := parseUBLAmount(totals.child("cbc:TaxInclusiveAmount"), currency)
+	check(ok, "BR-14", "total with VAT is missing or invalid")
+	payable, ok := parseUBLAmount(totals.child("cbc:PayableAmount"), currency)
+	check(ok, "BR-15", "amount due is missing or invalid")
+	check(!ok || payable == taxInclusive, "BR-CO-16", "amount due does not match the total with VAT")
+
+	var documentTax *xmlNode
+	for _, total := range root.all("cac:TaxTotal") {
+		if total.child("cbc:TaxAmount").attr("currencyID") == currency {
+			documentTax = total
+		}
+	}
+	check(documentTax != nil, "BR-CO-14", "VAT total in the document currency is missing")
+	if documentTax != nil {
+		taxAmount, ok := parseUBLAmount(documentTax.child("cbc:TaxAmount"), currency)
+		check(ok && taxInclusive == taxExclusive+taxAmount, "BR-CO-15", "total with VAT does not equal total without VAT plus VAT")
+
+		subtotals := documentTax.all("cac:TaxSubtotal")
+		check(len(subtotals) > 0, "BR-CO-18", "VAT breakdown is missing")
+		for _, subtotal := range subtotals {
+			category := subtotal.child("cac:TaxCategory")
+			if category.value("cbc:ID") != outOfScopeTaxCategory {
+				continue
+			}
+			taxable, ok := parseUBLAmount(subtotal.child("cbc:TaxableAmount"), currency)
+			check(ok && taxable == lineSum, "BR-O-08", "taxable amount does not match the lines")
+			tax, ok := parseUBLAmount(subtotal.child("cbc:TaxAmount"), currency)
+			check(ok && tax == 0, "BR-O-09", "VAT amount must be zero outside the scope of VAT")
+			check(category.value("cbc:TaxExemptionReasonCode") != "" || category.value("cbc:TaxExemptionReason") != "",
+				"BR-O-10", "VAT exemption reason is missing")
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf(constants.ErrEInvoiceInvalid+": %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+func validateUBLAddress(address *xmlNode, party, countryRule string) (violations []string) {
+	if address == nil {
+		return nil
+	}
+	country := address.value("cac:Country", "cbc:IdentificationCode")
+	if !countryCodePattern.MatchString(country) {
+		violations = append(violations, countryRule+" "+party+" country code is missing")
+	}
+	if country != "RO" {
+		return
+	}
+	if address.value("cbc:StreetName") == "" {
+		violations = append(violations, "CIUS-RO "+party+" street is missing")
+	}
+	if address.value("cbc:CityName") == "" {
+		violations = append(violations, "CIUS-RO "+party+" city is missing")
+	}
+	county := address.value("cbc:CountrySubentity")
+	if _, ok := romanianCountyNames[county]; !ok {
+		violations = append(violations, "CIUS-RO "+party+" county must be an ISO 3166-2:RO code")
+	}
+	if county == bucharestCountySubunit && !ciusROSectorCity.MatchString(address.value("cbc:CityName")) {
+		violations = append(violations, "CIUS-RO "+party+" city in Bucharest must be SECTOR1 to SECTOR6")
+	}
+	return
+}
+
+// parseUBLAmount reads an amount in minor units, checking it has at most two decimals and
+// is expressed in the document currency.
+func parseUBLAmount(node *xmlNode, currency string) (int64, bool) {
+	if node == nil || node.attr("currencyID") != currency || !amountPattern.MatchString(node.text) {
+		return 0, false
+	}
+	whole, fraction, _ := strings.Cut(node.text, ".")
+	fraction = (fraction + "00")[:2]
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return amount, true
+}
+
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	text     string
+	children []*xmlNode
+}
+
+func parseXMLTree(document []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	var root *xmlNode
+	var stack []*xmlNode
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name, attrs: t.Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += strings.TrimSpace(string(t))
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("document is empty")
+	}
+	return root, nil
+}
+
+var ublPrefixes = map[string]string{
+	"cac": ublCACNamespace,
+	"cbc": ublCBCNamespace,
+}
+
+// child follows a path of prefixed element names, e.g. "cac:Party", "cbc:ID".
+func (n *xmlNode) child(path ...string) *xmlNode {
+	node := n
+	for _, name := range path {
+		if node == nil {
+			return nil
+		}
+		children := node.all(name)
+		if len(children) == 0 {
+			return nil
+		}
+		node = children[0]
+	}
+	return node
+}
+
+func (n *xmlNode) all(name string) (nodes []*xmlNode) {
+	if n == nil {
+		return nil
+	}
+	prefix, local, _ := strings.Cut(name, ":")
+	for _, child := range n.children {
+		if child.name.Space == ublPrefixes[prefix] && child.name.Local == local {
+			nodes = append(nodes, child)
+		}
+	}
+	return
+}
+
+func (n *xmlNode) value(path ...string) string {
+	if node := n.child(path...); node != nil {
+		return node.text
+	}
+	return ""
+}
+
+func (n *xmlNode) attr(name string) string {
+	if n == nil {
+		return ""
+	}
+	for _, attr := range n.attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
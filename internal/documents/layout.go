@@ -0,0 +1,363 @@
+package documents
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/signintech/gopdf"
+)
+
+// Layout describes where a document prints its content. Coordinates are in points from
+// the top left of an A4 page; table headings and rows are relative to their own top.
+type Layout struct {
+	Header          []Element `json:"header"`
+	Body            []Element `json:"body"`
+	SecondaryHeader []Element `json:"secondaryHeader"`
+	Footer          []Element `json:"footer"`
+	Table           *Table    `json:"table"`
+}
+
+// Table lists the document items, continuing on new pages with the secondary header
+// once a page is full.
+type Table struct {
+	FirstPageY    float64   `json:"firstPageY"`
+	NextPageY     float64   `json:"nextPageY"`
+	RowOffset     float64   `json:"rowOffset"`
+	RowHeight     float64   `json:"rowHeight"`
+	FirstPageRows int       `json:"firstPageRows"`
+	NextPageRows  int       `json:"nextPageRows"`
+	Heading       []Element `json:"heading"`
+	Row           []Element `json:"row"`
+}
+
+// Element is one text, list of lines, image or rule. Text and paths may reference the
+// document fields as {field}, and text may print a catalogue entry in the document
+// language as {t:key}; an element with If set is skipped when that field is empty.
+type Element struct {
+	Type       string  `json:"type"`
+	Text       string  `json:"text,omitempty"`
+	List       string  `json:"list,omitempty"`
+	Path       string  `json:"path,omitempty"`
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	X2         float64 `json:"x2,omitempty"`
+	Y2         float64 `json:"y2,omitempty"`
+	Width      float64 `json:"width,omitempty"`
+	Height     float64 `json:"height,omitempty"`
+	Align      string  `json:"align,omitempty"`
+	LineHeight float64 `json:"lineHeight,omitempty"`
+	Font       string  `json:"font,omitempty"`
+	Size       float64 `json:"size,omitempty"`
+	Color      []uint8 `json:"color,omitempty"`
+	If         string  `json:"if,omitempty"`
+}
+
+const (
+	InvoiceLayout        = "invoice"
+	CreditNoteLayout     = "credit_note"
+	PayoutReportLayout   = "payout_report"
+	MonthlyReportLayout  = "monthly_report"
+	DonorStatementLayout = "donor_statement"
+)
+
+//go:embed layouts/*.json
+var defaultLayouts embed.FS
+
+// LayoutNames lists the documents rendered from layouts, in the order admins see them.
+func (s *DocumentService) LayoutNames() []string {
+	return []string{InvoiceLayout, CreditNoteLayout, PayoutReportLayout, MonthlyReportLayout, DonorStatementLayout}
+}
+
+// DefaultLayout returns the layout shipped with the application.
+func (s *DocumentService) DefaultLayout(name string) ([]byte, error) {
+	return defaultLayout(name)
+}
+
+func defaultLayout(name string) ([]byte, error) {
+	definition, err := defaultLayouts.ReadFile("layouts/" + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf(constants.ErrLayoutUnknown, name)
+	}
+	return definition, nil
+}
+
+func loadLayout(name string, definition []byte) (*Layout, error) {
+	if len(definition) == 0 {
+		var err error
+		if definition, err = defaultLayout(name); err != nil {
+			return nil, err
+		}
+	}
+	return ParseLayout(definition)
+}
+
+// ParseLayout decodes a layout definition and checks its elements are well formed.
+func ParseLayout(definition []byte) (*Layout, error) {
+	decoder := json.NewDecoder(bytes.NewReader(definition))
+	decoder.DisallowUnknownFields()
+
+	var layout Layout
+	if err := decoder.Decode(&layout); err != nil {
+		return nil, fmt.Errorf(constants.ErrLayoutMalformed+": %w", err)
+	}
+
+	sections := map[string][]Element{
+		"header":          layout.Header,
+		"body":            layout.Body,
+		"secondaryHeader": layout.SecondaryHeader,
+		"footer":          layout.Footer,
+	}
+	if table := layout.Table; table != nil {
+		if table.RowHeight <= 0 || table.FirstPageRows < 1 || table.NextPageRows < 1 {
+			return nil, fmt.Errorf(constants.ErrLayoutMalformed + ": table needs a row height and rows per page")
+		}
+		sections["table heading"] = table.Heading
+		sections["table row"] = table.Row
+	}
+	for section, elements := range sections {
+		for i, element := range elements {
+			if err := validateElement(element); err != nil {
+				return nil, fmt.Errorf(constants.ErrLayoutMalformed+": %s element %d: %w", section, i+1, err)
+			}
+		}
+	}
+	return &layout, nil
+}
+
+func validateElement(element Element) error {
+	switch element.Type {
+	case "text":
+		if element.Text == "" {
+			return fmt.Errorf("text is missing")
+		}
+	case "lines":
+		if element.List == "" {
+			return fmt.Errorf("list is missing")
+		}
+	case "image":
+		if element.Path == "" || element.Width <= 0 || element.Height <= 0 {
+			return fmt.Errorf("image needs a path, width and height")
+		}
+	case "line":
+	default:
+		return fmt.Errorf("unknown type %q", element.Type)
+	}
+	if element.Align != "" && element.Align != "left" && element.Align != "right" {
+		return fmt.Errorf("align must be left or right")
+	}
+	if _, ok := layoutFonts[element.Font]; !ok {
+		return fmt.Errorf("font must be regular or bold")
+	}
+	if element.Color != nil && len(element.Color) != 3 {
+		return fmt.Errorf("color must be three RGB values")
+	}
+	return nil
+}
+
+var layoutFonts = map[string]string{
+	"":        "Roboto",
+	"regular": "Roboto",
+	"bold":    "Roboto-Bold",
+}
+
+// layoutData holds what a document prints: single fields, lists printed one per line
+// and the table rows, whose fields take precedence over the document ones.
+type layoutData struct {
+	lang   string
+	fields map[string]string
+	lists  map[string][]string
+	rows   []map[string]string
+}
+
+func renderLayout(layout *Layout, data *layoutData) (*gopdf.GoPdf, error) {
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+	pdf.AddPage()
+
+	if err := setFonts(pdf); err != nil {
+		return nil, fmt.Errorf("failed setting fonts: %w", err)
+	}
+	resetTextStyles(pdf)
+
+	table := layout.Table
+	pages := 1
+	if table != nil {
+		pages = pagesNeeded(len(data.rows), table.FirstPageRows, table.NextPageRows)
+	}
+	page := 1
+	fields := func() map[string]string {
+		fields := map[string]string{"page": strconv.Itoa(page), "pages": strconv.Itoa(pages)}
+		for key, value := range data.fields {
+			fields[key] = value
+		}
+		return fields
+	}
+
+	pageFields := fields()
+	for _, section := range [][]Element{layout.Header, layout.Body, layout.Footer} {
+		if err := renderElements(pdf, section, 0, data, pageFields); err != nil {
+			return nil, err
+		}
+	}
+	if table == nil {
+		return pdf, nil
+	}
+	if err := renderElements(pdf, table.Heading, table.FirstPageY, data, pageFields); err != nil {
+		return nil, err
+	}
+
+	currentY := table.FirstPageY + table.RowOffset
+	capacity := table.FirstPageRows
+	var count int
+	for _, row := range data.rows {
+		if count == capacity {
+			pdf.AddPage()
+			page++
+			pageFields = fields()
+
+			for _, section := range [][]Element{layout.SecondaryHeader, layout.Footer} {
+				if err := renderElements(pdf, section, 0, data, pageFields); err != nil {
+					return nil, err
+				}
+			}
+			if err := renderElements(pdf, table.Heading, table.NextPageY, data, pageFields); err != nil {
+				return nil, err
+			}
+			currentY = table.NextPageY + table.RowOffset
+			capacity = table.NextPageRows
+			count = 0
+		}
+		if err := renderElements(pdf, table.Row, currentY, data, row, pageFields); err != nil {
+			return nil, err
+		}
+		currentY += table.RowHeight
+		count++
+	}
+	return pdf, nil
+}
+
+func renderElements(pdf *gopdf.GoPdf, elements []Element, offsetY float64, data *layoutData, fields ...map[string]string) error {
+	for _, element := range elements {
+		if element.If != "" {
+			value, err := expandFields("{"+element.If+"}", fields)
+			if err != nil {
+				return err
+			}
+			if value == "" {
+				continue
+			}
+		}
+		if err := renderElement(pdf, element, offsetY, data, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderElement(pdf *gopdf.GoPdf, element Element, offsetY float64, data *layoutData, fields []map[string]string) error {
+	y := element.Y + offsetY
+	switch element.Type {
+	case "image":
+		path, err := expandFields(element.Path, fields)
+		if err != nil {
+			return err
+		}
+		if err = addImage(pdf, path, element.X, y, element.Width, element.Height); err != nil {
+			return fmt.Errorf("failed adding image %s: %w", path, err)
+		}
+	case "line":
+		pdf.Line(element.X, y, element.X2, element.Y2+offsetY)
+	case "text":
+		text, err := expandFields(translate(element.Text, data.lang), fields)
+		if err != nil {
+			return err
+		}
+		setElementStyle(pdf, element)
+		setAlignedText(pdf, element.Align, element.X, y, text)
+		resetTextStyles(pdf)
+	case "lines":
+		lines, ok := data.lists[element.List]
+		if !ok {
+			return fmt.Errorf(constants.ErrLayoutFieldUnknown, element.List)
+		}
+		spacing := element.LineHeight
+		if spacing == 0 {
+			spacing = lineHeight
+		}
+		setElementStyle(pdf, element)
+		for i, line := range lines {
+			setAlignedText(pdf, element.Align, element.X, y+float64(i)*spacing, line)
+		}
+		resetTextStyles(pdf)
+	}
+	return nil
+}
+
+func setElementStyle(pdf *gopdf.GoPdf, element Element) {
+	size := element.Size
+	if size == 0 {
+		size = 10
+	}
+	pdf.SetFont(layoutFonts[element.Font], "", size)
+	if element.Color != nil {
+		pdf.SetTextColor(element.Color[0], element.Color[1], element.Color[2])
+	}
+}
+
+func setAlignedText(pdf *gopdf.GoPdf, align string, x, y float64, text string) {
+	if align == "right" {
+		setRightAlignedText(pdf, x, y, text)
+		return
+	}
+	setText(pdf, x, y, text)
+}
+
+var fieldPattern = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+
+// expandFields replaces every {field} with its value, looking the field up in order.
+func expandFields(text string, fields []map[string]string) (string, error) {
+	var unknown string
+	expanded := fieldPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := match[1 : len(match)-1]
+		for _, set := range fields {
+			if value, ok := set[name]; ok {
+				return value
+			}
+		}
+		unknown = name
+		return match
+	})
+	if unknown != "" {
+		return "", fmt.Errorf(constants.ErrLayoutFieldUnknown, unknown)
+	}
+	return expanded, nil
+}
+
+var translationPattern = regexp.MustCompile(`\{t:([A-Za-z0-9.]+)\}`)
+
+// translate replaces every {t:key} with its catalogue entry in lang.
+func translate(text, lang string) string {
+	return translationPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return i18n.T(lang, match[3:len(match)-1])
+	})
+}
+
+// pagesNeeded counts the pages a table of itemsLength rows takes.
+func pagesNeeded(itemsLength, firstPageCapacity, subsequentPageCapacity int) int {
+	remainingItems := itemsLength - firstPageCapacity
+	var totalPages int
+
+	if remainingItems > 0 {
+		additionalPages := (remainingItems + subsequentPageCapacity - 1) / subsequentPageCapacity
+		totalPages = 1 + additionalPages
+	} else {
+		totalPages = 1
+	}
+	return totalPages
+}
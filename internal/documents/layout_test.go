@@ -0,0 +1,143 @@
+package documents
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+)
+
+func TestParseLayout(t *testing.T) {
+	testCases := map[string]struct {
+		definition  string
+		expectError bool
+	}{
+		"empty":          {definition: `{}`},
+		"text":           {definition: `{"header": [{"type": "text", "text": "Factură", "x": 555, "y": 32, "align": "right", "font": "bold"}]}`},
+		"notJSON":        {definition: `header:`, expectError: true},
+		"unknownKey":     {definition: `{"heading": []}`, expectError: true},
+		"unknownType":    {definition: `{"body": [{"type": "circle"}]}`, expectError: true},
+		"emptyText":      {definition: `{"body": [{"type": "text"}]}`, expectError: true},
+		"imageNoSize":    {definition: `{"body": [{"type": "image", "path": "logo.png"}]}`, expectError: true},
+		"unknownFont":    {definition: `{"body": [{"type": "text", "text": "a", "font": "italic"}]}`, expectError: true},
+		"unknownAlign":   {definition: `{"body": [{"type": "text", "text": "a", "align": "center"}]}`, expectError: true},
+		"shortColor":     {definition: `{"body": [{"type": "text", "text": "a", "color": [0, 0]}]}`, expectError: true},
+		"tableNoRows":    {definition: `{"table": {"rowHeight": 50}}`, expectError: true},
+		"badTableRow":    {definition: `{"table": {"rowHeight": 50, "firstPageRows": 8, "nextPageRows": 12, "row": [{"type": "lines"}]}}`, expectError: true},
+		"tableWithLines": {definition: `{"table": {"rowHeight": 50, "firstPageRows": 8, "nextPageRows": 12, "row": [{"type": "line", "x2": 555}]}}`},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseLayout([]byte(tc.definition))
+
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error, but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestDefaultLayouts(t *testing.T) {
+	service := NewDocumentService()
+
+	for _, name := range service.LayoutNames() {
+		t.Run(name, func(t *testing.T) {
+			definition, err := service.DefaultLayout(name)
+			if err != nil {
+				t.Fatalf("Expected default layout, got %v", err)
+			}
+			if _, err = ParseLayout(definition); err != nil {
+				t.Errorf("Expected default layout to parse, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExpandFields(t *testing.T) {
+	document := map[string]string{"gross": "100,00 lei", "page": "1"}
+	row := map[string]string{"gross": "-50,00 lei"}
+
+	testCases := map[string]struct {
+		text        string
+		fields      []map[string]string
+		expected    string
+		expectError bool
+	}{
+		"plain":        {text: "Total:", fields: []map[string]string{document}, expected: "Total:"},
+		"field":        {text: "Donație de {gross}", fields: []map[string]string{document}, expected: "Donație de 100,00 lei"},
+		"rowFirst":     {text: "{gross}", fields: []map[string]string{row, document}, expected: "-50,00 lei"},
+		"fallback":     {text: "Pagina {page}", fields: []map[string]string{row, document}, expected: "Pagina 1"},
+		"unknownField": {text: "{net}", fields: []map[string]string{document}, expectError: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := expandFields(tc.text, tc.fields)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	testCases := map[string]struct {
+		text     string
+		lang     string
+		expected string
+	}{
+		"plain":      {text: "Total:", lang: i18n.English, expected: "Total:"},
+		"romanian":   {text: "{t:document.invoice.title}", lang: i18n.Romanian, expected: "Factură"},
+		"english":    {text: "{t:document.invoice.title}", lang: i18n.English, expected: "Invoice"},
+		"withFields": {text: "{t:document.page} {page} {t:document.of} {pages}", lang: i18n.English, expected: "Page {page} of {pages}"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := translate(tc.text, tc.lang); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestPayoutReportRow(t *testing.T) {
+	testCases := map[string]struct {
+		input       *dto.PayoutReportItem
+		expectedFee string
+		expectedNet string
+	}{
+		"donation":      {input: dto.NewPayoutReportItem("txn_1", "donation", "", "", "100,00 lei", "2,10 lei", "97,90 lei"), expectedFee: "-2,10 lei", expectedNet: "97,90 lei"},
+		"refund":        {input: dto.NewPayoutReportItem("txn_2", "refund", "Rambursare", "", "50,00 lei", "0,00 lei", "50,00 lei"), expectedFee: "-0,00 lei", expectedNet: "-50,00 lei"},
+		"reinstatement": {input: dto.NewPayoutReportItem("txn_3", "dispute_reinstatement", "Dispută câștigată", "", "50,00 lei", "15,00 lei", "65,00 lei"), expectedFee: "15,00 lei", expectedNet: "65,00 lei"},
+		"fee":           {input: dto.NewPayoutReportItem("txn_4", "fee", "Taxă Stripe", "", "0,00 lei", "0,00 lei", "10,00 lei"), expectedFee: "-0,00 lei", expectedNet: "-10,00 lei"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			row := payoutReportRow(tc.input, i18n.Romanian)
+
+			if row["fee"] != tc.expectedFee || row["net"] != tc.expectedNet {
+				t.Errorf("Expected fee %v and net %v, got %v and %v", tc.expectedFee, tc.expectedNet, row["fee"], row["net"])
+			}
+			if tc.input.Type == "donation" && !strings.HasPrefix(row["description"], "Donație de ") {
+				t.Errorf("Expected donation description, got %v", row["description"])
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package documents
+
+import (
+	"strings"
+
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+)
+
+const lineHeight = 16
+
+// issuerLines lists the organisation as invoices print it under the logo, leaving out
+// the identifiers that are not filled in.
+func issuerLines(organisation *dto.Organisation, lang string) []string {
+	lines := []string{organisation.Name}
+	if organisation.TaxID != "" {
+		lines = append(lines, i18n.T(lang, "document.taxID")+": "+organisation.TaxID)
+	}
+	if organisation.RegistrationNumber != "" {
+		lines = append(lines, i18n.T(lang, "document.registrationNumber")+": "+organisation.RegistrationNumber)
+	}
+	lines = append(lines, addressLines(organisation.Address, lang)...)
+	if organisation.IBAN != "" {
+		lines = append(lines, strings.TrimSpace("IBAN: "+organisation.IBAN+" "+organisation.Bank))
+	}
+	return lines
+}
+
+func addressLines(address *dto.Address, lang string) []string {
+	if address == nil {
+		return nil
+	}
+	lines := []string{address.Street}
+	if address.AdditionalStreet != "" {
+		lines = append(lines, address.AdditionalStreet)
+	}
+	return append(lines, strings.TrimSpace(address.PostalCode+" "+address.City), countryName(address.Country, lang))
+}
+
+func countryName(code, lang string) string {
+	if strings.EqualFold(code, "RO") {
+		return i18n.T(lang, "document.country.RO")
+	}
+	return strings.ToUpper(code)
+}
+
+// organisationLayoutData exposes the issuer profile to layouts, with the issuer block and
+// the postal address as lists, and sets the language the layout prints in.
+func organisationLayoutData(organisation *dto.Organisation, lang string) *layoutData {
+	return &layoutData{
+		lang: lang,
+		fields: map[string]string{
+			"name":               organisation.Name,
+			"taxID":              organisation.TaxID,
+			"registrationNumber": organisation.RegistrationNumber,
+			"iban":               organisation.IBAN,
+			"bank":               organisation.Bank,
+			"email":              organisation.Email,
+			"phone":              organisation.Phone,
+			"stripeAccount":      organisation.StripeAccount,
+			"logoPath":           organisation.LogoPath,
+			"smallLogoPath":      organisation.SmallLogoPath,
+		},
+		lists: map[string][]string{
+			"issuer":  issuerLines(organisation, lang),
+			"address": addressLines(organisation.Address, lang),
+		},
+	}
+}
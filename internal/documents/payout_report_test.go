@@ -19,7 +19,7 @@ func TestPagesNeeded(t *testing.T) {
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			actualPages := pagesNeeded(tc.itemsLength)
+			actualPages := pagesNeeded(tc.itemsLength, 8, 12)
 			if actualPages != tc.expectedPages {
 				t.Errorf("For itemsLength %d, expected %d pages, got %d pages", tc.itemsLength, tc.expectedPages, actualPages)
 			}
@@ -0,0 +1,80 @@
+package documents
+
+import (
+	"fmt"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/signintech/gopdf"
+)
+
+// previewRows fills more than the first page, so previews show the secondary header too.
+const previewRows = 10
+
+// PreviewLayout renders a layout in lang with sample data, every optional field filled in,
+// so an admin can check it before saving.
+func (s *DocumentService) PreviewLayout(name string, layout []byte, organisation *dto.Organisation, lang string) (*gopdf.GoPdf, error) {
+	switch name {
+	case InvoiceLayout:
+		return s.GenerateInvoice(previewDonation(), organisation, layout, lang)
+	case CreditNoteLayout:
+		return s.GenerateCreditNote(previewCreditNoteData(), organisation, layout, lang)
+	case PayoutReportLayout:
+		return s.GeneratePayoutReport(previewPayoutReportData(lang), organisation, layout, lang)
+	case MonthlyReportLayout:
+		return s.GenerateMonthlyReport(previewMonthlyReportData(lang), organisation, layout, lang)
+	case DonorStatementLayout:
+		return s.GenerateDonorStatement(previewDonorStatementData(), organisation, layout, lang)
+	}
+	return nil, fmt.Errorf(constants.ErrLayoutUnknown, name)
+}
+
+func previewDonation() *dto.FormattedDonation {
+	return dto.NewFormattedDonation("txn_preview", "HNT-2024-000123", "22 Sep 2024", "100,00 lei", "2,10 lei", "97,90 lei", "ron",
+		"20,00 € × 5,0000 = 100,00 lei", "Ion Popescu", "ion@example.com", "txn_payout_preview", "", "")
+}
+
+func previewCreditNoteData() *dto.CreditNoteData {
+	refund := dto.NewFormattedRefund("txn_refund_preview", "24 Sep 2024", "50,00 lei", "0,00 lei", "50,00 lei", "txn_preview", "txn_payout_preview")
+	return dto.NewCreditNoteData(refund, previewDonation())
+}
+
+func previewPayoutReportData(lang string) *dto.PayoutReportData {
+	payout := dto.NewFormattedPayout("txn_payout_preview", "30 Sep 2024", "1.000,00 lei", "21,00 lei", "979,00 lei", i18n.T(lang, "preview.failedPayout"), nil, nil, nil, nil)
+	items := []*dto.PayoutReportItem{
+		dto.NewPayoutReportItem("txn_refund_preview", "refund", i18n.T(lang, "preview.refund"), "24 Sep 2024", "50,00 lei", "0,00 lei", "50,00 lei"),
+		dto.NewPayoutReportItem("txn_fee_preview", "fee", i18n.T(lang, "document.column.fee"), "25 Sep 2024", "0,00 lei", "0,00 lei", "10,00 lei"),
+	}
+	for len(items) < previewRows {
+		items = append(items, dto.NewPayoutReportItem("txn_donation_preview", "donation", "20,00 € × 5,0000 = 100,00 lei", "22 Sep 2024", "100,00 lei", "2,10 lei", "97,90 lei"))
+	}
+	return dto.NewPayoutReportData(payout, items)
+}
+
+func previewMonthlyReportData(lang string) *dto.MonthlyReportData {
+	august := []*dto.FormattedPayout{
+		dto.NewFormattedPayout("txn_payout_failed", "05 Aug 2024", "100,00 lei", "2,10 lei", "97,90 lei", i18n.T(lang, "preview.failedPayout"), nil, nil, nil, nil),
+		dto.NewFormattedPayout("txn_payout_preview", "30 Aug 2024", "1.000,00 lei", "21,00 lei", "979,00 lei", "", nil, nil, nil, nil),
+	}
+	var september []*dto.FormattedPayout
+	for len(august)+len(september)+2 < previewRows {
+		september = append(september, dto.NewFormattedPayout("txn_payout_preview", "30 Sep 2024", "1.000,00 lei", "21,00 lei", "979,00 lei", "", nil, nil, nil, nil))
+	}
+	months := []*dto.ReportMonth{
+		dto.NewReportMonth(i18n.T(lang, "ui.month.08")+" 2024", "1.000,00 lei", "21,00 lei", "979,00 lei", august),
+		dto.NewReportMonth(i18n.T(lang, "ui.month.09")+" 2024", "6.000,00 lei", "126,00 lei", "5.874,00 lei", september),
+	}
+	return dto.NewMonthlyReportData(i18n.T(lang, "document.periodReport.title"), i18n.T(lang, "report.quarter", 3, 2024),
+		"1 Jul, 2024", "30 Sep, 2024", "1 Oct, 2024", "7.000,00 lei", "147,00 lei", "6.853,00 lei", append(august, september...), months)
+}
+
+func previewDonorStatementData() *dto.DonorStatementData {
+	items := []*dto.DonorStatementItem{
+		dto.NewDonorStatementItem("txn_refund_preview", "refund", "HNT-2024-000123", "24 Sep 2024", "50,00 lei", ""),
+	}
+	for len(items) < previewRows {
+		items = append(items, dto.NewDonorStatementItem("txn_donation_preview", "donation", "HNT-2024-000123", "22 Sep 2024", "100,00 lei", "20,00 € × 5,0000 = 100,00 lei"))
+	}
+	return dto.NewDonorStatementData("2024", "Ion Popescu", "ion@example.com", "1 Jan, 2024", "31 Dec, 2024", "15 Jan, 2025", previewRows-1, "900,00 lei", "50,00 lei", "850,00 lei", items)
+}
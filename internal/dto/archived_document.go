@@ -0,0 +1,29 @@
+package dto
+
+type ArchivedDocument struct {
+	ID        int64
+	Type      string
+	Reference string
+	Lang      string
+	Version   int64
+	SHA256    string
+	Size      int64
+	Issued    string
+	IssuedBy  string
+	Reason    string
+}
+
+func NewArchivedDocument(id int64, documentType, reference, lang string, version int64, sha256 string, size int64, issued, issuedBy, reason string) *ArchivedDocument {
+	return &ArchivedDocument{
+		ID:        id,
+		Type:      documentType,
+		Reference: reference,
+		Lang:      lang,
+		Version:   version,
+		SHA256:    sha256,
+		Size:      size,
+		Issued:    issued,
+		IssuedBy:  issuedBy,
+		Reason:    reason,
+	}
+}
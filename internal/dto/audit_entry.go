@@ -0,0 +1,39 @@
+package dto
+
+// FormattedAuditEntry is an audit entry as the audit page lists it.
+type FormattedAuditEntry struct {
+	Created   string
+	Username  string
+	IP        string
+	Action    string
+	Entity    string
+	Reference string
+	Changes   []*FormattedAuditChange
+}
+
+func NewFormattedAuditEntry(created, username, ip, action, entity, reference string, changes []*FormattedAuditChange) *FormattedAuditEntry {
+	return &FormattedAuditEntry{
+		Created:   created,
+		Username:  username,
+		IP:        ip,
+		Action:    action,
+		Entity:    entity,
+		Reference: reference,
+		Changes:   changes,
+	}
+}
+
+// FormattedAuditChange is a changed column. An empty value was null.
+type FormattedAuditChange struct {
+	Column string
+	Before string
+	After  string
+}
+
+func NewFormattedAuditChange(column, before, after string) *FormattedAuditChange {
+	return &FormattedAuditChange{
+		Column: column,
+		Before: before,
+		After:  after,
+	}
+}
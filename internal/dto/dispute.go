@@ -0,0 +1,23 @@
+package dto
+
+type FormattedDisputeAdjustment struct {
+	ID        string
+	Type      string
+	Created   string
+	Gross     string
+	Fee       string
+	Net       string
+	DisputeID string
+}
+
+func NewFormattedDisputeAdjustment(id, adjustmentType, created, gross, fee, net, disputeID string) *FormattedDisputeAdjustment {
+	return &FormattedDisputeAdjustment{
+		ID:        id,
+		Type:      adjustmentType,
+		Created:   created,
+		Gross:     gross,
+		Fee:       fee,
+		Net:       net,
+		DisputeID: disputeID,
+	}
+}
@@ -0,0 +1,15 @@
+package dto
+
+type DocumentLayout struct {
+	Name       string
+	Definition string
+	Custom     bool
+}
+
+func NewDocumentLayout(name, definition string, custom bool) *DocumentLayout {
+	return &DocumentLayout{
+		Name:       name,
+		Definition: definition,
+		Custom:     custom,
+	}
+}
@@ -1,25 +1,55 @@
 package dto
 
 type FormattedDonation struct {
-	ID          string
-	Created     string
-	Gross       string
-	Fee         string
-	Net         string
-	ClientName  string
-	ClientEmail string
-	PayoutID    string
+	ID            string
+	InvoiceNumber string
+	Created       string
+	Gross         string
+	Fee           string
+	Net           string
+	Currency      string
+	Conversion    string
+	ClientName    string
+	ClientEmail   string
+	PayoutID      string
+	DisputeStatus string
+	DonorID       string
 }
 
-func NewFormattedDonation(id, created, gross, fee, net, clientName, clientEmail, payoutID string) *FormattedDonation {
+func NewFormattedDonation(id, invoiceNumber, created, gross, fee, net, currency, conversion, clientName, clientEmail, payoutID, disputeStatus, donorID string) *FormattedDonation {
 	return &FormattedDonation{
-		ID:          id,
-		Created:     created,
-		Gross:       gross,
-		Fee:         fee,
-		Net:         net,
-		ClientName:  clientName,
-		ClientEmail: clientEmail,
-		PayoutID:    payoutID,
+		ID:            id,
+		InvoiceNumber: invoiceNumber,
+		Created:       created,
+		Gross:         gross,
+		Fee:           fee,
+		Net:           net,
+		Currency:      currency,
+		Conversion:    conversion,
+		ClientName:    clientName,
+		ClientEmail:   clientEmail,
+		PayoutID:      payoutID,
+		DisputeStatus: disputeStatus,
+		DonorID:       donorID,
+	}
+}
+
+// EInvoiceData holds the invoice in the machine-readable form e-Factura expects: ISO dates,
+// currency codes and amounts with a decimal point.
+type EInvoiceData struct {
+	Donation     *FormattedDonation
+	Buyer        *Address
+	IssueDate    string
+	CurrencyCode string
+	Amount       string
+}
+
+func NewEInvoiceData(donation *FormattedDonation, buyer *Address, issueDate, currencyCode, amount string) *EInvoiceData {
+	return &EInvoiceData{
+		Donation:     donation,
+		Buyer:        buyer,
+		IssueDate:    issueDate,
+		CurrencyCode: currencyCode,
+		Amount:       amount,
 	}
 }
@@ -0,0 +1,48 @@
+package dto
+
+// FormattedDonorSummary is a donor as the donor list shows them.
+type FormattedDonorSummary struct {
+	ID       string
+	Name     string
+	Email    string
+	Gifts    int64
+	LastGift string
+}
+
+func NewFormattedDonorSummary(id, name, email string, gifts int64, lastGift string) *FormattedDonorSummary {
+	return &FormattedDonorSummary{
+		ID:       id,
+		Name:     name,
+		Email:    email,
+		Gifts:    gifts,
+		LastGift: lastGift,
+	}
+}
+
+// DonorDetail is a donor's giving history. Totals has a sum per currency given in, and
+// donations are listed newest first.
+type DonorDetail struct {
+	ID        string
+	Name      string
+	Email     string
+	Emails    []string
+	Gifts     int
+	Totals    []string
+	FirstGift string
+	LastGift  string
+	Donations []*FormattedDonation
+}
+
+func NewDonorDetail(id, name, email string, emails []string, gifts int, totals []string, firstGift, lastGift string, donations []*FormattedDonation) *DonorDetail {
+	return &DonorDetail{
+		ID:        id,
+		Name:      name,
+		Email:     email,
+		Emails:    emails,
+		Gifts:     gifts,
+		Totals:    totals,
+		FirstGift: firstGift,
+		LastGift:  lastGift,
+		Donations: donations,
+	}
+}
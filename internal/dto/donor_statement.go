@@ -0,0 +1,52 @@
+package dto
+
+type DonorStatementData struct {
+	Year         string
+	ClientName   string
+	ClientEmail  string
+	PeriodStart  string
+	PeriodEnd    string
+	EmissionDate string
+	Count        int
+	Gross        string
+	Refunded     string
+	Net          string
+	Items        []*DonorStatementItem
+}
+
+func NewDonorStatementData(year, clientName, clientEmail, periodStart, periodEnd, emissionDate string, count int, gross, refunded, net string, items []*DonorStatementItem) *DonorStatementData {
+	return &DonorStatementData{
+		Year:         year,
+		ClientName:   clientName,
+		ClientEmail:  clientEmail,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		EmissionDate: emissionDate,
+		Count:        count,
+		Gross:        gross,
+		Refunded:     refunded,
+		Net:          net,
+		Items:        items,
+	}
+}
+
+// DonorStatementItem is a donation or a refund of one, in the order they happened.
+type DonorStatementItem struct {
+	ID            string
+	Type          string
+	InvoiceNumber string
+	Created       string
+	Amount        string
+	Conversion    string
+}
+
+func NewDonorStatementItem(id, itemType, invoiceNumber, created, amount, conversion string) *DonorStatementItem {
+	return &DonorStatementItem{
+		ID:            id,
+		Type:          itemType,
+		InvoiceNumber: invoiceNumber,
+		Created:       created,
+		Amount:        amount,
+		Conversion:    conversion,
+	}
+}
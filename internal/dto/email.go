@@ -0,0 +1,84 @@
+package dto
+
+// EmailMessage is a message ready to be sent: a text and an HTML body of the same content.
+type EmailMessage struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []*EmailAttachment
+}
+
+func NewEmailMessage(to, subject, text, html string) *EmailMessage {
+	return &EmailMessage{
+		To:      to,
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+	}
+}
+
+type EmailAttachment struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+func NewEmailAttachment(name, contentType string, content []byte) *EmailAttachment {
+	return &EmailAttachment{
+		Name:        name,
+		ContentType: contentType,
+		Content:     content,
+	}
+}
+
+// InvoiceEmailData fills the invoice email templates.
+type InvoiceEmailData struct {
+	Recipient         string
+	ClientName        string
+	InvoiceNumber     string
+	Created           string
+	Amount            string
+	OrganisationName  string
+	OrganisationEmail string
+}
+
+func NewInvoiceEmailData(recipient, clientName, invoiceNumber, created, amount, organisationName, organisationEmail string) *InvoiceEmailData {
+	return &InvoiceEmailData{
+		Recipient:         recipient,
+		ClientName:        clientName,
+		InvoiceNumber:     invoiceNumber,
+		Created:           created,
+		Amount:            amount,
+		OrganisationName:  organisationName,
+		OrganisationEmail: organisationEmail,
+	}
+}
+
+type FormattedEmail struct {
+	ID          int64
+	DonationID  string
+	Recipient   string
+	Created     string
+	RequestedBy string
+	Status      string
+	Error       string
+	Attempts    string
+	NextAttempt string
+	Sent        string
+}
+
+func NewFormattedEmail(id int64, donationID, recipient, created, requestedBy, status, errorText, attempts, nextAttempt, sent string) *FormattedEmail {
+	return &FormattedEmail{
+		ID:          id,
+		DonationID:  donationID,
+		Recipient:   recipient,
+		Created:     created,
+		RequestedBy: requestedBy,
+		Status:      status,
+		Error:       errorText,
+		Attempts:    attempts,
+		NextAttempt: nextAttempt,
+		Sent:        sent,
+	}
+}
@@ -0,0 +1,9 @@
+package dto
+
+// ExportSummary counts the documents written to an export. Documents that failed to
+// render are marked in the manifest and listed in Failures.
+type ExportSummary struct {
+	Documents int
+	Failed    int
+	Failures  []string
+}
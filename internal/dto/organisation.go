@@ -0,0 +1,51 @@
+package dto
+
+type Address struct {
+	Street           string
+	AdditionalStreet string
+	City             string
+	County           string
+	PostalCode       string
+	Country          string
+}
+
+func NewAddress(street, additionalStreet, city, county, postalCode, country string) *Address {
+	return &Address{
+		Street:           street,
+		AdditionalStreet: additionalStreet,
+		City:             city,
+		County:           county,
+		PostalCode:       postalCode,
+		Country:          country,
+	}
+}
+
+type Organisation struct {
+	Name               string
+	TaxID              string
+	RegistrationNumber string
+	IBAN               string
+	Bank               string
+	Email              string
+	Phone              string
+	StripeAccount      string
+	LogoPath           string
+	SmallLogoPath      string
+	Address            *Address
+}
+
+func NewOrganisation(name, taxID, registrationNumber, iban, bank, email, phone, stripeAccount, logoPath, smallLogoPath string, address *Address) *Organisation {
+	return &Organisation{
+		Name:               name,
+		TaxID:              taxID,
+		RegistrationNumber: registrationNumber,
+		IBAN:               iban,
+		Bank:               bank,
+		Email:              email,
+		Phone:              phone,
+		StripeAccount:      stripeAccount,
+		LogoPath:           logoPath,
+		SmallLogoPath:      smallLogoPath,
+		Address:            address,
+	}
+}
@@ -1,24 +1,30 @@
 package dto
 
 type FormattedPayout struct {
-	ID        string
-	Created   string
-	Gross     string
-	Fee       string
-	Net       string
-	Donations []*FormattedDonation
-	Fees      []*FormattedFee
+	ID          string
+	Created     string
+	Gross       string
+	Fee         string
+	Net         string
+	Status      string
+	Donations   []*FormattedDonation
+	Fees        []*FormattedFee
+	Refunds     []*FormattedRefund
+	Adjustments []*FormattedDisputeAdjustment
 }
 
-func NewFormattedPayout(id, created, gross, fee, net string, donations []*FormattedDonation, fees []*FormattedFee) *FormattedPayout {
+func NewFormattedPayout(id, created, gross, fee, net, status string, donations []*FormattedDonation, fees []*FormattedFee, refunds []*FormattedRefund, adjustments []*FormattedDisputeAdjustment) *FormattedPayout {
 	return &FormattedPayout{
-		ID:        id,
-		Created:   created,
-		Gross:     gross,
-		Fee:       fee,
-		Net:       net,
-		Donations: donations,
-		Fees:      fees,
+		ID:          id,
+		Created:     created,
+		Gross:       gross,
+		Fee:         fee,
+		Net:         net,
+		Status:      status,
+		Donations:   donations,
+		Fees:        fees,
+		Refunds:     refunds,
+		Adjustments: adjustments,
 	}
 }
 
@@ -57,6 +63,8 @@ func NewPayoutReportItem(id, itemType, description, created, gross, fee, net str
 }
 
 type MonthlyReportData struct {
+	Title        string
+	Period       string
 	MonthStart   string
 	MonthEnd     string
 	EmissionDate string
@@ -64,10 +72,13 @@ type MonthlyReportData struct {
 	Fee          string
 	Net          string
 	Payouts      []*FormattedPayout
+	Months       []*ReportMonth
 }
 
-func NewMonthlyReportData(monthStart, monthEnd, emissionDate, gross, fee, net string, payouts []*FormattedPayout) *MonthlyReportData {
+func NewMonthlyReportData(title, period, monthStart, monthEnd, emissionDate, gross, fee, net string, payouts []*FormattedPayout, months []*ReportMonth) *MonthlyReportData {
 	return &MonthlyReportData{
+		Title:        title,
+		Period:       period,
 		MonthStart:   monthStart,
 		MonthEnd:     monthEnd,
 		EmissionDate: emissionDate,
@@ -75,20 +86,48 @@ func NewMonthlyReportData(monthStart, monthEnd, emissionDate, gross, fee, net st
 		Fee:          fee,
 		Net:          net,
 		Payouts:      payouts,
+		Months:       months,
 	}
 }
 
 type MonthlyReportView struct {
 	Date    string
+	Period  string
+	From    string
+	To      string
 	Gross   string
 	Fee     string
 	Net     string
 	Payouts []*FormattedPayout
+	Months  []*ReportMonth
 }
 
-func NewMonthlyReportView(date, gross, fee, net string, payouts []*FormattedPayout) *MonthlyReportView {
+func NewMonthlyReportView(date, period, from, to, gross, fee, net string, payouts []*FormattedPayout, months []*ReportMonth) *MonthlyReportView {
 	return &MonthlyReportView{
 		Date:    date,
+		Period:  period,
+		From:    from,
+		To:      to,
+		Gross:   gross,
+		Fee:     fee,
+		Net:     net,
+		Payouts: payouts,
+		Months:  months,
+	}
+}
+
+// ReportMonth is one month of a report with its subtotals.
+type ReportMonth struct {
+	Month   string
+	Gross   string
+	Fee     string
+	Net     string
+	Payouts []*FormattedPayout
+}
+
+func NewReportMonth(month, gross, fee, net string, payouts []*FormattedPayout) *ReportMonth {
+	return &ReportMonth{
+		Month:   month,
 		Gross:   gross,
 		Fee:     fee,
 		Net:     net,
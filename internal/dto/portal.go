@@ -0,0 +1,51 @@
+package dto
+
+// PortalDocuments is what a donor signed in through a magic link can download.
+type PortalDocuments struct {
+	Email    string
+	Invoices []*PortalInvoice
+	Years    []string
+}
+
+func NewPortalDocuments(email string, invoices []*PortalInvoice, years []string) *PortalDocuments {
+	return &PortalDocuments{
+		Email:    email,
+		Invoices: invoices,
+		Years:    years,
+	}
+}
+
+type PortalInvoice struct {
+	ID            string
+	InvoiceNumber string
+	Created       string
+	Amount        string
+}
+
+func NewPortalInvoice(id, invoiceNumber, created, amount string) *PortalInvoice {
+	return &PortalInvoice{
+		ID:            id,
+		InvoiceNumber: invoiceNumber,
+		Created:       created,
+		Amount:        amount,
+	}
+}
+
+// PortalLinkEmailData fills the magic link email templates.
+type PortalLinkEmailData struct {
+	Recipient         string
+	Link              string
+	Expires           string
+	OrganisationName  string
+	OrganisationEmail string
+}
+
+func NewPortalLinkEmailData(recipient, link, expires, organisationName, organisationEmail string) *PortalLinkEmailData {
+	return &PortalLinkEmailData{
+		Recipient:         recipient,
+		Link:              link,
+		Expires:           expires,
+		OrganisationName:  organisationName,
+		OrganisationEmail: organisationEmail,
+	}
+}
@@ -0,0 +1,35 @@
+package dto
+
+type FormattedRefund struct {
+	ID         string
+	Created    string
+	Gross      string
+	Fee        string
+	Net        string
+	DonationID string
+	PayoutID   string
+}
+
+func NewFormattedRefund(id, created, gross, fee, net, donationID, payoutID string) *FormattedRefund {
+	return &FormattedRefund{
+		ID:         id,
+		Created:    created,
+		Gross:      gross,
+		Fee:        fee,
+		Net:        net,
+		DonationID: donationID,
+		PayoutID:   payoutID,
+	}
+}
+
+type CreditNoteData struct {
+	Refund   *FormattedRefund
+	Donation *FormattedDonation
+}
+
+func NewCreditNoteData(refund *FormattedRefund, donation *FormattedDonation) *CreditNoteData {
+	return &CreditNoteData{
+		Refund:   refund,
+		Donation: donation,
+	}
+}
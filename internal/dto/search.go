@@ -0,0 +1,23 @@
+package dto
+
+// FormattedSearchResult is a donation, fee or payout as the search page lists it. PayoutID
+// is the payout whose document lists the result, empty while it is not paid out.
+type FormattedSearchResult struct {
+	Kind     string
+	ID       string
+	PayoutID string
+	Title    string
+	Date     string
+	Amount   string
+}
+
+func NewFormattedSearchResult(kind, id, payoutID, title, date, amount string) *FormattedSearchResult {
+	return &FormattedSearchResult{
+		Kind:     kind,
+		ID:       id,
+		PayoutID: payoutID,
+		Title:    title,
+		Date:     date,
+		Amount:   amount,
+	}
+}
@@ -0,0 +1,25 @@
+package dto
+
+type FormattedStripeEvent struct {
+	ID          string
+	Type        string
+	Received    string
+	Processed   string
+	Status      string
+	Error       string
+	Attempts    string
+	NextAttempt string
+}
+
+func NewFormattedStripeEvent(id, eventType, received, processed, status, errorText, attempts, nextAttempt string) *FormattedStripeEvent {
+	return &FormattedStripeEvent{
+		ID:          id,
+		Type:        eventType,
+		Received:    received,
+		Processed:   processed,
+		Status:      status,
+		Error:       errorText,
+		Attempts:    attempts,
+		NextAttempt: nextAttempt,
+	}
+}
@@ -0,0 +1,201 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/stripe/stripe-go/v79"
+)
+
+// fixture is the layout of a fake gateway JSON file. Objects use the Stripe API
+// JSON format. PayoutTransactions lists the balance transaction IDs of every payout,
+// starting with the payout's own transaction, as the API returns them.
+type fixture struct {
+	BalanceTransactions []*stripe.BalanceTransaction `json:"balance_transactions"`
+	Charges             []*stripe.Charge             `json:"charges"`
+	Refunds             []*stripe.Refund             `json:"refunds"`
+	Disputes            []*stripe.Dispute            `json:"disputes"`
+	Payouts             []*stripe.Payout             `json:"payouts"`
+	PayoutTransactions  map[string][]string          `json:"payout_transactions"`
+}
+
+// FakeGateway serves Stripe objects from JSON fixtures, so the services can run without the network.
+type FakeGateway struct {
+	transactions       map[string]*stripe.BalanceTransaction
+	charges            map[string]*stripe.Charge
+	refunds            map[string]*stripe.Refund
+	disputes           map[string]*stripe.Dispute
+	payouts            map[string]*stripe.Payout
+	payoutTransactions map[string][]string
+}
+
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{
+		transactions:       make(map[string]*stripe.BalanceTransaction),
+		charges:            make(map[string]*stripe.Charge),
+		refunds:            make(map[string]*stripe.Refund),
+		disputes:           make(map[string]*stripe.Dispute),
+		payouts:            make(map[string]*stripe.Payout),
+		payoutTransactions: make(map[string][]string),
+	}
+}
+
+// LoadFakeGateway reads the given fixture files into one fake gateway.
+// Objects in later files replace objects with the same ID.
+func LoadFakeGateway(paths ...string) (*FakeGateway, error) {
+	g := NewFakeGateway()
+	for _, path := range paths {
+		if err := g.Load(path); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func (g *FakeGateway) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read fixture: %w", err)
+	}
+	var f fixture
+	if err = json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+
+	for _, transaction := range f.BalanceTransactions {
+		g.transactions[transaction.ID] = transaction
+	}
+	for _, charge := range f.Charges {
+		g.charges[charge.ID] = charge
+	}
+	for _, refund := range f.Refunds {
+		g.refunds[refund.ID] = refund
+	}
+	for _, dispute := range f.Disputes {
+		g.disputes[dispute.ID] = dispute
+	}
+	for _, payout := range f.Payouts {
+		g.payouts[payout.ID] = payout
+	}
+	for payoutID, transactionIDs := range f.PayoutTransactions {
+		g.payoutTransactions[payoutID] = transactionIDs
+	}
+	return nil
+}
+
+func (g *FakeGateway) GetBalanceTransaction(id string) (*stripe.BalanceTransaction, error) {
+	transaction, ok := g.transactions[id]
+	if !ok {
+		return nil, fmt.Errorf("no such balance transaction: %s", id)
+	}
+	return transaction, nil
+}
+
+func (g *FakeGateway) ListPayoutTransactions(payoutID string) ([]*stripe.BalanceTransaction, error) {
+	var transactions []*stripe.BalanceTransaction
+	for _, id := range g.payoutTransactions[payoutID] {
+		transaction, err := g.GetBalanceTransaction(id)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
+
+func (g *FakeGateway) GetCharge(id string) (*stripe.Charge, error) {
+	charge, ok := g.charges[id]
+	if !ok {
+		return nil, fmt.Errorf("no such charge: %s", id)
+	}
+	return charge, nil
+}
+
+func (g *FakeGateway) ListCharges(created *stripe.RangeQueryParams) ([]*stripe.Charge, error) {
+	var charges []*stripe.Charge
+	for _, charge := range g.charges {
+		if inRange(charge.Created, created) {
+			charges = append(charges, charge)
+		}
+	}
+	sort.Slice(charges, func(i, j int) bool {
+		return charges[i].Created > charges[j].Created
+	})
+	return charges, nil
+}
+
+func (g *FakeGateway) GetRefund(id string) (*stripe.Refund, error) {
+	refund, ok := g.refunds[id]
+	if !ok {
+		return nil, fmt.Errorf("no such refund: %s", id)
+	}
+	if refund.Charge != nil {
+		if charge, ok := g.charges[refund.Charge.ID]; ok {
+			refund.Charge = charge
+		}
+	}
+	return refund, nil
+}
+
+func (g *FakeGateway) ListChargeRefunds(chargeID string) ([]*stripe.Refund, error) {
+	var refunds []*stripe.Refund
+	for _, refund := range g.refunds {
+		if refund.Charge == nil || refund.Charge.ID != chargeID {
+			continue
+		}
+		if refund.BalanceTransaction != nil {
+			if transaction, ok := g.transactions[refund.BalanceTransaction.ID]; ok {
+				refund.BalanceTransaction = transaction
+			}
+		}
+		refunds = append(refunds, refund)
+	}
+	sort.Slice(refunds, func(i, j int) bool {
+		return refunds[i].Created > refunds[j].Created
+	})
+	return refunds, nil
+}
+
+func (g *FakeGateway) GetDispute(id string) (*stripe.Dispute, error) {
+	dispute, ok := g.disputes[id]
+	if !ok {
+		return nil, fmt.Errorf("no such dispute: %s", id)
+	}
+	return dispute, nil
+}
+
+func (g *FakeGateway) ListPayouts(created *stripe.RangeQueryParams) ([]*stripe.Payout, error) {
+	var payouts []*stripe.Payout
+	for _, payout := range g.payouts {
+		if inRange(payout.Created, created) {
+			payouts = append(payouts, payout)
+		}
+	}
+	sort.Slice(payouts, func(i, j int) bool {
+		return payouts[i].Created > payouts[j].Created
+	})
+	return payouts, nil
+}
+
+// inRange reports whether created matches the range filter, which the Stripe API
+// treats as unbounded when it is nil.
+func inRange(created int64, params *stripe.RangeQueryParams) bool {
+	if params == nil {
+		return true
+	}
+	if params.GreaterThan != 0 && created <= params.GreaterThan {
+		return false
+	}
+	if params.GreaterThanOrEqual != 0 && created < params.GreaterThanOrEqual {
+		return false
+	}
+	if params.LesserThan != 0 && created >= params.LesserThan {
+		return false
+	}
+	if params.LesserThanOrEqual != 0 && created > params.LesserThanOrEqual {
+		return false
+	}
+	return true
+}
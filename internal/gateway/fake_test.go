@@ -0,0 +1,32 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stripe/stripe-go/v79"
+)
+
+func TestInRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		created  int64
+		params   *stripe.RangeQueryParams
+		expected bool
+	}{
+		{name: "noRange", created: 100, params: nil, expected: true},
+		{name: "fromIncluded", created: 100, params: &stripe.RangeQueryParams{GreaterThanOrEqual: 100}, expected: true},
+		{name: "beforeFrom", created: 99, params: &stripe.RangeQueryParams{GreaterThanOrEqual: 100}, expected: false},
+		{name: "toExcluded", created: 200, params: &stripe.RangeQueryParams{LesserThan: 200}, expected: false},
+		{name: "insideRange", created: 150, params: &stripe.RangeQueryParams{GreaterThanOrEqual: 100, LesserThan: 200}, expected: true},
+		{name: "afterExclusiveFrom", created: 100, params: &stripe.RangeQueryParams{GreaterThan: 100}, expected: false},
+		{name: "inclusiveTo", created: 200, params: &stripe.RangeQueryParams{LesserThanOrEqual: 200}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := inRange(tt.created, tt.params); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
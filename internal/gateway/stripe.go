@@ -0,0 +1,111 @@
+package gateway
+
+import (
+	"github.com/stripe/stripe-go/v79"
+	"github.com/stripe/stripe-go/v79/client"
+)
+
+// StripeGateway fetches objects from the Stripe API with its own client, so it
+// does not depend on the package-level stripe.Key.
+type StripeGateway struct {
+	api *client.API
+}
+
+func NewStripeGateway(key string) *StripeGateway {
+	return &StripeGateway{api: client.New(key, nil)}
+}
+
+func (g *StripeGateway) GetBalanceTransaction(id string) (*stripe.BalanceTransaction, error) {
+	params := &stripe.BalanceTransactionParams{}
+	return g.api.BalanceTransactions.Get(id, params)
+}
+
+func (g *StripeGateway) ListPayoutTransactions(payoutID string) ([]*stripe.BalanceTransaction, error) {
+	params := &stripe.BalanceTransactionListParams{}
+	params.Payout = &payoutID
+
+	iter := g.api.BalanceTransactions.List(params)
+
+	var transactions []*stripe.BalanceTransaction
+	for iter.Next() {
+		transactions = append(transactions, iter.BalanceTransaction())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// GetCharge expands the customer, whose preferred locales pick the document language.
+func (g *StripeGateway) GetCharge(id string) (*stripe.Charge, error) {
+	params := &stripe.ChargeParams{}
+	params.AddExpand("customer")
+	return g.api.Charges.Get(id, params)
+}
+
+func (g *StripeGateway) ListCharges(created *stripe.RangeQueryParams) ([]*stripe.Charge, error) {
+	params := &stripe.ChargeListParams{}
+	params.CreatedRange = created
+	params.AddExpand("data.customer")
+
+	iter := g.api.Charges.List(params)
+
+	var charges []*stripe.Charge
+	for iter.Next() {
+		charges = append(charges, iter.Charge())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return charges, nil
+}
+
+// GetRefund expands the refunded charge, which links the refund to its donation.
+func (g *StripeGateway) GetRefund(id string) (*stripe.Refund, error) {
+	params := &stripe.RefundParams{}
+	params.AddExpand("charge")
+	return g.api.Refunds.Get(id, params)
+}
+
+// ListChargeRefunds expands the balance transaction of every refund.
+func (g *StripeGateway) ListChargeRefunds(chargeID string) ([]*stripe.Refund, error) {
+	params := &stripe.RefundListParams{}
+	params.Charge = &chargeID
+	params.AddExpand("data.balance_transaction")
+
+	iter := g.api.Refunds.List(params)
+
+	var refunds []*stripe.Refund
+	for iter.Next() {
+		refunds = append(refunds, iter.Refund())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (g *StripeGateway) GetDispute(id string) (*stripe.Dispute, error) {
+	params := &stripe.DisputeParams{}
+	return g.api.Disputes.Get(id, params)
+}
+
+func (g *StripeGateway) ListPayouts(created *stripe.RangeQueryParams) ([]*stripe.Payout, error) {
+	params := &stripe.PayoutListParams{}
+	params.CreatedRange = created
+
+	iter := g.api.Payouts.List(params)
+
+	var payouts []*stripe.Payout
+	for iter.Next() {
+		payouts = append(payouts, iter.Payout())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return payouts, nil
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+// HandleArchive lists the issued versions of a document and reissues it on POST.
+func (h *PWAHandler) HandleArchive(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	lang := requestLanguage(w, r)
+	documentType := r.FormValue("type")
+	reference := r.FormValue("reference")
+
+	message := ""
+	if r.Method == http.MethodPost {
+		documentLang, err := documentLanguage(r.FormValue("lang"))
+		if err != nil {
+			http.Error(w, "Unsupported language", http.StatusBadRequest)
+			return
+		}
+		_, err = h.archive.Reissue(documentType, reference, documentLang, user.Username, r.FormValue("reason"))
+		if err == nil {
+			if !recordAccess(w, h.audit, requestActor(r, user.Username), models.AuditReissue, documentType, reference) {
+				return
+			}
+			http.Redirect(w, r, archiveURL(documentType, reference)+"&reissued=1", http.StatusSeeOther)
+			return
+		}
+		var validationError *custom_errors.ValidationError
+		if !errors.As(err, &validationError) {
+			log.Printf("Archive service error: %v\n", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		message = validationError.Error()
+	}
+
+	versions, err := h.archive.Versions(documentType, reference)
+	if err != nil {
+		log.Printf("Archive service error: %v\n", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	data := struct {
+		Type      string
+		Reference string
+		Versions  []*dto.ArchivedDocument
+		Reissued  bool
+		Error     string
+	}{
+		Type:      documentType,
+		Reference: reference,
+		Versions:  versions,
+		Reissued:  r.FormValue("reissued") != "",
+		Error:     message,
+	}
+
+	var buffer bytes.Buffer
+	if err := executeTemplate(&buffer, h.tmpl, "archive", lang, data); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	buffer.WriteTo(w)
+}
+
+// HandleArchivedDocument serves one stored version of a document.
+func (h *PWAHandler) HandleArchivedDocument(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	document, content, err := h.archive.ArchivedDocument(id)
+	if err != nil {
+		log.Printf("Archive service error: %v\n", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if !recordAccess(w, h.audit, requestActor(r, user.Username), models.AuditDownload, document.Type, document.Reference) {
+		return
+	}
+	writeArchivedDocument(w, document, content)
+}
+
+func archiveURL(documentType, reference string) string {
+	return "/archive?type=" + url.QueryEscape(documentType) + "&reference=" + url.QueryEscape(reference)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+// AuditRecorder records what a user did that changed nothing, such as a download.
+type AuditRecorder interface {
+	Record(actor models.Actor, action, entity, reference string) error
+}
+
+type AuditService interface {
+	ListEntries(username, action, entity, reference, from, to string) ([]*dto.FormattedAuditEntry, error)
+}
+
+type AuditHandler struct {
+	service AuditService
+	tmpl    *template.Template
+}
+
+func NewAuditHandler(service AuditService) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+		tmpl:    parseTemplates(),
+	}
+}
+
+// auditActions are the actions the audit page filters by.
+var auditActions = []string{
+	models.AuditInsert,
+	models.AuditUpdate,
+	models.AuditDelete,
+	models.AuditMerge,
+	models.AuditRetry,
+	models.AuditResend,
+	models.AuditReissue,
+	models.AuditDownload,
+	models.AuditLogin,
+	models.AuditLoginFailed,
+}
+
+// HandleAudit lists the audit log, newest first, keeping the filters in the form.
+func (h *AuditHandler) HandleAudit(w http.ResponseWriter, r *http.Request) {
+	if _, err := authorize(r, "admin"); err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	data := struct {
+		Username  string
+		Action    string
+		Entity    string
+		Reference string
+		From      string
+		To        string
+		Actions   []string
+		Entries   []*dto.FormattedAuditEntry
+		Error     string
+	}{
+		Username:  query.Get("user"),
+		Action:    query.Get("action"),
+		Entity:    query.Get("entity"),
+		Reference: query.Get("reference"),
+		From:      query.Get("from"),
+		To:        query.Get("to"),
+		Actions:   auditActions,
+	}
+
+	entries, err := h.service.ListEntries(data.Username, data.Action, data.Entity, data.Reference, data.From, data.To)
+	if err != nil {
+		var validationError *custom_errors.ValidationError
+		if !errors.As(err, &validationError) {
+			log.Printf("Audit service error: %v\n", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		data.Error = validationError.Error()
+	}
+	data.Entries = entries
+
+	var buffer bytes.Buffer
+	if err := executeTemplate(&buffer, h.tmpl, "audit", requestLanguage(w, r), data); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	buffer.WriteTo(w)
+}
+
+// recordAccess audits the action before anything is served, answering with an error when
+// it cannot be recorded, so nothing is served without a trace.
+func recordAccess(w http.ResponseWriter, audit AuditRecorder, actor models.Actor, action, entity, reference string) bool {
+	if err := audit.Record(actor, action, entity, reference); err != nil {
+		log.Printf("Audit service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// logAccess audits a streamed download once it is written, when it is too late to answer
+// with an error.
+func logAccess(audit AuditRecorder, actor models.Actor, action, entity, reference string) {
+	if err := audit.Record(actor, action, entity, reference); err != nil {
+		log.Printf("Audit service error: %v\n", err)
+	}
+}
+
+// requestActor is the user behind a request and the address they came from.
+func requestActor(r *http.Request, username string) models.Actor {
+	return models.Actor{Username: username, IP: clientIP(r)}
+}
+
+// trustedProxies are the proxies whose X-Real-IP is believed, set once at startup.
+var trustedProxies []*net.IPNet
+
+// TrustProxies sets the proxies, such as nginx, allowed to name the client in X-Real-IP.
+func TrustProxies(proxies []*net.IPNet) {
+	trustedProxies = proxies
+}
+
+// clientIP is the address of the connection, or the one a trusted proxy passes in
+// X-Real-IP. The header of anyone else is ignored, since a client can set it to anything.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := r.Header.Get("X-Real-IP"); ip != "" && trustedProxy(host) {
+		return ip
+	}
+	return host
+}
+
+func trustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	testCases := map[string]struct {
+		remoteAddr string
+		realIP     string
+		expected   string
+	}{
+		"nginx":         {remoteAddr: "172.18.0.3:41234", realIP: "203.0.113.7", expected: "203.0.113.7"},
+		"untrusted":     {remoteAddr: "198.51.100.9:41234", realIP: "203.0.113.7", expected: "198.51.100.9"},
+		"untrustedIPv6": {remoteAddr: "[2001:db8::1]:41234", realIP: "203.0.113.7", expected: "2001:db8::1"},
+		"direct":        {remoteAddr: "203.0.113.7:41234", expected: "203.0.113.7"},
+		"directIPv6":    {remoteAddr: "[2001:db8::1]:41234", expected: "2001:db8::1"},
+		"withoutPort":   {remoteAddr: "203.0.113.7", expected: "203.0.113.7"},
+	}
+
+	_, network, _ := net.ParseCIDR("172.18.0.0/16")
+	TrustProxies([]*net.IPNet{network})
+	t.Cleanup(func() { TrustProxies(nil) })
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.realIP != "" {
+				r.Header.Set("X-Real-IP", tc.realIP)
+			}
+
+			if result := clientIP(r); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"time"
 
 	"github.com/diother/go-invoices/internal/custom_errors"
-	"github.com/diother/go-invoices/internal/helpers"
+	"github.com/diother/go-invoices/internal/i18n"
 	"github.com/diother/go-invoices/internal/models"
 )
 
@@ -20,31 +20,21 @@ type AuthService interface {
 
 type AuthHandler struct {
 	service AuthService
+	audit   AuditRecorder
 	tmpl    *template.Template
 }
 
-func NewAuthHandler(service AuthService) *AuthHandler {
-	tmpl := template.New("base").Funcs(template.FuncMap{
-		"slice": helpers.SliceHelper,
-		"attr":  helpers.AttrHelper,
-	})
-	tmpl, err := tmpl.ParseGlob("internal/views/*.html")
-	if err != nil {
-		log.Fatalf("Failed to parse templates: %v", err)
-	}
-	tmpl, err = tmpl.ParseGlob("internal/views/components/*.html")
-	if err != nil {
-		log.Fatalf("Failed to parse templates: %v", err)
-	}
+func NewAuthHandler(service AuthService, audit AuditRecorder) *AuthHandler {
 	return &AuthHandler{
 		service: service,
-		tmpl:    tmpl,
+		audit:   audit,
+		tmpl:    parseTemplates(),
 	}
 }
 
 func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet {
-		if err := h.tmpl.ExecuteTemplate(w, "login", nil); err != nil {
+		if err := executeTemplate(w, h.tmpl, "login", requestLanguage(w, r), nil); err != nil {
 			log.Printf("Template execution failed: %v", err)
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
@@ -65,7 +55,9 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			var credentialsError *custom_errors.CredentialsError
 			if errors.As(err, &credentialsError) {
-				http.Error(w, credentialsError.Error(), http.StatusUnauthorized)
+				logAccess(h.audit, requestActor(r, username), models.AuditLoginFailed, "user", username)
+				// Credentials errors are catalogue keys.
+				http.Error(w, i18n.T(requestLanguage(w, r), credentialsError.Error()), http.StatusUnauthorized)
 				return
 			}
 			log.Printf("Auth service error: %v\n", err)
@@ -73,6 +65,10 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if !recordAccess(w, h.audit, requestActor(r, user.Username), models.AuditLogin, "user", user.Username) {
+			return
+		}
+
 		session, err := h.service.GenerateSession(user)
 		if err != nil {
 			log.Printf("Auth service error: %v\n", err)
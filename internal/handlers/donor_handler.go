@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+type DonorService interface {
+	ListDonors(search string) ([]*dto.FormattedDonorSummary, error)
+	Donor(id, lang string) (*dto.DonorDetail, error)
+	RenameDonor(actor models.Actor, id, name string) error
+	MergeDonor(actor models.Actor, id, duplicateEmail string) error
+}
+
+type DonorHandler struct {
+	service DonorService
+	tmpl    *template.Template
+}
+
+func NewDonorHandler(service DonorService) *DonorHandler {
+	return &DonorHandler{
+		service: service,
+		tmpl:    parseTemplates(),
+	}
+}
+
+// HandleDonors lists donors by name, narrowed by a search on name or email.
+func (h *DonorHandler) HandleDonors(w http.ResponseWriter, r *http.Request) {
+	if _, err := authorize(r, "admin"); err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	search := r.URL.Query().Get("q")
+	donors, err := h.service.ListDonors(search)
+	if err != nil {
+		log.Printf("Donor service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Search string
+		Donors []*dto.FormattedDonorSummary
+	}{
+		Search: search,
+		Donors: donors,
+	}
+
+	var buffer bytes.Buffer
+	if err := executeTemplate(&buffer, h.tmpl, "donors", requestLanguage(w, r), data); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	buffer.WriteTo(w)
+}
+
+// HandleDonor shows a donor's giving history, and renames the donor on POST.
+func (h *DonorHandler) HandleDonor(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	lang := requestLanguage(w, r)
+	id := r.FormValue("id")
+	if r.Method == http.MethodGet {
+		h.render(w, lang, id, r.FormValue("saved"), "")
+		return
+	}
+
+	err = h.service.RenameDonor(requestActor(r, user.Username), id, r.FormValue("name"))
+	h.redirectOrRender(w, r, lang, id, "renamed", err)
+}
+
+// HandleDonorMerge merges the donor known by the duplicate email into the donor.
+func (h *DonorHandler) HandleDonorMerge(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	id := r.FormValue("id")
+	err = h.service.MergeDonor(requestActor(r, user.Username), id, r.FormValue("duplicate"))
+	h.redirectOrRender(w, r, requestLanguage(w, r), id, "merged", err)
+}
+
+func (h *DonorHandler) redirectOrRender(w http.ResponseWriter, r *http.Request, lang, id, saved string, err error) {
+	if err != nil {
+		var validationError *custom_errors.ValidationError
+		if errors.As(err, &validationError) {
+			w.WriteHeader(http.StatusBadRequest)
+			h.render(w, lang, id, "", validationError.Error())
+			return
+		}
+		log.Printf("Donor service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/donor?id="+url.QueryEscape(id)+"&saved="+saved, http.StatusSeeOther)
+}
+
+func (h *DonorHandler) render(w http.ResponseWriter, lang, id, saved, message string) {
+	donor, err := h.service.Donor(id, lang)
+	if err != nil {
+		var validationError *custom_errors.ValidationError
+		if errors.As(err, &validationError) {
+			http.Error(w, validationError.Error(), http.StatusNotFound)
+			return
+		}
+		log.Printf("Donor service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Donor *dto.DonorDetail
+		Saved string
+		Error string
+	}{
+		Donor: donor,
+		Saved: saved,
+		Error: message,
+	}
+
+	var buffer bytes.Buffer
+	if err := executeTemplate(&buffer, h.tmpl, "donor", lang, data); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	buffer.WriteTo(w)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+type EmailService interface {
+	ListEmails(status string) ([]*dto.FormattedEmail, error)
+	ResendInvoice(donationID, user string) error
+}
+
+type EmailHandler struct {
+	service EmailService
+	audit   AuditRecorder
+	tmpl    *template.Template
+}
+
+func NewEmailHandler(service EmailService, audit AuditRecorder) *EmailHandler {
+	return &EmailHandler{
+		service: service,
+		audit:   audit,
+		tmpl:    parseTemplates(),
+	}
+}
+
+// HandleEmails lists the outbox by status, failed emails first.
+func (h *EmailHandler) HandleEmails(w http.ResponseWriter, r *http.Request) {
+	if _, err := authorize(r, "admin"); err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	status := r.FormValue("status")
+	if status == "" {
+		status = "failed"
+	}
+
+	emails, err := h.service.ListEmails(status)
+	if err != nil {
+		log.Printf("Email service error: %v\n", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	data := struct {
+		Status string
+		Resent bool
+		Emails []*dto.FormattedEmail
+	}{
+		Status: status,
+		Resent: r.FormValue("resent") != "",
+		Emails: emails,
+	}
+
+	var buffer bytes.Buffer
+	if err := executeTemplate(&buffer, h.tmpl, "emails", requestLanguage(w, r), data); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	buffer.WriteTo(w)
+}
+
+// HandleEmailResend queues the invoice of a donation to be emailed again.
+func (h *EmailHandler) HandleEmailResend(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	donationID := r.FormValue("ID")
+	if donationID == "" {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ResendInvoice(donationID, user.Username); err != nil {
+		var validationError *custom_errors.ValidationError
+		if errors.As(err, &validationError) {
+			http.Error(w, validationError.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Email service error: %v\n", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if !recordAccess(w, h.audit, requestActor(r, user.Username), models.AuditResend, "donation", donationID) {
+		return
+	}
+
+	http.Redirect(w, r, "/emails?status=queued&resent=1", http.StatusSeeOther)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+type ExportService interface {
+	Export(w io.Writer, month, from, to, lang, user string) (*dto.ExportSummary, error)
+}
+
+type ExportHandler struct {
+	service ExportService
+	audit   AuditRecorder
+}
+
+func NewExportHandler(service ExportService, audit AuditRecorder) *ExportHandler {
+	return &ExportHandler{
+		service: service,
+		audit:   audit,
+	}
+}
+
+// HandleExport streams the documents of a month, or of a from and to date, as a ZIP.
+func (h *ExportHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	lang, err := documentLanguage(query.Get("lang"))
+	if err != nil {
+		http.Error(w, "Unsupported language", http.StatusBadRequest)
+		return
+	}
+	month, from, to := query.Get("month"), query.Get("from"), query.Get("to")
+
+	response := &zipResponse{ResponseWriter: w, filename: exportFileName(month, from, to)}
+	summary, err := h.service.Export(response, month, from, to, lang, user.Username)
+	if err != nil {
+		if response.started {
+			log.Printf("Export failed while streaming: %v\n", err)
+			return
+		}
+		var validationError *custom_errors.ValidationError
+		if errors.As(err, &validationError) {
+			http.Error(w, validationError.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Export service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	for _, failure := range summary.Failures {
+		log.Printf("Export left out %s\n", failure)
+	}
+	logAccess(h.audit, requestActor(r, user.Username), models.AuditDownload, "export", exportReference(month, from, to))
+}
+
+// zipResponse sets the download headers on the first write, so an export that fails
+// before streaming starts can still answer with an error.
+type zipResponse struct {
+	http.ResponseWriter
+	filename string
+	started  bool
+}
+
+func (z *zipResponse) Write(p []byte) (int, error) {
+	if !z.started {
+		z.started = true
+		z.Header().Set("Content-Type", "application/zip")
+		z.Header().Set("Content-Disposition", "attachment; filename="+z.filename)
+	}
+	return z.ResponseWriter.Write(p)
+}
+
+func exportFileName(month, from, to string) string {
+	return "export-" + exportReference(month, from, to) + ".zip"
+}
+
+func exportReference(month, from, to string) string {
+	if month != "" {
+		return month
+	}
+	return from + "_" + to
+}
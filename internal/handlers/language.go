@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/diother/go-invoices/internal/i18n"
+)
+
+const languageCookie = "lang"
+
+// requestLanguage picks the language views are shown in: a lang parameter, which is
+// remembered in a cookie, then the cookie, then the browser's Accept-Language header.
+func requestLanguage(w http.ResponseWriter, r *http.Request) string {
+	if lang := i18n.Language(r.URL.Query().Get("lang")); lang != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     languageCookie,
+			Value:    lang,
+			Path:     "/",
+			MaxAge:   365 * 24 * 60 * 60,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return lang
+	}
+	if cookie, err := r.Cookie(languageCookie); err == nil {
+		if lang := i18n.Language(cookie.Value); lang != "" {
+			return lang
+		}
+	}
+	return acceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+// acceptLanguage returns the first supported language of an Accept-Language header, which
+// browsers send in order of preference.
+func acceptLanguage(header string) string {
+	var locales []string
+	for _, entry := range strings.Split(header, ",") {
+		locale, _, _ := strings.Cut(entry, ";")
+		locales = append(locales, locale)
+	}
+	return i18n.Resolve(locales...)
+}
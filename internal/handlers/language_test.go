@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestLanguage(t *testing.T) {
+	testCases := map[string]struct {
+		url            string
+		cookie         string
+		acceptLanguage string
+		expected       string
+		expectCookie   bool
+	}{
+		"default":        {url: "/", expected: "ro"},
+		"parameter":      {url: "/?lang=en", cookie: "ro", expected: "en", expectCookie: true},
+		"badParameter":   {url: "/?lang=fr", cookie: "en", expected: "en"},
+		"cookie":         {url: "/", cookie: "en", acceptLanguage: "ro", expected: "en"},
+		"acceptLanguage": {url: "/", acceptLanguage: "fr-FR,en-GB;q=0.8,ro;q=0.5", expected: "en"},
+		"unsupported":    {url: "/", acceptLanguage: "de-DE,fr;q=0.9", expected: "ro"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			if tc.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: languageCookie, Value: tc.cookie})
+			}
+			if tc.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			if result := requestLanguage(w, r); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+			if hasCookie := len(w.Result().Cookies()) > 0; hasCookie != tc.expectCookie {
+				t.Errorf("Expected cookie set %v, got %v", tc.expectCookie, hasCookie)
+			}
+		})
+	}
+}
+
+func TestDocumentLanguage(t *testing.T) {
+	testCases := map[string]struct {
+		value       string
+		expected    string
+		expectError bool
+	}{
+		"empty":       {value: "", expected: ""},
+		"english":     {value: "en", expected: "en"},
+		"locale":      {value: "ro-RO", expected: "ro"},
+		"unsupported": {value: "fr", expectError: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := documentLanguage(tc.value)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
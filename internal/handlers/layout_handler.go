@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/signintech/gopdf"
+)
+
+type LayoutService interface {
+	LayoutNames() []string
+	GetLayout(name string) (*dto.DocumentLayout, error)
+	PreviewLayout(name, definition, lang string) (*gopdf.GoPdf, error)
+	SaveLayout(actor models.Actor, name, definition string) error
+	ResetLayout(actor models.Actor, name string) error
+}
+
+// layoutTitles holds the catalogue key of each document's title.
+var layoutTitles = map[string]string{
+	"invoice":         "document.invoice.title",
+	"credit_note":     "document.creditNote.title",
+	"payout_report":   "document.payoutReport.title",
+	"monthly_report":  "document.monthlyReport.title",
+	"donor_statement": "document.statement.title",
+}
+
+type LayoutHandler struct {
+	service LayoutService
+	tmpl    *template.Template
+}
+
+func NewLayoutHandler(service LayoutService) *LayoutHandler {
+	return &LayoutHandler{
+		service: service,
+		tmpl:    parseTemplates(),
+	}
+}
+
+func (h *LayoutHandler) HandleLayouts(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	lang := requestLanguage(w, r)
+	name := r.FormValue("name")
+	if name == "" {
+		name = h.service.LayoutNames()[0]
+	}
+
+	if r.Method == http.MethodGet {
+		layout, err := h.service.GetLayout(name)
+		if err != nil {
+			log.Printf("Layout service error: %v\n", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		h.render(w, lang, layout, r.FormValue("saved") != "", "")
+		return
+	}
+
+	definition := r.FormValue("definition")
+	switch r.FormValue("action") {
+	case "preview":
+		pdf, err := h.service.PreviewLayout(name, definition, lang)
+		if err != nil {
+			var validationError *custom_errors.ValidationError
+			if errors.As(err, &validationError) {
+				http.Error(w, validationError.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Layout service error: %v\n", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", "inline; filename=preview.pdf")
+
+		if _, err = pdf.WriteTo(w); err != nil {
+			http.Error(w, "Failed to write PDF", http.StatusInternalServerError)
+		}
+		return
+
+	case "reset":
+		if err := h.service.ResetLayout(requestActor(r, user.Username), name); err != nil {
+			log.Printf("Layout service error: %v\n", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+	default:
+		if err := h.service.SaveLayout(requestActor(r, user.Username), name, definition); err != nil {
+			var validationError *custom_errors.ValidationError
+			if errors.As(err, &validationError) {
+				w.WriteHeader(http.StatusBadRequest)
+				h.render(w, lang, dto.NewDocumentLayout(name, definition, true), false, validationError.Error())
+				return
+			}
+			log.Printf("Layout service error: %v\n", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	http.Redirect(w, r, "/layouts?saved=1&name="+url.QueryEscape(name), http.StatusSeeOther)
+}
+
+func (h *LayoutHandler) render(w http.ResponseWriter, lang string, layout *dto.DocumentLayout, saved bool, message string) {
+	type layoutLink struct {
+		Name  string
+		Title string
+	}
+	var layouts []layoutLink
+	for _, name := range h.service.LayoutNames() {
+		layouts = append(layouts, layoutLink{Name: name, Title: i18n.T(lang, layoutTitles[name])})
+	}
+
+	data := struct {
+		Layouts []layoutLink
+		Layout  *dto.DocumentLayout
+		Title   string
+		Saved   bool
+		Error   string
+	}{
+		Layouts: layouts,
+		Layout:  layout,
+		Title:   i18n.T(lang, layoutTitles[layout.Name]),
+		Saved:   saved,
+		Error:   message,
+	}
+
+	var buffer bytes.Buffer
+	if err := executeTemplate(&buffer, h.tmpl, "layouts", lang, data); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	buffer.WriteTo(w)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/signintech/gopdf"
+)
+
+type PortalService interface {
+	RequestLink(email, lang string) error
+	Documents(token string) (*dto.PortalDocuments, error)
+	Invoice(token, donationID, lang, ip string) (*gopdf.GoPdf, error)
+	Statement(token, year, lang, ip string) (*gopdf.GoPdf, error)
+}
+
+// PortalHandler serves the public donor portal. Its routes sit outside the session
+// middleware; every request past the first is authorised by the signed token it carries.
+type PortalHandler struct {
+	service PortalService
+	tmpl    *template.Template
+}
+
+func NewPortalHandler(service PortalService) *PortalHandler {
+	return &PortalHandler{
+		service: service,
+		tmpl:    parseTemplates(),
+	}
+}
+
+type portalPage struct {
+	Email string
+	Sent  bool
+	Error string
+}
+
+// HandlePortal shows the form a donor asks for a magic link with, and sends the link.
+func (h *PortalHandler) HandlePortal(w http.ResponseWriter, r *http.Request) {
+	setPortalHeaders(w)
+	lang := requestLanguage(w, r)
+	if r.Method == http.MethodGet {
+		h.renderPortal(w, lang, &portalPage{})
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+	email := r.FormValue("email")
+
+	if err := h.service.RequestLink(email, lang); err != nil {
+		var validationError *custom_errors.ValidationError
+		if errors.As(err, &validationError) {
+			w.WriteHeader(http.StatusBadRequest)
+			h.renderPortal(w, lang, &portalPage{Email: email, Error: validationError.Error()})
+			return
+		}
+		// Only an invalid email is reported; whether the link goes out is never known here.
+		log.Printf("Portal service error: %v\n", err)
+	}
+	h.renderPortal(w, lang, &portalPage{Email: email, Sent: true})
+}
+
+// HandlePortalDocuments lists the documents of the donor a magic link was signed for.
+func (h *PortalHandler) HandlePortalDocuments(w http.ResponseWriter, r *http.Request) {
+	setPortalHeaders(w)
+	lang := requestLanguage(w, r)
+	token := r.URL.Query().Get("token")
+
+	documents, err := h.service.Documents(token)
+	if err != nil {
+		var validationError *custom_errors.ValidationError
+		if errors.As(err, &validationError) {
+			w.WriteHeader(http.StatusBadRequest)
+			h.renderPortal(w, lang, &portalPage{Error: validationError.Error()})
+			return
+		}
+		log.Printf("Portal service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Token     string
+		Documents *dto.PortalDocuments
+	}{
+		Token:     token,
+		Documents: documents,
+	}
+
+	var buffer bytes.Buffer
+	if err := executeTemplate(&buffer, h.tmpl, "portal_documents", lang, data); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	buffer.WriteTo(w)
+}
+
+// HandlePortalInvoice serves the invoice of one of the donor's donations.
+func (h *PortalHandler) HandlePortalInvoice(w http.ResponseWriter, r *http.Request) {
+	setPortalHeaders(w)
+	query := r.URL.Query()
+	lang, err := documentLanguage(query.Get("lang"))
+	if err != nil {
+		http.Error(w, "Unsupported language", http.StatusBadRequest)
+		return
+	}
+
+	pdf, err := h.service.Invoice(query.Get("token"), query.Get("id"), lang, clientIP(r))
+	writePortalPDF(w, pdf, err, "invoice.pdf")
+}
+
+// HandlePortalStatement serves the donor's annual statement for a year.
+func (h *PortalHandler) HandlePortalStatement(w http.ResponseWriter, r *http.Request) {
+	setPortalHeaders(w)
+	query := r.URL.Query()
+	lang, err := documentLanguage(query.Get("lang"))
+	if err != nil {
+		http.Error(w, "Unsupported language", http.StatusBadRequest)
+		return
+	}
+	year := query.Get("year")
+
+	pdf, err := h.service.Statement(query.Get("token"), year, lang, clientIP(r))
+	writePortalPDF(w, pdf, err, "statement-"+year+".pdf")
+}
+
+func (h *PortalHandler) renderPortal(w http.ResponseWriter, lang string, page *portalPage) {
+	if err := executeTemplate(w, h.tmpl, "portal", lang, page); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func writePortalPDF(w http.ResponseWriter, pdf *gopdf.GoPdf, err error, filename string) {
+	if err != nil {
+		var validationError *custom_errors.ValidationError
+		if errors.As(err, &validationError) {
+			http.Error(w, validationError.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Portal service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename="+filename)
+
+	if _, err = pdf.WriteTo(w); err != nil {
+		http.Error(w, "Failed to write PDF", http.StatusInternalServerError)
+	}
+}
+
+// setPortalHeaders keeps portal pages and documents out of shared caches, and keeps the
+// token in their address from leaking to other sites through the Referer header.
+func setPortalHeaders(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+}
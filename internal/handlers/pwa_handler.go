@@ -6,42 +6,47 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/diother/go-invoices/internal/dto"
-	"github.com/diother/go-invoices/internal/helpers"
+	"github.com/diother/go-invoices/internal/i18n"
 	"github.com/diother/go-invoices/internal/models"
-	"github.com/signintech/gopdf"
 )
 
 type AccountingService interface {
-	GenerateInvoice(id string) (*gopdf.GoPdf, error)
-	GeneratePayoutReport(id string) (*gopdf.GoPdf, error)
-	GenerateMonthlyReport(date string) (*gopdf.GoPdf, error)
-	GenerateMonthlyReportView(date string) (*dto.MonthlyReportView, error)
+	GenerateMonthlyReportView(date, lang string) (*dto.MonthlyReportView, error)
+}
+
+type DocumentArchive interface {
+	Document(documentType, reference, lang, user string) (*dto.ArchivedDocument, []byte, error)
+	ArchivedDocument(id int64) (*dto.ArchivedDocument, []byte, error)
+	Versions(documentType, reference string) ([]*dto.ArchivedDocument, error)
+	Reissue(documentType, reference, lang, user, reason string) (*dto.ArchivedDocument, error)
+}
+
+type EventLogService interface {
+	ListEvents(status string) ([]*dto.FormattedStripeEvent, error)
+	RetryEvent(id string) error
 }
 
 type PWAHandler struct {
-	service AccountingService
-	tmpl    *template.Template
+	service  AccountingService
+	archive  DocumentArchive
+	events   EventLogService
+	audit    AuditRecorder
+	location *time.Location
+	tmpl     *template.Template
 }
 
-func NewPWAHandler(service AccountingService) *PWAHandler {
-	tmpl := template.New("base").Funcs(template.FuncMap{
-		"slice": helpers.SliceHelper,
-		"attr":  helpers.AttrHelper,
-	})
-	tmpl, err := tmpl.ParseGlob("internal/views/*.html")
-	if err != nil {
-		log.Fatalf("Failed to parse templates: %v", err)
-	}
-	tmpl, err = tmpl.ParseGlob("internal/views/components/*.html")
-	if err != nil {
-		log.Fatalf("Failed to parse templates: %v", err)
-	}
+func NewPWAHandler(service AccountingService, archive DocumentArchive, events EventLogService, audit AuditRecorder, location *time.Location) *PWAHandler {
 	return &PWAHandler{
-		service: service,
-		tmpl:    tmpl,
+		service:  service,
+		archive:  archive,
+		events:   events,
+		audit:    audit,
+		location: location,
+		tmpl:     parseTemplates(),
 	}
 }
 
@@ -51,22 +56,26 @@ func (h *PWAHandler) HandleDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	now := time.Now().In(h.location)
 	data := struct {
 		Month string
 		Year  string
 	}{
-		Month: time.Now().Format("01"),
-		Year:  time.Now().Format("2006"),
+		Month: now.Format("01"),
+		Year:  now.Format("2006"),
 	}
-	if err := h.tmpl.ExecuteTemplate(w, "home", data); err != nil {
+	if err := executeTemplate(w, h.tmpl, "home", requestLanguage(w, r), data); err != nil {
 		log.Printf("Template execution failed: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// HandleDocuments serves documents from the archive, issuing them on first request.
+// Reports of a period that has not ended are rendered on every request.
 func (h *PWAHandler) HandleDocuments(w http.ResponseWriter, r *http.Request) {
-	if _, err := authorize(r, "admin"); err != nil {
+	user, err := authorize(r, "admin")
+	if err != nil {
 		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
 		return
 	}
@@ -83,46 +92,30 @@ func (h *PWAHandler) HandleDocuments(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
-
-	var pdf *gopdf.GoPdf
-	var err error
-
-	switch documentType {
-	case "donation":
-		pdf, err = h.service.GenerateInvoice(documentID)
-		if err != nil {
-			log.Printf("Accounting service error: %v\n", err)
-			http.Error(w, "Internal server error", http.StatusBadRequest)
-			return
-		}
-
-	case "payout":
-		pdf, err = h.service.GeneratePayoutReport(documentID)
-		if err != nil {
-			log.Printf("Accounting service error: %v\n", err)
-			http.Error(w, "Internal server error", http.StatusBadRequest)
-			return
-		}
-
-	case "monthly":
-		pdf, err = h.service.GenerateMonthlyReport(documentDate)
-		if err != nil {
-			log.Printf("Accounting service error: %v\n", err)
-			http.Error(w, "Internal server error", http.StatusBadRequest)
-			return
-		}
-
-	default:
-		http.Error(w, "Invalid document type", http.StatusBadRequest)
+	lang, err := documentLanguage(r.FormValue("lang"))
+	if err != nil {
+		http.Error(w, "Unsupported language", http.StatusBadRequest)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/pdf")
-	w.Header().Set("Content-Disposition", "inline; filename=document.pdf")
-
-	if _, err = pdf.WriteTo(w); err != nil {
-		http.Error(w, "Failed to write PDF", http.StatusInternalServerError)
+	// The e-Factura XML of an invoice is archived as a document of its own.
+	if documentType == "donation" && r.FormValue("format") == "xml" {
+		documentType = "einvoice"
+	}
+	reference := documentID
+	if documentType == "monthly" {
+		reference = documentDate
+	}
+	document, content, err := h.archive.Document(documentType, reference, lang, user.Username)
+	if err != nil {
+		log.Printf("Archive service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusBadRequest)
+		return
+	}
+	if !recordAccess(w, h.audit, requestActor(r, user.Username), models.AuditDownload, documentType, reference) {
 		return
 	}
+	writeArchivedDocument(w, document, content)
 }
 
 func (h *PWAHandler) HandleMonthly(w http.ResponseWriter, r *http.Request) {
@@ -136,15 +129,14 @@ func (h *PWAHandler) HandleMonthly(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	documentMonth := r.FormValue("month")
-	documentYear := r.FormValue("year")
-	documentDate := documentYear + "-" + documentMonth
+	documentDate := reportPeriod(r.FormValue("period"), r.FormValue("year"), r.FormValue("month"), r.FormValue("from"), r.FormValue("to"))
 	if documentDate == "" {
 		http.Error(w, "Bad request", http.StatusBadRequest)
 		return
 	}
 
-	data, err := h.service.GenerateMonthlyReportView(documentDate)
+	lang := requestLanguage(w, r)
+	data, err := h.service.GenerateMonthlyReportView(documentDate, lang)
 	if err != nil {
 		log.Printf("Accounting service error: %v\n", err)
 		http.Error(w, "Internal server error", http.StatusBadRequest)
@@ -152,7 +144,7 @@ func (h *PWAHandler) HandleMonthly(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var buffer bytes.Buffer
-	if err := h.tmpl.ExecuteTemplate(&buffer, "monthly", data); err != nil {
+	if err := executeTemplate(&buffer, h.tmpl, "monthly", lang, data); err != nil {
 		log.Printf("Template execution failed: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -161,6 +153,98 @@ func (h *PWAHandler) HandleMonthly(w http.ResponseWriter, r *http.Request) {
 	buffer.WriteTo(w)
 }
 
+func (h *PWAHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if _, err := authorize(r, "admin"); err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	status := r.FormValue("status")
+	if status == "" {
+		status = "dead"
+	}
+
+	events, err := h.events.ListEvents(status)
+	if err != nil {
+		log.Printf("Event service error: %v\n", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	data := struct {
+		Status string
+		Events []*dto.FormattedStripeEvent
+	}{
+		Status: status,
+		Events: events,
+	}
+
+	var buffer bytes.Buffer
+	if err := executeTemplate(&buffer, h.tmpl, "events", requestLanguage(w, r), data); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	buffer.WriteTo(w)
+}
+
+func (h *PWAHandler) HandleEventRetry(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	eventID := r.FormValue("ID")
+	if eventID == "" {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.events.RetryEvent(eventID); err != nil {
+		log.Printf("Event service error: %v\n", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if !recordAccess(w, h.audit, requestActor(r, user.Username), models.AuditRetry, "stripe_event", eventID) {
+		return
+	}
+
+	http.Redirect(w, r, "/events?status="+url.QueryEscape(r.FormValue("status")), http.StatusSeeOther)
+}
+
+// writeArchivedDocument sends the stored bytes unchanged, with their hash as the ETag.
+// Documents rendered without being archived have no version in their name. E-invoices are
+// downloaded for upload to e-Factura; PDFs open in the browser.
+func writeArchivedDocument(w http.ResponseWriter, document *dto.ArchivedDocument, content []byte) {
+	contentType, extension, disposition := "application/pdf", "pdf", "inline"
+	if document.Type == "einvoice" {
+		contentType, extension, disposition = "application/xml", "xml", "attachment"
+	}
+	filename := fmt.Sprintf("%s-%s-v%d.%s", document.Type, document.Reference, document.Version, extension)
+	if document.Version == 0 {
+		filename = fmt.Sprintf("%s-%s.%s", document.Type, document.Reference, extension)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", disposition+"; filename="+filename)
+	w.Header().Set("ETag", `"`+document.SHA256+`"`)
+
+	if _, err := w.Write(content); err != nil {
+		http.Error(w, "Failed to write document", http.StatusInternalServerError)
+	}
+}
+
 func validateDocumentRequest(documentType, documentID, documentDate string) error {
 	if documentType == "" {
 		return fmt.Errorf("")
@@ -178,6 +262,35 @@ func validateDocumentRequest(documentType, documentID, documentDate string) erro
 	return nil
 }
 
+// reportPeriod builds the period of a report from the dashboard forms: a year with a
+// month (09), a quarter (Q3) or nothing for the whole year, or a from and to date.
+func reportPeriod(period, year, month, from, to string) string {
+	switch {
+	case period != "":
+		return period
+	case from != "" || to != "":
+		return from + "_" + to
+	case year == "":
+		return ""
+	case month == "":
+		return year
+	}
+	return year + "-" + month
+}
+
+// documentLanguage checks the language a document was requested in. Empty leaves the
+// choice to the service: the donor's language for invoices, the default for reports.
+func documentLanguage(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	lang := i18n.Language(value)
+	if lang == "" {
+		return "", fmt.Errorf("unsupported language %s", value)
+	}
+	return lang, nil
+}
+
 func authorize(r *http.Request, allowedRoles ...string) (*models.User, error) {
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok || user == nil {
@@ -31,3 +31,25 @@ func TestValidateDocumentRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestReportPeriod(t *testing.T) {
+	testCases := map[string]struct {
+		period, year, month, from, to string
+		expected                      string
+	}{
+		"month":     {year: "2024", month: "09", expected: "2024-09"},
+		"quarter":   {year: "2024", month: "Q3", expected: "2024-Q3"},
+		"wholeYear": {year: "2024", expected: "2024"},
+		"range":     {from: "2024-07-01", to: "2024-09-30", expected: "2024-07-01_2024-09-30"},
+		"period":    {period: "2024-Q1", year: "2024", month: "09", expected: "2024-Q1"},
+		"nothing":   {expected: ""},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := reportPeriod(tc.period, tc.year, tc.month, tc.from, tc.to); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
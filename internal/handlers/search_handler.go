@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+)
+
+type SearchService interface {
+	Search(query, minAmount, maxAmount, from, to string) ([]*dto.FormattedSearchResult, error)
+}
+
+type SearchHandler struct {
+	service SearchService
+	tmpl    *template.Template
+}
+
+func NewSearchHandler(service SearchService) *SearchHandler {
+	return &SearchHandler{
+		service: service,
+		tmpl:    parseTemplates(),
+	}
+}
+
+// HandleSearch searches donations, fees and payouts, keeping the filters in the form.
+func (h *SearchHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	if _, err := authorize(r, "admin"); err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	data := struct {
+		Query     string
+		MinAmount string
+		MaxAmount string
+		From      string
+		To        string
+		Searched  bool
+		Results   []*dto.FormattedSearchResult
+		Error     string
+	}{
+		Query:     query.Get("q"),
+		MinAmount: query.Get("min"),
+		MaxAmount: query.Get("max"),
+		From:      query.Get("from"),
+		To:        query.Get("to"),
+	}
+	data.Searched = data.Query != "" || data.MinAmount != "" || data.MaxAmount != "" || data.From != "" || data.To != ""
+
+	results, err := h.service.Search(data.Query, data.MinAmount, data.MaxAmount, data.From, data.To)
+	if err != nil {
+		var validationError *custom_errors.ValidationError
+		if !errors.As(err, &validationError) {
+			log.Printf("Search service error: %v\n", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		data.Error = validationError.Error()
+	}
+	data.Results = results
+
+	var buffer bytes.Buffer
+	if err := executeTemplate(&buffer, h.tmpl, "search", requestLanguage(w, r), data); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	buffer.WriteTo(w)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+const maxLogoSize = 2 << 20
+
+type OrganisationService interface {
+	GetOrganisation() (*dto.Organisation, error)
+	UpdateOrganisation(actor models.Actor, organisation *dto.Organisation, logo, smallLogo []byte) error
+}
+
+type SettingsHandler struct {
+	service OrganisationService
+	tmpl    *template.Template
+}
+
+func NewSettingsHandler(service OrganisationService) *SettingsHandler {
+	return &SettingsHandler{
+		service: service,
+		tmpl:    parseTemplates(),
+	}
+}
+
+func (h *SettingsHandler) HandleSettings(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	lang := requestLanguage(w, r)
+	if r.Method == http.MethodGet {
+		organisation, err := h.service.GetOrganisation()
+		if err != nil {
+			log.Printf("Organisation service error: %v\n", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		h.render(w, lang, organisation, r.FormValue("saved") != "", "")
+		return
+	}
+
+	if err := r.ParseMultipartForm(2 * maxLogoSize); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	organisation := dto.NewOrganisation(
+		r.FormValue("name"),
+		r.FormValue("taxID"),
+		r.FormValue("registrationNumber"),
+		r.FormValue("iban"),
+		r.FormValue("bank"),
+		r.FormValue("email"),
+		r.FormValue("phone"),
+		r.FormValue("stripeAccount"),
+		"",
+		"",
+		dto.NewAddress(
+			r.FormValue("street"),
+			r.FormValue("additionalStreet"),
+			r.FormValue("city"),
+			r.FormValue("county"),
+			r.FormValue("postalCode"),
+			r.FormValue("country"),
+		),
+	)
+	logo, err := readUpload(r, "logo")
+	if err != nil {
+		http.Error(w, "Failed to read logo", http.StatusBadRequest)
+		return
+	}
+	smallLogo, err := readUpload(r, "smallLogo")
+	if err != nil {
+		http.Error(w, "Failed to read logo", http.StatusBadRequest)
+		return
+	}
+
+	if err = h.service.UpdateOrganisation(requestActor(r, user.Username), organisation, logo, smallLogo); err != nil {
+		var validationError *custom_errors.ValidationError
+		if errors.As(err, &validationError) {
+			w.WriteHeader(http.StatusBadRequest)
+			h.render(w, lang, organisation, false, validationError.Error())
+			return
+		}
+		log.Printf("Organisation service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/settings?saved=1", http.StatusSeeOther)
+}
+
+func (h *SettingsHandler) render(w http.ResponseWriter, lang string, organisation *dto.Organisation, saved bool, message string) {
+	data := struct {
+		Organisation *dto.Organisation
+		Saved        bool
+		Error        string
+	}{
+		Organisation: organisation,
+		Saved:        saved,
+		Error:        message,
+	}
+
+	var buffer bytes.Buffer
+	if err := executeTemplate(&buffer, h.tmpl, "settings", lang, data); err != nil {
+		log.Printf("Template execution failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	buffer.WriteTo(w)
+}
+
+// readUpload returns the uploaded file, or nil when the field was left empty.
+func readUpload(r *http.Request, field string) ([]byte, error) {
+	file, _, err := r.FormFile(field)
+	if err == http.ErrMissingFile {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxLogoSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxLogoSize {
+		return nil, errors.New("logo is larger than 2 MB")
+	}
+	return content, nil
+}
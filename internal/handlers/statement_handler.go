@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/signintech/gopdf"
+)
+
+type StatementService interface {
+	GenerateDonorStatement(email, year, lang string) (*gopdf.GoPdf, error)
+}
+
+type StatementExporter interface {
+	ExportDonorStatements(w io.Writer, year, lang string) (*dto.ExportSummary, error)
+}
+
+type StatementHandler struct {
+	service StatementService
+	export  StatementExporter
+	audit   AuditRecorder
+}
+
+func NewStatementHandler(service StatementService, export StatementExporter, audit AuditRecorder) *StatementHandler {
+	return &StatementHandler{
+		service: service,
+		export:  export,
+		audit:   audit,
+	}
+}
+
+// HandleStatement serves the annual statement of one donor.
+func (h *StatementHandler) HandleStatement(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	lang, err := documentLanguage(query.Get("lang"))
+	if err != nil {
+		http.Error(w, "Unsupported language", http.StatusBadRequest)
+		return
+	}
+	email, year := query.Get("email"), query.Get("year")
+
+	pdf, err := h.service.GenerateDonorStatement(email, year, lang)
+	if err != nil {
+		var validationError *custom_errors.ValidationError
+		if errors.As(err, &validationError) {
+			http.Error(w, validationError.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Accounting service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !recordAccess(w, h.audit, requestActor(r, user.Username), models.AuditDownload, "statement", year+"/"+email) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=statement-"+year+".pdf")
+
+	if _, err = pdf.WriteTo(w); err != nil {
+		http.Error(w, "Failed to write PDF", http.StatusInternalServerError)
+	}
+}
+
+// HandleStatements streams the annual statements of every donor of a year as a ZIP.
+func (h *StatementHandler) HandleStatements(w http.ResponseWriter, r *http.Request) {
+	user, err := authorize(r, "admin")
+	if err != nil {
+		http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	lang, err := documentLanguage(query.Get("lang"))
+	if err != nil {
+		http.Error(w, "Unsupported language", http.StatusBadRequest)
+		return
+	}
+	year := query.Get("year")
+
+	response := &zipResponse{ResponseWriter: w, filename: "statements-" + year + ".zip"}
+	summary, err := h.export.ExportDonorStatements(response, year, lang)
+	if err != nil {
+		if response.started {
+			log.Printf("Statement export failed while streaming: %v\n", err)
+			return
+		}
+		var validationError *custom_errors.ValidationError
+		if errors.As(err, &validationError) {
+			http.Error(w, validationError.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Export service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	for _, failure := range summary.Failures {
+		log.Printf("Statement export left out %s\n", failure)
+	}
+	logAccess(h.audit, requestActor(r, user.Username), models.AuditDownload, "statements", year)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"html/template"
+	"io"
+	"log"
+
+	"github.com/diother/go-invoices/internal/helpers"
+	"github.com/diother/go-invoices/internal/i18n"
+)
+
+// parseTemplates loads every view with its components. Handlers call it once at start-up.
+func parseTemplates() *template.Template {
+	tmpl := template.New("base").Funcs(template.FuncMap{
+		"slice": helpers.SliceHelper,
+		"attr":  helpers.AttrHelper,
+	}).Funcs(languageFuncs(i18n.Default))
+	tmpl, err := tmpl.ParseGlob("internal/views/*.html")
+	if err != nil {
+		log.Fatalf("Failed to parse templates: %v", err)
+	}
+	tmpl, err = tmpl.ParseGlob("internal/views/components/*.html")
+	if err != nil {
+		log.Fatalf("Failed to parse templates: %v", err)
+	}
+	return tmpl
+}
+
+// executeTemplate renders the view in lang, on a copy of the parsed templates so requests
+// in different languages do not share the translation functions.
+func executeTemplate(w io.Writer, tmpl *template.Template, name, lang string, data any) error {
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	return clone.Funcs(languageFuncs(lang)).ExecuteTemplate(w, name, data)
+}
+
+func languageFuncs(lang string) template.FuncMap {
+	return template.FuncMap{
+		"lang": func() string { return lang },
+		"t": func(key string, args ...any) string {
+			return i18n.T(lang, key, args...)
+		},
+	}
+}
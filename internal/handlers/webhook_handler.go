@@ -1,37 +1,31 @@
 package handlers
 
 import (
-	"encoding/json"
 	"io"
 	"log"
 	"net/http"
 
-	"github.com/stripe/stripe-go/v79"
 	"github.com/stripe/stripe-go/v79/webhook"
 )
 
-type DonationService interface {
-	ProcessDonation(charge *stripe.Charge) error
-}
-
-type PayoutService interface {
-	ProcessPayout(payout *stripe.Payout) error
+type EventService interface {
+	EnqueueEvent(id, eventType string, payload []byte) (bool, error)
 }
 
 type WebhookHandler struct {
-	donation       DonationService
-	payout         PayoutService
+	event          EventService
 	endpointSecret string
 }
 
-func NewWebhookHandler(donation DonationService, payout PayoutService, secret string) *WebhookHandler {
+func NewWebhookHandler(event EventService, secret string) *WebhookHandler {
 	return &WebhookHandler{
-		donation:       donation,
-		payout:         payout,
+		event:          event,
 		endpointSecret: secret,
 	}
 }
 
+// HandleWebhooks verifies and queues Stripe events, acknowledging them right away.
+// The events are processed in the background by the event worker.
 func (h *WebhookHandler) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
@@ -52,35 +46,14 @@ func (h *WebhookHandler) HandleWebhooks(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	switch event.Type {
-	case "charge.updated":
-		var charge stripe.Charge
-		if err = json.Unmarshal(event.Data.Raw, &charge); err != nil {
-			log.Printf("Invalid JSON: %v\n", err)
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
-			return
-		}
-		if err = h.donation.ProcessDonation(&charge); err != nil {
-			log.Printf("Webhook service error: %v\n", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-
-	case "payout.reconciliation_completed":
-		var payout stripe.Payout
-		if err = json.Unmarshal(event.Data.Raw, &payout); err != nil {
-			log.Printf("Invalid JSON: %v\n", err)
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
-			return
-		}
-		if err = h.payout.ProcessPayout(&payout); err != nil {
-			log.Printf("Webhook service error: %v\n", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-
-	default:
-		log.Println("Unsupported event type:", event.Type)
+	queued, err := h.event.EnqueueEvent(event.ID, string(event.Type), body)
+	if err != nil {
+		log.Printf("Event service error: %v\n", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !queued {
+		log.Printf("Event %s already queued or processed, skipping\n", event.ID)
 	}
 
 	w.WriteHeader(http.StatusOK)
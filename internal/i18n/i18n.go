@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	Romanian = "ro"
+	English  = "en"
+)
+
+// Default is the language of documents and views when nothing else is known.
+const Default = Romanian
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var catalogues = loadCatalogues(Romanian, English)
+
+func loadCatalogues(languages ...string) map[string]map[string]string {
+	catalogues := make(map[string]map[string]string, len(languages))
+	for _, language := range languages {
+		definition, err := locales.ReadFile("locales/" + language + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalogue %s: %v", language, err))
+		}
+		var catalogue map[string]string
+		if err = json.Unmarshal(definition, &catalogue); err != nil {
+			panic(fmt.Sprintf("i18n: malformed catalogue %s: %v", language, err))
+		}
+		catalogues[language] = catalogue
+	}
+	return catalogues
+}
+
+// Languages lists the supported languages, the default first.
+func Languages() []string {
+	return []string{Romanian, English}
+}
+
+// Language returns the supported language of a locale such as "en-GB", "en_US" or "RO",
+// or an empty string when it is not supported.
+func Language(locale string) string {
+	base, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	base = strings.ToLower(strings.TrimSpace(base))
+	if _, ok := catalogues[base]; ok {
+		return base
+	}
+	return ""
+}
+
+// Resolve picks the first supported language among the locales, or the default.
+func Resolve(locales ...string) string {
+	for _, locale := range locales {
+		if language := Language(locale); language != "" {
+			return language
+		}
+	}
+	return Default
+}
+
+// T translates key into language, formatting args into it when given. Keys missing from
+// the catalogue fall back to Romanian, then to the key itself.
+func T(language, key string, args ...any) string {
+	text, ok := catalogues[language][key]
+	if !ok {
+		if text, ok = catalogues[Default][key]; !ok {
+			text = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
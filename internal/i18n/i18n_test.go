@@ -0,0 +1,83 @@
+package i18n
+
+import "testing"
+
+func TestCataloguesMatch(t *testing.T) {
+	for key := range catalogues[Default] {
+		for _, language := range Languages() {
+			if _, ok := catalogues[language][key]; !ok {
+				t.Errorf("Expected %v catalogue to translate %v", language, key)
+			}
+		}
+	}
+	for _, language := range Languages() {
+		if len(catalogues[language]) != len(catalogues[Default]) {
+			t.Errorf("Expected %v catalogue to have %d keys, got %d", language, len(catalogues[Default]), len(catalogues[language]))
+		}
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	testCases := map[string]struct {
+		locale   string
+		expected string
+	}{
+		"base":        {locale: "en", expected: English},
+		"region":      {locale: "en-GB", expected: English},
+		"underscore":  {locale: "en_US", expected: English},
+		"upperCase":   {locale: "RO", expected: Romanian},
+		"unsupported": {locale: "fr-FR", expected: ""},
+		"empty":       {locale: "", expected: ""},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := Language(tc.locale); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	testCases := map[string]struct {
+		locales  []string
+		expected string
+	}{
+		"first":       {locales: []string{"en", "ro"}, expected: English},
+		"skipsEmpty":  {locales: []string{"", "en-GB"}, expected: English},
+		"unsupported": {locales: []string{"de"}, expected: Default},
+		"none":        {locales: nil, expected: Default},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := Resolve(tc.locales...); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	testCases := map[string]struct {
+		language string
+		key      string
+		args     []any
+		expected string
+	}{
+		"romanian":    {language: Romanian, key: "document.invoice.title", expected: "Factură"},
+		"english":     {language: English, key: "document.invoice.title", expected: "Invoice"},
+		"unsupported": {language: "de", key: "document.invoice.title", expected: "Factură"},
+		"missingKey":  {language: English, key: "document.missing", expected: "document.missing"},
+		"args":        {language: English, key: "ui.monthly.title", args: []any{"2024-09"}, expected: "Report 2024-09"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := T(tc.language, tc.key, tc.args...); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
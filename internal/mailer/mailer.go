@@ -0,0 +1,243 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"embed"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	htmlTemplates = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap(languageFuncs(i18n.Default))).ParseFS(templates, "templates/*.html"))
+	textTemplates = texttemplate.Must(texttemplate.New("text").Funcs(texttemplate.FuncMap(languageFuncs(i18n.Default))).ParseFS(templates, "templates/*.txt"))
+)
+
+// SMTPMailer composes emails from the embedded templates and sends them through an SMTP
+// server. The connection is upgraded with STARTTLS when the server offers it, and the
+// login is skipped when no username is set, as for a local MailHog.
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// ComposeInvoice writes the email that goes with an invoice, in lang. The invoice itself
+// is attached by the caller.
+func (m *SMTPMailer) ComposeInvoice(data *dto.InvoiceEmailData, lang string) (*dto.EmailMessage, error) {
+	text, html, err := render("invoice", data, lang)
+	if err != nil {
+		return nil, err
+	}
+	subject := i18n.T(lang, "email.invoice.subject", data.InvoiceNumber, data.OrganisationName)
+	return dto.NewEmailMessage(data.Recipient, subject, text, html), nil
+}
+
+// ComposePortalLink writes the email that signs a donor in to the portal, in lang.
+func (m *SMTPMailer) ComposePortalLink(data *dto.PortalLinkEmailData, lang string) (*dto.EmailMessage, error) {
+	text, html, err := render("portal_link", data, lang)
+	if err != nil {
+		return nil, err
+	}
+	subject := i18n.T(lang, "email.portal.subject", data.OrganisationName)
+	return dto.NewEmailMessage(data.Recipient, subject, text, html), nil
+}
+
+// render executes the text and HTML templates of an email in lang.
+func render(name string, data any, lang string) (string, string, error) {
+	var text, html bytes.Buffer
+	textClone, err := textTemplates.Clone()
+	if err != nil {
+		return "", "", err
+	}
+	if err = textClone.Funcs(languageFuncs(lang)).ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return "", "", fmt.Errorf("%s text template failed: %w", name, err)
+	}
+	htmlClone, err := htmlTemplates.Clone()
+	if err != nil {
+		return "", "", err
+	}
+	if err = htmlClone.Funcs(languageFuncs(lang)).ExecuteTemplate(&html, name+".html", data); err != nil {
+		return "", "", fmt.Errorf("%s html template failed: %w", name, err)
+	}
+	return text.String(), html.String(), nil
+}
+
+func (m *SMTPMailer) Send(message *dto.EmailMessage) error {
+	from, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("sender address invalid: %w", err)
+	}
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("recipient address invalid: %w", err)
+	}
+	content, err := buildMessage(from, to, message, time.Now())
+	if err != nil {
+		return fmt.Errorf("build message failed: %w", err)
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	address := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	if err = smtp.SendMail(address, auth, from.Address, []string{to.Address}, content); err != nil {
+		return fmt.Errorf("smtp send failed: %w", err)
+	}
+	return nil
+}
+
+// buildMessage writes a multipart/mixed message: the text and HTML bodies as
+// multipart/alternative, followed by the attachments.
+func buildMessage(from, to *mail.Address, message *dto.EmailMessage, date time.Time) ([]byte, error) {
+	messageID, err := newMessageID(from.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	mixed := multipart.NewWriter(&body)
+	alternative, err := buildAlternative(message)
+	if err != nil {
+		return nil, err
+	}
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.boundary},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = part.Write(alternative.content); err != nil {
+		return nil, err
+	}
+	for _, attachment := range message.Attachments {
+		if err = writeAttachment(mixed, attachment); err != nil {
+			return nil, err
+		}
+	}
+	if err = mixed.Close(); err != nil {
+		return nil, err
+	}
+
+	var content bytes.Buffer
+	for _, header := range [][2]string{
+		{"From", from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/mixed; boundary=" + mixed.Boundary()},
+	} {
+		fmt.Fprintf(&content, "%s: %s\r\n", header[0], header[1])
+	}
+	content.WriteString("\r\n")
+	content.Write(body.Bytes())
+	return content.Bytes(), nil
+}
+
+type alternativeBody struct {
+	boundary string
+	content  []byte
+}
+
+func buildAlternative(message *dto.EmailMessage) (*alternativeBody, error) {
+	var content bytes.Buffer
+	writer := multipart.NewWriter(&content)
+	for _, body := range [][2]string{{"text/plain", message.Text}, {"text/html", message.HTML}} {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body[0] + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(part)
+		if _, err = encoder.Write([]byte(body[1])); err != nil {
+			return nil, err
+		}
+		if err = encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return &alternativeBody{boundary: writer.Boundary(), content: content.Bytes()}, nil
+}
+
+func writeAttachment(writer *multipart.Writer, attachment *dto.EmailAttachment) error {
+	name := mime.QEncoding.Encode("utf-8", attachment.Name)
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {fmt.Sprintf("%s; name=%q", attachment.ContentType, name)},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", name)},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// RFC 2045 limits base64 lines to 76 characters.
+	encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+	for len(encoded) > 76 {
+		if _, err = part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = part.Write([]byte(encoded + "\r\n"))
+	return err
+}
+
+func newMessageID(sender string) (string, error) {
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	_, domain, found := strings.Cut(sender, "@")
+	if !found {
+		domain = "localhost"
+	}
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain), nil
+}
+
+func languageFuncs(lang string) map[string]any {
+	return map[string]any{
+		"lang": func() string { return lang },
+		"t": func(key string, args ...any) string {
+			return i18n.T(lang, key, args...)
+		},
+	}
+}
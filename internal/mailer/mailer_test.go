@@ -0,0 +1,233 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+)
+
+func TestComposeInvoice(t *testing.T) {
+	testCases := map[string]struct {
+		data            *dto.InvoiceEmailData
+		lang            string
+		expectedSubject string
+		expectedText    []string
+	}{
+		"romanian": {
+			data:            dto.NewInvoiceEmailData("ion@example.com", "Ion Popescu", "HNT-2024-000001", "22 Sep 2024", "100,00 lei", "Asociația Hintermann", "contact@example.com"),
+			lang:            i18n.Romanian,
+			expectedSubject: "Factura HNT-2024-000001 de la Asociația Hintermann",
+			expectedText:    []string{"Bună ziua, Ion Popescu,", "100,00 lei din 22 Sep 2024", "contact@example.com"},
+		},
+		"english": {
+			data:            dto.NewInvoiceEmailData("ion@example.com", "Ion Popescu", "HNT-2024-000001", "22 Sep 2024", "100,00 lei", "Asociația Hintermann", ""),
+			lang:            i18n.English,
+			expectedSubject: "Invoice HNT-2024-000001 from Asociația Hintermann",
+			expectedText:    []string{"Hello Ion Popescu,", "Please find invoice HNT-2024-000001 attached."},
+		},
+		"anonymous": {
+			data:            dto.NewInvoiceEmailData("ion@example.com", "", "HNT-2024-000001", "22 Sep 2024", "100,00 lei", "Asociația Hintermann", ""),
+			lang:            i18n.English,
+			expectedSubject: "Invoice HNT-2024-000001 from Asociația Hintermann",
+			expectedText:    []string{"Hello,\n"},
+		},
+	}
+
+	mailer := NewSMTPMailer("localhost", 1025, "", "", "facturi@example.com")
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			message, err := mailer.ComposeInvoice(tc.data, tc.lang)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if message.To != tc.data.Recipient || message.Subject != tc.expectedSubject {
+				t.Errorf("Expected %v to %v, got %v to %v", tc.expectedSubject, tc.data.Recipient, message.Subject, message.To)
+			}
+			for _, expected := range tc.expectedText {
+				if !strings.Contains(message.Text, expected) {
+					t.Errorf("Expected text to contain %q, got %q", expected, message.Text)
+				}
+			}
+			if !strings.Contains(message.HTML, `lang="`+tc.lang+`"`) || !strings.Contains(message.HTML, "HNT-2024-000001") {
+				t.Errorf("Expected HTML in %v with the invoice number, got %q", tc.lang, message.HTML)
+			}
+		})
+	}
+}
+
+func TestComposePortalLink(t *testing.T) {
+	link := "https://app.example.org/portal/documents?token=abc.def"
+	data := dto.NewPortalLinkEmailData("ion@example.com", link, "22 Sep 2024 10:00", "Asociația Hintermann", "")
+	message, err := NewSMTPMailer("localhost", 1025, "", "", "facturi@example.com").ComposePortalLink(data, i18n.Romanian)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if message.Subject != "Documentele dumneavoastră de la Asociația Hintermann" {
+		t.Errorf("Expected subject in Romanian, got %v", message.Subject)
+	}
+	if !strings.Contains(message.Text, link) || !strings.Contains(message.Text, "valabil până la 22 Sep 2024 10:00") {
+		t.Errorf("Expected text with the link and its expiry, got %q", message.Text)
+	}
+	if !strings.Contains(message.HTML, `href="https://app.example.org/portal/documents?token=abc.def"`) {
+		t.Errorf("Expected HTML linking to the portal, got %q", message.HTML)
+	}
+}
+
+func TestSend(t *testing.T) {
+	server, received := startSMTPServer(t)
+	host, port, _ := net.SplitHostPort(server)
+	portNumber, _ := strconv.Atoi(port)
+	mailer := NewSMTPMailer(host, portNumber, "", "", "Asociația Hintermann <facturi@example.com>")
+
+	invoice := bytes.Repeat([]byte("%PDF-1.4 factură "), 40)
+	message := dto.NewEmailMessage("ion@example.com", "Factura HNT-2024-000001 de la Asociația Hintermann", "Bună ziua,\n", "<p>Bună ziua,</p>")
+	message.Attachments = append(message.Attachments, dto.NewEmailAttachment("HNT-2024-000001.pdf", "application/pdf", invoice))
+	if err := mailer.Send(message); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	delivery := <-received
+	if delivery.from != "facturi@example.com" || delivery.to != "ion@example.com" {
+		t.Errorf("Expected envelope facturi@example.com to ion@example.com, got %v to %v", delivery.from, delivery.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(delivery.data))
+	if err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != message.Subject {
+		t.Errorf("Expected subject %v, got %v", message.Subject, subject)
+	}
+
+	parts := readParts(t, parsed.Header.Get("Content-Type"), parsed.Body)
+	if len(parts) != 3 {
+		t.Fatalf("Expected text, HTML and attachment parts, got %d", len(parts))
+	}
+	if parts[0].contentType != "text/plain" || parts[0].content != "Bună ziua,\r\n" {
+		t.Errorf("Expected text body, got %v %q", parts[0].contentType, parts[0].content)
+	}
+	if parts[1].contentType != "text/html" || parts[1].content != "<p>Bună ziua,</p>" {
+		t.Errorf("Expected HTML body, got %v %q", parts[1].contentType, parts[1].content)
+	}
+	if parts[2].fileName != "HNT-2024-000001.pdf" || parts[2].content != string(invoice) {
+		t.Errorf("Expected the invoice attached unchanged, got %v of %d bytes", parts[2].fileName, len(parts[2].content))
+	}
+}
+
+type messagePart struct {
+	contentType string
+	fileName    string
+	content     string
+}
+
+// readParts flattens a multipart body, decoding quoted-printable and base64 parts.
+func readParts(t *testing.T, contentType string, body io.Reader) (parts []messagePart) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		t.Fatalf("Expected a multipart body, got %v", contentType)
+	}
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if strings.HasPrefix(partType, "multipart/") {
+			parts = append(parts, readParts(t, part.Header.Get("Content-Type"), part)...)
+			continue
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("Failed to read part: %v", err)
+		}
+		if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+			content, err = io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(content)))
+			if err != nil {
+				t.Fatalf("Failed to decode attachment: %v", err)
+			}
+		}
+		parts = append(parts, messagePart{contentType: partType, fileName: part.FileName(), content: string(content)})
+	}
+}
+
+type smtpDelivery struct {
+	from, to, data string
+}
+
+// startSMTPServer answers one SMTP session the way MailHog does, without TLS or login.
+func startSMTPServer(t *testing.T) (string, <-chan smtpDelivery) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan smtpDelivery, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		var delivery smtpDelivery
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				delivery.from = strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<> ")
+				reply("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				delivery.to = strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<> ")
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(dataLine, "."))
+				}
+				delivery.data = data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				received <- delivery
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
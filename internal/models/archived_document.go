@@ -0,0 +1,34 @@
+package models
+
+import "database/sql"
+
+// ArchivedDocument is one issued version of a document. The file is never changed once
+// stored; a reissue adds the next version.
+type ArchivedDocument struct {
+	ID        int64          `db:"id"`
+	Type      string         `db:"type"`
+	Reference string         `db:"reference"`
+	Lang      string         `db:"lang"`
+	Version   int64          `db:"version"`
+	Path      string         `db:"path"`
+	SHA256    string         `db:"sha256"`
+	Size      int64          `db:"size"`
+	Issued    int64          `db:"issued"`
+	IssuedBy  string         `db:"issued_by"`
+	Reason    sql.NullString `db:"reason"`
+}
+
+func NewArchivedDocument(documentType, reference, lang string, version int64, path, sha256 string, size, issued int64, issuedBy string, reason sql.NullString) *ArchivedDocument {
+	return &ArchivedDocument{
+		Type:      documentType,
+		Reference: reference,
+		Lang:      lang,
+		Version:   version,
+		Path:      path,
+		SHA256:    sha256,
+		Size:      size,
+		Issued:    issued,
+		IssuedBy:  issuedBy,
+		Reason:    reason,
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import "database/sql"
+
+const (
+	AuditInsert      = "insert"
+	AuditUpdate      = "update"
+	AuditDelete      = "delete"
+	AuditMerge       = "merge"
+	AuditRetry       = "retry"
+	AuditResend      = "resend"
+	AuditReissue     = "reissue"
+	AuditDownload    = "download"
+	AuditLogin       = "login"
+	AuditLoginFailed = "login_failed"
+)
+
+// Actor is who an audit entry is recorded for: a signed-in user, a donor on the portal, or
+// Stripe for changes webhooks bring in. IP is empty when there is no request behind it.
+type Actor struct {
+	Username string
+	IP       string
+}
+
+var StripeActor = Actor{Username: "stripe"}
+
+// AuditEntry records an action on a record. Changes holds a JSON object of the changed
+// columns, each with its value before and after, and is null for actions that change
+// nothing, such as downloads.
+type AuditEntry struct {
+	ID        int64          `db:"id"`
+	Created   int64          `db:"created"`
+	Username  string         `db:"username"`
+	IP        string         `db:"ip"`
+	Action    string         `db:"action"`
+	Entity    string         `db:"entity"`
+	Reference string         `db:"reference"`
+	Changes   sql.NullString `db:"changes"`
+}
+
+func NewAuditEntry(created int64, actor Actor, action, entity, reference string) *AuditEntry {
+	return &AuditEntry{
+		Created:   created,
+		Username:  actor.Username,
+		IP:        actor.IP,
+		Action:    action,
+		Entity:    entity,
+		Reference: reference,
+	}
+}
+
+// AuditFilter narrows the audit log. Empty fields match every entry, and Start and End
+// bound the creation time as [Start, End) when set.
+type AuditFilter struct {
+	Username  string
+	Action    string
+	Entity    string
+	Reference string
+	Start     sql.NullInt64
+	End       sql.NullInt64
+}
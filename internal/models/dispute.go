@@ -0,0 +1,59 @@
+package models
+
+import "database/sql"
+
+type Dispute struct {
+	ID         string         `db:"id"`
+	Created    uint64         `db:"created"`
+	Amount     int64          `db:"amount"`
+	Reason     string         `db:"reason"`
+	Status     string         `db:"status"`
+	DonationID string         `db:"donation_id"`
+	EventID    sql.NullString `db:"event_id"`
+}
+
+func NewDispute(id string, created uint64, amount int64, reason, status, donationID string, eventID sql.NullString) *Dispute {
+	return &Dispute{
+		ID:         id,
+		Created:    created,
+		Amount:     amount,
+		Reason:     reason,
+		Status:     status,
+		DonationID: donationID,
+		EventID:    eventID,
+	}
+}
+
+const (
+	DisputeWithdrawal    = "withdrawal"
+	DisputeReinstatement = "reinstatement"
+)
+
+type DisputeAdjustment struct {
+	ID             string         `db:"id"`
+	Type           string         `db:"type"`
+	Created        uint64         `db:"created"`
+	Gross          int64          `db:"gross"`
+	Fee            int64          `db:"fee"`
+	Net            int64          `db:"net"`
+	Currency       string         `db:"currency"`
+	DisputeID      string         `db:"dispute_id"`
+	PayoutID       sql.NullString `db:"payout_id"`
+	FailedPayoutID sql.NullString `db:"failed_payout_id"`
+	EventID        sql.NullString `db:"event_id"`
+}
+
+func NewDisputeAdjustment(id, adjustmentType string, created uint64, gross, fee, net int64, currency, disputeID string, payoutID, eventID sql.NullString) *DisputeAdjustment {
+	return &DisputeAdjustment{
+		ID:        id,
+		Type:      adjustmentType,
+		Created:   created,
+		Gross:     gross,
+		Fee:       fee,
+		Net:       net,
+		Currency:  currency,
+		DisputeID: disputeID,
+		PayoutID:  payoutID,
+		EventID:   eventID,
+	}
+}
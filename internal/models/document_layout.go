@@ -0,0 +1,16 @@
+package models
+
+// DocumentLayout is a layout an admin changed. Documents without one use the default.
+type DocumentLayout struct {
+	Name       string `db:"name"`
+	Definition string `db:"definition"`
+	Updated    int64  `db:"updated"`
+}
+
+func NewDocumentLayout(name, definition string, updated int64) *DocumentLayout {
+	return &DocumentLayout{
+		Name:       name,
+		Definition: definition,
+		Updated:    updated,
+	}
+}
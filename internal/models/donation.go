@@ -3,25 +3,48 @@ package models
 import "database/sql"
 
 type Donation struct {
-	ID          string         `db:"id"`
-	Created     uint64         `db:"created"`
-	Gross       uint32         `db:"gross"`
-	Fee         uint32         `db:"fee"`
-	Net         uint32         `db:"net"`
-	ClientName  string         `db:"client_name"`
-	ClientEmail string         `db:"client_email"`
-	PayoutID    sql.NullString `db:"payout_id"`
+	ID               string          `db:"id"`
+	Created          uint64          `db:"created"`
+	Gross            int64           `db:"gross"`
+	Fee              int64           `db:"fee"`
+	Net              int64           `db:"net"`
+	Currency         string          `db:"currency"`
+	ClientName       string          `db:"client_name"`
+	ClientEmail      string          `db:"client_email"`
+	ClientAddress1   sql.NullString  `db:"client_address_line1"`
+	ClientAddress2   sql.NullString  `db:"client_address_line2"`
+	ClientCity       sql.NullString  `db:"client_city"`
+	ClientState      sql.NullString  `db:"client_state"`
+	ClientPostalCode sql.NullString  `db:"client_postal_code"`
+	ClientCountry    sql.NullString  `db:"client_country"`
+	Locale           sql.NullString  `db:"locale"`
+	OriginalAmount   sql.NullInt64   `db:"original_amount"`
+	OriginalCurrency sql.NullString  `db:"original_currency"`
+	ExchangeRate     sql.NullFloat64 `db:"exchange_rate"`
+	InvoiceSeries    sql.NullString  `db:"invoice_series"`
+	InvoiceYear      sql.NullInt64   `db:"invoice_year"`
+	InvoiceNumber    sql.NullInt64   `db:"invoice_number"`
+	InvoiceIssued    sql.NullInt64   `db:"invoice_issued"`
+	PayoutID         sql.NullString  `db:"payout_id"`
+	FailedPayoutID   sql.NullString  `db:"failed_payout_id"`
+	EventID          sql.NullString  `db:"event_id"`
+	DonorID          sql.NullInt64   `db:"donor_id"`
 }
 
-func NewDonation(id string, created uint64, gross, fee, net uint32, clientName, clientEmail string, payoutID sql.NullString) *Donation {
+func NewDonation(id string, created uint64, gross, fee, net int64, currency, clientName, clientEmail string, originalAmount sql.NullInt64, originalCurrency sql.NullString, exchangeRate sql.NullFloat64, payoutID, eventID sql.NullString) *Donation {
 	return &Donation{
-		ID:          id,
-		Created:     created,
-		Gross:       gross,
-		Fee:         fee,
-		Net:         net,
-		ClientName:  clientName,
-		ClientEmail: clientEmail,
-		PayoutID:    payoutID,
+		ID:               id,
+		Created:          created,
+		Gross:            gross,
+		Fee:              fee,
+		Net:              net,
+		Currency:         currency,
+		ClientName:       clientName,
+		ClientEmail:      clientEmail,
+		OriginalAmount:   originalAmount,
+		OriginalCurrency: originalCurrency,
+		ExchangeRate:     exchangeRate,
+		PayoutID:         payoutID,
+		EventID:          eventID,
 	}
 }
@@ -0,0 +1,27 @@
+package models
+
+import "database/sql"
+
+// Donor is a person who donated, matched across donations by any of their emails. The
+// name is the one an admin corrects once for all of the donor's donations.
+type Donor struct {
+	ID      int64  `db:"id"`
+	Name    string `db:"name"`
+	Email   string `db:"email"`
+	Created int64  `db:"created"`
+}
+
+func NewDonor(name, email string, created int64) *Donor {
+	return &Donor{
+		Name:    name,
+		Email:   email,
+		Created: created,
+	}
+}
+
+// DonorSummary is a donor as listed, with the number of gifts and the latest one.
+type DonorSummary struct {
+	Donor
+	Gifts    int64         `db:"gifts"`
+	LastGift sql.NullInt64 `db:"last_gift"`
+}
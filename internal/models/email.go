@@ -0,0 +1,38 @@
+package models
+
+import "database/sql"
+
+const (
+	EmailQueued = "queued"
+	EmailSent   = "sent"
+	EmailFailed = "failed"
+)
+
+// Email is one delivery of a donation's invoice in the outbox. A queued email that
+// failed keeps its error until the next attempt; it is failed once it runs out of them.
+type Email struct {
+	ID          int64          `db:"id"`
+	DonationID  string         `db:"donation_id"`
+	Recipient   string         `db:"recipient"`
+	Created     int64          `db:"created"`
+	RequestedBy string         `db:"requested_by"`
+	Status      string         `db:"status"`
+	Error       sql.NullString `db:"error"`
+	Attempts    uint32         `db:"attempts"`
+	NextAttempt sql.NullInt64  `db:"next_attempt"`
+	Sent        sql.NullInt64  `db:"sent"`
+}
+
+func NewEmail(donationID, recipient string, created int64, requestedBy, status string, errorText sql.NullString, attempts uint32, nextAttempt, sent sql.NullInt64) *Email {
+	return &Email{
+		DonationID:  donationID,
+		Recipient:   recipient,
+		Created:     created,
+		RequestedBy: requestedBy,
+		Status:      status,
+		Error:       errorText,
+		Attempts:    attempts,
+		NextAttempt: nextAttempt,
+		Sent:        sent,
+	}
+}
@@ -3,19 +3,24 @@ package models
 import "database/sql"
 
 type Fee struct {
-	ID          string         `db:"id"`
-	Description string         `db:"description"`
-	Created     uint64         `db:"created"`
-	Fee         uint32         `db:"fee"`
-	PayoutID    sql.NullString `db:"payout_id"`
+	ID             string         `db:"id"`
+	Description    string         `db:"description"`
+	Created        uint64         `db:"created"`
+	Fee            int64          `db:"fee"`
+	Currency       string         `db:"currency"`
+	PayoutID       sql.NullString `db:"payout_id"`
+	FailedPayoutID sql.NullString `db:"failed_payout_id"`
+	EventID        sql.NullString `db:"event_id"`
 }
 
-func NewFee(id, description string, created uint64, fee uint32, payoutID sql.NullString) *Fee {
+func NewFee(id, description string, created uint64, fee int64, currency string, payoutID, eventID sql.NullString) *Fee {
 	return &Fee{
 		ID:          id,
 		Description: description,
 		Created:     created,
 		Fee:         fee,
+		Currency:    currency,
 		PayoutID:    payoutID,
+		EventID:     eventID,
 	}
 }
@@ -0,0 +1,46 @@
+package models
+
+// Organisation is the issuer profile printed on every document. The table holds one row.
+type Organisation struct {
+	ID                 int64  `db:"id"`
+	Name               string `db:"name"`
+	TaxID              string `db:"tax_id"`
+	RegistrationNumber string `db:"registration_number"`
+	Street             string `db:"street"`
+	AdditionalStreet   string `db:"additional_street"`
+	City               string `db:"city"`
+	County             string `db:"county"`
+	PostalCode         string `db:"postal_code"`
+	Country            string `db:"country"`
+	IBAN               string `db:"iban"`
+	Bank               string `db:"bank"`
+	Email              string `db:"email"`
+	Phone              string `db:"phone"`
+	StripeAccount      string `db:"stripe_account"`
+	LogoPath           string `db:"logo_path"`
+	SmallLogoPath      string `db:"small_logo_path"`
+	Updated            int64  `db:"updated"`
+}
+
+func NewOrganisation(name, taxID, registrationNumber, street, additionalStreet, city, county, postalCode, country, iban, bank, email, phone, stripeAccount, logoPath, smallLogoPath string, updated int64) *Organisation {
+	return &Organisation{
+		ID:                 1,
+		Name:               name,
+		TaxID:              taxID,
+		RegistrationNumber: registrationNumber,
+		Street:             street,
+		AdditionalStreet:   additionalStreet,
+		City:               city,
+		County:             county,
+		PostalCode:         postalCode,
+		Country:            country,
+		IBAN:               iban,
+		Bank:               bank,
+		Email:              email,
+		Phone:              phone,
+		StripeAccount:      stripeAccount,
+		LogoPath:           logoPath,
+		SmallLogoPath:      smallLogoPath,
+		Updated:            updated,
+	}
+}
@@ -1,19 +1,56 @@
 package models
 
+import "database/sql"
+
+const (
+	PayoutPaid     = "paid"
+	PayoutFailed   = "failed"
+	PayoutCanceled = "canceled"
+)
+
 type Payout struct {
-	ID      string `db:"id"`
-	Created uint64 `db:"created"`
-	Gross   uint32 `db:"gross"`
-	Fee     uint32 `db:"fee"`
-	Net     uint32 `db:"net"`
+	ID                   string         `db:"id"`
+	Created              uint64         `db:"created"`
+	Gross                int64          `db:"gross"`
+	Fee                  int64          `db:"fee"`
+	Net                  int64          `db:"net"`
+	Currency             string         `db:"currency"`
+	Status               string         `db:"status"`
+	FailureTransactionID sql.NullString `db:"failure_transaction_id"`
+	EventID              sql.NullString `db:"event_id"`
 }
 
-func NewPayout(id string, created uint64, gross, fee, net uint32) *Payout {
+func NewPayout(id string, created uint64, gross, fee, net int64, currency, status string, failureTransactionID, eventID sql.NullString) *Payout {
 	return &Payout{
-		ID:      id,
-		Created: created,
-		Gross:   gross,
-		Fee:     fee,
-		Net:     net,
+		ID:                   id,
+		Created:              created,
+		Gross:                gross,
+		Fee:                  fee,
+		Net:                  net,
+		Currency:             currency,
+		Status:               status,
+		FailureTransactionID: failureTransactionID,
+		EventID:              eventID,
+	}
+}
+
+type PayoutStatusChange struct {
+	ID             int64          `db:"id"`
+	PayoutID       string         `db:"payout_id"`
+	Status         string         `db:"status"`
+	Created        uint64         `db:"created"`
+	FailureCode    sql.NullString `db:"failure_code"`
+	FailureMessage sql.NullString `db:"failure_message"`
+	EventID        sql.NullString `db:"event_id"`
+}
+
+func NewPayoutStatusChange(payoutID, status string, created uint64, failureCode, failureMessage, eventID sql.NullString) *PayoutStatusChange {
+	return &PayoutStatusChange{
+		PayoutID:       payoutID,
+		Status:         status,
+		Created:        created,
+		FailureCode:    failureCode,
+		FailureMessage: failureMessage,
+		EventID:        eventID,
 	}
 }
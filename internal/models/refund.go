@@ -0,0 +1,30 @@
+package models
+
+import "database/sql"
+
+type Refund struct {
+	ID             string         `db:"id"`
+	Created        uint64         `db:"created"`
+	Gross          int64          `db:"gross"`
+	Fee            int64          `db:"fee"`
+	Net            int64          `db:"net"`
+	Currency       string         `db:"currency"`
+	DonationID     string         `db:"donation_id"`
+	PayoutID       sql.NullString `db:"payout_id"`
+	FailedPayoutID sql.NullString `db:"failed_payout_id"`
+	EventID        sql.NullString `db:"event_id"`
+}
+
+func NewRefund(id string, created uint64, gross, fee, net int64, currency, donationID string, payoutID, eventID sql.NullString) *Refund {
+	return &Refund{
+		ID:         id,
+		Created:    created,
+		Gross:      gross,
+		Fee:        fee,
+		Net:        net,
+		Currency:   currency,
+		DonationID: donationID,
+		PayoutID:   payoutID,
+		EventID:    eventID,
+	}
+}
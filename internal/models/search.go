@@ -0,0 +1,25 @@
+package models
+
+import "database/sql"
+
+// SearchFilter narrows a search. Empty terms match everything; unset bounds are open.
+// Start and End bound the creation time as [Start, End).
+type SearchFilter struct {
+	Terms     []string
+	Start     sql.NullInt64
+	End       sql.NullInt64
+	MinAmount sql.NullInt64
+	MaxAmount sql.NullInt64
+}
+
+// SearchResult is a donation, fee or payout found by a search. The amount is the gross of
+// a donation, the fee of a fee and the net of a payout. A payout is its own payout.
+type SearchResult struct {
+	Kind      string         `db:"kind"`
+	Reference string         `db:"reference"`
+	PayoutID  sql.NullString `db:"payout_id"`
+	Created   uint64         `db:"created"`
+	Amount    int64          `db:"amount"`
+	Currency  string         `db:"currency"`
+	Title     string         `db:"title"`
+}
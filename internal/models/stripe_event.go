@@ -0,0 +1,37 @@
+package models
+
+import "database/sql"
+
+const (
+	StripeEventReceived   = "received"
+	StripeEventProcessing = "processing"
+	StripeEventProcessed  = "processed"
+	StripeEventFailed     = "failed"
+	StripeEventDead       = "dead"
+)
+
+type StripeEvent struct {
+	ID          string         `db:"id"`
+	Type        string         `db:"type"`
+	Payload     string         `db:"payload"`
+	Received    uint64         `db:"received"`
+	Processed   sql.NullInt64  `db:"processed"`
+	Status      string         `db:"status"`
+	Error       sql.NullString `db:"error"`
+	Attempts    uint32         `db:"attempts"`
+	NextAttempt sql.NullInt64  `db:"next_attempt"`
+}
+
+func NewStripeEvent(id, eventType, payload string, received uint64, processed sql.NullInt64, status string, errorText sql.NullString, attempts uint32, nextAttempt sql.NullInt64) *StripeEvent {
+	return &StripeEvent{
+		ID:          id,
+		Type:        eventType,
+		Payload:     payload,
+		Received:    received,
+		Processed:   processed,
+		Status:      status,
+		Error:       errorText,
+		Attempts:    attempts,
+		NextAttempt: nextAttempt,
+	}
+}
@@ -0,0 +1,118 @@
+package money
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/diother/go-invoices/internal/constants"
+)
+
+// DefaultCurrency is assumed for amounts recorded before currencies were stored.
+const DefaultCurrency = "ron"
+
+// Stripe currencies without minor units, where amounts are already whole units.
+var zeroDecimalCurrencies = map[string]bool{
+	"bif": true, "clp": true, "djf": true, "gnf": true, "jpy": true, "kmf": true,
+	"krw": true, "mga": true, "pyg": true, "rwf": true, "ugx": true, "vnd": true,
+	"vuv": true, "xaf": true, "xof": true, "xpf": true,
+}
+
+// Money is an amount in minor units of a lowercase ISO currency code, as Stripe reports it.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func New(amount int64, currency string) Money {
+	currency = strings.ToLower(currency)
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf(constants.ErrMoneyCurrencyMismatch+": %s and %s", m.Currency, other.Currency)
+	}
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) ||
+		(other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return Money{}, fmt.Errorf(constants.ErrMoneyOverflow)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, fmt.Errorf(constants.ErrMoneyOverflow)
+	}
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// String formats the amount in the Romanian locale, e.g. "1.234,56 lei" or "-20,00 EUR".
+func (m Money) String() string {
+	sign, whole, fraction := m.split()
+	formatted := groupThousands(whole)
+	if fraction != "" {
+		formatted += "," + fraction
+	}
+	return sign + formatted + " " + Label(m.Currency)
+}
+
+// Decimal formats the amount in major units with a decimal point and no grouping, e.g.
+// "1234.56", as machine-readable formats such as UBL expect.
+func (m Money) Decimal() string {
+	sign, whole, fraction := m.split()
+	if fraction != "" {
+		return sign + whole + "." + fraction
+	}
+	return sign + whole
+}
+
+func (m Money) split() (sign, whole, fraction string) {
+	decimals := 2
+	if zeroDecimalCurrencies[m.Currency] {
+		decimals = 0
+	}
+	if m.Amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absUint(m.Amount), 10)
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	return sign, digits[:len(digits)-decimals], digits[len(digits)-decimals:]
+}
+
+// Label names the currency as the documents print it: lei for ron, the ISO code otherwise.
+func Label(currency string) string {
+	if currency == "" || strings.EqualFold(currency, DefaultCurrency) {
+		return "lei"
+	}
+	return strings.ToUpper(currency)
+}
+
+// FormatRate formats an exchange rate with four decimals and a decimal comma.
+func FormatRate(rate float64) string {
+	return strings.Replace(strconv.FormatFloat(rate, 'f', 4, 64), ".", ",", 1)
+}
+
+func groupThousands(digits string) string {
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(digit)
+	}
+	return b.String()
+}
+
+func absUint(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
@@ -0,0 +1,130 @@
+package money
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/diother/go-invoices/internal/constants"
+)
+
+func TestString(t *testing.T) {
+	testCases := map[string]struct {
+		money    Money
+		expected string
+	}{
+		"zero":             {money: New(0, "ron"), expected: "0,00 lei"},
+		"cents":            {money: New(5, "ron"), expected: "0,05 lei"},
+		"belowThousand":    {money: New(99999, "ron"), expected: "999,99 lei"},
+		"thousands":        {money: New(123456, "ron"), expected: "1.234,56 lei"},
+		"millions":         {money: New(123456789012, "ron"), expected: "1.234.567.890,12 lei"},
+		"negative":         {money: New(-123456, "ron"), expected: "-1.234,56 lei"},
+		"missingCurrency":  {money: New(100, ""), expected: "1,00 lei"},
+		"foreignCurrency":  {money: New(2000, "EUR"), expected: "20,00 EUR"},
+		"zeroDecimal":      {money: New(1500, "jpy"), expected: "1.500 JPY"},
+		"minInt64":         {money: New(math.MinInt64, "ron"), expected: "-92.233.720.368.547.758,08 lei"},
+		"maxInt64":         {money: New(math.MaxInt64, "ron"), expected: "92.233.720.368.547.758,07 lei"},
+		"zeroDecimalSmall": {money: New(7, "jpy"), expected: "7 JPY"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := tc.money.String(); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	testCases := map[string]struct {
+		money    Money
+		expected string
+	}{
+		"zero":        {money: New(0, "ron"), expected: "0.00"},
+		"cents":       {money: New(5, "ron"), expected: "0.05"},
+		"thousands":   {money: New(123456, "ron"), expected: "1234.56"},
+		"negative":    {money: New(-2000, "eur"), expected: "-20.00"},
+		"zeroDecimal": {money: New(1500, "jpy"), expected: "1500"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := tc.money.Decimal(); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	testCases := map[string]struct {
+		a        Money
+		b        Money
+		expected Money
+		err      string
+	}{
+		"sameCurrency":     {a: New(1000, "ron"), b: New(250, "ron"), expected: New(1250, "ron")},
+		"negative":         {a: New(1000, "ron"), b: New(-1250, "ron"), expected: New(-250, "ron")},
+		"currencyMismatch": {a: New(1000, "ron"), b: New(1000, "eur"), err: constants.ErrMoneyCurrencyMismatch},
+		"overflow":         {a: New(math.MaxInt64, "ron"), b: New(1, "ron"), err: constants.ErrMoneyOverflow},
+		"underflow":        {a: New(math.MinInt64, "ron"), b: New(-1, "ron"), err: constants.ErrMoneyOverflow},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := tc.a.Add(tc.b)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("Expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestSub(t *testing.T) {
+	testCases := map[string]struct {
+		a        Money
+		b        Money
+		expected Money
+		err      string
+	}{
+		"sameCurrency":     {a: New(1000, "ron"), b: New(300, "ron"), expected: New(700, "ron")},
+		"belowZero":        {a: New(300, "ron"), b: New(1000, "ron"), expected: New(-700, "ron")},
+		"currencyMismatch": {a: New(1000, "ron"), b: New(300, "eur"), err: constants.ErrMoneyCurrencyMismatch},
+		"overflow":         {a: New(0, "ron"), b: New(math.MinInt64, "ron"), err: constants.ErrMoneyOverflow},
+		"underflow":        {a: New(math.MinInt64, "ron"), b: New(1, "ron"), err: constants.ErrMoneyOverflow},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := tc.a.Sub(tc.b)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("Expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatRate(t *testing.T) {
+	if result := FormatRate(4.97); result != "4,9700" {
+		t.Errorf("Expected %v, got %v", "4,9700", result)
+	}
+}
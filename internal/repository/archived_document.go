@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/diother/go-invoices/internal/models"
+)
+
+// GetLatestArchivedDocument returns nil when the document was never issued.
+func (r *PWARepository) GetLatestArchivedDocument(documentType, reference, lang string) (*models.ArchivedDocument, error) {
+	var document models.ArchivedDocument
+	query := "SELECT * FROM archived_documents WHERE type = ? AND reference = ? AND lang = ? ORDER BY version DESC LIMIT 1"
+
+	if err := r.db.Get(&document, r.db.Rebind(query), documentType, reference, lang); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve archived document: %w", err)
+	}
+	return &document, nil
+}
+
+func (r *PWARepository) GetArchivedDocument(id int64) (*models.ArchivedDocument, error) {
+	var document models.ArchivedDocument
+	query := "SELECT * FROM archived_documents WHERE id = ?"
+
+	if err := r.db.Get(&document, r.db.Rebind(query), id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("archived document not found")
+		}
+		return nil, fmt.Errorf("failed to retrieve archived document: %w", err)
+	}
+	return &document, nil
+}
+
+// GetArchivedDocumentVersions lists every version of a document, in every language,
+// newest first.
+func (r *PWARepository) GetArchivedDocumentVersions(documentType, reference string) ([]*models.ArchivedDocument, error) {
+	var documents []*models.ArchivedDocument
+	query := "SELECT * FROM archived_documents WHERE type = ? AND reference = ? ORDER BY lang, version DESC"
+
+	if err := r.db.Select(&documents, r.db.Rebind(query), documentType, reference); err != nil {
+		return nil, fmt.Errorf("failed to retrieve archived documents: %w", err)
+	}
+	return documents, nil
+}
+
+// InsertArchivedDocument sets the ID of the stored document. The ID is read back with
+// RETURNING, since the PostgreSQL driver does not report the last insert ID.
+func (r *PWARepository) InsertArchivedDocument(document *models.ArchivedDocument) error {
+	query := `
+	INSERT INTO archived_documents (type, reference, lang, version, path, sha256, size, issued, issued_by, reason)
+	VALUES (:type, :reference, :lang, :version, :path, :sha256, :size, :issued, :issued_by, :reason)
+	RETURNING id
+	`
+	statement, err := r.db.PrepareNamed(query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare archived document insertion: %w", err)
+	}
+	defer statement.Close()
+
+	if err = statement.Get(&document.ID, document); err != nil {
+		return fmt.Errorf("failed to insert archived document: %w", err)
+	}
+	return nil
+}
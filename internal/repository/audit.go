@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const insertAuditEntryQuery = `
+	INSERT INTO audit_log (created, username, ip, action, entity, reference, changes)
+	VALUES (:created, :username, :ip, :action, :entity, :reference, :changes)
+	`
+
+// audit records a change a webhook made within the repository's open transaction, so the
+// entry is only kept with the change. An update that changed nothing is not recorded.
+func (r *WebhookRepository) audit(action, entity, reference string, before, after interface{}) error {
+	entry := models.NewAuditEntry(time.Now().Unix(), models.StripeActor, action, entity, reference)
+	if err := setAuditChanges(entry, before, after); err != nil {
+		return err
+	}
+	if action == models.AuditUpdate && !entry.Changes.Valid {
+		return nil
+	}
+	if _, err := r.execNamed(insertAuditEntryQuery, entry); err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// auditInserted records an insert that may have been skipped as a duplicate.
+func (r *WebhookRepository) auditInserted(result sql.Result, entity, reference string, record interface{}) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		return err
+	}
+	return r.audit(models.AuditInsert, entity, reference, nil, record)
+}
+
+// auditInTransaction records a change the actor made within tx, so the entry is only kept
+// with the change.
+func auditInTransaction(tx *sqlx.Tx, actor models.Actor, action, entity, reference string, before, after interface{}) error {
+	entry := models.NewAuditEntry(time.Now().Unix(), actor, action, entity, reference)
+	if err := setAuditChanges(entry, before, after); err != nil {
+		return err
+	}
+	if _, err := tx.NamedExec(insertAuditEntryQuery, entry); err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// InsertAuditEntry records the entry with the columns that differ between before and
+// after, either of which may be nil.
+func (r *PWARepository) InsertAuditEntry(entry *models.AuditEntry, before, after interface{}) error {
+	if err := setAuditChanges(entry, before, after); err != nil {
+		return err
+	}
+	query, args, err := r.db.BindNamed(insertAuditEntryQuery+" RETURNING id", entry)
+	if err != nil {
+		return err
+	}
+	if err = r.db.Get(&entry.ID, query, args...); err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// GetAuditEntries returns up to limit entries matching the filter, newest first.
+func (r *PWARepository) GetAuditEntries(filter models.AuditFilter, limit int) (entries []*models.AuditEntry, err error) {
+	var conditions []string
+	var args []interface{}
+	filters := []struct {
+		condition string
+		value     interface{}
+		set       bool
+	}{
+		{"username = ?", filter.Username, filter.Username != ""},
+		{"action = ?", filter.Action, filter.Action != ""},
+		{"entity = ?", filter.Entity, filter.Entity != ""},
+		{"reference = ?", filter.Reference, filter.Reference != ""},
+		{"created >= ?", filter.Start.Int64, filter.Start.Valid},
+		{"created < ?", filter.End.Int64, filter.End.Valid},
+	}
+	for _, f := range filters {
+		if f.set {
+			conditions = append(conditions, f.condition)
+			args = append(args, f.value)
+		}
+	}
+
+	query := "SELECT * FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	if err := r.db.Select(&entries, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to retrieve audit entries: %w", err)
+	}
+	return
+}
+
+type auditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// setAuditChanges sets the changes of the entry to the columns of before and after whose
+// values differ, and to null when none do.
+func setAuditChanges(entry *models.AuditEntry, before, after interface{}) error {
+	beforeColumns, err := auditColumns(before)
+	if err != nil {
+		return err
+	}
+	afterColumns, err := auditColumns(after)
+	if err != nil {
+		return err
+	}
+
+	changes := make(map[string]auditChange)
+	for column, value := range beforeColumns {
+		if !reflect.DeepEqual(value, afterColumns[column]) {
+			changes[column] = auditChange{Before: value, After: afterColumns[column]}
+		}
+	}
+	for column, value := range afterColumns {
+		if _, ok := beforeColumns[column]; !ok && value != nil {
+			changes[column] = auditChange{After: value}
+		}
+	}
+	if len(changes) == 0 {
+		entry.Changes = sql.NullString{}
+		return nil
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+	entry.Changes = sql.NullString{String: string(encoded), Valid: true}
+	return nil
+}
+
+// auditColumns reads a struct into its values by column, as stored: named by their db tag
+// and with nullable values as their value or nil. Fields without a tag keep their name.
+func auditColumns(record interface{}) (map[string]interface{}, error) {
+	value := reflect.ValueOf(record)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return nil, nil
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot audit a %s", value.Kind())
+	}
+
+	columns := make(map[string]interface{})
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous {
+			embedded, err := auditColumns(value.Field(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			for column, fieldValue := range embedded {
+				columns[column] = fieldValue
+			}
+			continue
+		}
+
+		column := field.Tag.Get("db")
+		if column == "-" {
+			continue
+		}
+		if column == "" {
+			column = field.Name
+		}
+		fieldValue := value.Field(i).Interface()
+		if valuer, ok := fieldValue.(driver.Valuer); ok {
+			var err error
+			if fieldValue, err = valuer.Value(); err != nil {
+				return nil, err
+			}
+		}
+		columns[column] = fieldValue
+	}
+	return columns, nil
+}
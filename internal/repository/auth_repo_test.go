@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestSessions(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		if _, err := db.NamedExec("INSERT INTO users (username, password, role) VALUES (:username, :password, :role)", models.NewUser("admin", "hash", "admin")); err != nil {
+			t.Fatalf("Failed to insert user: %v", err)
+		}
+		repo := NewAuthRepository(db)
+
+		user, err := repo.GetUserByUsername("admin")
+		if err != nil || user.ID == 0 || user.Role != "admin" {
+			t.Fatalf("Expected the admin user, got %+v and %v", user, err)
+		}
+		if _, err = repo.GetUserByUsername("nobody"); err == nil {
+			t.Errorf("Expected an unknown username to fail")
+		}
+
+		if err = repo.InsertSession(models.NewSession(-42, user.ID, 1000)); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		session, err := repo.GetSession(-42)
+		if err != nil || session.UserID != user.ID || session.ExpiresAt != 1000 {
+			t.Errorf("Expected the user's session, got %+v and %v", session, err)
+		}
+		if byID, err := repo.GetUserByID(session.UserID); err != nil || byID.Username != "admin" {
+			t.Errorf("Expected the session's user, got %+v and %v", byID, err)
+		}
+	})
+}
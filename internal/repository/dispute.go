@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/diother/go-invoices/internal/models"
+)
+
+// UpsertDispute inserts a dispute or refreshes the status of a known one,
+// since every charge.dispute.* event carries the current dispute state.
+func (r *WebhookRepository) UpsertDispute(dispute *models.Dispute) error {
+	var before models.Dispute
+	err := r.get(&before, "SELECT * FROM disputes WHERE id = ?", dispute.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	exists := err == nil
+
+	query := `
+    INSERT INTO disputes (id, created, amount, reason, status, donation_id, event_id)
+	VALUES (:id, :created, :amount, :reason, :status, :donation_id, :event_id)
+	ON CONFLICT (id) DO UPDATE
+	SET amount = excluded.amount, reason = excluded.reason, status = excluded.status
+    `
+	if _, err = r.execNamed(query, dispute); err != nil {
+		return err
+	}
+	if !exists {
+		return r.audit(models.AuditInsert, "dispute", dispute.ID, nil, dispute)
+	}
+	after := before
+	after.Amount, after.Reason, after.Status = dispute.Amount, dispute.Reason, dispute.Status
+	return r.audit(models.AuditUpdate, "dispute", dispute.ID, before, after)
+}
+
+func (r *WebhookRepository) InsertDisputeAdjustment(adjustment *models.DisputeAdjustment) error {
+	query := `
+    INSERT INTO dispute_adjustments (id, type, created, gross, fee, net, currency, dispute_id, payout_id, event_id)
+	VALUES (:id, :type, :created, :gross, :fee, :net, :currency, :dispute_id, :payout_id, :event_id)
+	ON CONFLICT (id) DO NOTHING
+    `
+	result, err := r.execNamed(query, adjustment)
+	if err != nil {
+		return err
+	}
+	return r.auditInserted(result, "dispute_adjustment", adjustment.ID, adjustment)
+}
+
+func (r *WebhookRepository) UpdateDisputeAdjustmentPayout(adjustment *models.DisputeAdjustment) (bool, error) {
+	return r.linkPayout("dispute_adjustments", adjustment.ID, adjustment.PayoutID)
+}
+
+func (r *PWARepository) GetPayoutDisputes(payoutID string) (disputes []*models.Dispute, err error) {
+	query := `
+	SELECT disputes.* FROM disputes
+	JOIN donations ON donations.id = disputes.donation_id
+	WHERE donations.payout_id = ? OR donations.failed_payout_id = ?
+	`
+	if err := r.db.Select(&disputes, r.db.Rebind(query), payoutID, payoutID); err != nil {
+		return nil, fmt.Errorf("failed to retrieve disputes: %w", err)
+	}
+	return
+}
+
+func (r *PWARepository) GetRelatedDisputeAdjustments(payoutID string) (adjustments []*models.DisputeAdjustment, err error) {
+	query := "SELECT id, type, created, gross, fee, net, currency, dispute_id FROM dispute_adjustments WHERE payout_id = ? OR failed_payout_id = ?"
+
+	if err := r.db.Select(&adjustments, r.db.Rebind(query), payoutID, payoutID); err != nil {
+		return nil, fmt.Errorf("failed to retrieve dispute adjustments: %w", err)
+	}
+	return
+}
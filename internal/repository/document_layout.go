@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/diother/go-invoices/internal/models"
+)
+
+// GetDocumentLayout returns nil when the document still uses its default layout.
+func (r *PWARepository) GetDocumentLayout(name string) (*models.DocumentLayout, error) {
+	var layout models.DocumentLayout
+	query := "SELECT * FROM document_layouts WHERE name = ?"
+
+	if err := r.db.Get(&layout, r.db.Rebind(query), name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve document layout: %w", err)
+	}
+	return &layout, nil
+}
+
+func (r *PWARepository) UpsertDocumentLayout(layout *models.DocumentLayout) error {
+	query := `
+	INSERT INTO document_layouts (name, definition, updated)
+	VALUES (:name, :definition, :updated)
+	ON CONFLICT (name) DO UPDATE SET definition = excluded.definition, updated = excluded.updated
+	`
+	if _, err := r.db.NamedExec(query, layout); err != nil {
+		return fmt.Errorf("failed to save document layout: %w", err)
+	}
+	return nil
+}
+
+func (r *PWARepository) DeleteDocumentLayout(name string) error {
+	if _, err := r.db.Exec(r.db.Rebind("DELETE FROM document_layouts WHERE name = ?"), name); err != nil {
+		return fmt.Errorf("failed to delete document layout: %w", err)
+	}
+	return nil
+}
@@ -7,35 +7,42 @@ import (
 	"github.com/diother/go-invoices/internal/models"
 )
 
-func (r *WebhookRepository) InsertDonation(donation *models.Donation) error {
+// InsertDonation reports whether the donation was stored. It is not when the donation is
+// already stored, since its charge event and its payout's event may both record it.
+func (r *WebhookRepository) InsertDonation(donation *models.Donation) (bool, error) {
 	query := `
-    INSERT INTO donations (id, created, gross, fee, net, client_name, client_email, payout_id)
-	VALUES (:id, :created, :gross, :fee, :net, :client_name, :client_email, :payout_id)
+    INSERT INTO donations (id, created, gross, fee, net, currency, client_name, client_email, client_address_line1, client_address_line2, client_city, client_state, client_postal_code, client_country, locale, original_amount, original_currency, exchange_rate, invoice_series, invoice_year, invoice_number, invoice_issued, payout_id, event_id, donor_id)
+	VALUES (:id, :created, :gross, :fee, :net, :currency, :client_name, :client_email, :client_address_line1, :client_address_line2, :client_city, :client_state, :client_postal_code, :client_country, :locale, :original_amount, :original_currency, :exchange_rate, :invoice_series, :invoice_year, :invoice_number, :invoice_issued, :payout_id, :event_id, :donor_id)
+	ON CONFLICT (id) DO NOTHING
     `
-	_, err := r.execNamed(query, donation)
-	return err
-}
-
-func (r *WebhookRepository) UpdateRelatedPayout(donation *models.Donation) (bool, error) {
-	query := `
-	UPDATE donations
-	SET payout_id = :payout_id
-	WHERE id = :id
-	`
 	result, err := r.execNamed(query, donation)
-
-	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return false, err
 	}
-	return rowsAffected != 0, nil
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		return false, err
+	}
+	return true, r.audit(models.AuditInsert, "donation", donation.ID, nil, donation)
+}
+
+// DonationExists checks within the open transaction, if any.
+func (r *WebhookRepository) DonationExists(id string) (exists bool, err error) {
+	if err := r.get(&exists, "SELECT EXISTS (SELECT 1 FROM donations WHERE id = ?)", id); err != nil {
+		return false, fmt.Errorf("failed to check donation: %w", err)
+	}
+	return
+}
+
+func (r *WebhookRepository) UpdateRelatedPayout(donation *models.Donation) (bool, error) {
+	return r.linkPayout("donations", donation.ID, donation.PayoutID)
 }
 
 func (r *PWARepository) GetDonation(id string) (*models.Donation, error) {
 	var donation models.Donation
 	query := "SELECT * FROM donations WHERE id = ?"
 
-	if err := r.db.Get(&donation, query, id); err != nil {
+	if err := r.db.Get(&donation, r.db.Rebind(query), id); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("donation with id %s not found", id)
 		}
@@ -45,9 +52,9 @@ func (r *PWARepository) GetDonation(id string) (*models.Donation, error) {
 }
 
 func (r *PWARepository) GetRelatedDonations(payoutID string) (donations []*models.Donation, err error) {
-	query := "SELECT id, created, gross, fee, net, client_name FROM donations WHERE payout_id = ?"
+	query := "SELECT id, created, gross, fee, net, currency, client_name, original_amount, original_currency, exchange_rate, invoice_series, invoice_year, invoice_number, donor_id FROM donations WHERE payout_id = ? OR failed_payout_id = ?"
 
-	if err := r.db.Select(&donations, query, payoutID); err != nil {
+	if err := r.db.Select(&donations, r.db.Rebind(query), payoutID, payoutID); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no donations with payout_id: %s", payoutID)
 		}
@@ -55,3 +62,66 @@ func (r *PWARepository) GetRelatedDonations(payoutID string) (donations []*model
 	}
 	return
 }
+
+// GetDonationsCreatedBetween returns the donations created in [start, end), oldest first.
+func (r *PWARepository) GetDonationsCreatedBetween(start, end int64) (donations []*models.Donation, err error) {
+	query := "SELECT * FROM donations WHERE created >= ? AND created < ? ORDER BY created, id"
+
+	if err := r.db.Select(&donations, r.db.Rebind(query), start, end); err != nil {
+		return nil, fmt.Errorf("failed to retrieve donations: %w", err)
+	}
+	return
+}
+
+// donorDonations matches the donations of the donor an email belongs to, made from any of
+// the emails merged into that donor. Donations not linked to a donor match by their own
+// email. It takes the email twice.
+const donorDonations = `(donations.donor_id IN (SELECT donor_id FROM donor_emails WHERE email = lower(trim(?)))
+	OR (donations.donor_id IS NULL AND lower(donations.client_email) = lower(trim(?))))`
+
+// GetDonorDonations returns the donations of the donor behind email in [start, end),
+// oldest first. Emails are compared without regard to case.
+func (r *PWARepository) GetDonorDonations(email string, start, end int64) (donations []*models.Donation, err error) {
+	query := "SELECT * FROM donations WHERE " + donorDonations + " AND created >= ? AND created < ? ORDER BY created, id"
+
+	if err := r.db.Select(&donations, r.db.Rebind(query), email, email, start, end); err != nil {
+		return nil, fmt.Errorf("failed to retrieve donations: %w", err)
+	}
+	return
+}
+
+// GetDonorEmails lists one lowercased email for every donor who donated in [start, end):
+// the donor's own email, whichever of the merged emails they gave from.
+func (r *PWARepository) GetDonorEmails(start, end int64) (emails []string, err error) {
+	query := `
+	SELECT DISTINCT COALESCE(donors.email, lower(donations.client_email))
+	FROM donations
+	LEFT JOIN donors ON donors.id = donations.donor_id
+	WHERE donations.client_email != '' AND donations.created >= ? AND donations.created < ?
+	ORDER BY 1
+	`
+
+	if err := r.db.Select(&emails, r.db.Rebind(query), start, end); err != nil {
+		return nil, fmt.Errorf("failed to retrieve donor emails: %w", err)
+	}
+	return
+}
+
+func (r *PWARepository) DonationExists(id string) (exists bool, err error) {
+	query := "SELECT EXISTS (SELECT 1 FROM donations WHERE id = ?)"
+
+	if err := r.db.Get(&exists, r.db.Rebind(query), id); err != nil {
+		return false, fmt.Errorf("failed to check donation: %w", err)
+	}
+	return
+}
+
+// GetDonationsByDonor returns every donation of a donor, oldest first.
+func (r *PWARepository) GetDonationsByDonor(donorID int64) (donations []*models.Donation, err error) {
+	query := "SELECT * FROM donations WHERE donor_id = ? ORDER BY created, id"
+
+	if err := r.db.Select(&donations, r.db.Rebind(query), donorID); err != nil {
+		return nil, fmt.Errorf("failed to retrieve donations: %w", err)
+	}
+	return
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// UpsertDonor sets the ID of the donor known by donor.Email, recording a new donor when no
+// one is. Two first donations from one email processed in parallel conflict on
+// donor_emails, and the event that loses is retried.
+func (r *WebhookRepository) UpsertDonor(donor *models.Donor) error {
+	err := r.get(&donor.ID, "SELECT donor_id FROM donor_emails WHERE email = ?", donor.Email)
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	query := "INSERT INTO donors (name, email, created) VALUES (?, ?, ?) RETURNING id"
+	if err = r.get(&donor.ID, query, donor.Name, donor.Email, donor.Created); err != nil {
+		return err
+	}
+	if _, err = r.execNamed("INSERT INTO donor_emails (email, donor_id) VALUES (:email, :id)", donor); err != nil {
+		return err
+	}
+	return r.audit(models.AuditInsert, "donor", strconv.FormatInt(donor.ID, 10), nil, donor)
+}
+
+// GetDonor returns nil when there is no donor with the ID.
+func (r *PWARepository) GetDonor(id int64) (*models.Donor, error) {
+	var donor models.Donor
+	query := "SELECT * FROM donors WHERE id = ?"
+
+	if err := r.db.Get(&donor, r.db.Rebind(query), id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve donor: %w", err)
+	}
+	return &donor, nil
+}
+
+// GetDonorByEmail finds the donor by any of their emails. It returns nil when no donor
+// uses the email.
+func (r *PWARepository) GetDonorByEmail(email string) (*models.Donor, error) {
+	var donor models.Donor
+	query := "SELECT donors.* FROM donors JOIN donor_emails ON donor_emails.donor_id = donors.id WHERE donor_emails.email = ?"
+
+	if err := r.db.Get(&donor, r.db.Rebind(query), email); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve donor: %w", err)
+	}
+	return &donor, nil
+}
+
+func (r *PWARepository) GetDonorEmailAliases(donorID int64) (emails []string, err error) {
+	query := "SELECT email FROM donor_emails WHERE donor_id = ? ORDER BY email"
+
+	if err := r.db.Select(&emails, r.db.Rebind(query), donorID); err != nil {
+		return nil, fmt.Errorf("failed to retrieve donor emails: %w", err)
+	}
+	return
+}
+
+// GetDonors lists up to limit donors whose name or one of whose emails contains search,
+// without regard to case, by name.
+func (r *PWARepository) GetDonors(search string, limit int) (donors []*models.DonorSummary, err error) {
+	query := `
+	SELECT donors.id, donors.name, donors.email, donors.created, COUNT(donations.id) AS gifts, MAX(donations.created) AS last_gift
+	FROM donors
+	LEFT JOIN donations ON donations.donor_id = donors.id
+	WHERE lower(donors.name) LIKE ? ESCAPE '\' OR donors.id IN (SELECT donor_id FROM donor_emails WHERE email LIKE ? ESCAPE '\')
+	GROUP BY donors.id, donors.name, donors.email, donors.created
+	ORDER BY lower(donors.name), donors.id
+	LIMIT ?
+	`
+	pattern := "%" + escapeLike(strings.ToLower(search)) + "%"
+
+	if err := r.db.Select(&donors, r.db.Rebind(query), pattern, pattern, limit); err != nil {
+		return nil, fmt.Errorf("failed to retrieve donors: %w", err)
+	}
+	return
+}
+
+// RenameDonor corrects the name of a donor and of every donation they made, so documents
+// generated from now on carry it. Archived documents keep the name they were issued with.
+// The rename is audited for the actor within the same transaction.
+func (r *PWARepository) RenameDonor(actor models.Actor, id int64, name string) error {
+	return r.inTransaction(func(tx *sqlx.Tx) error {
+		var donor models.Donor
+		if err := tx.Get(&donor, tx.Rebind("SELECT * FROM donors WHERE id = ?"), id); err != nil {
+			return fmt.Errorf("failed to retrieve donor: %w", err)
+		}
+		if _, err := tx.Exec(tx.Rebind("UPDATE donors SET name = ? WHERE id = ?"), name, id); err != nil {
+			return fmt.Errorf("failed to rename donor: %w", err)
+		}
+		if _, err := tx.Exec(tx.Rebind("UPDATE donations SET client_name = ? WHERE donor_id = ?"), name, id); err != nil {
+			return fmt.Errorf("failed to rename donor donations: %w", err)
+		}
+		renamed := donor
+		renamed.Name = name
+		return auditInTransaction(tx, actor, models.AuditUpdate, "donor", strconv.FormatInt(id, 10), &donor, &renamed)
+	})
+}
+
+// MergeDonors moves the donations and emails of the duplicate to the donor and deletes the
+// duplicate, so later donations from its emails are matched to the donor. The merge is
+// audited for the duplicate, with the donor it was merged into, within the same
+// transaction.
+func (r *PWARepository) MergeDonors(actor models.Actor, donorID, duplicateID int64) error {
+	return r.inTransaction(func(tx *sqlx.Tx) error {
+		var duplicate models.Donor
+		if err := tx.Get(&duplicate, tx.Rebind("SELECT * FROM donors WHERE id = ?"), duplicateID); err != nil {
+			return fmt.Errorf("failed to retrieve donor: %w", err)
+		}
+		statements := []struct {
+			query string
+			args  []interface{}
+		}{
+			{"UPDATE donations SET donor_id = ? WHERE donor_id = ?", []interface{}{donorID, duplicateID}},
+			{"UPDATE donor_emails SET donor_id = ? WHERE donor_id = ?", []interface{}{donorID, duplicateID}},
+			{"UPDATE donors SET created = (SELECT MIN(created) FROM donors WHERE id IN (?, ?)) WHERE id = ?", []interface{}{donorID, duplicateID, donorID}},
+			{"DELETE FROM donors WHERE id = ?", []interface{}{duplicateID}},
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(tx.Rebind(statement.query), statement.args...); err != nil {
+				return fmt.Errorf("failed to merge donors: %w", err)
+			}
+		}
+		merged := struct {
+			MergedInto int64 `db:"merged_into"`
+		}{MergedInto: donorID}
+		return auditInTransaction(tx, actor, models.AuditMerge, "donor", strconv.FormatInt(duplicateID, 10), &duplicate, merged)
+	})
+}
+
+// escapeLike makes the wildcards of a search match themselves in a LIKE ... ESCAPE '\'
+// pattern.
+func escapeLike(search string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(search)
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/diother/go-invoices/internal/models"
+)
+
+const insertEmailQuery = `
+	INSERT INTO emails (donation_id, recipient, created, requested_by, status, error, attempts, next_attempt, sent)
+	VALUES (:donation_id, :recipient, :created, :requested_by, :status, :error, :attempts, :next_attempt, :sent)
+	`
+
+// EnqueueEmail adds the email to the outbox inside the open transaction, so it is only
+// sent once the donation it belongs to is committed.
+func (r *WebhookRepository) EnqueueEmail(email *models.Email) error {
+	_, err := r.execNamed(insertEmailQuery, email)
+	return err
+}
+
+func (r *PWARepository) EnqueueEmail(email *models.Email) error {
+	if _, err := r.db.NamedExec(insertEmailQuery, email); err != nil {
+		return fmt.Errorf("failed to insert email: %w", err)
+	}
+	return nil
+}
+
+func (r *PWARepository) GetDueEmails(now int64, limit int) (emails []*models.Email, err error) {
+	query := `
+	SELECT * FROM emails
+	WHERE status = 'queued' AND (next_attempt IS NULL OR next_attempt <= ?)
+	ORDER BY created, id
+	LIMIT ?
+	`
+	if err := r.db.Select(&emails, r.db.Rebind(query), now, limit); err != nil {
+		return nil, fmt.Errorf("failed to retrieve due emails: %w", err)
+	}
+	return
+}
+
+func (r *PWARepository) UpdateEmail(email *models.Email) error {
+	query := `
+	UPDATE emails
+	SET status = :status, error = :error, attempts = :attempts, next_attempt = :next_attempt, sent = :sent
+	WHERE id = :id
+	`
+	if _, err := r.db.NamedExec(query, email); err != nil {
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+	return nil
+}
+
+func (r *PWARepository) GetEmails(status string) (emails []*models.Email, err error) {
+	query := "SELECT * FROM emails WHERE status = ? ORDER BY created DESC, id DESC"
+
+	if err := r.db.Select(&emails, r.db.Rebind(query), status); err != nil {
+		return nil, fmt.Errorf("failed to retrieve emails: %w", err)
+	}
+	return
+}
@@ -9,17 +9,19 @@ import (
 
 func (r *WebhookRepository) InsertFee(fee *models.Fee) error {
 	query := `
-    INSERT INTO fees (id, description, created, fee, payout_id)
-	VALUES (:id, :description, :created, :fee, :payout_id)
+    INSERT INTO fees (id, description, created, fee, currency, payout_id, event_id)
+	VALUES (:id, :description, :created, :fee, :currency, :payout_id, :event_id)
     `
-	_, err := r.execNamed(query, fee)
-	return err
+	if _, err := r.execNamed(query, fee); err != nil {
+		return err
+	}
+	return r.audit(models.AuditInsert, "fee", fee.ID, nil, fee)
 }
 
 func (r *PWARepository) GetRelatedFees(payoutID string) (fees []*models.Fee, err error) {
-	query := "SELECT id, description, created, fee FROM fees WHERE payout_id = ?"
+	query := "SELECT id, description, created, fee, currency FROM fees WHERE payout_id = ? OR failed_payout_id = ?"
 
-	if err := r.db.Select(&fees, query, payoutID); err != nil {
+	if err := r.db.Select(&fees, r.db.Rebind(query), payoutID, payoutID); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no fees with payout_id: %s", payoutID)
 		}
@@ -0,0 +1,27 @@
+package repository
+
+// NextInvoiceNumber reserves the next number of a series for the given year and returns
+// it with its issue date: the donation's created time, or the issue date of the number
+// before it when that is later, so issue dates never go back as numbers go up. It must
+// run in the transaction that stores the donation, so a rollback gives the number back,
+// and the sequence row it updates stays locked until then, so reservations of a series
+// are made one at a time.
+func (r *WebhookRepository) NextInvoiceNumber(series string, year int, created int64) (number, issued int64, err error) {
+	query := `
+	INSERT INTO invoice_sequences (series, year, last_number, last_issued)
+	VALUES (?, ?, 1, ?)
+	ON CONFLICT (series, year) DO UPDATE SET
+		last_number = invoice_sequences.last_number + 1,
+		last_issued = CASE WHEN excluded.last_issued > invoice_sequences.last_issued
+			THEN excluded.last_issued ELSE invoice_sequences.last_issued END
+	RETURNING last_number, last_issued
+	`
+	var reservation struct {
+		Number int64 `db:"last_number"`
+		Issued int64 `db:"last_issued"`
+	}
+	if err = r.get(&reservation, query, series, year, created); err != nil {
+		return 0, 0, err
+	}
+	return reservation.Number, reservation.Issued, nil
+}
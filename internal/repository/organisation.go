@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/diother/go-invoices/internal/models"
+)
+
+func (r *PWARepository) GetOrganisation() (*models.Organisation, error) {
+	var organisation models.Organisation
+	query := "SELECT * FROM organisation WHERE id = 1"
+
+	if err := r.db.Get(&organisation, r.db.Rebind(query)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("organisation profile not found")
+		}
+		return nil, fmt.Errorf("failed to retrieve organisation: %w", err)
+	}
+	return &organisation, nil
+}
+
+func (r *PWARepository) UpdateOrganisation(organisation *models.Organisation) error {
+	query := `
+	UPDATE organisation
+	SET name = :name, tax_id = :tax_id, registration_number = :registration_number,
+		street = :street, additional_street = :additional_street, city = :city, county = :county,
+		postal_code = :postal_code, country = :country, iban = :iban, bank = :bank, email = :email,
+		phone = :phone, stripe_account = :stripe_account, logo_path = :logo_path,
+		small_logo_path = :small_logo_path, updated = :updated
+	WHERE id = 1
+	`
+	if _, err := r.db.NamedExec(query, organisation); err != nil {
+		return fmt.Errorf("failed to update organisation: %w", err)
+	}
+	return nil
+}
@@ -7,20 +7,174 @@ import (
 	"github.com/diother/go-invoices/internal/models"
 )
 
+// payoutTransactionTables are the transactions a payout pays out, with the entity their
+// audit entries are recorded under.
+var payoutTransactionTables = []struct {
+	table  string
+	entity string
+}{
+	{"donations", "donation"},
+	{"fees", "fee"},
+	{"refunds", "refund"},
+	{"dispute_adjustments", "dispute_adjustment"},
+}
+
+// payoutLink is the payout of a transaction, and the failed payout that released it.
+type payoutLink struct {
+	PayoutID       sql.NullString `db:"payout_id"`
+	FailedPayoutID sql.NullString `db:"failed_payout_id"`
+}
+
+type payoutLinkRow struct {
+	ID string `db:"id"`
+	payoutLink
+}
+
 func (r *WebhookRepository) InsertPayout(payout *models.Payout) error {
 	query := `
-    INSERT INTO payouts (id, created, gross, fee, net)
-    VALUES (:id, :created, :gross, :fee, :net)
+    INSERT INTO payouts (id, created, gross, fee, net, currency, status, failure_transaction_id, event_id)
+    VALUES (:id, :created, :gross, :fee, :net, :currency, :status, :failure_transaction_id, :event_id)
+    `
+	if _, err := r.execNamed(query, payout); err != nil {
+		return err
+	}
+	return r.audit(models.AuditInsert, "payout", payout.ID, nil, payout)
+}
+
+func (r *WebhookRepository) UpdatePayoutStatus(payout *models.Payout) (bool, error) {
+	var before models.Payout
+	if err := r.get(&before, "SELECT * FROM payouts WHERE id = ?", payout.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	query := `
+	UPDATE payouts
+	SET status = :status, failure_transaction_id = :failure_transaction_id
+	WHERE id = :id
+	`
+	if _, err := r.execNamed(query, payout); err != nil {
+		return false, err
+	}
+	after := before
+	after.Status, after.FailureTransactionID = payout.Status, payout.FailureTransactionID
+	return true, r.audit(models.AuditUpdate, "payout", payout.ID, before, after)
+}
+
+func (r *WebhookRepository) InsertPayoutStatusChange(change *models.PayoutStatusChange) error {
+	query := `
+    INSERT INTO payout_status_history (payout_id, status, created, failure_code, failure_message, event_id)
+	VALUES (:payout_id, :status, :created, :failure_code, :failure_message, :event_id)
     `
-	_, err := r.execNamed(query, payout)
+	_, err := r.execNamed(query, change)
 	return err
 }
 
+// ReleasePayoutTransactions marks every transaction of a failed payout as unpaid again,
+// keeping a reference to the failed payout so the next payout can claim them.
+func (r *WebhookRepository) ReleasePayoutTransactions(payoutID string) error {
+	for _, transactions := range payoutTransactionTables {
+		rows, err := r.payoutLinks(transactions.table, "payout_id = ?", payoutID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", transactions.table, err)
+		}
+		query := fmt.Sprintf(`
+		UPDATE %s
+		SET failed_payout_id = payout_id, payout_id = NULL
+		WHERE payout_id = :payout_id
+		`, transactions.table)
+		if _, err := r.execNamed(query, map[string]interface{}{"payout_id": payoutID}); err != nil {
+			return fmt.Errorf("failed to release %s: %w", transactions.table, err)
+		}
+		for _, row := range rows {
+			after := payoutLink{FailedPayoutID: row.PayoutID}
+			if err = r.audit(models.AuditUpdate, transactions.entity, row.ID, row.payoutLink, after); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RelinkFailedPayoutTransactions moves the transactions released by a failed payout
+// to the payout that returned their funds.
+func (r *WebhookRepository) RelinkFailedPayoutTransactions(failedPayoutID, payoutID string) error {
+	for _, transactions := range payoutTransactionTables {
+		rows, err := r.payoutLinks(transactions.table, "failed_payout_id = ? AND payout_id IS NULL", failedPayoutID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", transactions.table, err)
+		}
+		query := fmt.Sprintf(`
+		UPDATE %s
+		SET payout_id = :payout_id
+		WHERE failed_payout_id = :failed_payout_id AND payout_id IS NULL
+		`, transactions.table)
+		args := map[string]interface{}{"payout_id": payoutID, "failed_payout_id": failedPayoutID}
+		if _, err := r.execNamed(query, args); err != nil {
+			return fmt.Errorf("failed to relink %s: %w", transactions.table, err)
+		}
+		for _, row := range rows {
+			after := row.payoutLink
+			after.PayoutID = sql.NullString{String: payoutID, Valid: true}
+			if err = r.audit(models.AuditUpdate, transactions.entity, row.ID, row.payoutLink, after); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// linkPayout sets the payout of a transaction, reporting whether the transaction exists.
+func (r *WebhookRepository) linkPayout(table, id string, payoutID sql.NullString) (bool, error) {
+	rows, err := r.payoutLinks(table, "id = ?", id)
+	if err != nil || len(rows) == 0 {
+		return false, err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET payout_id = :payout_id WHERE id = :id", table)
+	if _, err = r.execNamed(query, map[string]interface{}{"id": id, "payout_id": payoutID}); err != nil {
+		return false, err
+	}
+	after := rows[0].payoutLink
+	after.PayoutID = payoutID
+	return true, r.audit(models.AuditUpdate, transactionEntity(table), id, rows[0].payoutLink, after)
+}
+
+func (r *WebhookRepository) payoutLinks(table, condition string, args ...interface{}) (rows []payoutLinkRow, err error) {
+	query := fmt.Sprintf("SELECT id, payout_id, failed_payout_id FROM %s WHERE %s", table, condition)
+	err = r.selectAll(&rows, query, args...)
+	return
+}
+
+func transactionEntity(table string) string {
+	for _, transactions := range payoutTransactionTables {
+		if transactions.table == table {
+			return transactions.entity
+		}
+	}
+	return table
+}
+
+func (r *WebhookRepository) GetFailedPayout(failureTransactionID string) (*models.Payout, error) {
+	var payout models.Payout
+	query := "SELECT * FROM payouts WHERE failure_transaction_id = ?"
+
+	if err := r.get(&payout, query, failureTransactionID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payout with failure transaction %s not found", failureTransactionID)
+		}
+		return nil, fmt.Errorf("failed to retrieve payout: %w", err)
+	}
+	return &payout, nil
+}
+
 func (r *PWARepository) GetMonthlyPayouts(monthStart, monthEnd int64) ([]*models.Payout, error) {
 	var payouts []*models.Payout
 	query := "SELECT * FROM payouts WHERE created >= ? AND created <= ?"
 
-	if err := r.db.Select(&payouts, query, monthStart, monthEnd); err != nil {
+	if err := r.db.Select(&payouts, r.db.Rebind(query), monthStart, monthEnd); err != nil {
 		return nil, err
 	}
 	return payouts, nil
@@ -30,7 +184,7 @@ func (r *PWARepository) GetPayout(id string) (*models.Payout, error) {
 	var payout models.Payout
 	query := "SELECT * FROM payouts WHERE id = ?"
 
-	if err := r.db.Get(&payout, query, id); err != nil {
+	if err := r.db.Get(&payout, r.db.Rebind(query), id); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("payout with id %s not found", id)
 		}
@@ -38,3 +192,12 @@ func (r *PWARepository) GetPayout(id string) (*models.Payout, error) {
 	}
 	return &payout, nil
 }
+
+func (r *PWARepository) PayoutExists(id string) (exists bool, err error) {
+	query := "SELECT EXISTS (SELECT 1 FROM payouts WHERE id = ?)"
+
+	if err := r.db.Get(&exists, r.db.Rebind(query), id); err != nil {
+		return false, fmt.Errorf("failed to check payout: %w", err)
+	}
+	return
+}
@@ -11,3 +11,16 @@ type PWARepository struct {
 func NewPWARepository(db *sqlx.DB) *PWARepository {
 	return &PWARepository{db: db}
 }
+
+// inTransaction runs fn in a transaction, committed when fn succeeds.
+func (r *PWARepository) inTransaction(fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/diother/go-invoices/database"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestDonorQueries(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		webhookRepo := NewWebhookRepository(db)
+		for _, donation := range []*models.Donation{
+			{ID: "ch_1", Created: 100, Gross: 1000, Currency: "ron", ClientName: "Ion", ClientEmail: "Ion@Example.com"},
+			{ID: "ch_2", Created: 200, Gross: 2000, Currency: "ron", ClientName: "Ion", ClientEmail: "ion@example.com"},
+			{ID: "ch_3", Created: 300, Gross: 3000, Currency: "ron", ClientName: "Maria", ClientEmail: "maria@example.com"},
+			{ID: "ch_4", Created: 400, Gross: 4000, Currency: "ron", ClientName: "Anonim", ClientEmail: ""},
+		} {
+			if _, err := webhookRepo.InsertDonation(donation); err != nil {
+				t.Fatalf("Failed to insert donation: %v", err)
+			}
+		}
+		repo := NewPWARepository(db)
+
+		donations, err := repo.GetDonorDonations("ION@example.com", 0, 1000)
+		if err != nil || len(donations) != 2 || donations[0].ID != "ch_1" || donations[1].ID != "ch_2" {
+			t.Errorf("Expected both of Ion's donations oldest first, got %+v and %v", donations, err)
+		}
+		if donations, err = repo.GetDonorDonations("ion@example.com", 150, 1000); err != nil || len(donations) != 1 {
+			t.Errorf("Expected one of Ion's donations in range, got %d and %v", len(donations), err)
+		}
+
+		emails, err := repo.GetDonorEmails(0, 1000)
+		if err != nil || strings.Join(emails, ",") != "ion@example.com,maria@example.com" {
+			t.Errorf("Expected the two donor emails, got %v and %v", emails, err)
+		}
+
+		if exists, err := repo.DonationExists("ch_3"); err != nil || !exists {
+			t.Errorf("Expected donation ch_3 to exist, got %v and %v", exists, err)
+		}
+		if exists, err := repo.DonationExists("ch_missing"); err != nil || exists {
+			t.Errorf("Expected donation ch_missing not to exist, got %v and %v", exists, err)
+		}
+	})
+}
+
+func TestInsertArchivedDocument(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		repo := NewPWARepository(db)
+
+		var ids []int64
+		for version := int64(1); version <= 2; version++ {
+			document := &models.ArchivedDocument{Type: "donation", Reference: "ch_1", Lang: "ro", Version: version, Path: "donation/ch_1.pdf", SHA256: "abc", Issued: 100, IssuedBy: "admin"}
+			if err := repo.InsertArchivedDocument(document); err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			ids = append(ids, document.ID)
+		}
+		if ids[0] == 0 || ids[0] == ids[1] {
+			t.Fatalf("Expected distinct IDs for the documents, got %v", ids)
+		}
+
+		latest, err := repo.GetLatestArchivedDocument("donation", "ch_1", "ro")
+		if err != nil || latest.ID != ids[1] || latest.Version != 2 {
+			t.Errorf("Expected version 2 as the latest document, got %+v and %v", latest, err)
+		}
+		duplicate := &models.ArchivedDocument{Type: "donation", Reference: "ch_1", Lang: "ro", Version: 2, Path: "donation/ch_1.pdf", SHA256: "abc", Issued: 100, IssuedBy: "admin"}
+		if err = repo.InsertArchivedDocument(duplicate); err == nil {
+			t.Errorf("Expected a duplicate version to be refused")
+		}
+	})
+}
+
+func TestEmailOutbox(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		donation := &models.Donation{ID: "ch_1", Created: 100, Gross: 1000, Currency: "ron", ClientName: "Ion", ClientEmail: "ion@example.com"}
+		if _, err := NewWebhookRepository(db).InsertDonation(donation); err != nil {
+			t.Fatalf("Failed to insert donation: %v", err)
+		}
+		repo := NewPWARepository(db)
+		for _, nextAttempt := range []sql.NullInt64{{}, {Int64: 500, Valid: true}} {
+			email := models.NewEmail("ch_1", "ion@example.com", 100, "admin", models.EmailQueued, sql.NullString{}, 0, nextAttempt, sql.NullInt64{})
+			if err := repo.EnqueueEmail(email); err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+		}
+
+		due, err := repo.GetDueEmails(200, 10)
+		if err != nil || len(due) != 1 {
+			t.Fatalf("Expected one email due, got %d and %v", len(due), err)
+		}
+		due[0].Status = models.EmailSent
+		due[0].Attempts = 1
+		due[0].Sent = sql.NullInt64{Int64: 200, Valid: true}
+		if err = repo.UpdateEmail(due[0]); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+
+		sent, err := repo.GetEmails(models.EmailSent)
+		if err != nil || len(sent) != 1 || sent[0].ID != due[0].ID || sent[0].Sent.Int64 != 200 {
+			t.Errorf("Expected the sent email recorded, got %+v and %v", sent, err)
+		}
+		if due, err = repo.GetDueEmails(600, 10); err != nil || len(due) != 1 {
+			t.Errorf("Expected the delayed email due later, got %d and %v", len(due), err)
+		}
+	})
+}
+
+func TestDonors(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		webhookRepo := NewWebhookRepository(db)
+		ids := make(map[string]int64)
+		for _, donation := range []*models.Donation{
+			{ID: "ch_1", Created: 100, Gross: 1000, Currency: "ron", ClientName: "Ion Popscu", ClientEmail: "ion@example.com"},
+			{ID: "ch_2", Created: 200, Gross: 2000, Currency: "ron", ClientName: "Ion Popescu", ClientEmail: "ion@example.com"},
+			{ID: "ch_3", Created: 300, Gross: 3000, Currency: "ron", ClientName: "Ion Popescu", ClientEmail: "ion.popescu@example.com"},
+			{ID: "ch_4", Created: 400, Gross: 4000, Currency: "ron", ClientName: "Maria 100%", ClientEmail: "maria@example.com"},
+		} {
+			donor := models.NewDonor(donation.ClientName, donation.ClientEmail, int64(donation.Created))
+			if err := webhookRepo.UpsertDonor(donor); err != nil {
+				t.Fatalf("Failed to upsert donor: %v", err)
+			}
+			if id, ok := ids[donation.ClientEmail]; ok && id != donor.ID {
+				t.Errorf("Expected %s matched to donor %d, got %d", donation.ClientEmail, id, donor.ID)
+			}
+			ids[donation.ClientEmail] = donor.ID
+			donation.DonorID = sql.NullInt64{Int64: donor.ID, Valid: true}
+			if _, err := webhookRepo.InsertDonation(donation); err != nil {
+				t.Fatalf("Failed to insert donation: %v", err)
+			}
+		}
+		repo := NewPWARepository(db)
+		ion, duplicate := ids["ion@example.com"], ids["ion.popescu@example.com"]
+
+		donors, err := repo.GetDonors("ION", 10)
+		if err != nil || len(donors) != 2 || donors[0].Gifts+donors[1].Gifts != 3 {
+			t.Fatalf("Expected both of Ion's donors with 3 gifts, got %+v and %v", donors, err)
+		}
+		if donors, err = repo.GetDonors("100%", 10); err != nil || len(donors) != 1 || donors[0].ID != ids["maria@example.com"] {
+			t.Errorf("Expected a search for a literal %% to find Maria only, got %+v and %v", donors, err)
+		}
+
+		admin := models.Actor{Username: "admin", IP: "192.0.2.1"}
+		if err = repo.RenameDonor(admin, ion, "Ion Popescu"); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if err = repo.MergeDonors(admin, ion, duplicate); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		entries, err := repo.GetAuditEntries(models.AuditFilter{Entity: "donor", Username: "admin"}, 10)
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("Expected the rename and the merge audited, got %+v and %v", entries, err)
+		}
+		if entries[0].Action != models.AuditMerge || entries[0].Reference != strconv.FormatInt(duplicate, 10) || !strings.Contains(entries[0].Changes.String, `"merged_into"`) {
+			t.Errorf("Expected the merge of the duplicate audited, got %+v", entries[0])
+		}
+		if entries[1].Action != models.AuditUpdate || entries[1].Reference != strconv.FormatInt(ion, 10) || !strings.Contains(entries[1].Changes.String, `"after":"Ion Popescu"`) {
+			t.Errorf("Expected the rename audited, got %+v", entries[1])
+		}
+		if err = repo.MergeDonors(admin, ion, 999); err == nil {
+			t.Errorf("Expected a merge of an unknown donor to fail")
+		}
+		if entries, err = repo.GetAuditEntries(models.AuditFilter{Entity: "donor", Username: "admin"}, 10); err != nil || len(entries) != 2 {
+			t.Errorf("Expected a failed merge not audited, got %d entries and %v", len(entries), err)
+		}
+		if gone, err := repo.GetDonor(duplicate); err != nil || gone != nil {
+			t.Errorf("Expected the duplicate deleted, got %+v and %v", gone, err)
+		}
+		emails, err := repo.GetDonorEmailAliases(ion)
+		if err != nil || strings.Join(emails, ",") != "ion.popescu@example.com,ion@example.com" {
+			t.Errorf("Expected both emails on the merged donor, got %v and %v", emails, err)
+		}
+		donations, err := repo.GetDonationsByDonor(ion)
+		if err != nil || len(donations) != 3 || donations[0].ClientName != "Ion Popescu" {
+			t.Errorf("Expected the merged donor's 3 donations with the corrected name, got %+v and %v", donations, err)
+		}
+		if donations, err = repo.GetDonorDonations("ION.popescu@example.com", 0, 1000); err != nil || len(donations) != 3 {
+			t.Errorf("Expected the merged donor's 3 donations by either email, got %d and %v", len(donations), err)
+		}
+		statementEmails, err := repo.GetDonorEmails(0, 1000)
+		if err != nil || strings.Join(statementEmails, ",") != "ion@example.com,maria@example.com" {
+			t.Errorf("Expected one email per donor after the merge, got %v and %v", statementEmails, err)
+		}
+
+		donor := models.NewDonor("Ion", "ion.popescu@example.com", 500)
+		if err = webhookRepo.UpsertDonor(donor); err != nil || donor.ID != ion {
+			t.Errorf("Expected a merged email to match the donor %d, got %d and %v", ion, donor.ID, err)
+		}
+		if byEmail, err := repo.GetDonorByEmail("ion.popescu@example.com"); err != nil || byEmail.ID != ion || byEmail.Created != 100 {
+			t.Errorf("Expected the merged donor found by email since the first gift, got %+v and %v", byEmail, err)
+		}
+	})
+}
+
+func TestSearch(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		webhookRepo := NewWebhookRepository(db)
+		if err := webhookRepo.InsertPayout(&models.Payout{ID: "po_1", Created: 500, Gross: 5000, Fee: 200, Net: 4800, Currency: "ron", Status: models.PayoutPaid}); err != nil {
+			t.Fatalf("Failed to insert payout: %v", err)
+		}
+		for _, donation := range []*models.Donation{
+			{ID: "ch_1", Created: 100, Gross: 1000, Currency: "ron", ClientName: "Ion Ionescu", ClientEmail: "ion@example.com",
+				InvoiceSeries: sql.NullString{String: "HNT", Valid: true}, InvoiceYear: sql.NullInt64{Int64: 2024, Valid: true}, InvoiceNumber: sql.NullInt64{Int64: 7, Valid: true}},
+			{ID: "ch_2", Created: 200, Gross: 4000, Currency: "ron", ClientName: "Ștefan Pop", ClientEmail: "pop@example.com"},
+		} {
+			if _, err := webhookRepo.InsertDonation(donation); err != nil {
+				t.Fatalf("Failed to insert donation: %v", err)
+			}
+		}
+		if err := webhookRepo.InsertFee(&models.Fee{ID: "fee_1", Description: "Stripe billing", Created: 300, Fee: 200, Currency: "ron", PayoutID: sql.NullString{String: "po_1", Valid: true}}); err != nil {
+			t.Fatalf("Failed to insert fee: %v", err)
+		}
+		if _, err := webhookRepo.UpdateRelatedPayout(&models.Donation{ID: "ch_1", PayoutID: sql.NullString{String: "po_1", Valid: true}}); err != nil {
+			t.Fatalf("Failed to relate donation: %v", err)
+		}
+		repo := NewPWARepository(db)
+
+		testCases := map[string]struct {
+			filter   models.SearchFilter
+			expected string
+		}{
+			"name":          {filter: models.SearchFilter{Terms: []string{"ionescu"}}, expected: "ch_1"},
+			"email":         {filter: models.SearchFilter{Terms: []string{"pop@example.com"}}, expected: "ch_2"},
+			"invoiceNumber": {filter: models.SearchFilter{Terms: []string{"HNT-2024-000007"}}, expected: "ch_1"},
+			"feeText":       {filter: models.SearchFilter{Terms: []string{"billing"}}, expected: "fee_1"},
+			"payoutID":      {filter: models.SearchFilter{Terms: []string{"po_1"}}, expected: "po_1"},
+			"everyTerm":     {filter: models.SearchFilter{Terms: []string{"ion", "stefan"}}, expected: ""},
+			"all":           {filter: models.SearchFilter{}, expected: "po_1,fee_1,ch_2,ch_1"},
+			"dateRange":     {filter: models.SearchFilter{Start: sql.NullInt64{Int64: 200, Valid: true}, End: sql.NullInt64{Int64: 500, Valid: true}}, expected: "fee_1,ch_2"},
+			"amountRange":   {filter: models.SearchFilter{MinAmount: sql.NullInt64{Int64: 1000, Valid: true}, MaxAmount: sql.NullInt64{Int64: 4000, Valid: true}}, expected: "ch_2,ch_1"},
+		}
+		if db.DriverName() == database.SQLite {
+			testCases["withoutDiacritics"] = struct {
+				filter   models.SearchFilter
+				expected string
+			}{filter: models.SearchFilter{Terms: []string{"stef"}}, expected: "ch_2"}
+		}
+
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				results, err := repo.Search(tc.filter, 10)
+				if err != nil {
+					t.Fatalf("Expected no error, but got: %v", err)
+				}
+				var references []string
+				for _, result := range results {
+					references = append(references, result.Reference)
+				}
+				if strings.Join(references, ",") != tc.expected {
+					t.Errorf("Expected %q, got %v", tc.expected, references)
+				}
+			})
+		}
+
+		results, err := repo.Search(models.SearchFilter{Terms: []string{"ionescu"}}, 10)
+		if err != nil || len(results) != 1 || results[0].PayoutID.String != "po_1" || results[0].Amount != 1000 || results[0].Title != "Ion Ionescu" {
+			t.Errorf("Expected the donation with the payout it was paid out in, got %+v and %v", results, err)
+		}
+	})
+}
+
+func TestAuditLog(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		type donor struct {
+			Name  string         `db:"name"`
+			Email sql.NullString `db:"email"`
+		}
+		repo := NewPWARepository(db)
+		admin := models.Actor{Username: "admin", IP: "192.0.2.1"}
+		inserts := []struct {
+			entry         *models.AuditEntry
+			before, after interface{}
+		}{
+			{models.NewAuditEntry(100, admin, models.AuditLogin, "user", "admin"), nil, nil},
+			{models.NewAuditEntry(200, admin, models.AuditUpdate, "donor", "1"),
+				&donor{Name: "Ion", Email: sql.NullString{String: "ion@example.com", Valid: true}},
+				&donor{Name: "Ion Ionescu", Email: sql.NullString{String: "ion@example.com", Valid: true}}},
+			{models.NewAuditEntry(300, models.StripeActor, models.AuditInsert, "donor", "2"), nil, &donor{Name: "Ana"}},
+		}
+		for _, e := range inserts {
+			if err := repo.InsertAuditEntry(e.entry, e.before, e.after); err != nil {
+				t.Fatalf("Failed to insert audit entry: %v", err)
+			}
+		}
+
+		testCases := map[string]struct {
+			filter   models.AuditFilter
+			expected string
+		}{
+			"all":       {filter: models.AuditFilter{}, expected: "donor/2,donor/1,user/admin"},
+			"user":      {filter: models.AuditFilter{Username: "admin"}, expected: "donor/1,user/admin"},
+			"action":    {filter: models.AuditFilter{Action: models.AuditInsert}, expected: "donor/2"},
+			"entity":    {filter: models.AuditFilter{Entity: "donor"}, expected: "donor/2,donor/1"},
+			"reference": {filter: models.AuditFilter{Entity: "donor", Reference: "1"}, expected: "donor/1"},
+			"dateRange": {filter: models.AuditFilter{Start: sql.NullInt64{Int64: 100, Valid: true}, End: sql.NullInt64{Int64: 300, Valid: true}}, expected: "donor/1,user/admin"},
+		}
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				entries, err := repo.GetAuditEntries(tc.filter, 10)
+				if err != nil {
+					t.Fatalf("Expected no error, but got: %v", err)
+				}
+				var references []string
+				for _, entry := range entries {
+					references = append(references, entry.Entity+"/"+entry.Reference)
+				}
+				if strings.Join(references, ",") != tc.expected {
+					t.Errorf("Expected %q, got %v", tc.expected, references)
+				}
+			})
+		}
+
+		entries, err := repo.GetAuditEntries(models.AuditFilter{}, 10)
+		if err != nil || len(entries) != 3 {
+			t.Fatalf("Expected the audit entries, got %+v and %v", entries, err)
+		}
+		changes := map[string]string{
+			"user/admin": "",
+			"donor/1":    `{"name":{"before":"Ion","after":"Ion Ionescu"}}`,
+			"donor/2":    `{"name":{"before":null,"after":"Ana"}}`,
+		}
+		for _, entry := range entries {
+			if expected := changes[entry.Entity+"/"+entry.Reference]; entry.Changes.String != expected {
+				t.Errorf("Expected the changes of %s/%s to be %s, got %s", entry.Entity, entry.Reference, expected, entry.Changes.String)
+			}
+		}
+		if entries[2].Username != "admin" || entries[2].IP != "192.0.2.1" || entries[0].Username != "stripe" {
+			t.Errorf("Expected the actors recorded, got %+v", entries)
+		}
+
+		if _, err := db.Exec("UPDATE audit_log SET username = 'someone'"); err == nil {
+			t.Error("Expected audit entries not to be updated")
+		}
+		if _, err := db.Exec("DELETE FROM audit_log"); err == nil {
+			t.Error("Expected audit entries not to be deleted")
+		}
+	})
+}
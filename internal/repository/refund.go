@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/diother/go-invoices/internal/models"
+)
+
+// InsertRefund ignores refunds that are already stored, since every charge.refunded
+// event carries the full list of refunds made on the charge.
+func (r *WebhookRepository) InsertRefund(refund *models.Refund) error {
+	query := `
+    INSERT INTO refunds (id, created, gross, fee, net, currency, donation_id, payout_id, event_id)
+	VALUES (:id, :created, :gross, :fee, :net, :currency, :donation_id, :payout_id, :event_id)
+	ON CONFLICT (id) DO NOTHING
+    `
+	result, err := r.execNamed(query, refund)
+	if err != nil {
+		return err
+	}
+	return r.auditInserted(result, "refund", refund.ID, refund)
+}
+
+func (r *WebhookRepository) UpdateRefundPayout(refund *models.Refund) (bool, error) {
+	return r.linkPayout("refunds", refund.ID, refund.PayoutID)
+}
+
+func (r *PWARepository) GetRefund(id string) (*models.Refund, error) {
+	var refund models.Refund
+	query := "SELECT * FROM refunds WHERE id = ?"
+
+	if err := r.db.Get(&refund, r.db.Rebind(query), id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("refund with id %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to retrieve refund: %w", err)
+	}
+	return &refund, nil
+}
+
+func (r *PWARepository) GetRelatedRefunds(payoutID string) (refunds []*models.Refund, err error) {
+	query := "SELECT id, created, gross, fee, net, currency, donation_id FROM refunds WHERE payout_id = ? OR failed_payout_id = ?"
+
+	if err := r.db.Select(&refunds, r.db.Rebind(query), payoutID, payoutID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no refunds with payout_id: %s", payoutID)
+		}
+		return nil, fmt.Errorf("failed to retrieve refunds: %w", err)
+	}
+	return
+}
+
+// GetRefundsCreatedBetween returns the refunds created in [start, end), oldest first.
+func (r *PWARepository) GetRefundsCreatedBetween(start, end int64) (refunds []*models.Refund, err error) {
+	query := "SELECT * FROM refunds WHERE created >= ? AND created < ? ORDER BY created, id"
+
+	if err := r.db.Select(&refunds, r.db.Rebind(query), start, end); err != nil {
+		return nil, fmt.Errorf("failed to retrieve refunds: %w", err)
+	}
+	return
+}
+
+// GetDonorRefunds returns the refunds made in [start, end) on donations of the donor behind
+// email, oldest first.
+func (r *PWARepository) GetDonorRefunds(email string, start, end int64) (refunds []*models.Refund, err error) {
+	query := `
+	SELECT refunds.* FROM refunds
+	JOIN donations ON donations.id = refunds.donation_id
+	WHERE ` + donorDonations + ` AND refunds.created >= ? AND refunds.created < ?
+	ORDER BY refunds.created, refunds.id
+	`
+	if err := r.db.Select(&refunds, r.db.Rebind(query), email, email, start, end); err != nil {
+		return nil, fmt.Errorf("failed to retrieve refunds: %w", err)
+	}
+	return
+}
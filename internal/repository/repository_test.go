@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/diother/go-invoices/database"
+	"github.com/diother/go-invoices/database/dbtest"
+	"github.com/jmoiron/sqlx"
+)
+
+// forEachDatabase runs test against a migrated, empty database of every engine. PostgreSQL
+// joins in when TEST_POSTGRES_DSN is set. SQLite needs the sqlite_fts5 build tag, which
+// make test passes.
+func forEachDatabase(t *testing.T, test func(t *testing.T, db *sqlx.DB)) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err = os.Chdir(filepath.Join(wd, "..", "..")); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	for driver, dsn := range dbtest.DSNs(t) {
+		t.Run(driver, func(t *testing.T) {
+			db, err := database.InitDB(dsn)
+			if err != nil {
+				t.Fatalf("Failed to connect to the database: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			if err = database.ApplyMigrations(dsn); err != nil {
+				t.Fatalf("Failed to apply migrations: %v", err)
+			}
+			test(t, db)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/diother/go-invoices/database"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+// Search returns up to limit donations, fees and payouts matching every term of the
+// filter, newest first. Terms match the IDs, donor names and emails, invoice numbers and
+// fee descriptions. SQLite matches word prefixes in a full-text index, ignoring case and
+// diacritics; PostgreSQL matches substrings without regard to case.
+func (r *PWARepository) Search(filter models.SearchFilter, limit int) (results []*models.SearchResult, err error) {
+	var conditions []string
+	var args []interface{}
+
+	if r.db.DriverName() == database.Postgres {
+		for _, term := range filter.Terms {
+			conditions = append(conditions, `content ILIKE ? ESCAPE '\'`)
+			args = append(args, "%"+escapeLike(term)+"%")
+		}
+	} else if match := matchExpression(filter.Terms); match != "" {
+		conditions = append(conditions, "search_index MATCH ?")
+		args = append(args, match)
+	}
+
+	bounds := []struct {
+		condition string
+		value     interface{}
+		valid     bool
+	}{
+		{"created >= ?", filter.Start.Int64, filter.Start.Valid},
+		{"created < ?", filter.End.Int64, filter.End.Valid},
+		{"amount >= ?", filter.MinAmount.Int64, filter.MinAmount.Valid},
+		{"amount <= ?", filter.MaxAmount.Int64, filter.MaxAmount.Valid},
+	}
+	for _, bound := range bounds {
+		if bound.valid {
+			conditions = append(conditions, bound.condition)
+			args = append(args, bound.value)
+		}
+	}
+
+	query := "SELECT kind, reference, payout_id, created, amount, currency, title FROM search_index"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created DESC, reference LIMIT ?"
+	args = append(args, limit)
+
+	if err := r.db.Select(&results, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	return
+}
+
+// matchExpression turns the terms into an FTS5 query matching each term as a phrase
+// prefix, so "ion" finds Ionescu and an invoice number matches across its dashes. Terms
+// without letters or digits would be empty phrases, and are dropped.
+func matchExpression(terms []string) string {
+	var phrases []string
+	for _, term := range terms {
+		term = strings.ReplaceAll(term, `"`, "")
+		if strings.IndexFunc(term, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		phrases = append(phrases, `"`+term+`"*`)
+	}
+	return strings.Join(phrases, " ")
+}
@@ -20,7 +20,7 @@ func (r *AuthRepository) GetSession(sessionToken int64) (*models.Session, error)
 	var session models.Session
 	query := "SELECT * FROM sessions WHERE session_token = ?"
 
-	if err := r.db.Get(&session, query, sessionToken); err != nil {
+	if err := r.db.Get(&session, r.db.Rebind(query), sessionToken); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no session with the id: %v", sessionToken)
 		}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"github.com/diother/go-invoices/internal/models"
+)
+
+// EnqueueStripeEvent stores a newly received event, or requeues a failed or dead one
+// delivered again by Stripe. It reports false when the event is already queued or processed.
+func (r *WebhookRepository) EnqueueStripeEvent(event *models.StripeEvent) (bool, error) {
+	query := `
+    INSERT INTO stripe_events (id, type, payload, received, processed, status, error, attempts, next_attempt)
+	VALUES (:id, :type, :payload, :received, :processed, :status, :error, :attempts, :next_attempt)
+	ON CONFLICT (id) DO UPDATE
+	SET payload = excluded.payload, received = excluded.received, processed = NULL, status = excluded.status,
+		error = NULL, attempts = 0, next_attempt = excluded.next_attempt
+	WHERE stripe_events.status IN ('failed', 'dead')
+    `
+	result, err := r.execNamed(query, event)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected != 0, nil
+}
+
+// ClaimStripeEvent moves a queued event to processing and counts the attempt.
+// It reports false when another worker claimed the event first.
+func (r *WebhookRepository) ClaimStripeEvent(id string) (bool, error) {
+	query := `
+	UPDATE stripe_events
+	SET status = 'processing', attempts = attempts + 1
+	WHERE id = :id AND status IN ('received', 'failed')
+	`
+	result, err := r.execNamed(query, map[string]interface{}{"id": id})
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected != 0, nil
+}
+
+func (r *WebhookRepository) UpdateStripeEvent(event *models.StripeEvent) error {
+	query := `
+	UPDATE stripe_events
+	SET processed = :processed, status = :status, error = :error, next_attempt = :next_attempt
+	WHERE id = :id
+	`
+	_, err := r.execNamed(query, event)
+	return err
+}
+
+// RetryStripeEvent queues a failed or dead event for immediate processing with a fresh attempt count.
+func (r *WebhookRepository) RetryStripeEvent(event *models.StripeEvent) (bool, error) {
+	query := `
+	UPDATE stripe_events
+	SET status = :status, error = NULL, attempts = 0, next_attempt = :next_attempt
+	WHERE id = :id AND status IN ('failed', 'dead')
+	`
+	result, err := r.execNamed(query, event)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected != 0, nil
+}
+
+// ResetProcessingStripeEvents requeues events left in processing by a stopped server.
+func (r *WebhookRepository) ResetProcessingStripeEvents() error {
+	query := `
+	UPDATE stripe_events
+	SET status = 'received'
+	WHERE status = 'processing'
+	`
+	_, err := r.execNamed(query, map[string]interface{}{})
+	return err
+}
+
+func (r *WebhookRepository) GetDueStripeEvents(now int64, limit int) (events []*models.StripeEvent, err error) {
+	query := `
+	SELECT * FROM stripe_events
+	WHERE status IN ('received', 'failed') AND (next_attempt IS NULL OR next_attempt <= ?)
+	ORDER BY received
+	LIMIT ?
+	`
+	if err := r.db.Select(&events, r.db.Rebind(query), now, limit); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (r *WebhookRepository) GetStripeEvents(status string) (events []*models.StripeEvent, err error) {
+	query := "SELECT id, type, received, processed, status, error, attempts, next_attempt FROM stripe_events WHERE status = ? ORDER BY received DESC"
+
+	if err := r.db.Select(&events, r.db.Rebind(query), status); err != nil {
+		return nil, err
+	}
+	return
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/diother/go-invoices/internal/constants"
 	"github.com/diother/go-invoices/internal/custom_errors"
 	"github.com/diother/go-invoices/internal/models"
 )
@@ -12,9 +13,9 @@ func (r *AuthRepository) GetUserByUsername(username string) (*models.User, error
 	var user models.User
 	query := "SELECT * FROM users WHERE username = ?"
 
-	if err := r.db.Get(&user, query, username); err != nil {
+	if err := r.db.Get(&user, r.db.Rebind(query), username); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, custom_errors.NewCredentialsError("Nume de utilizator sau parolă invalide")
+			return nil, custom_errors.NewCredentialsError(constants.ErrLoginInvalid)
 		}
 		return nil, fmt.Errorf("failed to retrieve user: %w", err)
 	}
@@ -25,7 +26,7 @@ func (r *AuthRepository) GetUserByID(id int64) (*models.User, error) {
 	var user models.User
 	query := "SELECT * FROM users WHERE id = ?"
 
-	if err := r.db.Get(&user, query, id); err != nil {
+	if err := r.db.Get(&user, r.db.Rebind(query), id); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("no user with the id: %v", id)
 		}
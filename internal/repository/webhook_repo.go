@@ -49,3 +49,17 @@ func (r *WebhookRepository) execNamed(query string, arg interface{}) (sql.Result
 	}
 	return r.db.NamedExec(query, arg)
 }
+
+func (r *WebhookRepository) get(dest interface{}, query string, args ...interface{}) error {
+	if r.tx != nil {
+		return r.tx.Get(dest, r.tx.Rebind(query), args...)
+	}
+	return r.db.Get(dest, r.db.Rebind(query), args...)
+}
+
+func (r *WebhookRepository) selectAll(dest interface{}, query string, args ...interface{}) error {
+	if r.tx != nil {
+		return r.tx.Select(dest, r.tx.Rebind(query), args...)
+	}
+	return r.db.Select(dest, r.db.Rebind(query), args...)
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestNextInvoiceNumber(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		repo := NewWebhookRepository(db)
+
+		for _, reservation := range []struct {
+			series   string
+			year     int
+			created  int64
+			expected int64
+			issued   int64
+		}{
+			{"HNT", 2024, 100, 1, 100},
+			{"HNT", 2024, 300, 2, 300},
+			{"HNT", 2025, 50, 1, 50},
+			{"DON", 2024, 200, 1, 200},
+			{"HNT", 2024, 200, 3, 300},
+		} {
+			number, issued, err := repo.NextInvoiceNumber(reservation.series, reservation.year, reservation.created)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if number != reservation.expected || issued != reservation.issued {
+				t.Errorf("Expected %s-%d number %d issued at %d, got %d issued at %d", reservation.series, reservation.year, reservation.expected, reservation.issued, number, issued)
+			}
+		}
+
+		if err := repo.BeginTransaction(); err != nil {
+			t.Fatalf("Failed to begin transaction: %v", err)
+		}
+		if _, _, err := repo.NextInvoiceNumber("HNT", 2024, 400); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if err := repo.Rollback(); err != nil {
+			t.Fatalf("Failed to roll back: %v", err)
+		}
+		if number, issued, err := repo.NextInvoiceNumber("HNT", 2024, 350); err != nil || number != 4 || issued != 350 {
+			t.Errorf("Expected a rolled back number and date to be given back, got %d issued at %d and %v", number, issued, err)
+		}
+	})
+}
+
+func TestNextInvoiceNumberConcurrent(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		const workers = 8
+		issuedByNumber := make(map[int64]int64)
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(created int64) {
+				defer wg.Done()
+				repo := NewWebhookRepository(db)
+				if err := repo.BeginTransaction(); err != nil {
+					t.Errorf("Failed to begin transaction: %v", err)
+					return
+				}
+				number, issued, err := repo.NextInvoiceNumber("HNT", 2024, created)
+				if err != nil {
+					repo.Rollback()
+					t.Errorf("Expected no error, but got: %v", err)
+					return
+				}
+				if err = repo.Commit(); err != nil {
+					t.Errorf("Failed to commit: %v", err)
+					return
+				}
+				mu.Lock()
+				issuedByNumber[number] = issued
+				mu.Unlock()
+			}(int64(1000 - i*100))
+		}
+		wg.Wait()
+
+		for number := int64(1); number <= workers; number++ {
+			issued, ok := issuedByNumber[number]
+			if !ok {
+				t.Fatalf("Expected numbers 1 to %d without gaps, got %v", workers, issuedByNumber)
+			}
+			if number > 1 && issued < issuedByNumber[number-1] {
+				t.Errorf("Expected number %d to be issued no earlier than number %d, got %v", number, number-1, issuedByNumber)
+			}
+		}
+	})
+}
+
+func TestStripeEventQueue(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		repo := NewWebhookRepository(db)
+		event := models.NewStripeEvent("evt_1", "charge.updated", "{}", 100, sql.NullInt64{}, models.StripeEventReceived, sql.NullString{}, 0, sql.NullInt64{})
+
+		if queued, err := repo.EnqueueStripeEvent(event); err != nil || !queued {
+			t.Fatalf("Expected the event queued, got %v and %v", queued, err)
+		}
+		if queued, err := repo.EnqueueStripeEvent(event); err != nil || queued {
+			t.Errorf("Expected a redelivered event to be ignored, got %v and %v", queued, err)
+		}
+
+		due, err := repo.GetDueStripeEvents(200, 10)
+		if err != nil || len(due) != 1 {
+			t.Fatalf("Expected the event due, got %d and %v", len(due), err)
+		}
+		if claimed, err := repo.ClaimStripeEvent("evt_1"); err != nil || !claimed {
+			t.Fatalf("Expected the event claimed, got %v and %v", claimed, err)
+		}
+		if claimed, err := repo.ClaimStripeEvent("evt_1"); err != nil || claimed {
+			t.Errorf("Expected a claimed event not to be claimed again, got %v and %v", claimed, err)
+		}
+
+		event.Status = models.StripeEventDead
+		event.Error = sql.NullString{String: "gateway down", Valid: true}
+		if err = repo.UpdateStripeEvent(event); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		dead, err := repo.GetStripeEvents(models.StripeEventDead)
+		if err != nil || len(dead) != 1 || dead[0].Attempts != 1 || dead[0].Error.String != "gateway down" {
+			t.Fatalf("Expected the dead event after one attempt, got %+v and %v", dead, err)
+		}
+
+		event.Status = models.StripeEventReceived
+		if queued, err := repo.EnqueueStripeEvent(event); err != nil || !queued {
+			t.Errorf("Expected a dead event delivered again to be requeued, got %v and %v", queued, err)
+		}
+	})
+}
+
+func TestUpdateRelatedPayout(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *sqlx.DB) {
+		repo := NewWebhookRepository(db)
+		payout := &models.Payout{ID: "po_1", Created: 200, Gross: 1000, Currency: "ron", Status: models.PayoutPaid}
+		if err := repo.InsertPayout(payout); err != nil {
+			t.Fatalf("Failed to insert payout: %v", err)
+		}
+		donation := &models.Donation{ID: "ch_1", Created: 100, Gross: 1000, Currency: "ron", ClientName: "Ion", ClientEmail: "ion@example.com"}
+		if _, err := repo.InsertDonation(donation); err != nil {
+			t.Fatalf("Failed to insert donation: %v", err)
+		}
+
+		donation.PayoutID = sql.NullString{String: "po_1", Valid: true}
+		if updated, err := repo.UpdateRelatedPayout(donation); err != nil || !updated {
+			t.Errorf("Expected the donation linked to the payout, got %v and %v", updated, err)
+		}
+		missing := &models.Donation{ID: "ch_missing", PayoutID: donation.PayoutID}
+		if updated, err := repo.UpdateRelatedPayout(missing); err != nil || updated {
+			t.Errorf("Expected an unknown donation not to be updated, got %v and %v", updated, err)
+		}
+
+		related, err := NewPWARepository(db).GetRelatedDonations("po_1")
+		if err != nil || len(related) != 1 || related[0].ID != "ch_1" {
+			t.Errorf("Expected the payout's donation, got %+v and %v", related, err)
+		}
+
+		entries, err := NewPWARepository(db).GetAuditEntries(models.AuditFilter{Entity: "donation", Reference: "ch_1"}, 10)
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("Expected the donation's insert and update audited, got %+v and %v", entries, err)
+		}
+		if entries[0].Action != models.AuditUpdate || entries[0].Username != "stripe" || entries[0].Changes.String != `{"payout_id":{"before":null,"after":"po_1"}}` {
+			t.Errorf("Expected the link to the payout audited, got %+v", entries[0])
+		}
+	})
+}
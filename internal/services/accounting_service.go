@@ -2,53 +2,153 @@ package services
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
 	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
 	"github.com/signintech/gopdf"
 )
 
 type PWARepository interface {
 	GetDonation(id string) (*models.Donation, error)
+	GetDonorDonations(email string, start, end int64) ([]*models.Donation, error)
+	GetDonorRefunds(email string, start, end int64) ([]*models.Refund, error)
 	GetRelatedDonations(payoutID string) ([]*models.Donation, error)
 	GetPayout(id string) (*models.Payout, error)
 	GetMonthlyPayouts(monthStart, monthEnd int64) ([]*models.Payout, error)
 	GetRelatedFees(payoutID string) ([]*models.Fee, error)
+	GetRefund(id string) (*models.Refund, error)
+	GetRelatedRefunds(payoutID string) ([]*models.Refund, error)
+	GetPayoutDisputes(payoutID string) ([]*models.Dispute, error)
+	GetRelatedDisputeAdjustments(payoutID string) ([]*models.DisputeAdjustment, error)
+	GetOrganisation() (*models.Organisation, error)
+	GetDocumentLayout(name string) (*models.DocumentLayout, error)
 }
 
 type DocumentService interface {
-	GenerateInvoice(donation *dto.FormattedDonation) (*gopdf.GoPdf, error)
-	GeneratePayoutReport(payoutReportData *dto.PayoutReportData) (*gopdf.GoPdf, error)
-	GenerateMonthlyReport(monthlyReportData *dto.MonthlyReportData) (*gopdf.GoPdf, error)
+	GenerateInvoice(donation *dto.FormattedDonation, organisation *dto.Organisation, layout []byte, lang string) (*gopdf.GoPdf, error)
+	GenerateCreditNote(creditNoteData *dto.CreditNoteData, organisation *dto.Organisation, layout []byte, lang string) (*gopdf.GoPdf, error)
+	GeneratePayoutReport(payoutReportData *dto.PayoutReportData, organisation *dto.Organisation, layout []byte, lang string) (*gopdf.GoPdf, error)
+	GenerateMonthlyReport(monthlyReportData *dto.MonthlyReportData, organisation *dto.Organisation, layout []byte, lang string) (*gopdf.GoPdf, error)
+	GenerateDonorStatement(statementData *dto.DonorStatementData, organisation *dto.Organisation, layout []byte, lang string) (*gopdf.GoPdf, error)
+	GenerateEInvoice(eInvoiceData *dto.EInvoiceData, organisation *dto.Organisation) ([]byte, error)
 }
 
 type AccountingService struct {
 	repo     PWARepository
 	document DocumentService
+	location *time.Location
 }
 
-func NewAccountingService(repo PWARepository, document DocumentService) *AccountingService {
+// NewAccountingService computes month boundaries and document dates in the given location.
+func NewAccountingService(repo PWARepository, document DocumentService, location *time.Location) *AccountingService {
 	return &AccountingService{
 		repo:     repo,
 		document: document,
+		location: location,
 	}
 }
 
-func (s *AccountingService) GenerateInvoice(id string) (pdf *gopdf.GoPdf, err error) {
+// organisation loads the issuer profile for every document, so settings changes apply
+// to the next document generated.
+func (s *AccountingService) organisation() (*dto.Organisation, error) {
+	organisationModel, err := s.repo.GetOrganisation()
+	if err != nil {
+		return nil, fmt.Errorf("fetch organisation failed: %w", err)
+	}
+	return transformOrganisationModelToDTO(organisationModel), nil
+}
+
+// layout returns the layout an admin saved for the document, or nil for the default one.
+func (s *AccountingService) layout(name string) ([]byte, error) {
+	layoutModel, err := s.repo.GetDocumentLayout(name)
+	if err != nil {
+		return nil, fmt.Errorf("fetch document layout failed: %w", err)
+	}
+	if layoutModel == nil {
+		return nil, nil
+	}
+	return []byte(layoutModel.Definition), nil
+}
+
+// GenerateInvoice renders the invoice in lang, or in the donor's language when lang is empty.
+func (s *AccountingService) GenerateInvoice(id, lang string) (pdf *gopdf.GoPdf, err error) {
 	donationModel, err := s.repo.GetDonation(id)
 	if err != nil {
 		return nil, fmt.Errorf("fetch donation failed: %w", err)
 	}
-	donation := transformDonationModelToDTO(donationModel)
-	pdf, err = s.document.GenerateInvoice(donation)
+	organisation, err := s.organisation()
+	if err != nil {
+		return nil, err
+	}
+	lang = i18n.Resolve(lang, donationModel.Locale.String)
+	donation := transformInvoiceModelToDTO(donationModel, s.location, lang)
+	layout, err := s.layout("invoice")
+	if err != nil {
+		return nil, err
+	}
+	pdf, err = s.document.GenerateInvoice(donation, organisation, layout, lang)
 	if err != nil {
 		return nil, fmt.Errorf("generate invoice failed: %w", err)
 	}
 	return
 }
 
-func (s *AccountingService) GeneratePayoutReport(payoutID string) (pdf *gopdf.GoPdf, err error) {
+func (s *AccountingService) GenerateEInvoice(id string) ([]byte, error) {
+	donationModel, err := s.repo.GetDonation(id)
+	if err != nil {
+		return nil, fmt.Errorf("fetch donation failed: %w", err)
+	}
+	organisation, err := s.organisation()
+	if err != nil {
+		return nil, err
+	}
+	// The e-invoice is filed with ANAF, so all of its text is Romanian.
+	eInvoiceData := transformDonationModelToEInvoiceData(donationModel, s.location, i18n.Romanian)
+	document, err := s.document.GenerateEInvoice(eInvoiceData, organisation)
+	if err != nil {
+		return nil, fmt.Errorf("generate e-invoice failed: %w", err)
+	}
+	return document, nil
+}
+
+// GenerateCreditNote renders the credit note in lang, or in the donor's language when lang
+// is empty.
+func (s *AccountingService) GenerateCreditNote(id, lang string) (pdf *gopdf.GoPdf, err error) {
+	refundModel, err := s.repo.GetRefund(id)
+	if err != nil {
+		return nil, fmt.Errorf("fetch refund failed: %w", err)
+	}
+	donationModel, err := s.repo.GetDonation(refundModel.DonationID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch refunded donation failed: %w", err)
+	}
+	organisation, err := s.organisation()
+	if err != nil {
+		return nil, err
+	}
+
+	lang = i18n.Resolve(lang, donationModel.Locale.String)
+	creditNoteData := dto.NewCreditNoteData(
+		transformRefundModelToDTO(refundModel, s.location),
+		transformInvoiceModelToDTO(donationModel, s.location, lang),
+	)
+	layout, err := s.layout("credit_note")
+	if err != nil {
+		return nil, err
+	}
+	pdf, err = s.document.GenerateCreditNote(creditNoteData, organisation, layout, lang)
+	if err != nil {
+		return nil, fmt.Errorf("generate credit note failed: %w", err)
+	}
+	return
+}
+
+func (s *AccountingService) GeneratePayoutReport(payoutID, lang string) (pdf *gopdf.GoPdf, err error) {
+	lang = i18n.Resolve(lang)
 	payoutModel, err := s.repo.GetPayout(payoutID)
 	if err != nil {
 		return nil, fmt.Errorf("fetch payout failed: %w", err)
@@ -61,29 +161,51 @@ func (s *AccountingService) GeneratePayoutReport(payoutID string) (pdf *gopdf.Go
 	if err != nil {
 		return nil, fmt.Errorf("fetch related fees failed: %w", err)
 	}
+	refundModels, err := s.repo.GetRelatedRefunds(payoutID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch related refunds failed: %w", err)
+	}
+	adjustmentModels, err := s.repo.GetRelatedDisputeAdjustments(payoutID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch related dispute adjustments failed: %w", err)
+	}
+	organisation, err := s.organisation()
+	if err != nil {
+		return nil, err
+	}
 
-	items := transformDonationModelsToPayoutReportItems(donationModels)
-	items = append(items, transformFeeModelsToPayoutReportItems(feeModels)...)
+	items := transformDonationModelsToPayoutReportItems(donationModels, s.location, lang)
+	items = append(items, transformRefundModelsToPayoutReportItems(refundModels, s.location, lang)...)
+	items = append(items, transformDisputeAdjustmentModelsToPayoutReportItems(adjustmentModels, s.location, lang)...)
+	items = append(items, transformFeeModelsToPayoutReportItems(feeModels, s.location)...)
 
 	payoutReportData := dto.NewPayoutReportData(
-		transformPayoutModelToDTO(payoutModel),
+		transformPayoutModelToDTO(payoutModel, s.location, lang),
 		items,
 	)
-	pdf, err = s.document.GeneratePayoutReport(payoutReportData)
+	layout, err := s.layout("payout_report")
+	if err != nil {
+		return nil, err
+	}
+	pdf, err = s.document.GeneratePayoutReport(payoutReportData, organisation, layout, lang)
 	if err != nil {
 		return nil, fmt.Errorf("generate payout report failed: %w", err)
 	}
 	return
 }
 
-func (s *AccountingService) GenerateMonthlyReport(stringDate string) (pdf *gopdf.GoPdf, err error) {
-	date, err := validateMonthString(stringDate)
+// GenerateMonthlyReport renders the report of a period: a month (2024-09), a quarter
+// (2024-Q3), a year (2024) or a range of days (2024-07-01_2024-09-30). Periods longer
+// than a month list a subtotal after each month's payouts.
+func (s *AccountingService) GenerateMonthlyReport(stringDate, lang string) (pdf *gopdf.GoPdf, err error) {
+	lang = i18n.Resolve(lang)
+	period, err := parseReportPeriod(stringDate, s.location)
 	if err != nil {
-		return nil, fmt.Errorf("month string invalid: %w", err)
+		return nil, fmt.Errorf("report period invalid: %w", err)
 	}
 
-	monthStartUnix, monthEndUnix := getUnixTimestampsForMonth(date)
-	payoutModels, err := s.repo.GetMonthlyPayouts(monthStartUnix, monthEndUnix)
+	periodStartUnix, periodEndUnix := getUnixTimestampsForPeriod(period)
+	payoutModels, err := s.repo.GetMonthlyPayouts(periodStartUnix, periodEndUnix)
 	if err != nil {
 		return nil, fmt.Errorf("fetch payouts failed: %w", err)
 	}
@@ -95,44 +217,70 @@ func (s *AccountingService) GenerateMonthlyReport(stringDate string) (pdf *gopdf
 	if err != nil {
 		return nil, fmt.Errorf("monthly report sum failed: %w", err)
 	}
+	months, err := splitReportMonths(period, payoutModels, s.location)
+	if err != nil {
+		return nil, fmt.Errorf("monthly subtotals failed: %w", err)
+	}
+	organisation, err := s.organisation()
+	if err != nil {
+		return nil, err
+	}
 
-	monthlyReportData := transformToMonthlyReportData(date, gross, fee, net, payoutModels)
-	pdf, err = s.document.GenerateMonthlyReport(monthlyReportData)
+	monthlyReportData := transformToMonthlyReportData(period, gross, fee, net, payoutModels, months, s.location, lang)
+	layout, err := s.layout("monthly_report")
+	if err != nil {
+		return nil, err
+	}
+	pdf, err = s.document.GenerateMonthlyReport(monthlyReportData, organisation, layout, lang)
 	if err != nil {
 		return nil, fmt.Errorf("generate monthly report failed: %w", err)
 	}
 	return
 }
 
-func (s *AccountingService) GenerateMonthlyReportView(stringDate string) (*dto.MonthlyReportView, error) {
-	date, err := validateMonthString(stringDate)
+// GenerateMonthlyReportView lists the payouts of a period, grouped by month, as
+// GenerateMonthlyReport does.
+func (s *AccountingService) GenerateMonthlyReportView(stringDate, lang string) (*dto.MonthlyReportView, error) {
+	lang = i18n.Resolve(lang)
+	period, err := parseReportPeriod(stringDate, s.location)
 	if err != nil {
-		return nil, fmt.Errorf("month string invalid: %w", err)
+		return nil, fmt.Errorf("report period invalid: %w", err)
 	}
 
-	monthStartUnix, monthEndUnix := getUnixTimestampsForMonth(date)
-	payoutModels, err := s.repo.GetMonthlyPayouts(monthStartUnix, monthEndUnix)
+	periodStartUnix, periodEndUnix := getUnixTimestampsForPeriod(period)
+	payoutModels, err := s.repo.GetMonthlyPayouts(periodStartUnix, periodEndUnix)
 	if err != nil {
 		return nil, fmt.Errorf("fetch payouts failed: %w", err)
 	}
 	if len(payoutModels) == 0 {
-		return transformToMonthlyReportView(stringDate, 0, 0, 0, nil), nil
+		zero := money.New(0, money.DefaultCurrency)
+		return transformToMonthlyReportView(stringDate, period, zero, zero, zero, nil, nil, lang), nil
 	}
 
 	gross, fee, net, err := monthlyReportSum(payoutModels)
 	if err != nil {
 		return nil, fmt.Errorf("monthly report sum failed: %w", err)
 	}
-
-	payouts, err := s.transformMonthlyViewPayoutModelsInDTOs(payoutModels)
+	reportMonths, err := splitReportMonths(period, payoutModels, s.location)
 	if err != nil {
-		return nil, fmt.Errorf("monthly view payout models failed: %w", err)
+		return nil, fmt.Errorf("monthly subtotals failed: %w", err)
 	}
 
-	return transformToMonthlyReportView(stringDate, gross, fee, net, payouts), nil
+	var payouts []*dto.FormattedPayout
+	var months []*dto.ReportMonth
+	for _, reportMonth := range reportMonths {
+		monthPayouts, err := s.transformMonthlyViewPayoutModelsInDTOs(reportMonth.payouts, lang)
+		if err != nil {
+			return nil, fmt.Errorf("monthly view payout models failed: %w", err)
+		}
+		payouts = append(payouts, monthPayouts...)
+		months = append(months, transformToReportMonth(reportMonth, monthPayouts, lang))
+	}
+
+	return transformToMonthlyReportView(stringDate, period, gross, fee, net, payouts, months, lang), nil
 }
 
-func (s AccountingService) transformMonthlyViewPayoutModelsInDTOs(payoutModels []*models.Payout) (payouts []*dto.FormattedPayout, err error) {
+func (s AccountingService) transformMonthlyViewPayoutModelsInDTOs(payoutModels []*models.Payout, lang string) (payouts []*dto.FormattedPayout, err error) {
 	for _, payoutModel := range payoutModels {
 		donationModels, err := s.repo.GetRelatedDonations(payoutModel.ID)
 		if err != nil {
@@ -142,145 +290,335 @@ func (s AccountingService) transformMonthlyViewPayoutModelsInDTOs(payoutModels [
 		if err != nil {
 			return nil, fmt.Errorf("fetch related fees failed: %w", err)
 		}
-		donations := transformDonationModelsToDTOs(donationModels)
-		fees := transformFeeModelsToDTOs(feeModels)
-		payouts = append(payouts, transformMonthlyViewPayoutModelToDTO(payoutModel, donations, fees))
+		refundModels, err := s.repo.GetRelatedRefunds(payoutModel.ID)
+		if err != nil {
+			return nil, fmt.Errorf("fetch related refunds failed: %w", err)
+		}
+		disputeModels, err := s.repo.GetPayoutDisputes(payoutModel.ID)
+		if err != nil {
+			return nil, fmt.Errorf("fetch payout disputes failed: %w", err)
+		}
+		adjustmentModels, err := s.repo.GetRelatedDisputeAdjustments(payoutModel.ID)
+		if err != nil {
+			return nil, fmt.Errorf("fetch related dispute adjustments failed: %w", err)
+		}
+		donations := transformDonationModelsToDTOs(donationModels, s.location, lang)
+		applyDisputeStatuses(donations, disputeModels, lang)
+		fees := transformFeeModelsToDTOs(feeModels, s.location)
+		refunds := transformRefundModelsToDTOs(refundModels, s.location)
+		adjustments := transformDisputeAdjustmentModelsToDTOs(adjustmentModels, s.location)
+		payouts = append(payouts, transformMonthlyViewPayoutModelToDTO(payoutModel, donations, fees, refunds, adjustments, s.location, lang))
 	}
 	return
 }
 
-func transformToMonthlyReportView(date string, gross, fee, net uint32, payouts []*dto.FormattedPayout) *dto.MonthlyReportView {
+func transformToMonthlyReportView(date string, period *reportPeriod, gross, fee, net money.Money, payouts []*dto.FormattedPayout, months []*dto.ReportMonth, lang string) *dto.MonthlyReportView {
 	return dto.NewMonthlyReportView(
 		date,
-		fmt.Sprintf("%.2f lei", float64(gross)/100),
-		fmt.Sprintf("%.2f lei", float64(fee)/100),
-		fmt.Sprintf("%.2f lei", float64(net)/100),
+		formatReportPeriod(period, lang),
+		period.start.Format("2006-01-02"),
+		period.end.AddDate(0, 0, -1).Format("2006-01-02"),
+		gross.String(),
+		fee.String(),
+		net.String(),
 		payouts,
+		months,
 	)
 }
 
-func transformMonthlyViewPayoutModelToDTO(payout *models.Payout, donations []*dto.FormattedDonation, fees []*dto.FormattedFee) *dto.FormattedPayout {
+func transformMonthlyViewPayoutModelToDTO(payout *models.Payout, donations []*dto.FormattedDonation, fees []*dto.FormattedFee, refunds []*dto.FormattedRefund, adjustments []*dto.FormattedDisputeAdjustment, location *time.Location, lang string) *dto.FormattedPayout {
 	return dto.NewFormattedPayout(
 		payout.ID,
-		time.Unix(int64(payout.Created), 0).UTC().Format("02 Jan 2006"),
-		fmt.Sprintf("%.2f lei", float64(payout.Gross)/100),
-		fmt.Sprintf("%.2f lei", float64(payout.Fee)/100),
-		fmt.Sprintf("%.2f lei", float64(payout.Net)/100),
+		formatDate(payout.Created, location),
+		money.New(payout.Gross, payout.Currency).String(),
+		money.New(payout.Fee, payout.Currency).String(),
+		money.New(payout.Net, payout.Currency).String(),
+		formatPayoutStatus(payout.Status, lang),
 		donations,
 		fees,
+		refunds,
+		adjustments,
 	)
 }
 
-func transformPayoutModelsToDTOs(payoutModels []*models.Payout) (payouts []*dto.FormattedPayout) {
+func transformPayoutModelsToDTOs(payoutModels []*models.Payout, location *time.Location, lang string) (payouts []*dto.FormattedPayout) {
 	for _, payoutModel := range payoutModels {
-		payouts = append(payouts, transformPayoutModelToDTO(payoutModel))
+		payouts = append(payouts, transformPayoutModelToDTO(payoutModel, location, lang))
 	}
 	return
 }
 
-func transformPayoutModelToDTO(payout *models.Payout) *dto.FormattedPayout {
+func transformPayoutModelToDTO(payout *models.Payout, location *time.Location, lang string) *dto.FormattedPayout {
 	return dto.NewFormattedPayout(
 		payout.ID,
-		time.Unix(int64(payout.Created), 0).UTC().Format("02 Jan 2006"),
-		fmt.Sprintf("%.2f lei", float64(payout.Gross)/100),
-		fmt.Sprintf("%.2f lei", float64(payout.Fee)/100),
-		fmt.Sprintf("%.2f lei", float64(payout.Net)/100),
+		formatDate(payout.Created, location),
+		money.New(payout.Gross, payout.Currency).String(),
+		money.New(payout.Fee, payout.Currency).String(),
+		money.New(payout.Net, payout.Currency).String(),
+		formatPayoutStatus(payout.Status, lang),
+		nil,
+		nil,
 		nil,
 		nil,
 	)
 }
 
-func transformDonationModelsToDTOs(donationModels []*models.Donation) (donations []*dto.FormattedDonation) {
+func transformDonationModelsToDTOs(donationModels []*models.Donation, location *time.Location, lang string) (donations []*dto.FormattedDonation) {
 	for _, donationModel := range donationModels {
-		donations = append(donations, transformDonationModelToDTO(donationModel))
+		donations = append(donations, transformDonationModelToDTO(donationModel, location, lang))
 	}
 	return
 }
 
-func transformDonationModelToDTO(donation *models.Donation) *dto.FormattedDonation {
+func transformDonationModelToDTO(donation *models.Donation, location *time.Location, lang string) *dto.FormattedDonation {
 	return dto.NewFormattedDonation(
 		donation.ID,
-		time.Unix(int64(donation.Created), 0).UTC().Format("02 Jan 2006"),
-		fmt.Sprintf("%.2f lei", float64(donation.Gross)/100),
-		fmt.Sprintf("%.2f lei", float64(donation.Fee)/100),
-		fmt.Sprintf("%.2f lei", float64(donation.Net)/100),
+		formatInvoiceNumber(donation),
+		formatDate(donation.Created, location),
+		money.New(donation.Gross, donation.Currency).String(),
+		money.New(donation.Fee, donation.Currency).String(),
+		money.New(donation.Net, donation.Currency).String(),
+		donation.Currency,
+		formatConversion(donation, lang),
 		donation.ClientName,
 		donation.ClientEmail,
 		donation.PayoutID.String,
+		"",
+		formatDonorID(donation.DonorID),
 	)
 }
 
-func transformDonationModelsToPayoutReportItems(donationModels []*models.Donation) (donations []*dto.PayoutReportItem) {
+// transformInvoiceModelToDTO formats a donation as its invoice shows it, dated when the
+// invoice was issued.
+func transformInvoiceModelToDTO(donation *models.Donation, location *time.Location, lang string) *dto.FormattedDonation {
+	invoice := transformDonationModelToDTO(donation, location, lang)
+	invoice.Created = formatDate(invoiceIssued(donation), location)
+	return invoice
+}
+
+func transformDonationModelToEInvoiceData(donation *models.Donation, location *time.Location, lang string) *dto.EInvoiceData {
+	var buyer *dto.Address
+	if donation.ClientCountry.Valid {
+		buyer = dto.NewAddress(
+			donation.ClientAddress1.String,
+			donation.ClientAddress2.String,
+			donation.ClientCity.String,
+			donation.ClientState.String,
+			donation.ClientPostalCode.String,
+			donation.ClientCountry.String,
+		)
+	}
+	return dto.NewEInvoiceData(
+		transformInvoiceModelToDTO(donation, location, lang),
+		buyer,
+		time.Unix(int64(invoiceIssued(donation)), 0).In(location).Format("2006-01-02"),
+		strings.ToUpper(money.New(0, donation.Currency).Currency),
+		money.New(donation.Gross, donation.Currency).Decimal(),
+	)
+}
+
+func transformDonationModelsToPayoutReportItems(donationModels []*models.Donation, location *time.Location, lang string) (donations []*dto.PayoutReportItem) {
 	for _, donationModel := range donationModels {
-		donations = append(donations, transformDonationModelToPayoutReportItem(donationModel))
+		donations = append(donations, transformDonationModelToPayoutReportItem(donationModel, location, lang))
 	}
 	return
 }
 
-func transformDonationModelToPayoutReportItem(donation *models.Donation) *dto.PayoutReportItem {
+func transformDonationModelToPayoutReportItem(donation *models.Donation, location *time.Location, lang string) *dto.PayoutReportItem {
 	return dto.NewPayoutReportItem(
 		donation.ID,
 		"donation",
-		"",
-		time.Unix(int64(donation.Created), 0).UTC().Format("02 Jan 2006"),
-		fmt.Sprintf("%.2f lei", float64(donation.Gross)/100),
-		fmt.Sprintf("%.2f lei", float64(donation.Fee)/100),
-		fmt.Sprintf("%.2f lei", float64(donation.Net)/100),
+		formatConversion(donation, lang),
+		formatDate(donation.Created, location),
+		money.New(donation.Gross, donation.Currency).String(),
+		money.New(donation.Fee, donation.Currency).String(),
+		money.New(donation.Net, donation.Currency).String(),
 	)
 }
 
-func transformFeeModelsToDTOs(feeModels []*models.Fee) (fees []*dto.FormattedFee) {
+func transformFeeModelsToDTOs(feeModels []*models.Fee, location *time.Location) (fees []*dto.FormattedFee) {
 	for _, feeModel := range feeModels {
-		fees = append(fees, transformFeeModelToDTO(feeModel))
+		fees = append(fees, transformFeeModelToDTO(feeModel, location))
 	}
 	return
 }
 
-func transformFeeModelToDTO(fee *models.Fee) *dto.FormattedFee {
+func transformFeeModelToDTO(fee *models.Fee, location *time.Location) *dto.FormattedFee {
 	return dto.NewFormattedFee(
 		fee.ID,
 		fee.Description,
-		time.Unix(int64(fee.Created), 0).UTC().Format("02 Jan 2006"),
-		fmt.Sprintf("%.2f lei", float64(fee.Fee)/100),
+		formatDate(fee.Created, location),
+		money.New(fee.Fee, fee.Currency).String(),
 	)
 }
 
-func transformFeeModelsToPayoutReportItems(feeModels []*models.Fee) (fees []*dto.PayoutReportItem) {
+func transformFeeModelsToPayoutReportItems(feeModels []*models.Fee, location *time.Location) (fees []*dto.PayoutReportItem) {
 	for _, feeModel := range feeModels {
-		fees = append(fees, transformFeeModelToPayoutReportItem(feeModel))
+		fees = append(fees, transformFeeModelToPayoutReportItem(feeModel, location))
 	}
 	return
 }
 
-func transformFeeModelToPayoutReportItem(fee *models.Fee) *dto.PayoutReportItem {
+func transformFeeModelToPayoutReportItem(fee *models.Fee, location *time.Location) *dto.PayoutReportItem {
 	return dto.NewPayoutReportItem(
 		fee.ID,
 		"fee",
 		fee.Description,
-		time.Unix(int64(fee.Created), 0).UTC().Format("02 Jan 2006"),
-		"0.00 lei",
-		fmt.Sprintf("%.2f lei", float64(fee.Fee)/100),
-		fmt.Sprintf("%.2f lei", float64(fee.Fee)/100),
+		formatDate(fee.Created, location),
+		money.New(0, fee.Currency).String(),
+		money.New(fee.Fee, fee.Currency).String(),
+		money.New(fee.Fee, fee.Currency).String(),
+	)
+}
+
+func transformRefundModelsToDTOs(refundModels []*models.Refund, location *time.Location) (refunds []*dto.FormattedRefund) {
+	for _, refundModel := range refundModels {
+		refunds = append(refunds, transformRefundModelToDTO(refundModel, location))
+	}
+	return
+}
+
+func transformRefundModelToDTO(refund *models.Refund, location *time.Location) *dto.FormattedRefund {
+	return dto.NewFormattedRefund(
+		refund.ID,
+		formatDate(refund.Created, location),
+		money.New(refund.Gross, refund.Currency).String(),
+		money.New(refund.Fee, refund.Currency).String(),
+		money.New(refund.Net, refund.Currency).String(),
+		refund.DonationID,
+		refund.PayoutID.String,
+	)
+}
+
+func transformRefundModelsToPayoutReportItems(refundModels []*models.Refund, location *time.Location, lang string) (refunds []*dto.PayoutReportItem) {
+	for _, refundModel := range refundModels {
+		refunds = append(refunds, transformRefundModelToPayoutReportItem(refundModel, location, lang))
+	}
+	return
+}
+
+func transformRefundModelToPayoutReportItem(refund *models.Refund, location *time.Location, lang string) *dto.PayoutReportItem {
+	return dto.NewPayoutReportItem(
+		refund.ID,
+		"refund",
+		i18n.T(lang, "payout.refund")+" "+refund.DonationID,
+		formatDate(refund.Created, location),
+		money.New(refund.Gross, refund.Currency).String(),
+		money.New(refund.Fee, refund.Currency).String(),
+		money.New(refund.Net, refund.Currency).String(),
 	)
 }
 
-func transformToMonthlyReportData(date time.Time, gross, fee, net uint32, payoutModels []*models.Payout) *dto.MonthlyReportData {
-	monthStart, monthEnd, emissionDate := getMonthDatesFromISO(date)
-	payouts := transformPayoutModelsToDTOs(payoutModels)
+func transformDisputeAdjustmentModelsToDTOs(adjustmentModels []*models.DisputeAdjustment, location *time.Location) (adjustments []*dto.FormattedDisputeAdjustment) {
+	for _, adjustmentModel := range adjustmentModels {
+		adjustments = append(adjustments, transformDisputeAdjustmentModelToDTO(adjustmentModel, location))
+	}
+	return
+}
+
+func transformDisputeAdjustmentModelToDTO(adjustment *models.DisputeAdjustment, location *time.Location) *dto.FormattedDisputeAdjustment {
+	return dto.NewFormattedDisputeAdjustment(
+		adjustment.ID,
+		adjustment.Type,
+		formatDate(adjustment.Created, location),
+		money.New(adjustment.Gross, adjustment.Currency).String(),
+		money.New(adjustment.Fee, adjustment.Currency).String(),
+		money.New(adjustment.Net, adjustment.Currency).String(),
+		adjustment.DisputeID,
+	)
+}
+
+func transformDisputeAdjustmentModelsToPayoutReportItems(adjustmentModels []*models.DisputeAdjustment, location *time.Location, lang string) (adjustments []*dto.PayoutReportItem) {
+	for _, adjustmentModel := range adjustmentModels {
+		adjustments = append(adjustments, transformDisputeAdjustmentModelToPayoutReportItem(adjustmentModel, location, lang))
+	}
+	return
+}
+
+func transformDisputeAdjustmentModelToPayoutReportItem(adjustment *models.DisputeAdjustment, location *time.Location, lang string) *dto.PayoutReportItem {
+	itemType := "dispute_withdrawal"
+	description := i18n.T(lang, "dispute.withdrawal") + " " + adjustment.DisputeID
+	if adjustment.Type == models.DisputeReinstatement {
+		itemType = "dispute_reinstatement"
+		description = i18n.T(lang, "dispute.reinstatement") + " " + adjustment.DisputeID
+	}
+	return dto.NewPayoutReportItem(
+		adjustment.ID,
+		itemType,
+		description,
+		formatDate(adjustment.Created, location),
+		money.New(adjustment.Gross, adjustment.Currency).String(),
+		money.New(adjustment.Fee, adjustment.Currency).String(),
+		money.New(adjustment.Net, adjustment.Currency).String(),
+	)
+}
+
+func applyDisputeStatuses(donations []*dto.FormattedDonation, disputeModels []*models.Dispute, lang string) {
+	statuses := make(map[string]string, len(disputeModels))
+	for _, dispute := range disputeModels {
+		statuses[dispute.DonationID] = formatDisputeStatus(dispute.Status, lang)
+	}
+	for _, donation := range donations {
+		donation.DisputeStatus = statuses[donation.ID]
+	}
+}
+
+func formatDisputeStatus(status, lang string) string {
+	switch status {
+	case "warning_needs_response", "needs_response":
+		return i18n.T(lang, "dispute.status.open")
+	case "warning_under_review", "under_review":
+		return i18n.T(lang, "dispute.status.underReview")
+	case "warning_closed":
+		return i18n.T(lang, "dispute.status.closed")
+	case "won":
+		return i18n.T(lang, "dispute.status.won")
+	case "lost":
+		return i18n.T(lang, "dispute.status.lost")
+	default:
+		return i18n.T(lang, "dispute.status.other", status)
+	}
+}
+
+// formatPayoutStatus labels payouts whose funds never reached the bank account.
+func formatPayoutStatus(status, lang string) string {
+	switch status {
+	case models.PayoutFailed:
+		return i18n.T(lang, "payout.status.failed")
+	case models.PayoutCanceled:
+		return i18n.T(lang, "payout.status.canceled")
+	default:
+		return ""
+	}
+}
+
+func transformToMonthlyReportData(period *reportPeriod, gross, fee, net money.Money, payoutModels []*models.Payout, reportMonths []*reportMonth, location *time.Location, lang string) *dto.MonthlyReportData {
+	periodStart, periodEnd, emissionDate := getPeriodDates(period)
+	payouts := transformPayoutModelsToDTOs(payoutModels, location, lang)
+
+	var months []*dto.ReportMonth
+	for _, reportMonth := range reportMonths {
+		months = append(months, transformToReportMonth(reportMonth, transformPayoutModelsToDTOs(reportMonth.payouts, location, lang), lang))
+	}
 
 	return dto.NewMonthlyReportData(
-		monthStart,
-		monthEnd,
+		reportTitle(period, lang),
+		formatReportPeriod(period, lang),
+		periodStart,
+		periodEnd,
 		emissionDate,
-		fmt.Sprintf("%.2f lei", float64(gross)/100),
-		fmt.Sprintf("%.2f lei", float64(fee)/100),
-		fmt.Sprintf("%.2f lei", float64(net)/100),
+		gross.String(),
+		fee.String(),
+		net.String(),
 		payouts,
+		months,
 	)
 }
 
-func getUnixTimestampsForMonth(date time.Time) (monthStart, monthEnd int64) {
-	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+// getUnixTimestampsForMonth bounds the month by local midnights, so a month crossing a
+// DST change is an hour shorter or longer than the same month in UTC.
+func getUnixTimestampsForMonth(date time.Time, location *time.Location) (monthStart, monthEnd int64) {
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, location)
 	monthStart = start.Unix()
 
 	end := start.AddDate(0, 1, 0).Add(-time.Second)
@@ -288,15 +626,9 @@ func getUnixTimestampsForMonth(date time.Time) (monthStart, monthEnd int64) {
 	return
 }
 
-func getMonthDatesFromISO(date time.Time) (monthStart, monthEnd, emissionDate string) {
-	monthStartTime := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
-	monthEndTime := monthStartTime.AddDate(0, 1, 0).Add(-time.Second)
-	emissionDateTime := monthStartTime.AddDate(0, 1, 0)
-
-	monthStart = monthStartTime.Format("2 Jan, 2006")
-	monthEnd = monthEndTime.Format("2 Jan, 2006")
-	emissionDate = emissionDateTime.Format("2 Jan, 2006")
-	return
+// formatDate shows a Stripe timestamp as the calendar day it fell on in the given location.
+func formatDate(created uint64, location *time.Location) string {
+	return time.Unix(int64(created), 0).In(location).Format("02 Jan 2006")
 }
 
 func validateMonthString(date string) (time.Time, error) {
@@ -307,14 +639,75 @@ func validateMonthString(date string) (time.Time, error) {
 	return parsedDate, nil
 }
 
-func monthlyReportSum(payouts []*models.Payout) (gross, fee, net uint32, err error) {
+// monthlyReportSum adds up the payouts that reached the bank account, in the one currency
+// the report totals. Failed and cancelled payouts are counted with the payout that paid
+// their transactions out again.
+func monthlyReportSum(payouts []*models.Payout) (gross, fee, net money.Money, err error) {
+	currency := money.DefaultCurrency
 	for _, payout := range payouts {
-		gross += payout.Gross
-		fee += payout.Fee
-		net += payout.Net
+		if payout.Status != models.PayoutFailed && payout.Status != models.PayoutCanceled {
+			currency = payout.Currency
+			break
+		}
 	}
-	if gross-fee != net {
-		return 0, 0, 0, fmt.Errorf("monthly gross-fee %v != net %v", gross-fee, net)
+	gross, fee, net = money.New(0, currency), money.New(0, currency), money.New(0, currency)
+
+	for _, payout := range payouts {
+		if payout.Status == models.PayoutFailed || payout.Status == models.PayoutCanceled {
+			continue
+		}
+		if gross, err = gross.Add(money.New(payout.Gross, payout.Currency)); err != nil {
+			return money.Money{}, money.Money{}, money.Money{}, fmt.Errorf("monthly gross sum failed: %w", err)
+		}
+		if fee, err = fee.Add(money.New(payout.Fee, payout.Currency)); err != nil {
+			return money.Money{}, money.Money{}, money.Money{}, fmt.Errorf("monthly fee sum failed: %w", err)
+		}
+		if net, err = net.Add(money.New(payout.Net, payout.Currency)); err != nil {
+			return money.Money{}, money.Money{}, money.Money{}, fmt.Errorf("monthly net sum failed: %w", err)
+		}
+	}
+	expectedNet, err := gross.Sub(fee)
+	if err != nil {
+		return money.Money{}, money.Money{}, money.Money{}, fmt.Errorf("monthly gross-fee failed: %w", err)
+	}
+	if expectedNet != net {
+		return money.Money{}, money.Money{}, money.Money{}, fmt.Errorf("monthly gross-fee %v != net %v", expectedNet.Amount, net.Amount)
 	}
 	return
 }
+
+// formatConversion describes, in lang, the original charge of a donation settled in
+// another currency.
+func formatConversion(donation *models.Donation, lang string) string {
+	if !donation.OriginalCurrency.Valid || strings.EqualFold(donation.OriginalCurrency.String, donation.Currency) {
+		return ""
+	}
+	conversion := money.New(donation.OriginalAmount.Int64, donation.OriginalCurrency.String).String()
+	if !donation.ExchangeRate.Valid {
+		return conversion
+	}
+	return i18n.T(lang, "document.conversion", conversion, money.FormatRate(donation.ExchangeRate.Float64))
+}
+
+func transformOrganisationModelToDTO(organisation *models.Organisation) *dto.Organisation {
+	return dto.NewOrganisation(
+		organisation.Name,
+		organisation.TaxID,
+		organisation.RegistrationNumber,
+		organisation.IBAN,
+		organisation.Bank,
+		organisation.Email,
+		organisation.Phone,
+		organisation.StripeAccount,
+		organisation.LogoPath,
+		organisation.SmallLogoPath,
+		dto.NewAddress(
+			organisation.Street,
+			organisation.AdditionalStreet,
+			organisation.City,
+			organisation.County,
+			organisation.PostalCode,
+			organisation.Country,
+		),
+	)
+}
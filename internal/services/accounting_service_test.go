@@ -2,43 +2,74 @@ package services
 
 import (
 	"database/sql"
+	"math"
 	"testing"
 	"time"
 
 	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
 	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
 )
 
 func TestTransformToMonthlyReportView(t *testing.T) {
 	testCases := map[string]struct {
 		date     string
-		gross    uint32
-		fee      uint32
-		net      uint32
+		gross    money.Money
+		fee      money.Money
+		net      money.Money
 		payouts  []*dto.FormattedPayout
 		expected *dto.MonthlyReportView
 	}{
 		"validReport": {
 			date:    "2024-09",
-			gross:   123456,
-			fee:     1234,
-			net:     122222,
+			gross:   money.New(123456, "ron"),
+			fee:     money.New(1234, "ron"),
+			net:     money.New(122222, "ron"),
 			payouts: []*dto.FormattedPayout{{ID: "payout1"}},
 			expected: &dto.MonthlyReportView{
 				Date:  "2024-09",
-				Gross: "1234.56 lei",
-				Fee:   "12.34 lei",
-				Net:   "1222.22 lei",
+				Gross: "1.234,56 lei",
+				Fee:   "12,34 lei",
+				Net:   "1.222,22 lei",
 				Payouts: []*dto.FormattedPayout{
 					{ID: "payout1"},
 				},
 			},
 		},
+		"eurReport": {
+			date:  "2024-09",
+			gross: money.New(10000, "eur"),
+			fee:   money.New(300, "eur"),
+			net:   money.New(9700, "eur"),
+			expected: &dto.MonthlyReportView{
+				Date:  "2024-09",
+				Gross: "100,00 EUR",
+				Fee:   "3,00 EUR",
+				Net:   "97,00 EUR",
+			},
+		},
+		"emptyMonth": {
+			date:  "2024-10",
+			gross: money.New(0, ""),
+			fee:   money.New(0, ""),
+			net:   money.New(0, ""),
+			expected: &dto.MonthlyReportView{
+				Date:  "2024-10",
+				Gross: "0,00 lei",
+				Fee:   "0,00 lei",
+				Net:   "0,00 lei",
+			},
+		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformToMonthlyReportView(tc.date, tc.gross, tc.fee, tc.net, tc.payouts)
+			period, err := parseReportPeriod(tc.date, time.UTC)
+			if err != nil {
+				t.Fatalf("Expected valid period, got %v", err)
+			}
+			result := transformToMonthlyReportView(tc.date, period, tc.gross, tc.fee, tc.net, tc.payouts, nil, i18n.Romanian)
 			if result.Date != tc.expected.Date || result.Gross != tc.expected.Gross ||
 				result.Fee != tc.expected.Fee || result.Net != tc.expected.Net {
 				t.Errorf("Expected %v, got %v", tc.expected, result)
@@ -52,10 +83,12 @@ func TestTransformToMonthlyReportView(t *testing.T) {
 
 func TestTransformMonthlyViewPayoutModelToDTO(t *testing.T) {
 	testCases := map[string]struct {
-		payout    *models.Payout
-		donations []*dto.FormattedDonation
-		fees      []*dto.FormattedFee
-		expected  *dto.FormattedPayout
+		payout      *models.Payout
+		donations   []*dto.FormattedDonation
+		fees        []*dto.FormattedFee
+		refunds     []*dto.FormattedRefund
+		adjustments []*dto.FormattedDisputeAdjustment
+		expected    *dto.FormattedPayout
 	}{
 		"validPayoutDTO": {
 			payout: &models.Payout{
@@ -65,27 +98,35 @@ func TestTransformMonthlyViewPayoutModelToDTO(t *testing.T) {
 				Fee:     100,
 				Net:     9900,
 			},
-			donations: []*dto.FormattedDonation{{ID: "donation1"}},
-			fees:      []*dto.FormattedFee{{ID: "fee1"}},
+			donations:   []*dto.FormattedDonation{{ID: "donation1"}},
+			fees:        []*dto.FormattedFee{{ID: "fee1"}},
+			refunds:     []*dto.FormattedRefund{{ID: "refund1"}},
+			adjustments: []*dto.FormattedDisputeAdjustment{{ID: "adjustment1"}},
 			expected: &dto.FormattedPayout{
 				ID:      "payout1",
 				Created: "14 Nov 2023",
-				Gross:   "100.00 lei",
-				Fee:     "1.00 lei",
-				Net:     "99.00 lei",
+				Gross:   "100,00 lei",
+				Fee:     "1,00 lei",
+				Net:     "99,00 lei",
 				Donations: []*dto.FormattedDonation{
 					{ID: "donation1"},
 				},
 				Fees: []*dto.FormattedFee{
 					{ID: "fee1"},
 				},
+				Refunds: []*dto.FormattedRefund{
+					{ID: "refund1"},
+				},
+				Adjustments: []*dto.FormattedDisputeAdjustment{
+					{ID: "adjustment1"},
+				},
 			},
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformMonthlyViewPayoutModelToDTO(tc.payout, tc.donations, tc.fees)
+			result := transformMonthlyViewPayoutModelToDTO(tc.payout, tc.donations, tc.fees, tc.refunds, tc.adjustments, time.UTC, i18n.Romanian)
 
 			if result.ID != tc.expected.ID || result.Created != tc.expected.Created ||
 				result.Gross != tc.expected.Gross || result.Fee != tc.expected.Fee ||
@@ -98,6 +139,12 @@ func TestTransformMonthlyViewPayoutModelToDTO(t *testing.T) {
 			if len(result.Fees) != len(tc.expected.Fees) {
 				t.Errorf("Expected %d fees, got %d", len(tc.expected.Fees), len(result.Fees))
 			}
+			if len(result.Refunds) != len(tc.expected.Refunds) {
+				t.Errorf("Expected %d refunds, got %d", len(tc.expected.Refunds), len(result.Refunds))
+			}
+			if len(result.Adjustments) != len(tc.expected.Adjustments) {
+				t.Errorf("Expected %d adjustments, got %d", len(tc.expected.Adjustments), len(result.Adjustments))
+			}
 		})
 	}
 }
@@ -118,9 +165,9 @@ func TestTransformPayoutModelToDTO(t *testing.T) {
 			expected: &dto.FormattedPayout{
 				ID:        "payout1",
 				Created:   "14 Nov 2023",
-				Gross:     "150.00 lei",
-				Fee:       "5.00 lei",
-				Net:       "145.00 lei",
+				Gross:     "150,00 lei",
+				Fee:       "5,00 lei",
+				Net:       "145,00 lei",
 				Donations: nil,
 				Fees:      nil,
 			},
@@ -129,7 +176,7 @@ func TestTransformPayoutModelToDTO(t *testing.T) {
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformPayoutModelToDTO(tc.input)
+			result := transformPayoutModelToDTO(tc.input, time.UTC, i18n.Romanian)
 
 			if result.ID != tc.expected.ID ||
 				result.Created != tc.expected.Created ||
@@ -168,18 +215,18 @@ func TestTransformPayoutModelsToDTOs(t *testing.T) {
 				{
 					ID:        "payout1",
 					Created:   "14 Nov 2023",
-					Gross:     "150.00 lei",
-					Fee:       "5.00 lei",
-					Net:       "145.00 lei",
+					Gross:     "150,00 lei",
+					Fee:       "5,00 lei",
+					Net:       "145,00 lei",
 					Donations: nil,
 					Fees:      nil,
 				},
 				{
 					ID:        "payout2",
 					Created:   "14 Nov 2023",
-					Gross:     "200.00 lei",
-					Fee:       "6.00 lei",
-					Net:       "194.00 lei",
+					Gross:     "200,00 lei",
+					Fee:       "6,00 lei",
+					Net:       "194,00 lei",
 					Donations: nil,
 					Fees:      nil,
 				},
@@ -189,7 +236,7 @@ func TestTransformPayoutModelsToDTOs(t *testing.T) {
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformPayoutModelsToDTOs(tc.input)
+			result := transformPayoutModelsToDTOs(tc.input, time.UTC, i18n.Romanian)
 
 			if len(result) != len(tc.expected) {
 				t.Fatalf("Expected %d results, got %d", len(tc.expected), len(result))
@@ -215,24 +262,28 @@ func TestTransformDonationModelToDTO(t *testing.T) {
 	}{
 		"validDonation": {
 			input: &models.Donation{
-				ID:          "donation1",
-				Created:     1700000000,
-				Gross:       5000,
-				Fee:         100,
-				Net:         4900,
-				ClientName:  "John Doe",
-				ClientEmail: "john@example.com",
-				PayoutID:    sql.NullString{String: "payout1", Valid: true},
+				ID:            "donation1",
+				Created:       1700000000,
+				Gross:         5000,
+				Fee:           100,
+				Net:           4900,
+				ClientName:    "John Doe",
+				ClientEmail:   "john@example.com",
+				InvoiceSeries: sql.NullString{String: "HNT", Valid: true},
+				InvoiceYear:   sql.NullInt64{Int64: 2023, Valid: true},
+				InvoiceNumber: sql.NullInt64{Int64: 42, Valid: true},
+				PayoutID:      sql.NullString{String: "payout1", Valid: true},
 			},
 			expected: &dto.FormattedDonation{
-				ID:          "donation1",
-				Created:     "14 Nov 2023",
-				Gross:       "50.00 lei",
-				Fee:         "1.00 lei",
-				Net:         "49.00 lei",
-				ClientName:  "John Doe",
-				ClientEmail: "john@example.com",
-				PayoutID:    "payout1",
+				ID:            "donation1",
+				InvoiceNumber: "HNT-2023-000042",
+				Created:       "14 Nov 2023",
+				Gross:         "50,00 lei",
+				Fee:           "1,00 lei",
+				Net:           "49,00 lei",
+				ClientName:    "John Doe",
+				ClientEmail:   "john@example.com",
+				PayoutID:      "payout1",
 			},
 		},
 		"donationWithoutPayoutID": {
@@ -247,23 +298,25 @@ func TestTransformDonationModelToDTO(t *testing.T) {
 				PayoutID:    sql.NullString{Valid: false},
 			},
 			expected: &dto.FormattedDonation{
-				ID:          "donation2",
-				Created:     "14 Nov 2023",
-				Gross:       "100.00 lei",
-				Fee:         "5.00 lei",
-				Net:         "95.00 lei",
-				ClientName:  "Jane Doe",
-				ClientEmail: "jane@example.com",
-				PayoutID:    "",
+				ID:            "donation2",
+				InvoiceNumber: "donation2",
+				Created:       "14 Nov 2023",
+				Gross:         "100,00 lei",
+				Fee:           "5,00 lei",
+				Net:           "95,00 lei",
+				ClientName:    "Jane Doe",
+				ClientEmail:   "jane@example.com",
+				PayoutID:      "",
 			},
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformDonationModelToDTO(tc.input)
+			result := transformDonationModelToDTO(tc.input, time.UTC, "ro")
 
 			if result.ID != tc.expected.ID ||
+				result.InvoiceNumber != tc.expected.InvoiceNumber ||
 				result.Created != tc.expected.Created ||
 				result.Gross != tc.expected.Gross ||
 				result.Fee != tc.expected.Fee ||
@@ -309,9 +362,9 @@ func TestTransformDonationModelsToDTOs(t *testing.T) {
 				{
 					ID:          "donation1",
 					Created:     "14 Nov 2023",
-					Gross:       "50.00 lei",
-					Fee:         "1.00 lei",
-					Net:         "49.00 lei",
+					Gross:       "50,00 lei",
+					Fee:         "1,00 lei",
+					Net:         "49,00 lei",
 					ClientName:  "John Doe",
 					ClientEmail: "john@example.com",
 					PayoutID:    "payout1",
@@ -319,9 +372,9 @@ func TestTransformDonationModelsToDTOs(t *testing.T) {
 				{
 					ID:          "donation2",
 					Created:     "14 Nov 2023",
-					Gross:       "100.00 lei",
-					Fee:         "5.00 lei",
-					Net:         "95.00 lei",
+					Gross:       "100,00 lei",
+					Fee:         "5,00 lei",
+					Net:         "95,00 lei",
 					ClientName:  "Jane Doe",
 					ClientEmail: "jane@example.com",
 					PayoutID:    "",
@@ -332,7 +385,7 @@ func TestTransformDonationModelsToDTOs(t *testing.T) {
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformDonationModelsToDTOs(tc.input)
+			result := transformDonationModelsToDTOs(tc.input, time.UTC, "ro")
 
 			if len(result) != len(tc.expected) {
 				t.Fatalf("Expected %d donations, got %d", len(tc.expected), len(result))
@@ -372,16 +425,16 @@ func TestTransformDonationModelToPayoutReportItem(t *testing.T) {
 				Type:        "donation",
 				Description: "",
 				Created:     "14 Nov 2023",
-				Gross:       "50.00 lei",
-				Fee:         "1.00 lei",
-				Net:         "49.00 lei",
+				Gross:       "50,00 lei",
+				Fee:         "1,00 lei",
+				Net:         "49,00 lei",
 			},
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformDonationModelToPayoutReportItem(tc.input)
+			result := transformDonationModelToPayoutReportItem(tc.input, time.UTC, "ro")
 
 			if result.ID != tc.expected.ID ||
 				result.Type != tc.expected.Type ||
@@ -424,18 +477,18 @@ func TestTransformDonationModelsToPayoutReportItems(t *testing.T) {
 					Type:        "donation",
 					Description: "",
 					Created:     "14 Nov 2023",
-					Gross:       "50.00 lei",
-					Fee:         "1.00 lei",
-					Net:         "49.00 lei",
+					Gross:       "50,00 lei",
+					Fee:         "1,00 lei",
+					Net:         "49,00 lei",
 				},
 				{
 					ID:          "donation2",
 					Type:        "donation",
 					Description: "",
 					Created:     "14 Nov 2023",
-					Gross:       "100.00 lei",
-					Fee:         "5.00 lei",
-					Net:         "95.00 lei",
+					Gross:       "100,00 lei",
+					Fee:         "5,00 lei",
+					Net:         "95,00 lei",
 				},
 			},
 		},
@@ -443,7 +496,7 @@ func TestTransformDonationModelsToPayoutReportItems(t *testing.T) {
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformDonationModelsToPayoutReportItems(tc.input)
+			result := transformDonationModelsToPayoutReportItems(tc.input, time.UTC, "ro")
 
 			if len(result) != len(tc.expected) {
 				t.Fatalf("Expected %d donations, got %d", len(tc.expected), len(result))
@@ -480,14 +533,14 @@ func TestTransformFeeModelToDTO(t *testing.T) {
 				ID:          "fee1",
 				Description: "Transaction fee",
 				Created:     "14 Nov 2023",
-				Fee:         "5.00 lei",
+				Fee:         "5,00 lei",
 			},
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformFeeModelToDTO(tc.input)
+			result := transformFeeModelToDTO(tc.input, time.UTC)
 
 			if result.ID != tc.expected.ID ||
 				result.Description != tc.expected.Description ||
@@ -510,15 +563,15 @@ func TestTransformFeeModelsToDTOs(t *testing.T) {
 				{ID: "fee2", Description: "Service fee", Created: 1700000500, Fee: 1000},
 			},
 			expected: []*dto.FormattedFee{
-				{ID: "fee1", Description: "Transaction fee", Created: "14 Nov 2023", Fee: "5.00 lei"},
-				{ID: "fee2", Description: "Service fee", Created: "14 Nov 2023", Fee: "10.00 lei"},
+				{ID: "fee1", Description: "Transaction fee", Created: "14 Nov 2023", Fee: "5,00 lei"},
+				{ID: "fee2", Description: "Service fee", Created: "14 Nov 2023", Fee: "10,00 lei"},
 			},
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformFeeModelsToDTOs(tc.input)
+			result := transformFeeModelsToDTOs(tc.input, time.UTC)
 
 			if len(result) != len(tc.expected) {
 				t.Fatalf("Expected %d fees, got %d", len(tc.expected), len(result))
@@ -553,16 +606,16 @@ func TestTransformFeeModelToPayoutReportItem(t *testing.T) {
 				Type:        "fee",
 				Description: "Transaction fee",
 				Created:     "14 Nov 2023",
-				Gross:       "0.00 lei",
-				Fee:         "5.00 lei",
-				Net:         "5.00 lei",
+				Gross:       "0,00 lei",
+				Fee:         "5,00 lei",
+				Net:         "5,00 lei",
 			},
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformFeeModelToPayoutReportItem(tc.input)
+			result := transformFeeModelToPayoutReportItem(tc.input, time.UTC)
 
 			if result.ID != tc.expected.ID ||
 				result.Type != tc.expected.Type ||
@@ -588,15 +641,15 @@ func TestTransformFeeModelsToPayoutReportItems(t *testing.T) {
 				{ID: "fee2", Description: "Service fee", Created: 1700000500, Fee: 1000},
 			},
 			expected: []*dto.PayoutReportItem{
-				{ID: "fee1", Type: "fee", Description: "Transaction fee", Created: "14 Nov 2023", Gross: "0.00 lei", Fee: "5.00 lei", Net: "5.00 lei"},
-				{ID: "fee2", Type: "fee", Description: "Service fee", Created: "14 Nov 2023", Gross: "0.00 lei", Fee: "10.00 lei", Net: "10.00 lei"},
+				{ID: "fee1", Type: "fee", Description: "Transaction fee", Created: "14 Nov 2023", Gross: "0,00 lei", Fee: "5,00 lei", Net: "5,00 lei"},
+				{ID: "fee2", Type: "fee", Description: "Service fee", Created: "14 Nov 2023", Gross: "0,00 lei", Fee: "10,00 lei", Net: "10,00 lei"},
 			},
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformFeeModelsToPayoutReportItems(tc.input)
+			result := transformFeeModelsToPayoutReportItems(tc.input, time.UTC)
 
 			if len(result) != len(tc.expected) {
 				t.Fatalf("Expected %d fees, got %d", len(tc.expected), len(result))
@@ -649,7 +702,7 @@ func TestGetUnixTimestampsForMonth(t *testing.T) {
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			monthStart, monthEnd := getUnixTimestampsForMonth(tc.input)
+			monthStart, monthEnd := getUnixTimestampsForMonth(tc.input, time.UTC)
 
 			if monthStart != tc.expected.monthStart {
 				t.Errorf("Expected monthStart %d, got %d", tc.expected.monthStart, monthStart)
@@ -661,7 +714,106 @@ func TestGetUnixTimestampsForMonth(t *testing.T) {
 	}
 }
 
-func TestGetMonthDatesFromISO(t *testing.T) {
+func TestGetUnixTimestampsForMonthInBucharest(t *testing.T) {
+	bucharest, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+	testCases := map[string]struct {
+		input              time.Time
+		expectedMonthStart int64
+		expectedMonthEnd   int64
+	}{
+		"winterMonth": {
+			input:              time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expectedMonthStart: time.Date(2023, time.December, 31, 22, 0, 0, 0, time.UTC).Unix(),
+			expectedMonthEnd:   time.Date(2024, time.January, 31, 21, 59, 59, 0, time.UTC).Unix(),
+		},
+		"springForward": {
+			input:              time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			expectedMonthStart: time.Date(2024, time.February, 29, 22, 0, 0, 0, time.UTC).Unix(),
+			expectedMonthEnd:   time.Date(2024, time.March, 31, 20, 59, 59, 0, time.UTC).Unix(),
+		},
+		"summerMonth": {
+			input:              time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC),
+			expectedMonthStart: time.Date(2024, time.June, 30, 21, 0, 0, 0, time.UTC).Unix(),
+			expectedMonthEnd:   time.Date(2024, time.July, 31, 20, 59, 59, 0, time.UTC).Unix(),
+		},
+		"fallBack": {
+			input:              time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC),
+			expectedMonthStart: time.Date(2024, time.September, 30, 21, 0, 0, 0, time.UTC).Unix(),
+			expectedMonthEnd:   time.Date(2024, time.October, 31, 21, 59, 59, 0, time.UTC).Unix(),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			monthStart, monthEnd := getUnixTimestampsForMonth(tc.input, bucharest)
+
+			if monthStart != tc.expectedMonthStart {
+				t.Errorf("Expected monthStart %d, got %d", tc.expectedMonthStart, monthStart)
+			}
+			if monthEnd != tc.expectedMonthEnd {
+				t.Errorf("Expected monthEnd %d, got %d", tc.expectedMonthEnd, monthEnd)
+			}
+		})
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	bucharest, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+	testCases := map[string]struct {
+		input    time.Time
+		location *time.Location
+		expected string
+	}{
+		"utc": {
+			input:    time.Date(2024, time.August, 31, 21, 30, 0, 0, time.UTC),
+			location: time.UTC,
+			expected: "31 Aug 2024",
+		},
+		"firstOfMonthAfterLocalMidnight": {
+			input:    time.Date(2024, time.August, 31, 21, 30, 0, 0, time.UTC),
+			location: bucharest,
+			expected: "01 Sep 2024",
+		},
+		"beforeLocalMidnight": {
+			input:    time.Date(2024, time.August, 31, 20, 59, 59, 0, time.UTC),
+			location: bucharest,
+			expected: "31 Aug 2024",
+		},
+		"springForwardNight": {
+			input:    time.Date(2024, time.March, 31, 0, 30, 0, 0, time.UTC),
+			location: bucharest,
+			expected: "31 Mar 2024",
+		},
+		"fallBackNight": {
+			input:    time.Date(2024, time.October, 26, 21, 30, 0, 0, time.UTC),
+			location: bucharest,
+			expected: "27 Oct 2024",
+		},
+		"newYear": {
+			input:    time.Date(2024, time.December, 31, 22, 30, 0, 0, time.UTC),
+			location: bucharest,
+			expected: "01 Jan 2025",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := formatDate(uint64(tc.input.Unix()), tc.location)
+
+			if result != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetPeriodDates(t *testing.T) {
 	testCases := map[string]struct {
 		input    time.Time
 		expected struct {
@@ -698,7 +850,8 @@ func TestGetMonthDatesFromISO(t *testing.T) {
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			monthStart, monthEnd, emissionDate := getMonthDatesFromISO(tc.input)
+			period := &reportPeriod{kind: periodMonth, start: tc.input, end: tc.input.AddDate(0, 1, 0)}
+			monthStart, monthEnd, emissionDate := getPeriodDates(period)
 
 			if monthStart != tc.expected.monthStart {
 				t.Errorf("Expected monthStart %s, got %s", tc.expected.monthStart, monthStart)
@@ -764,32 +917,39 @@ func TestTransformToMonthlyReportData(t *testing.T) {
 
 	testCases := map[string]struct {
 		date   time.Time
-		gross  uint32
-		fee    uint32
-		net    uint32
+		gross  money.Money
+		fee    money.Money
+		net    money.Money
 		expect *dto.MonthlyReportData
 	}{
 		"validInput": {
 			date:  time.Date(2024, time.September, 1, 0, 0, 0, 0, time.UTC),
-			gross: 30000,
-			fee:   3000,
-			net:   27000,
+			gross: money.New(30000, "ron"),
+			fee:   money.New(3000, "ron"),
+			net:   money.New(27000, "ron"),
 			expect: dto.NewMonthlyReportData(
+				"Extras lunar",
+				"Septembrie 2024",
 				"1 Sep, 2024",
 				"30 Sep, 2024",
 				"1 Oct, 2024",
-				"300.00 lei",
-				"30.00 lei",
-				"270.00 lei",
-				transformPayoutModelsToDTOs(payoutModels),
+				"300,00 lei",
+				"30,00 lei",
+				"270,00 lei",
+				transformPayoutModelsToDTOs(payoutModels, time.UTC, i18n.Romanian),
+				nil,
 			),
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformToMonthlyReportData(tc.date, tc.gross, tc.fee, tc.net, payoutModels)
+			period := &reportPeriod{kind: periodMonth, start: tc.date, end: tc.date.AddDate(0, 1, 0)}
+			result := transformToMonthlyReportData(period, tc.gross, tc.fee, tc.net, payoutModels, nil, time.UTC, i18n.Romanian)
 
+			if result.Title != tc.expect.Title || result.Period != tc.expect.Period {
+				t.Errorf("Expected %s %s, got %s %s", tc.expect.Title, tc.expect.Period, result.Title, result.Period)
+			}
 			if result.MonthStart != tc.expect.MonthStart {
 				t.Errorf("Expected MonthStart %s, got %s", tc.expect.MonthStart, result.MonthStart)
 			}
@@ -818,21 +978,43 @@ func TestTransformToMonthlyReportData(t *testing.T) {
 func TestMonthlyReportSum(t *testing.T) {
 	testCases := map[string]struct {
 		payouts              []*models.Payout
-		expectedGross        uint32
-		expectedFee          uint32
-		expectedNet          uint32
+		expectedGross        int64
+		expectedFee          int64
+		expectedNet          int64
+		expectedCurrency     string
 		expectError          bool
 		expectedErrorMessage string
 	}{
 		"validPayouts": {
 			payouts: []*models.Payout{
-				{Gross: 10000, Fee: 1000, Net: 9000},
-				{Gross: 20000, Fee: 2000, Net: 18000},
+				{Gross: 10000, Fee: 1000, Net: 9000, Currency: "ron"},
+				{Gross: 20000, Fee: 2000, Net: 18000, Currency: "ron"},
+			},
+			expectedGross:    30000,
+			expectedFee:      3000,
+			expectedNet:      27000,
+			expectedCurrency: "ron",
+			expectError:      false,
+		},
+		"mixedCurrencies": {
+			payouts: []*models.Payout{
+				{Gross: 10000, Fee: 1000, Net: 9000, Currency: "ron"},
+				{Gross: 20000, Fee: 2000, Net: 18000, Currency: "eur"},
 			},
-			expectedGross: 30000,
-			expectedFee:   3000,
-			expectedNet:   27000,
-			expectError:   false,
+			expectError:          true,
+			expectedErrorMessage: "monthly gross sum failed: amounts have different currencies: ron and eur",
+		},
+		"failedPayoutsExcluded": {
+			payouts: []*models.Payout{
+				{Gross: 10000, Fee: 1000, Net: 9000, Status: models.PayoutPaid},
+				{Gross: 20000, Fee: 2000, Net: 18000, Status: models.PayoutFailed},
+				{Gross: 5000, Fee: 500, Net: 4500, Status: models.PayoutCanceled},
+			},
+			expectedGross:    10000,
+			expectedFee:      1000,
+			expectedNet:      9000,
+			expectedCurrency: "ron",
+			expectError:      false,
 		},
 		"mismatchNet": {
 			payouts: []*models.Payout{
@@ -846,11 +1028,20 @@ func TestMonthlyReportSum(t *testing.T) {
 			expectedErrorMessage: "monthly gross-fee 27000 != net 26000",
 		},
 		"noPayouts": {
-			payouts:       nil,
-			expectedGross: 0,
-			expectedFee:   0,
-			expectedNet:   0,
-			expectError:   false,
+			payouts:          nil,
+			expectedGross:    0,
+			expectedFee:      0,
+			expectedNet:      0,
+			expectedCurrency: "ron",
+			expectError:      false,
+		},
+		"overflow": {
+			payouts: []*models.Payout{
+				{Gross: math.MaxInt64, Fee: 0, Net: math.MaxInt64, Currency: "ron"},
+				{Gross: 1, Fee: 0, Net: 1, Currency: "ron"},
+			},
+			expectError:          true,
+			expectedErrorMessage: "monthly gross sum failed: amount overflows int64",
 		},
 	}
 
@@ -858,14 +1049,17 @@ func TestMonthlyReportSum(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			gross, fee, net, err := monthlyReportSum(tc.payouts)
 
-			if gross != tc.expectedGross {
-				t.Errorf("Expected gross %d, got %d", tc.expectedGross, gross)
+			if gross.Amount != tc.expectedGross {
+				t.Errorf("Expected gross %d, got %d", tc.expectedGross, gross.Amount)
+			}
+			if fee.Amount != tc.expectedFee {
+				t.Errorf("Expected fee %d, got %d", tc.expectedFee, fee.Amount)
 			}
-			if fee != tc.expectedFee {
-				t.Errorf("Expected fee %d, got %d", tc.expectedFee, fee)
+			if net.Amount != tc.expectedNet {
+				t.Errorf("Expected net %d, got %d", tc.expectedNet, net.Amount)
 			}
-			if net != tc.expectedNet {
-				t.Errorf("Expected net %d, got %d", tc.expectedNet, net)
+			if gross.Currency != tc.expectedCurrency {
+				t.Errorf("Expected currency %s, got %s", tc.expectedCurrency, gross.Currency)
 			}
 			if tc.expectError && err == nil {
 				t.Error("Expected an error but got none")
@@ -881,3 +1075,238 @@ func TestMonthlyReportSum(t *testing.T) {
 		})
 	}
 }
+
+func TestTransformRefundModelToDTO(t *testing.T) {
+	testCases := map[string]struct {
+		input    *models.Refund
+		expected *dto.FormattedRefund
+	}{
+		"validRefund": {
+			input: &models.Refund{
+				ID:         "refund1",
+				Created:    1700000000,
+				Gross:      5000,
+				Fee:        0,
+				Net:        5000,
+				DonationID: "donation1",
+				PayoutID:   sql.NullString{String: "payout1", Valid: true},
+			},
+			expected: &dto.FormattedRefund{
+				ID:         "refund1",
+				Created:    "14 Nov 2023",
+				Gross:      "50,00 lei",
+				Fee:        "0,00 lei",
+				Net:        "50,00 lei",
+				DonationID: "donation1",
+				PayoutID:   "payout1",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := transformRefundModelToDTO(tc.input, time.UTC)
+
+			if result.ID != tc.expected.ID ||
+				result.Created != tc.expected.Created ||
+				result.Gross != tc.expected.Gross ||
+				result.Fee != tc.expected.Fee ||
+				result.Net != tc.expected.Net ||
+				result.DonationID != tc.expected.DonationID ||
+				result.PayoutID != tc.expected.PayoutID {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestTransformRefundModelToPayoutReportItem(t *testing.T) {
+	testCases := map[string]struct {
+		input    *models.Refund
+		expected *dto.PayoutReportItem
+	}{
+		"validRefundReportItem": {
+			input: &models.Refund{
+				ID:         "refund1",
+				Created:    1700000000,
+				Gross:      5000,
+				Fee:        0,
+				Net:        5000,
+				DonationID: "donation1",
+			},
+			expected: &dto.PayoutReportItem{
+				ID:          "refund1",
+				Type:        "refund",
+				Description: "Rambursare donation1",
+				Created:     "14 Nov 2023",
+				Gross:       "50,00 lei",
+				Fee:         "0,00 lei",
+				Net:         "50,00 lei",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := transformRefundModelToPayoutReportItem(tc.input, time.UTC, i18n.Romanian)
+
+			if result.ID != tc.expected.ID ||
+				result.Type != tc.expected.Type ||
+				result.Description != tc.expected.Description ||
+				result.Created != tc.expected.Created ||
+				result.Gross != tc.expected.Gross ||
+				result.Fee != tc.expected.Fee ||
+				result.Net != tc.expected.Net {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestTransformDisputeAdjustmentModelToPayoutReportItem(t *testing.T) {
+	testCases := map[string]struct {
+		input    *models.DisputeAdjustment
+		expected *dto.PayoutReportItem
+	}{
+		"withdrawal": {
+			input: &models.DisputeAdjustment{
+				ID:        "adjustment1",
+				Type:      models.DisputeWithdrawal,
+				Created:   1700000000,
+				Gross:     5000,
+				Fee:       1500,
+				Net:       6500,
+				DisputeID: "dp_123",
+			},
+			expected: &dto.PayoutReportItem{
+				ID:          "adjustment1",
+				Type:        "dispute_withdrawal",
+				Description: "Retragere contestație dp_123",
+				Created:     "14 Nov 2023",
+				Gross:       "50,00 lei",
+				Fee:         "15,00 lei",
+				Net:         "65,00 lei",
+			},
+		},
+		"reinstatement": {
+			input: &models.DisputeAdjustment{
+				ID:        "adjustment2",
+				Type:      models.DisputeReinstatement,
+				Created:   1700000000,
+				Gross:     5000,
+				Fee:       1500,
+				Net:       6500,
+				DisputeID: "dp_123",
+			},
+			expected: &dto.PayoutReportItem{
+				ID:          "adjustment2",
+				Type:        "dispute_reinstatement",
+				Description: "Restituire contestație dp_123",
+				Created:     "14 Nov 2023",
+				Gross:       "50,00 lei",
+				Fee:         "15,00 lei",
+				Net:         "65,00 lei",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := transformDisputeAdjustmentModelToPayoutReportItem(tc.input, time.UTC, i18n.Romanian)
+
+			if *result != *tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestApplyDisputeStatuses(t *testing.T) {
+	donations := []*dto.FormattedDonation{{ID: "donation1"}, {ID: "donation2"}}
+	disputes := []*models.Dispute{{ID: "dp_123", Status: "needs_response", DonationID: "donation2"}}
+
+	applyDisputeStatuses(donations, disputes, i18n.Romanian)
+
+	if donations[0].DisputeStatus != "" {
+		t.Errorf("Expected no dispute status, got %q", donations[0].DisputeStatus)
+	}
+	if donations[1].DisputeStatus != "Contestație deschisă" {
+		t.Errorf("Expected dispute status %q, got %q", "Contestație deschisă", donations[1].DisputeStatus)
+	}
+}
+
+func TestFormatPayoutStatus(t *testing.T) {
+	testCases := map[string]struct {
+		status   string
+		lang     string
+		expected string
+	}{
+		"paid":          {models.PayoutPaid, i18n.Romanian, ""},
+		"failed":        {models.PayoutFailed, i18n.Romanian, "Eșuată"},
+		"canceled":      {models.PayoutCanceled, i18n.Romanian, "Anulată"},
+		"failedEnglish": {models.PayoutFailed, i18n.English, "Failed"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := formatPayoutStatus(tc.status, tc.lang); result != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatConversion(t *testing.T) {
+	converted := &models.Donation{
+		Currency:         "ron",
+		OriginalAmount:   sql.NullInt64{Int64: 1006, Valid: true},
+		OriginalCurrency: sql.NullString{String: "eur", Valid: true},
+		ExchangeRate:     sql.NullFloat64{Float64: 4.97, Valid: true},
+	}
+
+	testCases := map[string]struct {
+		donation *models.Donation
+		lang     string
+		expected string
+	}{
+		"converted": {
+			donation: converted,
+			lang:     "ro",
+			expected: "10,06 EUR la cursul 4,9700",
+		},
+		"convertedInEnglish": {
+			donation: converted,
+			lang:     "en",
+			expected: "10,06 EUR at an exchange rate of 4,9700",
+		},
+		"withoutRate": {
+			donation: &models.Donation{
+				Currency:         "ron",
+				OriginalAmount:   sql.NullInt64{Int64: 1006, Valid: true},
+				OriginalCurrency: sql.NullString{String: "eur", Valid: true},
+			},
+			lang:     "en",
+			expected: "10,06 EUR",
+		},
+		"sameCurrency": {
+			donation: &models.Donation{
+				Currency:         "ron",
+				OriginalAmount:   sql.NullInt64{Int64: 5000, Valid: true},
+				OriginalCurrency: sql.NullString{String: "ron", Valid: true},
+			},
+			expected: "",
+		},
+		"notRecorded": {
+			donation: &models.Donation{Currency: "ron"},
+			expected: "",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := formatConversion(tc.donation, tc.lang); result != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, result)
+			}
+		})
+	}
+}
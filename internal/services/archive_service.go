@@ -0,0 +1,312 @@
+package services
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/signintech/gopdf"
+)
+
+type ArchiveRepository interface {
+	GetLatestArchivedDocument(documentType, reference, lang string) (*models.ArchivedDocument, error)
+	GetArchivedDocument(id int64) (*models.ArchivedDocument, error)
+	GetArchivedDocumentVersions(documentType, reference string) ([]*models.ArchivedDocument, error)
+	InsertArchivedDocument(document *models.ArchivedDocument) error
+	GetDonation(id string) (*models.Donation, error)
+	GetRefund(id string) (*models.Refund, error)
+}
+
+type DocumentStorage interface {
+	Put(key string, content []byte) error
+	Get(key string) ([]byte, error)
+}
+
+type DocumentGenerator interface {
+	GenerateInvoice(id, lang string) (*gopdf.GoPdf, error)
+	GenerateCreditNote(id, lang string) (*gopdf.GoPdf, error)
+	GeneratePayoutReport(payoutID, lang string) (*gopdf.GoPdf, error)
+	GenerateMonthlyReport(stringDate, lang string) (*gopdf.GoPdf, error)
+	GenerateEInvoice(id string) ([]byte, error)
+}
+
+// ArchiveService issues every document once and serves the stored file afterwards, so a
+// reprint matches what was sent even after code, fonts or organisation data change. The
+// e-Factura XML of a donation is archived as an "einvoice" next to its PDF invoice.
+type ArchiveService struct {
+	repo      ArchiveRepository
+	storage   DocumentStorage
+	generator DocumentGenerator
+	location  *time.Location
+}
+
+func NewArchiveService(repo ArchiveRepository, storage DocumentStorage, generator DocumentGenerator, location *time.Location) *ArchiveService {
+	return &ArchiveService{
+		repo:      repo,
+		storage:   storage,
+		generator: generator,
+		location:  location,
+	}
+}
+
+// Document returns the latest issued version of a document, issuing the first one when
+// there is none. An empty lang is the document's own default language. Reports of a
+// period that has not ended yet are rendered every time and not archived, since later
+// payouts still change them.
+func (s *ArchiveService) Document(documentType, reference, lang, user string) (*dto.ArchivedDocument, []byte, error) {
+	if err := validateArchiveRequest(documentType, reference); err != nil {
+		return nil, nil, err
+	}
+	lang, err := s.language(documentType, reference, lang)
+	if err != nil {
+		return nil, nil, err
+	}
+	open, err := periodOpen(documentType, reference, time.Now(), s.location)
+	if err != nil {
+		return nil, nil, err
+	}
+	if open {
+		return s.render(documentType, reference, lang, user)
+	}
+	latest, err := s.repo.GetLatestArchivedDocument(documentType, reference, lang)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch archived document failed: %w", err)
+	}
+	if latest != nil {
+		return s.read(latest)
+	}
+
+	document, content, err := s.issue(documentType, reference, lang, 1, user, sql.NullString{})
+	if err != nil {
+		// Another request may have issued the document first.
+		if latest, _ = s.repo.GetLatestArchivedDocument(documentType, reference, lang); latest != nil {
+			return s.read(latest)
+		}
+		return nil, nil, err
+	}
+	return document, content, nil
+}
+
+// Reissue renders the document again as a new version. Earlier versions stay archived.
+func (s *ArchiveService) Reissue(documentType, reference, lang, user, reason string) (*dto.ArchivedDocument, error) {
+	if err := validateArchiveRequest(documentType, reference); err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		return nil, custom_errors.NewValidationError(constants.ErrArchiveReasonMissing)
+	}
+	lang, err := s.language(documentType, reference, lang)
+	if err != nil {
+		return nil, err
+	}
+	open, err := periodOpen(documentType, reference, time.Now(), s.location)
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, custom_errors.NewValidationError(constants.ErrArchivePeriodOpen, reference)
+	}
+	latest, err := s.repo.GetLatestArchivedDocument(documentType, reference, lang)
+	if err != nil {
+		return nil, fmt.Errorf("fetch archived document failed: %w", err)
+	}
+	var version int64 = 1
+	if latest != nil {
+		version = latest.Version + 1
+	}
+
+	document, _, err := s.issue(documentType, reference, lang, version, user, toNullString(reason))
+	return document, err
+}
+
+// ArchivedDocument returns one stored version.
+func (s *ArchiveService) ArchivedDocument(id int64) (*dto.ArchivedDocument, []byte, error) {
+	documentModel, err := s.repo.GetArchivedDocument(id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch archived document failed: %w", err)
+	}
+	return s.read(documentModel)
+}
+
+// Versions lists what was issued for a document, newest first within each language.
+func (s *ArchiveService) Versions(documentType, reference string) ([]*dto.ArchivedDocument, error) {
+	if err := validateArchiveRequest(documentType, reference); err != nil {
+		return nil, err
+	}
+	documentModels, err := s.repo.GetArchivedDocumentVersions(documentType, reference)
+	if err != nil {
+		return nil, fmt.Errorf("fetch archived documents failed: %w", err)
+	}
+	var documents []*dto.ArchivedDocument
+	for _, documentModel := range documentModels {
+		documents = append(documents, transformArchivedDocumentModelToDTO(documentModel, s.location))
+	}
+	return documents, nil
+}
+
+// language resolves lang the way the document is rendered: the donor's language for
+// invoices and credit notes, Romanian for e-invoices, the default one for reports.
+// Archiving under the resolved language keeps an empty lang and its explicit equivalent
+// on the same original.
+func (s *ArchiveService) language(documentType, reference, lang string) (string, error) {
+	donationID := reference
+	switch documentType {
+	case "einvoice":
+		return i18n.Romanian, nil
+	case "donation":
+	case "refund":
+		refundModel, err := s.repo.GetRefund(reference)
+		if err != nil {
+			return "", fmt.Errorf("fetch refund failed: %w", err)
+		}
+		donationID = refundModel.DonationID
+	default:
+		return i18n.Resolve(lang), nil
+	}
+	donationModel, err := s.repo.GetDonation(donationID)
+	if err != nil {
+		return "", fmt.Errorf("fetch donation failed: %w", err)
+	}
+	return i18n.Resolve(lang, donationModel.Locale.String), nil
+}
+
+// periodOpen tells whether a document is a report of a period that ends after now.
+func periodOpen(documentType, reference string, now time.Time, location *time.Location) (bool, error) {
+	if documentType != "monthly" {
+		return false, nil
+	}
+	period, err := parseReportPeriod(reference, location)
+	if err != nil {
+		return false, err
+	}
+	return period.end.After(now), nil
+}
+
+// render returns a document without archiving it. It has no ID or version.
+func (s *ArchiveService) render(documentType, reference, lang, user string) (*dto.ArchivedDocument, []byte, error) {
+	content, err := s.content(documentType, reference, lang)
+	if err != nil {
+		return nil, nil, err
+	}
+	documentModel := models.NewArchivedDocument(documentType, reference, lang, 0, "", documentHash(content), int64(len(content)), time.Now().Unix(), user, sql.NullString{})
+	return transformArchivedDocumentModelToDTO(documentModel, s.location), content, nil
+}
+
+func (s *ArchiveService) issue(documentType, reference, lang string, version int64, user string, reason sql.NullString) (*dto.ArchivedDocument, []byte, error) {
+	content, err := s.content(documentType, reference, lang)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hash := documentHash(content)
+	path := archiveKey(documentType, reference, lang, version, hash)
+	if err = s.storage.Put(path, content); err != nil {
+		return nil, nil, fmt.Errorf("store document failed: %w", err)
+	}
+
+	documentModel := models.NewArchivedDocument(documentType, reference, lang, version, path, hash, int64(len(content)), time.Now().Unix(), user, reason)
+	if err = s.repo.InsertArchivedDocument(documentModel); err != nil {
+		return nil, nil, fmt.Errorf("archive document failed: %w", err)
+	}
+	return transformArchivedDocumentModelToDTO(documentModel, s.location), content, nil
+}
+
+func (s *ArchiveService) content(documentType, reference, lang string) ([]byte, error) {
+	if documentType == "einvoice" {
+		return s.generator.GenerateEInvoice(reference)
+	}
+	pdf, err := s.generate(documentType, reference, lang)
+	if err != nil {
+		return nil, err
+	}
+	content, err := pdf.GetBytesPdfReturnErr()
+	if err != nil {
+		return nil, fmt.Errorf("render document failed: %w", err)
+	}
+	return content, nil
+}
+
+func (s *ArchiveService) generate(documentType, reference, lang string) (*gopdf.GoPdf, error) {
+	switch documentType {
+	case "donation":
+		return s.generator.GenerateInvoice(reference, lang)
+	case "refund":
+		return s.generator.GenerateCreditNote(reference, lang)
+	case "payout":
+		return s.generator.GeneratePayoutReport(reference, lang)
+	case "monthly":
+		return s.generator.GenerateMonthlyReport(reference, lang)
+	}
+	return nil, fmt.Errorf(constants.ErrArchiveTypeUnknown, documentType)
+}
+
+// read returns the stored file after checking it still matches the hash taken at issue.
+func (s *ArchiveService) read(documentModel *models.ArchivedDocument) (*dto.ArchivedDocument, []byte, error) {
+	content, err := s.storage.Get(documentModel.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read archived document failed: %w", err)
+	}
+	if documentHash(content) != documentModel.SHA256 {
+		return nil, nil, fmt.Errorf(constants.ErrArchiveHashMismatch, documentModel.ID)
+	}
+	return transformArchivedDocumentModelToDTO(documentModel, s.location), content, nil
+}
+
+var referencePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,100}$`)
+
+func validateArchiveRequest(documentType, reference string) error {
+	switch documentType {
+	case "donation", "einvoice", "refund", "payout", "monthly":
+	default:
+		return custom_errors.NewValidationError(constants.ErrArchiveTypeUnknown, documentType)
+	}
+	if !referencePattern.MatchString(reference) {
+		return custom_errors.NewValidationError(constants.ErrArchiveReference, reference)
+	}
+	return nil
+}
+
+// archiveKey names the file after its content, so a render interrupted before it was
+// recorded never blocks the next attempt at the same version.
+func archiveKey(documentType, reference, lang string, version int64, hash string) string {
+	if lang == "" {
+		lang = "default"
+	}
+	return fmt.Sprintf("%s/%s/v%d-%s-%s.%s", documentType, reference, version, lang, hash[:16], documentExtension(documentType))
+}
+
+// documentExtension is xml for e-invoices and pdf for everything else.
+func documentExtension(documentType string) string {
+	if documentType == "einvoice" {
+		return "xml"
+	}
+	return "pdf"
+}
+
+func documentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func transformArchivedDocumentModelToDTO(document *models.ArchivedDocument, location *time.Location) *dto.ArchivedDocument {
+	return dto.NewArchivedDocument(
+		document.ID,
+		document.Type,
+		document.Reference,
+		document.Lang,
+		document.Version,
+		document.SHA256,
+		document.Size,
+		time.Unix(document.Issued, 0).In(location).Format("02 Jan 2006 15:04"),
+		document.IssuedBy,
+		document.Reason.String,
+	)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateArchiveRequest(t *testing.T) {
+	testCases := map[string]struct {
+		documentType string
+		reference    string
+		expectError  bool
+	}{
+		"donation":      {documentType: "donation", reference: "txn_charge_1"},
+		"monthly":       {documentType: "monthly", reference: "2024-09"},
+		"einvoice":      {documentType: "einvoice", reference: "txn_charge_1"},
+		"unknownType":   {documentType: "statement", reference: "txn_charge_1", expectError: true},
+		"emptyRef":      {documentType: "payout", reference: "", expectError: true},
+		"pathTraversal": {documentType: "refund", reference: "../txn_1", expectError: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateArchiveRequest(tc.documentType, tc.reference)
+
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error, but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestArchiveKey(t *testing.T) {
+	hash := documentHash([]byte("%PDF-1.4"))
+
+	testCases := map[string]struct {
+		documentType string
+		lang         string
+		version      int64
+		expected     string
+	}{
+		"default":  {documentType: "donation", lang: "", version: 1, expected: "donation/txn_1/v1-default-" + hash[:16] + ".pdf"},
+		"english":  {documentType: "donation", lang: "en", version: 2, expected: "donation/txn_1/v2-en-" + hash[:16] + ".pdf"},
+		"einvoice": {documentType: "einvoice", lang: "ro", version: 1, expected: "einvoice/txn_1/v1-ro-" + hash[:16] + ".xml"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := archiveKey(tc.documentType, "txn_1", tc.lang, tc.version, hash); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestPeriodOpen(t *testing.T) {
+	location, _ := time.LoadLocation("Europe/Bucharest")
+	now := time.Date(2024, 10, 15, 12, 0, 0, 0, location)
+
+	testCases := map[string]struct {
+		documentType string
+		reference    string
+		expected     bool
+		expectError  bool
+	}{
+		"pastMonth":     {documentType: "monthly", reference: "2024-09"},
+		"currentMonth":  {documentType: "monthly", reference: "2024-10", expected: true},
+		"currentYear":   {documentType: "monthly", reference: "2024", expected: true},
+		"rangeEnded":    {documentType: "monthly", reference: "2024-10-01_2024-10-14"},
+		"rangeToday":    {documentType: "monthly", reference: "2024-10-01_2024-10-15", expected: true},
+		"payout":        {documentType: "payout", reference: "txn_payout_1"},
+		"invalidPeriod": {documentType: "monthly", reference: "2024-13", expectError: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := periodOpen(tc.documentType, tc.reference, now, location)
+
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error, but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, but got: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+// auditEntryLimit caps the audit page; filters narrow it down.
+const auditEntryLimit = 200
+
+// AuditRepository records audit entries. The columns that differ between before and after
+// are stored as the entry's changes.
+type AuditRepository interface {
+	InsertAuditEntry(entry *models.AuditEntry, before, after interface{}) error
+}
+
+type AuditLogRepository interface {
+	AuditRepository
+	GetAuditEntries(filter models.AuditFilter, limit int) ([]*models.AuditEntry, error)
+}
+
+// AuditService records what users do that changes nothing, such as signing in and
+// downloading documents, and lists the audit log. Changes are recorded by the services
+// that make them.
+type AuditService struct {
+	repo     AuditLogRepository
+	location *time.Location
+}
+
+func NewAuditService(repo AuditLogRepository, location *time.Location) *AuditService {
+	return &AuditService{repo: repo, location: location}
+}
+
+func (s *AuditService) Record(actor models.Actor, action, entity, reference string) error {
+	return recordAudit(s.repo, actor, action, entity, reference, nil, nil)
+}
+
+// ListEntries lists the newest entries matching the filters. Dates are local days, both
+// bounds included.
+func (s *AuditService) ListEntries(username, action, entity, reference, from, to string) ([]*dto.FormattedAuditEntry, error) {
+	filter := models.AuditFilter{
+		Username:  strings.TrimSpace(username),
+		Action:    strings.TrimSpace(action),
+		Entity:    strings.TrimSpace(entity),
+		Reference: strings.TrimSpace(reference),
+	}
+	var err error
+	if filter.Start, err = parseSearchDate(from, s.location, 0); err != nil {
+		return nil, err
+	}
+	if filter.End, err = parseSearchDate(to, s.location, 1); err != nil {
+		return nil, err
+	}
+	if filter.Start.Valid && filter.End.Valid && filter.Start.Int64 >= filter.End.Int64 {
+		return nil, custom_errors.NewValidationError(constants.ErrSearchRangeInvalid)
+	}
+
+	entryModels, err := s.repo.GetAuditEntries(filter, auditEntryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database audit entries fetch failed: %w", err)
+	}
+	return transformAuditEntryModelsToDTOs(entryModels, s.location)
+}
+
+// recordAudit records an action of the actor on a record, with the record before and after
+// the action when it changed it.
+func recordAudit(repo AuditRepository, actor models.Actor, action, entity, reference string, before, after interface{}) error {
+	entry := models.NewAuditEntry(time.Now().Unix(), actor, action, entity, reference)
+	if err := repo.InsertAuditEntry(entry, before, after); err != nil {
+		return fmt.Errorf("database audit entry insertion failed: %w", err)
+	}
+	return nil
+}
+
+func transformAuditEntryModelsToDTOs(entryModels []*models.AuditEntry, location *time.Location) (entries []*dto.FormattedAuditEntry, err error) {
+	for _, entry := range entryModels {
+		var changes []*dto.FormattedAuditChange
+		if entry.Changes.Valid {
+			if changes, err = transformAuditChanges(entry.Changes.String); err != nil {
+				return nil, fmt.Errorf("audit entry %d: %w", entry.ID, err)
+			}
+		}
+		entries = append(entries, dto.NewFormattedAuditEntry(
+			time.Unix(entry.Created, 0).In(location).Format("02 Jan 2006 15:04:05"),
+			entry.Username,
+			entry.IP,
+			entry.Action,
+			entry.Entity,
+			entry.Reference,
+			changes,
+		))
+	}
+	return
+}
+
+// transformAuditChanges lists the changed columns by name.
+func transformAuditChanges(encoded string) ([]*dto.FormattedAuditChange, error) {
+	var columns map[string]struct {
+		Before json.RawMessage `json:"before"`
+		After  json.RawMessage `json:"after"`
+	}
+	if err := json.Unmarshal([]byte(encoded), &columns); err != nil {
+		return nil, fmt.Errorf("audit changes decoding failed: %w", err)
+	}
+
+	changes := make([]*dto.FormattedAuditChange, 0, len(columns))
+	for column, change := range columns {
+		changes = append(changes, dto.NewFormattedAuditChange(column, formatAuditValue(change.Before), formatAuditValue(change.After)))
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Column < changes[j].Column })
+	return changes, nil
+}
+
+// formatAuditValue prints strings without their quotes and null as empty.
+func formatAuditValue(value json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(value, &text); err == nil {
+		return text
+	}
+	if string(value) == "null" {
+		return ""
+	}
+	return string(value)
+}
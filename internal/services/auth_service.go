@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/diother/go-invoices/internal/constants"
 	"github.com/diother/go-invoices/internal/custom_errors"
 	"github.com/diother/go-invoices/internal/models"
 	"golang.org/x/crypto/bcrypt"
@@ -42,7 +43,7 @@ func (s *AuthService) Authenticate(username, password string) (user *models.User
 	}
 
 	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, custom_errors.NewCredentialsError("Nume de utilizator sau parolă invalide")
+		return nil, custom_errors.NewCredentialsError(constants.ErrLoginInvalid)
 	}
 	return
 }
@@ -97,10 +98,10 @@ func generateSessionID() (int64, error) {
 
 func validateCredentials(username, password string) error {
 	if username == "" {
-		return fmt.Errorf("Lipsește numele de utilizator")
+		return fmt.Errorf(constants.ErrLoginUsernameMissing)
 	}
 	if password == "" {
-		return fmt.Errorf("Lipsește parola")
+		return fmt.Errorf(constants.ErrLoginPasswordMissing)
 	}
 	return nil
 }
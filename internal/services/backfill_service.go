@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/stripe/stripe-go/v79"
+)
+
+type BackfillRepository interface {
+	PayoutExists(id string) (bool, error)
+	DonationExists(id string) (bool, error)
+}
+
+type BackfillService struct {
+	repo     BackfillRepository
+	gateway  StripeGateway
+	donation *DonationService
+	payout   *PayoutService
+}
+
+func NewBackfillService(repo BackfillRepository, gateway StripeGateway, donation *DonationService, payout *PayoutService) *BackfillService {
+	return &BackfillService{
+		repo:     repo,
+		gateway:  gateway,
+		donation: donation,
+		payout:   payout,
+	}
+}
+
+// BackfillSummary counts what a backfill run did. In a dry run, Inserted counts
+// the records that would have been inserted.
+type BackfillSummary struct {
+	DonationsInserted int
+	DonationsSkipped  int
+	DonationsFailed   int
+	PayoutsInserted   int
+	PayoutsSkipped    int
+	PayoutsFailed     int
+	Failures          []string
+}
+
+// Backfill imports the charges and payouts created in [from, to) that are not in the database yet.
+// Charges are imported first so that payouts only have to link their donations.
+// A zero from or to leaves that end of the range open.
+func (s *BackfillService) Backfill(from, to time.Time, dryRun bool) (*BackfillSummary, error) {
+	summary := &BackfillSummary{}
+
+	charges, err := s.gateway.ListCharges(createdRange(from, to))
+	if err != nil {
+		return nil, fmt.Errorf("charges fetch failed: %w", err)
+	}
+	for _, charge := range reverseCharges(charges) {
+		if err = s.backfillCharge(charge, dryRun, summary); err != nil {
+			return nil, err
+		}
+	}
+
+	payouts, err := s.gateway.ListPayouts(createdRange(from, to))
+	if err != nil {
+		return nil, fmt.Errorf("payouts fetch failed: %w", err)
+	}
+	for _, payout := range reversePayouts(payouts) {
+		if err = s.backfillPayout(payout, dryRun, summary); err != nil {
+			return nil, err
+		}
+	}
+	return summary, nil
+}
+
+func (s *BackfillService) backfillCharge(charge *stripe.Charge, dryRun bool, summary *BackfillSummary) error {
+	if charge.Status != "succeeded" || charge.BalanceTransaction == nil {
+		summary.DonationsSkipped++
+		return nil
+	}
+	exists, err := s.repo.DonationExists(charge.BalanceTransaction.ID)
+	if err != nil {
+		return fmt.Errorf("donation check failed: %w", err)
+	}
+	if exists {
+		summary.DonationsSkipped++
+		return nil
+	}
+	if dryRun {
+		summary.DonationsInserted++
+		return nil
+	}
+
+	if err = s.donation.ProcessDonation(charge, ""); err != nil {
+		summary.DonationsFailed++
+		summary.Failures = append(summary.Failures, fmt.Sprintf("charge %s: %v", charge.ID, err))
+		return nil
+	}
+	summary.DonationsInserted++
+	return nil
+}
+
+func (s *BackfillService) backfillPayout(payout *stripe.Payout, dryRun bool, summary *BackfillSummary) error {
+	if payout.BalanceTransaction == nil {
+		summary.PayoutsSkipped++
+		return nil
+	}
+	switch payout.Status {
+	case "paid", "failed", "canceled":
+	default:
+		summary.PayoutsSkipped++
+		return nil
+	}
+
+	exists, err := s.repo.PayoutExists(payout.BalanceTransaction.ID)
+	if err != nil {
+		return fmt.Errorf("payout check failed: %w", err)
+	}
+	if exists {
+		summary.PayoutsSkipped++
+		return nil
+	}
+	if dryRun {
+		summary.PayoutsInserted++
+		return nil
+	}
+
+	if payout.Status == "paid" {
+		err = s.payout.ProcessPayout(payout, "")
+	} else {
+		err = s.payout.ProcessFailedPayout(payout, "")
+	}
+	if err != nil {
+		summary.PayoutsFailed++
+		summary.Failures = append(summary.Failures, fmt.Sprintf("payout %s: %v", payout.ID, err))
+		return nil
+	}
+	summary.PayoutsInserted++
+	return nil
+}
+
+func createdRange(from, to time.Time) *stripe.RangeQueryParams {
+	if from.IsZero() && to.IsZero() {
+		return nil
+	}
+	created := &stripe.RangeQueryParams{}
+	if !from.IsZero() {
+		created.GreaterThanOrEqual = from.Unix()
+	}
+	if !to.IsZero() {
+		created.LesserThan = to.Unix()
+	}
+	return created
+}
+
+// Stripe lists the newest objects first. Backfilling oldest first keeps a failed
+// payout ahead of the payout that returned its funds.
+func reverseCharges(charges []*stripe.Charge) []*stripe.Charge {
+	for i, j := 0, len(charges)-1; i < j; i, j = i+1, j-1 {
+		charges[i], charges[j] = charges[j], charges[i]
+	}
+	return charges
+}
+
+func reversePayouts(payouts []*stripe.Payout) []*stripe.Payout {
+	for i, j := 0, len(payouts)-1; i < j; i, j = i+1, j-1 {
+		payouts[i], payouts[j] = payouts[j], payouts[i]
+	}
+	return payouts
+}
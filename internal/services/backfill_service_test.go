@@ -0,0 +1,248 @@
+package services
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/internal/gateway"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/stripe/stripe-go/v79"
+)
+
+// fakeWebhookRepository keeps donations and payouts in memory, dropping what a rolled back
+// transaction wrote. Inserting a donation whose ID is in failing fails.
+type fakeWebhookRepository struct {
+	donations map[string]*models.Donation
+	payouts   map[string]*models.Payout
+	emails    []*models.Email
+	failing   map[string]bool
+	numbers   int64
+	committed *fakeWebhookRepository
+}
+
+func newFakeWebhookRepository() *fakeWebhookRepository {
+	return &fakeWebhookRepository{
+		donations: make(map[string]*models.Donation),
+		payouts:   make(map[string]*models.Payout),
+		failing:   make(map[string]bool),
+	}
+}
+
+func (r *fakeWebhookRepository) DonationExists(id string) (bool, error) {
+	_, ok := r.donations[id]
+	return ok, nil
+}
+
+func (r *fakeWebhookRepository) PayoutExists(id string) (bool, error) {
+	_, ok := r.payouts[id]
+	return ok, nil
+}
+
+func (r *fakeWebhookRepository) InsertDonation(donation *models.Donation) (bool, error) {
+	if r.failing[donation.ID] {
+		return false, fmt.Errorf("insert failed")
+	}
+	if _, ok := r.donations[donation.ID]; ok {
+		return false, nil
+	}
+	r.donations[donation.ID] = donation
+	return true, nil
+}
+
+func (r *fakeWebhookRepository) UpdateRelatedPayout(donation *models.Donation) (bool, error) {
+	existing, ok := r.donations[donation.ID]
+	if ok {
+		existing.PayoutID = donation.PayoutID
+	}
+	return ok, nil
+}
+
+func (r *fakeWebhookRepository) InsertPayout(payout *models.Payout) error {
+	r.payouts[payout.ID] = payout
+	return nil
+}
+
+func (r *fakeWebhookRepository) EnqueueEmail(email *models.Email) error {
+	r.emails = append(r.emails, email)
+	return nil
+}
+
+func (r *fakeWebhookRepository) NextInvoiceNumber(series string, year int, created int64) (int64, int64, error) {
+	r.numbers++
+	return r.numbers, created, nil
+}
+
+func (r *fakeWebhookRepository) UpsertDonor(donor *models.Donor) error { return nil }
+func (r *fakeWebhookRepository) InsertFee(fee *models.Fee) error       { return nil }
+func (r *fakeWebhookRepository) InsertRefund(refund *models.Refund) error {
+	return nil
+}
+func (r *fakeWebhookRepository) UpsertDispute(dispute *models.Dispute) error { return nil }
+func (r *fakeWebhookRepository) InsertDisputeAdjustment(adjustment *models.DisputeAdjustment) error {
+	return nil
+}
+func (r *fakeWebhookRepository) UpdateRefundPayout(refund *models.Refund) (bool, error) {
+	return false, nil
+}
+func (r *fakeWebhookRepository) UpdateDisputeAdjustmentPayout(adjustment *models.DisputeAdjustment) (bool, error) {
+	return false, nil
+}
+func (r *fakeWebhookRepository) UpdatePayoutStatus(payout *models.Payout) (bool, error) {
+	return false, nil
+}
+func (r *fakeWebhookRepository) InsertPayoutStatusChange(change *models.PayoutStatusChange) error {
+	return nil
+}
+func (r *fakeWebhookRepository) ReleasePayoutTransactions(payoutID string) error { return nil }
+func (r *fakeWebhookRepository) RelinkFailedPayoutTransactions(failedPayoutID, payoutID string) error {
+	return nil
+}
+func (r *fakeWebhookRepository) GetFailedPayout(failureTransactionID string) (*models.Payout, error) {
+	return nil, fmt.Errorf("failed payout not found")
+}
+func (r *fakeWebhookRepository) BeginTransaction() error {
+	r.committed = &fakeWebhookRepository{
+		donations: maps.Clone(r.donations),
+		payouts:   maps.Clone(r.payouts),
+		emails:    slices.Clone(r.emails),
+	}
+	return nil
+}
+
+func (r *fakeWebhookRepository) Rollback() error {
+	r.donations, r.payouts, r.emails = r.committed.donations, r.committed.payouts, r.committed.emails
+	return nil
+}
+
+func (r *fakeWebhookRepository) Commit() error { return nil }
+
+func TestBackfill(t *testing.T) {
+	testCases := map[string]struct {
+		existing  []string
+		failing   []string
+		dryRun    bool
+		expected  BackfillSummary
+		donations int
+		payouts   int
+	}{
+		"emptyDatabase": {
+			expected:  BackfillSummary{DonationsInserted: 2, PayoutsInserted: 1},
+			donations: 2,
+			payouts:   1,
+		},
+		"existingSkipped": {
+			existing:  []string{"txn_charge_1", "txn_payout_1"},
+			expected:  BackfillSummary{DonationsInserted: 1, DonationsSkipped: 1, PayoutsSkipped: 1},
+			donations: 2,
+			payouts:   1,
+		},
+		"dryRunOnlyCounts": {
+			dryRun:   true,
+			expected: BackfillSummary{DonationsInserted: 2, PayoutsInserted: 1},
+		},
+		"failureRecorded": {
+			failing: []string{"txn_charge_1"},
+			expected: BackfillSummary{DonationsInserted: 1, DonationsFailed: 1, PayoutsFailed: 1,
+				Failures: []string{"charge ch_1", "payout po_1"}},
+			donations: 1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			stripeGateway, err := gateway.LoadFakeGateway("testdata/payout.json")
+			if err != nil {
+				t.Fatalf("Failed to load fixtures: %v", err)
+			}
+			repo := newFakeWebhookRepository()
+			for _, id := range tc.existing {
+				if strings.HasPrefix(id, "txn_payout") {
+					repo.payouts[id] = &models.Payout{ID: id}
+				} else {
+					repo.donations[id] = &models.Donation{ID: id}
+				}
+			}
+			for _, id := range tc.failing {
+				repo.failing[id] = true
+			}
+			numbering := NewInvoiceNumbering("HNT", nil, time.UTC)
+			service := NewBackfillService(repo, stripeGateway,
+				NewDonationService(repo, stripeGateway, numbering), NewPayoutService(repo, stripeGateway, numbering))
+
+			summary, err := service.Backfill(time.Time{}, time.Time{}, tc.dryRun)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+
+			failures := summary.Failures
+			summary.Failures = nil
+			expected := tc.expected
+			expected.Failures = nil
+			if !reflect.DeepEqual(*summary, expected) {
+				t.Errorf("Expected summary %+v, got %+v", expected, *summary)
+			}
+			if len(failures) != len(tc.expected.Failures) {
+				t.Fatalf("Expected failures %v, got %v", tc.expected.Failures, failures)
+			}
+			for i, prefix := range tc.expected.Failures {
+				if !strings.HasPrefix(failures[i], prefix) {
+					t.Errorf("Expected failure %d to be about %s, got %s", i, prefix, failures[i])
+				}
+			}
+			if len(repo.donations) != tc.donations || len(repo.payouts) != tc.payouts {
+				t.Errorf("Expected %d donations and %d payouts stored, got %d and %d", tc.donations, tc.payouts, len(repo.donations), len(repo.payouts))
+			}
+			if len(repo.emails) != 0 {
+				t.Errorf("Expected a backfill to queue no emails, got %d", len(repo.emails))
+			}
+		})
+	}
+}
+
+func TestCreatedRange(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		from     time.Time
+		to       time.Time
+		expected *stripe.RangeQueryParams
+	}{
+		"openRange": {expected: nil},
+		"fromOnly":  {from: from, expected: &stripe.RangeQueryParams{GreaterThanOrEqual: from.Unix()}},
+		"toOnly":    {to: to, expected: &stripe.RangeQueryParams{LesserThan: to.Unix()}},
+		"fullRange": {from: from, to: to, expected: &stripe.RangeQueryParams{GreaterThanOrEqual: from.Unix(), LesserThan: to.Unix()}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := createdRange(tc.from, tc.to)
+			if tc.expected == nil {
+				if result != nil {
+					t.Errorf("Expected nil range, got %+v", result)
+				}
+				return
+			}
+			if result == nil || *result != *tc.expected {
+				t.Errorf("Expected range %+v, got %+v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestReversePayouts(t *testing.T) {
+	payouts := []*stripe.Payout{{ID: "po_3"}, {ID: "po_2"}, {ID: "po_1"}}
+
+	result := reversePayouts(payouts)
+
+	for i, id := range []string{"po_1", "po_2", "po_3"} {
+		if result[i].ID != id {
+			t.Errorf("Expected payout %d to be %v, got %v", i, id, result[i].ID)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/stripe/stripe-go/v79"
+)
+
+type DisputeService struct {
+	repo    WebhookRepository
+	gateway StripeGateway
+}
+
+func NewDisputeService(repo WebhookRepository, gateway StripeGateway) *DisputeService {
+	return &DisputeService{repo: repo, gateway: gateway}
+}
+
+func (s *DisputeService) ProcessDispute(dispute *stripe.Dispute, eventID string) (err error) {
+	if err = validateDispute(dispute); err != nil {
+		return fmt.Errorf("dispute validation error: %w", err)
+	}
+	for _, transaction := range dispute.BalanceTransactions {
+		if err = validateDisputeTransaction(transaction); err != nil {
+			return fmt.Errorf("dispute transaction validation failed: %w", err)
+		}
+	}
+
+	charge, err := s.gateway.GetCharge(dispute.Charge.ID)
+	if err != nil {
+		return fmt.Errorf("disputed charge fetch failed: %w", err)
+	}
+	if err = validateCharge(charge); err != nil {
+		return fmt.Errorf("disputed charge validation failed: %w", err)
+	}
+
+	if err = s.repo.BeginTransaction(); err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			s.repo.Rollback()
+			err = fmt.Errorf("panic occurred: %v", r)
+		} else if err != nil {
+			s.repo.Rollback()
+		} else {
+			s.repo.Commit()
+		}
+	}()
+
+	disputeModel := transformDisputeDTOToModel(dispute, charge.BalanceTransaction.ID, eventID)
+	if err = s.repo.UpsertDispute(disputeModel); err != nil {
+		return fmt.Errorf("database dispute upsert failed: %w", err)
+	}
+
+	for _, transaction := range dispute.BalanceTransactions {
+		adjustmentModel := transformDisputeAdjustmentDTOToModel(transaction, dispute.ID, "", eventID)
+		if err = s.repo.InsertDisputeAdjustment(adjustmentModel); err != nil {
+			return fmt.Errorf("database dispute adjustment insertion failed: %w", err)
+		}
+	}
+	return
+}
+
+func transformDisputeDTOToModel(dispute *stripe.Dispute, donationID, eventID string) *models.Dispute {
+	return models.NewDispute(
+		dispute.ID,
+		uint64(dispute.Created),
+		dispute.Amount,
+		string(dispute.Reason),
+		string(dispute.Status),
+		donationID,
+		toNullString(eventID),
+	)
+}
+
+func transformDisputeAdjustmentDTOToModel(transaction *stripe.BalanceTransaction, disputeID, payoutID, eventID string) *models.DisputeAdjustment {
+	adjustmentType := models.DisputeWithdrawal
+	if transaction.ReportingCategory == "dispute_reversal" {
+		adjustmentType = models.DisputeReinstatement
+	}
+	return models.NewDisputeAdjustment(
+		transaction.ID,
+		adjustmentType,
+		uint64(transaction.Created),
+		abs(transaction.Amount),
+		abs(transaction.Fee),
+		abs(transaction.Net),
+		string(transaction.Currency),
+		disputeID,
+		toNullString(payoutID),
+		toNullString(eventID),
+	)
+}
+
+func transformUpdateDisputeAdjustmentDTOToModel(transactionID, payoutID string) *models.DisputeAdjustment {
+	return models.NewDisputeAdjustment(transactionID, "", 0, 0, 0, 0, "", "", sql.NullString{String: payoutID, Valid: true}, sql.NullString{Valid: false})
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func validateDispute(dispute *stripe.Dispute) error {
+	if dispute == nil {
+		return fmt.Errorf(constants.ErrDisputeMissing)
+	}
+	if dispute.ID == "" {
+		return fmt.Errorf(constants.ErrDisputeIDMissing)
+	}
+	if dispute.Status == "" {
+		return fmt.Errorf(constants.ErrDisputeStatusMissing)
+	}
+	if dispute.Charge == nil {
+		return fmt.Errorf(constants.ErrDisputeChargeMissing)
+	}
+	if dispute.Charge.ID == "" {
+		return fmt.Errorf(constants.ErrDisputeChargeIDMissing)
+	}
+	return nil
+}
+
+// validateDisputeTransaction accepts the adjustment a dispute produces: a withdrawal
+// (reporting category dispute) takes the amount and the dispute fee out of the balance,
+// a reinstatement (dispute_reversal) puts them back.
+func validateDisputeTransaction(transaction *stripe.BalanceTransaction) error {
+	if transaction == nil {
+		return fmt.Errorf(constants.ErrTransactionMissing)
+	}
+	if transaction.Type != "adjustment" {
+		return fmt.Errorf(constants.ErrDisputeTransactionTypeInvalid)
+	}
+	if transaction.ID == "" {
+		return fmt.Errorf(constants.ErrTransactionIDMissing)
+	}
+	if transaction.Created <= 0 {
+		return fmt.Errorf(constants.ErrTransactionCreatedInvalid)
+	}
+
+	switch transaction.ReportingCategory {
+	case "dispute":
+		if transaction.Amount >= 0 {
+			return fmt.Errorf(constants.ErrDisputeTransactionAmountInvalid)
+		}
+		if transaction.Net >= 0 {
+			return fmt.Errorf(constants.ErrDisputeTransactionNetInvalid)
+		}
+	case "dispute_reversal":
+		if transaction.Amount <= 0 {
+			return fmt.Errorf(constants.ErrDisputeTransactionAmountInvalid)
+		}
+		if transaction.Net <= 0 {
+			return fmt.Errorf(constants.ErrDisputeTransactionNetInvalid)
+		}
+	default:
+		return fmt.Errorf(constants.ErrDisputeTransactionCategoryInvalid)
+	}
+
+	if transaction.Source == nil {
+		return fmt.Errorf(constants.ErrDisputeTransactionSourceMissing)
+	}
+	if transaction.Source.ID == "" {
+		return fmt.Errorf(constants.ErrDisputeTransactionSourceIDMissing)
+	}
+	return nil
+}
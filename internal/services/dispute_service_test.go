@@ -0,0 +1,128 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/stripe/stripe-go/v79"
+)
+
+func TestValidateDispute(t *testing.T) {
+	testCases := map[string]struct {
+		input    *stripe.Dispute
+		expected string
+	}{
+		"validDispute": {
+			&stripe.Dispute{ID: "dp_123456", Status: "needs_response", Charge: &stripe.Charge{ID: "ch_123456789"}},
+			"",
+		},
+		"disputeMissing":  {nil, constants.ErrDisputeMissing},
+		"IDMissing":       {&stripe.Dispute{Status: "needs_response"}, constants.ErrDisputeIDMissing},
+		"statusMissing":   {&stripe.Dispute{ID: "dp_123456"}, constants.ErrDisputeStatusMissing},
+		"chargeMissing":   {&stripe.Dispute{ID: "dp_123456", Status: "needs_response"}, constants.ErrDisputeChargeMissing},
+		"chargeIDMissing": {&stripe.Dispute{ID: "dp_123456", Status: "needs_response", Charge: &stripe.Charge{}}, constants.ErrDisputeChargeIDMissing},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateDispute(tc.input)
+			if tc.expected == "" && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+			if tc.expected != "" && (err == nil || err.Error() != tc.expected) {
+				t.Errorf("Expected error: %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateDisputeTransaction(t *testing.T) {
+	source := &stripe.BalanceTransactionSource{ID: "dp_123456"}
+
+	testCases := map[string]struct {
+		input    *stripe.BalanceTransaction
+		expected string
+	}{
+		"validWithdrawal": {
+			&stripe.BalanceTransaction{ID: "txn_dp_1", Type: "adjustment", ReportingCategory: "dispute", Created: 1234567890, Amount: -1000, Fee: 1500, Net: -2500, Source: source},
+			"",
+		},
+		"validReinstatement": {
+			&stripe.BalanceTransaction{ID: "txn_dp_2", Type: "adjustment", ReportingCategory: "dispute_reversal", Created: 1234567890, Amount: 1000, Fee: -1500, Net: 2500, Source: source},
+			"",
+		},
+		"transactionMissing":  {nil, constants.ErrTransactionMissing},
+		"typeInvalid":         {&stripe.BalanceTransaction{ID: "txn_dp_1", Type: "charge"}, constants.ErrDisputeTransactionTypeInvalid},
+		"IDMissing":           {&stripe.BalanceTransaction{Type: "adjustment"}, constants.ErrTransactionIDMissing},
+		"createdInvalid":      {&stripe.BalanceTransaction{ID: "txn_dp_1", Type: "adjustment"}, constants.ErrTransactionCreatedInvalid},
+		"categoryInvalid":     {&stripe.BalanceTransaction{ID: "txn_dp_1", Type: "adjustment", ReportingCategory: "other_adjustment", Created: 1234567890}, constants.ErrDisputeTransactionCategoryInvalid},
+		"withdrawalAmount":    {&stripe.BalanceTransaction{ID: "txn_dp_1", Type: "adjustment", ReportingCategory: "dispute", Created: 1234567890, Amount: 1000}, constants.ErrDisputeTransactionAmountInvalid},
+		"withdrawalNet":       {&stripe.BalanceTransaction{ID: "txn_dp_1", Type: "adjustment", ReportingCategory: "dispute", Created: 1234567890, Amount: -1000, Net: 0}, constants.ErrDisputeTransactionNetInvalid},
+		"reinstatementAmount": {&stripe.BalanceTransaction{ID: "txn_dp_2", Type: "adjustment", ReportingCategory: "dispute_reversal", Created: 1234567890, Amount: -1000}, constants.ErrDisputeTransactionAmountInvalid},
+		"reinstatementNet":    {&stripe.BalanceTransaction{ID: "txn_dp_2", Type: "adjustment", ReportingCategory: "dispute_reversal", Created: 1234567890, Amount: 1000, Net: 0}, constants.ErrDisputeTransactionNetInvalid},
+		"sourceMissing":       {&stripe.BalanceTransaction{ID: "txn_dp_1", Type: "adjustment", ReportingCategory: "dispute", Created: 1234567890, Amount: -1000, Net: -2500}, constants.ErrDisputeTransactionSourceMissing},
+		"sourceIDMissing":     {&stripe.BalanceTransaction{ID: "txn_dp_1", Type: "adjustment", ReportingCategory: "dispute", Created: 1234567890, Amount: -1000, Net: -2500, Source: &stripe.BalanceTransactionSource{}}, constants.ErrDisputeTransactionSourceIDMissing},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateDisputeTransaction(tc.input)
+			if tc.expected == "" && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+			if tc.expected != "" && (err == nil || err.Error() != tc.expected) {
+				t.Errorf("Expected error: %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestTransformDisputeAdjustmentDTOToModel(t *testing.T) {
+	testCases := map[string]struct {
+		transaction *stripe.BalanceTransaction
+		expected    *models.DisputeAdjustment
+	}{
+		"withdrawal": {
+			transaction: &stripe.BalanceTransaction{ID: "txn_dp_1", ReportingCategory: "dispute", Created: 1627849100, Amount: -1000, Fee: 1500, Net: -2500, Currency: "ron"},
+			expected: models.NewDisputeAdjustment(
+				"txn_dp_1",
+				models.DisputeWithdrawal,
+				uint64(1627849100),
+				int64(1000),
+				int64(1500),
+				int64(2500),
+				"ron",
+				"dp_123456",
+				sql.NullString{Valid: false},
+				sql.NullString{String: "evt_123456", Valid: true},
+			),
+		},
+		"reinstatement": {
+			transaction: &stripe.BalanceTransaction{ID: "txn_dp_2", ReportingCategory: "dispute_reversal", Created: 1627849100, Amount: 1000, Fee: -1500, Net: 2500, Currency: "ron"},
+			expected: models.NewDisputeAdjustment(
+				"txn_dp_2",
+				models.DisputeReinstatement,
+				uint64(1627849100),
+				int64(1000),
+				int64(1500),
+				int64(2500),
+				"ron",
+				"dp_123456",
+				sql.NullString{Valid: false},
+				sql.NullString{String: "evt_123456", Valid: true},
+			),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := transformDisputeAdjustmentDTOToModel(tc.transaction, "dp_123456", "", "evt_123456")
+
+			if *result != *tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -2,27 +2,39 @@ package services
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/diother/go-invoices/internal/models"
 	"github.com/stripe/stripe-go/v79"
-	"github.com/stripe/stripe-go/v79/balancetransaction"
 )
 
+// errDonationStored rolls back a donation another worker stored first, giving its invoice
+// number back.
+var errDonationStored = errors.New("donation already stored")
+
 type DonationService struct {
-	repo WebhookRepository
+	repo      WebhookRepository
+	gateway   StripeGateway
+	numbering *InvoiceNumbering
 }
 
-func NewDonationService(repo WebhookRepository) *DonationService {
-	return &DonationService{repo: repo}
+func NewDonationService(repo WebhookRepository, gateway StripeGateway, numbering *InvoiceNumbering) *DonationService {
+	return &DonationService{repo: repo, gateway: gateway, numbering: numbering}
 }
 
-func (s *DonationService) ProcessDonation(charge *stripe.Charge) (err error) {
+// ProcessDonation stores the donation of a charge with its invoice number, unless its
+// payout's event, or a redelivery processed in parallel, stored it already.
+func (s *DonationService) ProcessDonation(charge *stripe.Charge, eventID string) (err error) {
 	if err = validateCharge(charge); err != nil {
 		return fmt.Errorf("Charge validation error: %w", err)
 	}
 
-	transaction, err := fetchTransaction(charge.BalanceTransaction.ID)
+	if charge, err = s.expandCustomer(charge); err != nil {
+		return fmt.Errorf("Charge fetch error: %w", err)
+	}
+
+	transaction, err := s.gateway.GetBalanceTransaction(charge.BalanceTransaction.ID)
 	if err != nil {
 		return fmt.Errorf("Transaction fetch error: %w", err)
 	}
@@ -31,31 +43,96 @@ func (s *DonationService) ProcessDonation(charge *stripe.Charge) (err error) {
 		return fmt.Errorf("Transaction validation error: %w", err)
 	}
 
-	donation := transformNoPayoutDonationDTOToModel(transaction, charge)
-	if err = s.repo.InsertDonation(donation); err != nil {
-		return fmt.Errorf("Database donation insertion failed: %w", err)
+	if err = s.repo.BeginTransaction(); err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
-	return
-}
+	defer func() {
+		if r := recover(); r != nil {
+			s.repo.Rollback()
+			err = fmt.Errorf("panic occurred: %v", r)
+		} else if errors.Is(err, errDonationStored) {
+			s.repo.Rollback()
+			err = nil
+		} else if err != nil {
+			s.repo.Rollback()
+		} else {
+			s.repo.Commit()
+		}
+	}()
 
-func fetchTransaction(id string) (*stripe.BalanceTransaction, error) {
-	params := &stripe.BalanceTransactionParams{}
-	transaction, err := balancetransaction.Get(id, params)
+	exists, err := s.repo.DonationExists(transaction.ID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if exists {
+		return errDonationStored
+	}
+	donation := transformNoPayoutDonationDTOToModel(transaction, charge, eventID)
+	if err = s.numbering.Assign(s.repo, donation); err != nil {
+		return err
+	}
+	if err = assignDonor(s.repo, donation); err != nil {
+		return err
+	}
+	inserted, err := s.repo.InsertDonation(donation)
+	if err != nil {
+		return fmt.Errorf("Database donation insertion failed: %w", err)
 	}
-	return transaction, nil
+	if !inserted {
+		return errDonationStored
+	}
+	return enqueueInvoiceEmail(s.repo, donation)
 }
 
-func transformNoPayoutDonationDTOToModel(transaction *stripe.BalanceTransaction, charge *stripe.Charge) *models.Donation {
-	return models.NewDonation(
+func transformNoPayoutDonationDTOToModel(transaction *stripe.BalanceTransaction, charge *stripe.Charge, eventID string) *models.Donation {
+	donation := models.NewDonation(
 		transaction.ID,
 		uint64(transaction.Created),
-		uint32(transaction.Amount),
-		uint32(transaction.Fee),
-		uint32(transaction.Net),
+		transaction.Amount,
+		transaction.Fee,
+		transaction.Net,
+		string(transaction.Currency),
 		charge.BillingDetails.Name,
 		charge.BillingDetails.Email,
+		sql.NullInt64{Int64: charge.Amount, Valid: true},
+		toNullString(string(charge.Currency)),
+		toNullFloat64(transaction.ExchangeRate),
 		sql.NullString{Valid: false},
+		toNullString(eventID),
 	)
+	applyBillingAddress(donation, charge.BillingDetails.Address)
+	applyCustomerLocale(donation, charge.Customer)
+	return donation
+}
+
+// expandCustomer fetches the charge again when the webhook carried only the customer ID,
+// so the donor's preferred locale is known.
+func (s *DonationService) expandCustomer(charge *stripe.Charge) (*stripe.Charge, error) {
+	if charge.Customer == nil || charge.Customer.Object != "" {
+		return charge, nil
+	}
+	return s.gateway.GetCharge(charge.ID)
+}
+
+// applyBillingAddress keeps the billing address of the charge, which e-Factura requires
+// for the buyer.
+func applyBillingAddress(donation *models.Donation, address *stripe.Address) {
+	if address == nil {
+		return
+	}
+	donation.ClientAddress1 = toNullString(address.Line1)
+	donation.ClientAddress2 = toNullString(address.Line2)
+	donation.ClientCity = toNullString(address.City)
+	donation.ClientState = toNullString(address.State)
+	donation.ClientPostalCode = toNullString(address.PostalCode)
+	donation.ClientCountry = toNullString(address.Country)
+}
+
+// applyCustomerLocale keeps the customer's first preferred locale, which picks the
+// language of the donor's documents.
+func applyCustomerLocale(donation *models.Donation, customer *stripe.Customer) {
+	if customer == nil || len(customer.PreferredLocales) == 0 {
+		return
+	}
+	donation.Locale = toNullString(customer.PreferredLocales[0])
 }
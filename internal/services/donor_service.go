@@ -0,0 +1,199 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
+)
+
+// donorListLimit caps the donor list; a search narrows it down.
+const donorListLimit = 100
+
+type DonorRepository interface {
+	GetDonor(id int64) (*models.Donor, error)
+	GetDonorByEmail(email string) (*models.Donor, error)
+	GetDonorEmailAliases(donorID int64) ([]string, error)
+	GetDonors(search string, limit int) ([]*models.DonorSummary, error)
+	GetDonationsByDonor(donorID int64) ([]*models.Donation, error)
+	RenameDonor(actor models.Actor, id int64, name string) error
+	MergeDonors(actor models.Actor, donorID, duplicateID int64) error
+}
+
+// DonorService shows donors with their giving history, and lets admins correct names and
+// merge duplicates.
+type DonorService struct {
+	repo     DonorRepository
+	location *time.Location
+}
+
+func NewDonorService(repo DonorRepository, location *time.Location) *DonorService {
+	return &DonorService{repo: repo, location: location}
+}
+
+// ListDonors lists the donors whose name or email contains search, by name.
+func (s *DonorService) ListDonors(search string) ([]*dto.FormattedDonorSummary, error) {
+	donorModels, err := s.repo.GetDonors(strings.TrimSpace(search), donorListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database donors fetch failed: %w", err)
+	}
+	return transformDonorSummaryModelsToDTOs(donorModels, s.location), nil
+}
+
+// Donor shows the donor with their donations, described in lang.
+func (s *DonorService) Donor(id, lang string) (*dto.DonorDetail, error) {
+	donor, err := s.donor(id)
+	if err != nil {
+		return nil, err
+	}
+	emails, err := s.repo.GetDonorEmailAliases(donor.ID)
+	if err != nil {
+		return nil, fmt.Errorf("database donor emails fetch failed: %w", err)
+	}
+	donationModels, err := s.repo.GetDonationsByDonor(donor.ID)
+	if err != nil {
+		return nil, fmt.Errorf("database donor donations fetch failed: %w", err)
+	}
+	return transformToDonorDetail(donor, emails, donationModels, s.location, lang)
+}
+
+// RenameDonor corrects the donor's name on the donor and on all of their donations. The
+// repository audits the rename with it.
+func (s *DonorService) RenameDonor(actor models.Actor, id, name string) error {
+	donor, err := s.donor(id)
+	if err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return custom_errors.NewValidationError(constants.ErrDonorNameMissing)
+	}
+	if err = s.repo.RenameDonor(actor, donor.ID, name); err != nil {
+		return fmt.Errorf("database donor rename failed: %w", err)
+	}
+	return nil
+}
+
+// MergeDonor merges the donor known by duplicateEmail into the donor with the ID. The
+// duplicate's donations and emails move over, and its donor is deleted. The repository
+// audits the merge with it, for the duplicate, with the donor it was merged into.
+func (s *DonorService) MergeDonor(actor models.Actor, id, duplicateEmail string) error {
+	donor, err := s.donor(id)
+	if err != nil {
+		return err
+	}
+	duplicate, err := s.repo.GetDonorByEmail(normaliseDonorEmail(duplicateEmail))
+	if err != nil {
+		return fmt.Errorf("database donor fetch failed: %w", err)
+	}
+	if duplicate == nil {
+		return custom_errors.NewValidationError(constants.ErrDonorEmailUnknown, duplicateEmail)
+	}
+	if duplicate.ID == donor.ID {
+		return custom_errors.NewValidationError(constants.ErrDonorMergeSelf)
+	}
+	if err = s.repo.MergeDonors(actor, donor.ID, duplicate.ID); err != nil {
+		return fmt.Errorf("database donor merge failed: %w", err)
+	}
+	return nil
+}
+
+func (s *DonorService) donor(id string) (*models.Donor, error) {
+	donorID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, custom_errors.NewValidationError(constants.ErrDonorNotFound, id)
+	}
+	donor, err := s.repo.GetDonor(donorID)
+	if err != nil {
+		return nil, fmt.Errorf("database donor fetch failed: %w", err)
+	}
+	if donor == nil {
+		return nil, custom_errors.NewValidationError(constants.ErrDonorNotFound, id)
+	}
+	return donor, nil
+}
+
+// assignDonor links a donation to the donor of its email within the repository's open
+// transaction, recording the donor on their first donation. Donations without an email
+// stay anonymous.
+func assignDonor(repo WebhookRepository, donation *models.Donation) error {
+	email := normaliseDonorEmail(donation.ClientEmail)
+	if email == "" {
+		return nil
+	}
+	donor := models.NewDonor(donation.ClientName, email, int64(donation.Created))
+	if err := repo.UpsertDonor(donor); err != nil {
+		return fmt.Errorf("database donor upsert failed: %w", err)
+	}
+	donation.DonorID = sql.NullInt64{Int64: donor.ID, Valid: true}
+	return nil
+}
+
+// normaliseDonorEmail is how donor emails are matched: trimmed and lowercased, as the
+// migration that introduced donors matched the existing donations.
+func normaliseDonorEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func transformDonorSummaryModelsToDTOs(donorModels []*models.DonorSummary, location *time.Location) (donors []*dto.FormattedDonorSummary) {
+	for _, donor := range donorModels {
+		lastGift := ""
+		if donor.LastGift.Valid {
+			lastGift = formatDate(uint64(donor.LastGift.Int64), location)
+		}
+		donors = append(donors, dto.NewFormattedDonorSummary(strconv.FormatInt(donor.ID, 10), donor.Name, donor.Email, donor.Gifts, lastGift))
+	}
+	return
+}
+
+// transformToDonorDetail sums the donations per currency they settled in, since a donor
+// may have given in several.
+func transformToDonorDetail(donor *models.Donor, emails []string, donationModels []*models.Donation, location *time.Location, lang string) (*dto.DonorDetail, error) {
+	totals := make(map[string]money.Money)
+	var donations []*dto.FormattedDonation
+	var err error
+	for i := len(donationModels) - 1; i >= 0; i-- {
+		donation := donationModels[i]
+		total, ok := totals[donation.Currency]
+		if !ok {
+			total = money.New(0, donation.Currency)
+		}
+		if totals[donation.Currency], err = total.Add(money.New(donation.Gross, donation.Currency)); err != nil {
+			return nil, fmt.Errorf("donor total failed: %w", err)
+		}
+		donations = append(donations, transformDonationModelToDTO(donation, location, lang))
+	}
+
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	formattedTotals := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		formattedTotals = append(formattedTotals, totals[currency].String())
+	}
+
+	firstGift, lastGift := "", ""
+	if len(donationModels) > 0 {
+		firstGift = formatDate(donationModels[0].Created, location)
+		lastGift = formatDate(donationModels[len(donationModels)-1].Created, location)
+	}
+	return dto.NewDonorDetail(strconv.FormatInt(donor.ID, 10), donor.Name, donor.Email, emails, len(donationModels), formattedTotals, firstGift, lastGift, donations), nil
+}
+
+// formatDonorID returns an empty ID for anonymous donations.
+func formatDonorID(donorID sql.NullInt64) string {
+	if !donorID.Valid {
+		return ""
+	}
+	return strconv.FormatInt(donorID.Int64, 10)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
+)
+
+func TestTransformToDonorDetail(t *testing.T) {
+	donor := &models.Donor{ID: 7, Name: "Ion Popescu", Email: "ion@example.com"}
+	donations := []*models.Donation{
+		{ID: "txn_1", Created: 1700000000, Gross: 10000, Currency: "ron"},
+		{ID: "txn_2", Created: 1710000000, Gross: 2000, Currency: "eur"},
+		{ID: "txn_3", Created: 1727000000, Gross: 5000, Currency: "ron"},
+	}
+
+	detail, err := transformToDonorDetail(donor, []string{"ion@example.com"}, donations, time.UTC, "ro")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	expectedTotals := []string{money.New(2000, "eur").String(), money.New(15000, "ron").String()}
+	if strings.Join(detail.Totals, "|") != strings.Join(expectedTotals, "|") {
+		t.Errorf("Expected totals %v, got %v", expectedTotals, detail.Totals)
+	}
+	if detail.ID != "7" || detail.Gifts != 3 {
+		t.Errorf("Expected donor 7 with 3 gifts, got %v with %d", detail.ID, detail.Gifts)
+	}
+	if detail.FirstGift != formatDate(1700000000, time.UTC) || detail.LastGift != formatDate(1727000000, time.UTC) {
+		t.Errorf("Expected first and last gift of txn_1 and txn_3, got %v and %v", detail.FirstGift, detail.LastGift)
+	}
+	if detail.Donations[0].ID != "txn_3" || detail.Donations[2].ID != "txn_1" {
+		t.Errorf("Expected donations newest first, got %v first", detail.Donations[0].ID)
+	}
+}
+
+// fakeDonorRecorder is the webhook repository as assignDonor uses it.
+type fakeDonorRecorder struct {
+	WebhookRepository
+	upserted []*models.Donor
+}
+
+func (r *fakeDonorRecorder) UpsertDonor(donor *models.Donor) error {
+	donor.ID = int64(len(r.upserted) + 1)
+	r.upserted = append(r.upserted, donor)
+	return nil
+}
+
+func TestAssignDonor(t *testing.T) {
+	repo := &fakeDonorRecorder{}
+
+	anonymous := &models.Donation{ID: "txn_1", ClientName: "Anonim", ClientEmail: " "}
+	if err := assignDonor(repo, anonymous); err != nil || anonymous.DonorID.Valid || len(repo.upserted) != 0 {
+		t.Errorf("Expected a donation without an email to stay anonymous, got %v and %v", anonymous.DonorID, err)
+	}
+
+	donation := &models.Donation{ID: "txn_2", Created: 1727000000, ClientName: "Ion", ClientEmail: " Ion@Example.com "}
+	if err := assignDonor(repo, donation); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(repo.upserted) != 1 || repo.upserted[0].Email != "ion@example.com" || repo.upserted[0].Created != 1727000000 {
+		t.Fatalf("Expected the donor upserted by normalised email, got %+v", repo.upserted)
+	}
+	if donation.DonorID.Int64 != 1 || !donation.DonorID.Valid {
+		t.Errorf("Expected the donation linked to donor 1, got %v", donation.DonorID)
+	}
+}
+
+type fakeDonorRepository struct {
+	DonorRepository
+	donors map[string]*models.Donor
+	merged [2]int64
+	actor  models.Actor
+}
+
+func (r *fakeDonorRepository) GetDonor(id int64) (*models.Donor, error) {
+	for _, donor := range r.donors {
+		if donor.ID == id {
+			return donor, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeDonorRepository) GetDonorByEmail(email string) (*models.Donor, error) {
+	return r.donors[email], nil
+}
+
+func (r *fakeDonorRepository) MergeDonors(actor models.Actor, donorID, duplicateID int64) error {
+	r.merged, r.actor = [2]int64{donorID, duplicateID}, actor
+	return nil
+}
+
+func TestMergeDonor(t *testing.T) {
+	repo := &fakeDonorRepository{donors: map[string]*models.Donor{
+		"ion@example.com":         {ID: 1, Name: "Ion Popescu"},
+		"ion.popescu@example.com": {ID: 2, Name: "Ion Popescu"},
+	}}
+	service := NewDonorService(repo, time.UTC)
+
+	testCases := map[string]struct {
+		id          string
+		duplicate   string
+		expectedErr string
+	}{
+		"merged":       {id: "1", duplicate: " Ion.Popescu@example.com"},
+		"self":         {id: "1", duplicate: "ion@example.com", expectedErr: constants.ErrDonorMergeSelf},
+		"unknownEmail": {id: "1", duplicate: "maria@example.com", expectedErr: `no donor uses the email "maria@example.com"`},
+		"unknownDonor": {id: "9", duplicate: "ion@example.com", expectedErr: "donor 9 was not found"},
+		"invalidID":    {id: "x", duplicate: "ion@example.com", expectedErr: "donor x was not found"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			repo.merged, repo.actor = [2]int64{}, models.Actor{}
+			err := service.MergeDonor(models.Actor{Username: "admin", IP: "192.0.2.1"}, tc.id, tc.duplicate)
+			if tc.expectedErr != "" {
+				var validationError *custom_errors.ValidationError
+				if !errors.As(err, &validationError) || err.Error() != tc.expectedErr {
+					t.Fatalf("Expected validation error %q, got %v", tc.expectedErr, err)
+				}
+				if repo.merged != [2]int64{} {
+					t.Errorf("Expected no merge, got %v", repo.merged)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if repo.merged != [2]int64{1, 2} {
+				t.Errorf("Expected donor 2 merged into 1, got %v", repo.merged)
+			}
+			if repo.actor.Username != "admin" || repo.actor.IP != "192.0.2.1" {
+				t.Errorf("Expected the merge audited for admin, got %+v", repo.actor)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"fmt"
+	"net/mail"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
+	"github.com/signintech/gopdf"
+)
+
+// GenerateDonorStatement renders the statement of every donation the donor behind email
+// made in the calendar year, from any of their merged emails, with the refunds of that
+// year, in lang or in the donor's language when lang is empty. Statements are generated
+// on request and not archived, since the current year's statement grows with every
+// donation.
+func (s *AccountingService) GenerateDonorStatement(email, year, lang string) (pdf *gopdf.GoPdf, err error) {
+	start, end, err := validateStatementRequest(email, year, s.location)
+	if err != nil {
+		return nil, err
+	}
+	donationModels, err := s.repo.GetDonorDonations(email, start.Unix(), end.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("fetch donor donations failed: %w", err)
+	}
+	refundModels, err := s.repo.GetDonorRefunds(email, start.Unix(), end.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("fetch donor refunds failed: %w", err)
+	}
+	if len(donationModels) == 0 && len(refundModels) == 0 {
+		return nil, custom_errors.NewValidationError(constants.ErrStatementEmpty, email, year)
+	}
+
+	// Refunds may credit a donation from an earlier year, which the statement still names.
+	refunded := make(map[string]*models.Donation, len(donationModels))
+	for _, donationModel := range donationModels {
+		refunded[donationModel.ID] = donationModel
+	}
+	for _, refundModel := range refundModels {
+		if _, ok := refunded[refundModel.DonationID]; ok {
+			continue
+		}
+		if refunded[refundModel.DonationID], err = s.repo.GetDonation(refundModel.DonationID); err != nil {
+			return nil, fmt.Errorf("fetch refunded donation failed: %w", err)
+		}
+	}
+
+	locale := ""
+	if len(donationModels) > 0 {
+		locale = donationModels[len(donationModels)-1].Locale.String
+	}
+	lang = i18n.Resolve(lang, locale)
+	statementData, err := transformToDonorStatementData(start, end, donationModels, refundModels, refunded, s.location, lang)
+	if err != nil {
+		return nil, err
+	}
+	organisation, err := s.organisation()
+	if err != nil {
+		return nil, err
+	}
+	layout, err := s.layout("donor_statement")
+	if err != nil {
+		return nil, err
+	}
+
+	pdf, err = s.document.GenerateDonorStatement(statementData, organisation, layout, lang)
+	if err != nil {
+		return nil, fmt.Errorf("generate donor statement failed: %w", err)
+	}
+	return
+}
+
+// transformToDonorStatementData lists donations and refunds by date, summing them per
+// currency they settled in, since a donor may have given in several. The donor is named
+// as on their latest donation.
+func transformToDonorStatementData(start, end time.Time, donationModels []*models.Donation, refundModels []*models.Refund, refunded map[string]*models.Donation, location *time.Location, lang string) (*dto.DonorStatementData, error) {
+	var latest *models.Donation
+	if len(donationModels) > 0 {
+		latest = donationModels[len(donationModels)-1]
+	} else {
+		latest = refunded[refundModels[len(refundModels)-1].DonationID]
+	}
+	gross, refundedTotals := make(map[string]money.Money), make(map[string]money.Money)
+
+	var items []*dto.DonorStatementItem
+	i, j := 0, 0
+	for i < len(donationModels) || j < len(refundModels) {
+		if j == len(refundModels) || (i < len(donationModels) && donationModels[i].Created <= refundModels[j].Created) {
+			donation := donationModels[i]
+			if err := addToTotal(gross, money.New(donation.Gross, donation.Currency)); err != nil {
+				return nil, fmt.Errorf("statement donations sum failed: %w", err)
+			}
+			items = append(items, dto.NewDonorStatementItem(
+				donation.ID,
+				"donation",
+				formatInvoiceNumber(donation),
+				formatDate(donation.Created, location),
+				money.New(donation.Gross, donation.Currency).String(),
+				formatConversion(donation, lang),
+			))
+			i++
+			continue
+		}
+		refund := refundModels[j]
+		if err := addToTotal(refundedTotals, money.New(refund.Gross, refund.Currency)); err != nil {
+			return nil, fmt.Errorf("statement refunds sum failed: %w", err)
+		}
+		items = append(items, dto.NewDonorStatementItem(
+			refund.ID,
+			"refund",
+			formatInvoiceNumber(refunded[refund.DonationID]),
+			formatDate(refund.Created, location),
+			money.New(refund.Gross, refund.Currency).String(),
+			"",
+		))
+		j++
+	}
+
+	currencies := make([]string, 0, len(gross)+len(refundedTotals))
+	for _, totals := range []map[string]money.Money{gross, refundedTotals} {
+		for currency := range totals {
+			if !slices.Contains(currencies, currency) {
+				currencies = append(currencies, currency)
+			}
+		}
+	}
+	sort.Strings(currencies)
+	var formattedGross, formattedRefunded, formattedNet []string
+	for _, currency := range currencies {
+		donated, ok := gross[currency]
+		if !ok {
+			donated = money.New(0, currency)
+		}
+		refundedTotal, ok := refundedTotals[currency]
+		if !ok {
+			refundedTotal = money.New(0, currency)
+		}
+		net, err := donated.Sub(refundedTotal)
+		if err != nil {
+			return nil, fmt.Errorf("statement net failed: %w", err)
+		}
+		formattedGross = append(formattedGross, donated.String())
+		formattedRefunded = append(formattedRefunded, refundedTotal.String())
+		formattedNet = append(formattedNet, net.String())
+	}
+
+	return dto.NewDonorStatementData(
+		start.Format("2006"),
+		latest.ClientName,
+		latest.ClientEmail,
+		start.Format("2 Jan, 2006"),
+		end.Add(-time.Second).Format("2 Jan, 2006"),
+		time.Now().In(location).Format("2 Jan, 2006"),
+		len(donationModels),
+		strings.Join(formattedGross, ", "),
+		strings.Join(formattedRefunded, ", "),
+		strings.Join(formattedNet, ", "),
+		items,
+	), nil
+}
+
+// addToTotal adds amount to the total of its currency.
+func addToTotal(totals map[string]money.Money, amount money.Money) error {
+	total, ok := totals[amount.Currency]
+	if !ok {
+		total = money.New(0, amount.Currency)
+	}
+	sum, err := total.Add(amount)
+	if err != nil {
+		return err
+	}
+	totals[amount.Currency] = sum
+	return nil
+}
+
+// validateStatementRequest returns the local calendar year as [start, end). The email must
+// be a bare address, as donations store it.
+func validateStatementRequest(email, year string, location *time.Location) (start, end time.Time, err error) {
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return time.Time{}, time.Time{}, custom_errors.NewValidationError(constants.ErrStatementEmailInvalid, email)
+	}
+	start, err = parseStatementYear(year, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, start.AddDate(1, 0, 0), nil
+}
+
+func parseStatementYear(year string, location *time.Location) (time.Time, error) {
+	value, err := strconv.Atoi(year)
+	if err != nil || len(year) != 4 || value < 2000 {
+		return time.Time{}, custom_errors.NewValidationError(constants.ErrStatementYearInvalid, year)
+	}
+	return time.Date(value, time.January, 1, 0, 0, 0, 0, location), nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
+)
+
+func TestValidateStatementRequest(t *testing.T) {
+	testCases := map[string]struct {
+		email       string
+		year        string
+		expectError bool
+	}{
+		"valid":        {email: "ion@example.com", year: "2024"},
+		"badEmail":     {email: "ion", year: "2024", expectError: true},
+		"emptyEmail":   {email: "", year: "2024", expectError: true},
+		"shortYear":    {email: "ion@example.com", year: "24", expectError: true},
+		"notYear":      {email: "ion@example.com", year: "2024-01", expectError: true},
+		"ancientYear":  {email: "ion@example.com", year: "1999", expectError: true},
+		"missingYear":  {email: "ion@example.com", year: "", expectError: true},
+		"upperCase":    {email: "Ion@Example.com", year: "2025"},
+		"plusAddress":  {email: "ion+donations@example.com", year: "2024"},
+		"displayName":  {email: "Ion <ion@example.com>", year: "2024", expectError: true},
+		"spacesInYear": {email: "ion@example.com", year: " 2024", expectError: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := validateStatementRequest(tc.email, tc.year, time.UTC)
+
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error, but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestTransformToDonorStatementData(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+	earlier := &models.Donation{ID: "txn_0", Created: 1700000000, Gross: 3000, Currency: "ron", ClientName: "Ion Popescu"}
+	donations := []*models.Donation{
+		{ID: "txn_1", Created: 1710000000, Gross: 10000, Currency: "ron", ClientName: "Ion Popescu"},
+		{ID: "txn_2", Created: 1720000000, Gross: 5000, Currency: "ron", ClientName: "Ion Popescu-Ionescu", ClientEmail: "ion@example.com"},
+	}
+	refunds := []*models.Refund{
+		{ID: "txn_3", Created: 1705000000, Gross: 3000, Currency: "ron", DonationID: "txn_0"},
+		{ID: "txn_4", Created: 1715000000, Gross: 2000, Currency: "ron", DonationID: "txn_1"},
+	}
+	refunded := map[string]*models.Donation{"txn_0": earlier, "txn_1": donations[0], "txn_2": donations[1]}
+
+	data, err := transformToDonorStatementData(start, end, donations, refunds, refunded, time.UTC, "ro")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	var order []string
+	for _, item := range data.Items {
+		order = append(order, item.ID)
+	}
+	expectedOrder := []string{"txn_3", "txn_1", "txn_4", "txn_2"}
+	if len(order) != len(expectedOrder) {
+		t.Fatalf("Expected items %v, got %v", expectedOrder, order)
+	}
+	for i := range order {
+		if order[i] != expectedOrder[i] {
+			t.Fatalf("Expected items %v, got %v", expectedOrder, order)
+		}
+	}
+	if data.Count != 2 || data.Gross != "150,00 lei" || data.Refunded != "50,00 lei" || data.Net != "100,00 lei" {
+		t.Errorf("Expected 2 donations of 150,00 lei, 50,00 lei refunded and 100,00 lei net, got %d, %v, %v and %v", data.Count, data.Gross, data.Refunded, data.Net)
+	}
+	if data.ClientName != "Ion Popescu-Ionescu" {
+		t.Errorf("Expected the latest donor name, got %v", data.ClientName)
+	}
+	if data.PeriodStart != "1 Jan, 2024" || data.PeriodEnd != "31 Dec, 2024" {
+		t.Errorf("Expected the calendar year, got %v - %v", data.PeriodStart, data.PeriodEnd)
+	}
+}
+
+func TestTransformToDonorStatementDataMixedCurrencies(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	donations := []*models.Donation{
+		{ID: "txn_1", Created: 1710000000, Gross: 10000, Currency: "ron", ClientName: "Ion Popescu"},
+		{ID: "txn_2", Created: 1720000000, Gross: 2000, Currency: "eur", ClientName: "Ion Popescu"},
+	}
+	refunds := []*models.Refund{{ID: "txn_3", Created: 1725000000, Gross: 500, Currency: "eur", DonationID: "txn_2"}}
+	refunded := map[string]*models.Donation{"txn_1": donations[0], "txn_2": donations[1]}
+
+	data, err := transformToDonorStatementData(start, start.AddDate(1, 0, 0), donations, refunds, refunded, time.UTC, "ro")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	gross := money.New(2000, "eur").String() + ", " + money.New(10000, "ron").String()
+	refundedTotal := money.New(500, "eur").String() + ", " + money.New(0, "ron").String()
+	net := money.New(1500, "eur").String() + ", " + money.New(10000, "ron").String()
+	if data.Gross != gross || data.Refunded != refundedTotal || data.Net != net {
+		t.Errorf("Expected totals per currency %v, %v and %v, got %v, %v and %v", gross, refundedTotal, net, data.Gross, data.Refunded, data.Net)
+	}
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/database"
+	"github.com/diother/go-invoices/database/dbtest"
+	"github.com/diother/go-invoices/internal/documents"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/gateway"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/repository"
+	"github.com/diother/go-invoices/internal/storage"
+	"github.com/jmoiron/sqlx"
+	"github.com/stripe/stripe-go/v79"
+)
+
+// TestPayoutEndToEnd runs webhook events through the services into a fresh database
+// and renders the resulting documents, with Stripe served from fixtures. It runs once per
+// database engine.
+func TestPayoutEndToEnd(t *testing.T) {
+	for driver, dsn := range dbtest.DSNs(t) {
+		t.Run(driver, func(t *testing.T) {
+			testPayoutEndToEnd(t, setupEndToEnd(t, dsn))
+		})
+	}
+}
+
+func testPayoutEndToEnd(t *testing.T, db *sqlx.DB) {
+	webhookRepo := repository.NewWebhookRepository(db)
+	stripeGateway, err := gateway.LoadFakeGateway("internal/services/testdata/payout.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+	processor := NewWebhookEventProcessor(webhookRepo, stripeGateway, NewInvoiceNumbering("HNT", nil, time.UTC))
+
+	charge, err := stripeGateway.GetCharge("ch_1")
+	if err != nil {
+		t.Fatalf("Failed to get charge: %v", err)
+	}
+	if err = processor.ProcessEvent(newTestEvent(t, webhookRepo, "evt_charge_1", "charge.updated", charge)); err != nil {
+		t.Fatalf("Failed to process charge event: %v", err)
+	}
+
+	payout := &stripe.Payout{ID: "po_1", Status: stripe.PayoutStatusPaid}
+	if err = processor.ProcessEvent(newTestEvent(t, webhookRepo, "evt_payout_1", "payout.reconciliation_completed", payout)); err != nil {
+		t.Fatalf("Failed to process payout event: %v", err)
+	}
+
+	pwaRepo := repository.NewPWARepository(db)
+	savedPayout, err := pwaRepo.GetPayout("txn_payout_1")
+	if err != nil {
+		t.Fatalf("Failed to get payout: %v", err)
+	}
+	if savedPayout.Gross != 15000 || savedPayout.Fee != 575 || savedPayout.Net != 14425 {
+		t.Errorf("Expected payout 15000/575/14425, got %v/%v/%v", savedPayout.Gross, savedPayout.Fee, savedPayout.Net)
+	}
+
+	donations, err := pwaRepo.GetRelatedDonations("txn_payout_1")
+	if err != nil {
+		t.Fatalf("Failed to get donations: %v", err)
+	}
+	if len(donations) != 2 {
+		t.Fatalf("Expected 2 donations, got %d", len(donations))
+	}
+
+	donation, err := pwaRepo.GetDonation("txn_charge_1")
+	if err != nil {
+		t.Fatalf("Failed to get donation: %v", err)
+	}
+	if donation.EventID.String != "evt_charge_1" {
+		t.Errorf("Expected donation to keep event %v, got %v", "evt_charge_1", donation.EventID)
+	}
+	if number := formatInvoiceNumber(donation); number != "HNT-2024-000001" {
+		t.Errorf("Expected invoice number %v, got %v", "HNT-2024-000001", number)
+	}
+	if !donation.DonorID.Valid {
+		t.Errorf("Expected donation to be linked to its donor")
+	}
+	donor, err := NewDonorService(pwaRepo, time.UTC).Donor(formatDonorID(donation.DonorID), "")
+	if err != nil {
+		t.Fatalf("Failed to get donor: %v", err)
+	}
+	if donor.Gifts == 0 || donor.Donations[len(donor.Donations)-1].ID != "txn_charge_1" {
+		t.Errorf("Expected the donor's history to include txn_charge_1, got %+v", donor.Donations)
+	}
+	results, err := NewSearchService(pwaRepo, time.UTC).Search("HNT-2024-000001", "", "", "", "")
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "txn_charge_1" || results[0].PayoutID != "txn_payout_1" {
+		t.Errorf("Expected the invoice number to find txn_charge_1 in txn_payout_1, got %+v", results)
+	}
+	audit := NewAuditService(pwaRepo, time.UTC)
+	entries, err := audit.ListEntries("", "", "donation", "txn_charge_1", "", "")
+	if err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Action != models.AuditInsert || entries[0].Action != models.AuditUpdate || entries[0].Username != "stripe" {
+		t.Fatalf("Expected Stripe's insert and payout update of txn_charge_1 audited, got %+v", entries)
+	}
+	if changes := entries[0].Changes; len(changes) != 1 || changes[0].Column != "payout_id" || changes[0].Before != "" || changes[0].After != "txn_payout_1" {
+		t.Errorf("Expected the payout of txn_charge_1 audited as set, got %+v", changes)
+	}
+	if donation.Locale.String != "en-GB" {
+		t.Errorf("Expected donation to keep the customer locale %v, got %v", "en-GB", donation.Locale)
+	}
+
+	fees, err := pwaRepo.GetRelatedFees("txn_payout_1")
+	if err != nil {
+		t.Fatalf("Failed to get fees: %v", err)
+	}
+	if len(fees) != 1 {
+		t.Errorf("Expected 1 fee, got %d", len(fees))
+	}
+
+	converted, err := pwaRepo.GetDonation("txn_charge_2")
+	if err != nil {
+		t.Fatalf("Failed to get donation: %v", err)
+	}
+	if converted.Currency != "ron" || converted.OriginalCurrency.String != "eur" || converted.OriginalAmount.Int64 != 1006 {
+		t.Errorf("Expected ron donation converted from 1006 eur, got %v from %v %v", converted.Currency, converted.OriginalAmount, converted.OriginalCurrency)
+	}
+	if number := formatInvoiceNumber(converted); number != "HNT-2024-000002" {
+		t.Errorf("Expected invoice number %v, got %v", "HNT-2024-000002", number)
+	}
+
+	// The payout's event stored txn_charge_2 first; its charge event arriving late changes nothing.
+	lateCharge, err := stripeGateway.GetCharge("ch_2")
+	if err != nil {
+		t.Fatalf("Failed to get charge: %v", err)
+	}
+	if err = processor.ProcessEvent(newTestEvent(t, webhookRepo, "evt_charge_2", "charge.updated", lateCharge)); err != nil {
+		t.Fatalf("Expected a charge event after its payout's to succeed, got %v", err)
+	}
+	unchanged, err := pwaRepo.GetDonation("txn_charge_2")
+	if err != nil {
+		t.Fatalf("Failed to get donation: %v", err)
+	}
+	if formatInvoiceNumber(unchanged) != "HNT-2024-000002" || unchanged.EventID != converted.EventID {
+		t.Errorf("Expected txn_charge_2 to keep invoice HNT-2024-000002 and event %v, got %v and %v", converted.EventID, formatInvoiceNumber(unchanged), unchanged.EventID)
+	}
+	if number, _, err := webhookRepo.NextInvoiceNumber("HNT", 2024, 1727300000); err != nil || number != 3 {
+		t.Errorf("Expected no invoice number used by the late charge event, got next number %d and %v", number, err)
+	}
+
+	// The seeded profile has no CUI, which e-Factura requires.
+	admin := models.Actor{Username: "admin", IP: "192.0.2.1"}
+	organisation := NewOrganisationService(pwaRepo, t.TempDir())
+	if err = organisation.UpdateOrganisation(admin, testOrganisation(), nil, nil); err != nil {
+		t.Fatalf("Failed to update organisation: %v", err)
+	}
+
+	accounting := NewAccountingService(pwaRepo, documents.NewDocumentService(), time.UTC)
+	invoice, err := accounting.GenerateInvoice("txn_charge_2", "")
+	if err != nil {
+		t.Fatalf("Failed to generate invoice: %v", err)
+	}
+	assertPDF(t, invoice.GetBytesPdf())
+
+	for _, lang := range []string{"", i18n.Romanian} {
+		invoice, err = accounting.GenerateInvoice("txn_charge_1", lang)
+		if err != nil {
+			t.Fatalf("Failed to generate invoice in %q: %v", lang, err)
+		}
+		assertPDF(t, invoice.GetBytesPdf())
+	}
+
+	eInvoice, err := accounting.GenerateEInvoice("txn_charge_2")
+	if err != nil {
+		t.Fatalf("Failed to generate e-invoice: %v", err)
+	}
+	for _, expected := range []string{"<cbc:ID>HNT-2024-000002</cbc:ID>", "<cbc:CityName>SECTOR3</cbc:CityName>", "<cbc:CountrySubentity>RO-B</cbc:CountrySubentity>"} {
+		if !bytes.Contains(eInvoice, []byte(expected)) {
+			t.Errorf("Expected e-invoice to contain %v", expected)
+		}
+	}
+	if _, err = accounting.GenerateEInvoice("txn_charge_1"); err == nil {
+		t.Errorf("Expected e-invoice without a buyer address to fail validation")
+	}
+
+	report, err := accounting.GeneratePayoutReport("txn_payout_1", i18n.English)
+	if err != nil {
+		t.Fatalf("Failed to generate payout report: %v", err)
+	}
+	assertPDF(t, report.GetBytesPdf())
+
+	for _, period := range []string{"2024-09", "2024-Q3", "2024", "2024-08-15_2024-09-30"} {
+		periodReport, err := accounting.GenerateMonthlyReport(period, i18n.English)
+		if err != nil {
+			t.Fatalf("Failed to generate %s report: %v", period, err)
+		}
+		assertPDF(t, periodReport.GetBytesPdf())
+	}
+	view, err := accounting.GenerateMonthlyReportView("2024", i18n.English)
+	if err != nil {
+		t.Fatalf("Failed to generate yearly report view: %v", err)
+	}
+	if view.Period != "2024" || view.From != "2024-01-01" || view.To != "2024-12-31" || len(view.Months) == 0 {
+		t.Errorf("Expected the 2024 view grouped by month, got %v %v-%v with %d months", view.Period, view.From, view.To, len(view.Months))
+	}
+
+	statement, err := accounting.GenerateDonorStatement("ION@example.com", "2024", "")
+	if err != nil {
+		t.Fatalf("Failed to generate donor statement: %v", err)
+	}
+	assertPDF(t, statement.GetBytesPdf())
+	if _, err = accounting.GenerateDonorStatement("ion@example.com", "2023", ""); err == nil {
+		t.Errorf("Expected a statement without donations to fail")
+	}
+
+	archive := NewArchiveService(pwaRepo, storage.NewFileStorage(t.TempDir()), accounting, time.UTC)
+	var export bytes.Buffer
+	summary, err := NewExportService(pwaRepo, archive, accounting, time.UTC, 2).Export(&export, "2024-09", "", "", "", "admin")
+	if err != nil {
+		t.Fatalf("Failed to export documents: %v", err)
+	}
+	if summary.Failed != 0 {
+		t.Errorf("Expected every document to export, got %v", summary.Failures)
+	}
+	exported, err := zip.NewReader(bytes.NewReader(export.Bytes()), int64(export.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	for _, name := range []string{"invoices/HNT-2024-000002.pdf", "payouts/txn_payout_1.pdf", "monthly/2024-09.pdf", "manifest.csv"} {
+		if _, err = exported.Open(name); err != nil {
+			t.Errorf("Expected export to contain %v", name)
+		}
+	}
+
+	issued, content, err := archive.Document("donation", "txn_charge_2", "", "admin")
+	if err != nil {
+		t.Fatalf("Failed to issue invoice: %v", err)
+	}
+	assertPDF(t, content)
+	exportedInvoice, err := exported.Open("invoices/HNT-2024-000002.pdf")
+	if err != nil {
+		t.Fatalf("Failed to open exported invoice: %v", err)
+	}
+	if exportedContent, _ := io.ReadAll(exportedInvoice); !bytes.Equal(exportedContent, content) {
+		t.Errorf("Expected the export to contain the archived invoice")
+	}
+	served, again, err := archive.Document("donation", "txn_charge_2", "", "admin")
+	if err != nil {
+		t.Fatalf("Failed to serve archived invoice: %v", err)
+	}
+	if served.ID != issued.ID || served.SHA256 != issued.SHA256 || !bytes.Equal(content, again) {
+		t.Errorf("Expected the archived invoice to be served unchanged")
+	}
+	if explicit, _, err := archive.Document("donation", "txn_charge_2", issued.Lang, "admin"); err != nil || explicit.ID != issued.ID {
+		t.Errorf("Expected the invoice in its resolved language to be the same original, got %+v and %v", explicit, err)
+	}
+	if _, err = archive.Reissue("donation", "txn_charge_2", "", "admin", ""); err == nil {
+		t.Errorf("Expected reissue without a reason to fail")
+	}
+	reissued, err := archive.Reissue("donation", "txn_charge_2", "", "admin", "Adresă corectată")
+	if err != nil {
+		t.Fatalf("Failed to reissue invoice: %v", err)
+	}
+	if reissued.Version != 2 {
+		t.Errorf("Expected version %v, got %v", 2, reissued.Version)
+	}
+	versions, err := archive.Versions("donation", "txn_charge_2")
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || versions[1].ID != issued.ID {
+		t.Errorf("Expected versions 2 and 1, got %+v", versions)
+	}
+
+	issuedEInvoice, storedEInvoice, err := archive.Document("einvoice", "txn_charge_2", "", "admin")
+	if err != nil {
+		t.Fatalf("Failed to issue e-invoice: %v", err)
+	}
+	if !bytes.Equal(storedEInvoice, eInvoice) {
+		t.Errorf("Expected the e-invoice archived as generated")
+	}
+	servedEInvoice, againEInvoice, err := archive.Document("einvoice", "txn_charge_2", "", "admin")
+	if err != nil {
+		t.Fatalf("Failed to serve archived e-invoice: %v", err)
+	}
+	if servedEInvoice.ID != issuedEInvoice.ID || !bytes.Equal(storedEInvoice, againEInvoice) {
+		t.Errorf("Expected the archived e-invoice to be served unchanged")
+	}
+	reissuedEInvoice, err := archive.Reissue("einvoice", "txn_charge_2", "", "admin", "Adresă corectată")
+	if err != nil {
+		t.Fatalf("Failed to reissue e-invoice: %v", err)
+	}
+	if reissuedEInvoice.Version != 2 {
+		t.Errorf("Expected version %v, got %v", 2, reissuedEInvoice.Version)
+	}
+	if _, _, err = archive.Document("einvoice", "txn_charge_1", "", "admin"); err == nil {
+		t.Errorf("Expected an e-invoice failing validation not to be archived")
+	}
+
+	queued, err := pwaRepo.GetEmails("queued")
+	if err != nil {
+		t.Fatalf("Failed to get queued emails: %v", err)
+	}
+	if len(queued) != 2 {
+		t.Fatalf("Expected an email queued per donation, got %d", len(queued))
+	}
+	mailer := &fakeMailer{}
+	if err = NewEmailService(pwaRepo, archive, mailer, 3, time.UTC).SendDueEmails(10); err != nil {
+		t.Fatalf("Failed to send emails: %v", err)
+	}
+	sent, err := pwaRepo.GetEmails("sent")
+	if err != nil {
+		t.Fatalf("Failed to get sent emails: %v", err)
+	}
+	if len(sent) != 2 || len(mailer.sent) != 2 {
+		t.Fatalf("Expected 2 emails sent, got %d recorded and %d delivered", len(sent), len(mailer.sent))
+	}
+	_, latest, err := archive.Document("donation", "txn_charge_2", "", "admin")
+	if err != nil {
+		t.Fatalf("Failed to serve archived invoice: %v", err)
+	}
+	for _, message := range mailer.sent {
+		if message.To == "maria@example.com" && !bytes.Equal(message.Attachments[0].Content, latest) {
+			t.Errorf("Expected the reissued invoice attached to the email")
+		}
+	}
+
+	layouts := NewLayoutService(pwaRepo, documents.NewDocumentService())
+	for _, name := range layouts.LayoutNames() {
+		layout, err := layouts.GetLayout(name)
+		if err != nil {
+			t.Fatalf("Failed to get %v layout: %v", name, err)
+		}
+		for _, lang := range i18n.Languages() {
+			preview, err := layouts.PreviewLayout(name, layout.Definition, lang)
+			if err != nil {
+				t.Fatalf("Failed to preview %v layout in %v: %v", name, lang, err)
+			}
+			assertPDF(t, preview.GetBytesPdf())
+		}
+	}
+
+	broken := `{"body": [{"type": "text", "text": "{missing}", "x": 40, "y": 40}]}`
+	if err = layouts.SaveLayout(admin, "invoice", broken); err == nil {
+		t.Errorf("Expected layout with an unknown field to be rejected")
+	}
+	custom := `{"body": [{"type": "text", "text": "Factură {invoiceNumber}", "x": 40, "y": 40}]}`
+	if err = layouts.SaveLayout(admin, "invoice", custom); err != nil {
+		t.Fatalf("Failed to save layout: %v", err)
+	}
+	if invoice, err = accounting.GenerateInvoice("txn_charge_2", ""); err != nil {
+		t.Fatalf("Failed to generate invoice from saved layout: %v", err)
+	}
+	assertPDF(t, invoice.GetBytesPdf())
+
+	if entries, err = audit.ListEntries("admin", "", "", "", "", ""); err != nil {
+		t.Fatalf("Failed to list audit entries: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Entity != "document_layout" || entries[0].Action != models.AuditInsert || entries[1].Entity != "organisation" || entries[1].IP != "192.0.2.1" {
+		t.Errorf("Expected the admin's layout and organisation changes audited, got %+v", entries)
+	}
+}
+
+func testOrganisation() *dto.Organisation {
+	return dto.NewOrganisation(
+		"Asociația de Caritate Hintermann",
+		"12345674",
+		"",
+		"",
+		"",
+		"contact@hintermann.ro",
+		"",
+		"",
+		"",
+		"",
+		dto.NewAddress("Strada Spicului, Nr. 12", "Bl. 40, Sc. A, Ap. 12", "Brașov", "RO-BV", "500460", "RO"),
+	)
+}
+
+// setupEndToEnd moves to the module root, where the migrations and PDF assets live,
+// and returns the database of dsn, migrated.
+func setupEndToEnd(t *testing.T, dsn string) *sqlx.DB {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err = os.Chdir(filepath.Join(wd, "..", "..")); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	db, err := database.InitDB(dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err = database.ApplyMigrations(dsn); err != nil {
+		t.Fatalf("Failed to apply migrations: %v", err)
+	}
+	return db
+}
+
+// newTestEvent queues the event before returning it, as the webhook does, since the
+// records it creates reference it.
+func newTestEvent(t *testing.T, repo *repository.WebhookRepository, id, eventType string, object any) *stripe.Event {
+	t.Helper()
+
+	raw, err := json.Marshal(object)
+	if err != nil {
+		t.Fatalf("Failed to marshal event object: %v", err)
+	}
+	event := models.NewStripeEvent(id, eventType, string(raw), uint64(time.Now().Unix()), sql.NullInt64{}, models.StripeEventReceived, sql.NullString{}, 0, sql.NullInt64{})
+	if _, err = repo.EnqueueStripeEvent(event); err != nil {
+		t.Fatalf("Failed to queue event: %v", err)
+	}
+	return &stripe.Event{
+		ID:   id,
+		Type: stripe.EventType(eventType),
+		Data: &stripe.EventData{Raw: raw},
+	}
+}
+
+func assertPDF(t *testing.T, pdf []byte) {
+	t.Helper()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Errorf("Expected a PDF document, got %d bytes", len(pdf))
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
+)
+
+// emailSystemUser is recorded for emails queued when a donation is recorded, and as the
+// issuer of invoices archived to be sent.
+const emailSystemUser = "email"
+
+type EmailRepository interface {
+	EnqueueEmail(email *models.Email) error
+	GetDueEmails(now int64, limit int) ([]*models.Email, error)
+	UpdateEmail(email *models.Email) error
+	GetEmails(status string) ([]*models.Email, error)
+	GetDonation(id string) (*models.Donation, error)
+	GetOrganisation() (*models.Organisation, error)
+}
+
+// InvoiceArchive hands out the issued invoice, so the donor gets the archived copy.
+type InvoiceArchive interface {
+	Document(documentType, reference, lang, user string) (*dto.ArchivedDocument, []byte, error)
+}
+
+type Mailer interface {
+	ComposeInvoice(data *dto.InvoiceEmailData, lang string) (*dto.EmailMessage, error)
+	Send(message *dto.EmailMessage) error
+}
+
+type EmailService struct {
+	repo        EmailRepository
+	archive     InvoiceArchive
+	mailer      Mailer
+	maxAttempts uint32
+	location    *time.Location
+}
+
+func NewEmailService(repo EmailRepository, archive InvoiceArchive, mailer Mailer, maxAttempts uint32, location *time.Location) *EmailService {
+	return &EmailService{
+		repo:        repo,
+		archive:     archive,
+		mailer:      mailer,
+		maxAttempts: maxAttempts,
+		location:    location,
+	}
+}
+
+// ResendInvoice queues the invoice of a donation to be emailed again.
+func (s *EmailService) ResendInvoice(donationID, user string) error {
+	donation, err := s.repo.GetDonation(donationID)
+	if err != nil {
+		return fmt.Errorf("fetch donation failed: %w", err)
+	}
+	if donation.ClientEmail == "" {
+		return custom_errors.NewValidationError(constants.ErrEmailRecipientMissing, donationID)
+	}
+	if err = s.repo.EnqueueEmail(transformQueuedEmailDTOToModel(donation, user, time.Now().Unix())); err != nil {
+		return fmt.Errorf("database email insertion failed: %w", err)
+	}
+	return nil
+}
+
+// SendDueEmails sends up to limit queued emails whose next attempt is due. A failed
+// email is queued again with the webhook backoff until it runs out of attempts.
+func (s *EmailService) SendDueEmails(limit int) error {
+	emails, err := s.repo.GetDueEmails(time.Now().Unix(), limit)
+	if err != nil {
+		return fmt.Errorf("database due emails fetch failed: %w", err)
+	}
+	for _, email := range emails {
+		sendErr := s.send(email)
+		if sendErr != nil {
+			log.Printf("Email %d attempt %d failed: %v\n", email.ID, email.Attempts+1, sendErr)
+		}
+		completed := transformCompletedEmailDTOToModel(email, s.maxAttempts, time.Now(), sendErr)
+		if err = s.repo.UpdateEmail(completed); err != nil {
+			return fmt.Errorf("database email update failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *EmailService) send(email *models.Email) error {
+	donation, err := s.repo.GetDonation(email.DonationID)
+	if err != nil {
+		return fmt.Errorf("fetch donation failed: %w", err)
+	}
+	organisation, err := s.repo.GetOrganisation()
+	if err != nil {
+		return fmt.Errorf("fetch organisation failed: %w", err)
+	}
+	_, invoice, err := s.archive.Document("donation", donation.ID, "", emailSystemUser)
+	if err != nil {
+		return fmt.Errorf("issue invoice failed: %w", err)
+	}
+
+	lang := i18n.Resolve(donation.Locale.String)
+	message, err := s.mailer.ComposeInvoice(transformDonationModelToInvoiceEmailData(email.Recipient, donation, organisation, s.location), lang)
+	if err != nil {
+		return fmt.Errorf("compose email failed: %w", err)
+	}
+	message.Attachments = append(message.Attachments, dto.NewEmailAttachment(formatInvoiceNumber(donation)+".pdf", "application/pdf", invoice))
+	return s.mailer.Send(message)
+}
+
+func (s *EmailService) ListEmails(status string) ([]*dto.FormattedEmail, error) {
+	if err := validateEmailStatus(status); err != nil {
+		return nil, err
+	}
+	emailModels, err := s.repo.GetEmails(status)
+	if err != nil {
+		return nil, fmt.Errorf("database emails fetch failed: %w", err)
+	}
+	return transformEmailModelsToDTOs(emailModels, s.location), nil
+}
+
+// enqueueInvoiceEmail queues the invoice of a newly recorded donation for its donor, in
+// the transaction the donation is inserted in. Donations without an email are left out,
+// and so are donations recorded without a webhook event: a backfill imports past
+// donations whose donors were never meant to hear about them again.
+func enqueueInvoiceEmail(repo WebhookRepository, donation *models.Donation) error {
+	if !donation.EventID.Valid || strings.TrimSpace(donation.ClientEmail) == "" {
+		return nil
+	}
+	if err := repo.EnqueueEmail(transformQueuedEmailDTOToModel(donation, emailSystemUser, time.Now().Unix())); err != nil {
+		return fmt.Errorf("database email insertion failed: %w", err)
+	}
+	return nil
+}
+
+func transformQueuedEmailDTOToModel(donation *models.Donation, user string, created int64) *models.Email {
+	return models.NewEmail(
+		donation.ID,
+		strings.TrimSpace(donation.ClientEmail),
+		created,
+		user,
+		models.EmailQueued,
+		sql.NullString{Valid: false},
+		0,
+		sql.NullInt64{Int64: created, Valid: true},
+		sql.NullInt64{Valid: false},
+	)
+}
+
+func transformCompletedEmailDTOToModel(email *models.Email, maxAttempts uint32, completed time.Time, sendErr error) *models.Email {
+	updated := *email
+	updated.Attempts++
+	switch {
+	case sendErr == nil:
+		updated.Status = models.EmailSent
+		updated.Error = sql.NullString{Valid: false}
+		updated.NextAttempt = sql.NullInt64{Valid: false}
+		updated.Sent = sql.NullInt64{Int64: completed.Unix(), Valid: true}
+	case updated.Attempts >= maxAttempts:
+		updated.Status = models.EmailFailed
+		updated.Error = sql.NullString{String: sendErr.Error(), Valid: true}
+		updated.NextAttempt = sql.NullInt64{Valid: false}
+	default:
+		updated.Status = models.EmailQueued
+		updated.Error = sql.NullString{String: sendErr.Error(), Valid: true}
+		updated.NextAttempt = sql.NullInt64{Int64: completed.Add(retryDelay(updated.Attempts)).Unix(), Valid: true}
+	}
+	return &updated
+}
+
+func transformDonationModelToInvoiceEmailData(recipient string, donation *models.Donation, organisation *models.Organisation, location *time.Location) *dto.InvoiceEmailData {
+	return dto.NewInvoiceEmailData(
+		recipient,
+		donation.ClientName,
+		formatInvoiceNumber(donation),
+		formatDate(donation.Created, location),
+		money.New(donation.Gross, donation.Currency).String(),
+		organisation.Name,
+		organisation.Email,
+	)
+}
+
+func transformEmailModelsToDTOs(emailModels []*models.Email, location *time.Location) (emails []*dto.FormattedEmail) {
+	for _, emailModel := range emailModels {
+		emails = append(emails, transformEmailModelToDTO(emailModel, location))
+	}
+	return
+}
+
+func transformEmailModelToDTO(email *models.Email, location *time.Location) *dto.FormattedEmail {
+	var nextAttempt, sent string
+	if email.NextAttempt.Valid && email.Status == models.EmailQueued {
+		nextAttempt = time.Unix(email.NextAttempt.Int64, 0).In(location).Format("02 Jan 2006 15:04")
+	}
+	if email.Sent.Valid {
+		sent = time.Unix(email.Sent.Int64, 0).In(location).Format("02 Jan 2006 15:04")
+	}
+	return dto.NewFormattedEmail(
+		email.ID,
+		email.DonationID,
+		email.Recipient,
+		time.Unix(email.Created, 0).In(location).Format("02 Jan 2006 15:04"),
+		email.RequestedBy,
+		email.Status,
+		email.Error.String,
+		fmt.Sprintf("%d", email.Attempts),
+		nextAttempt,
+		sent,
+	)
+}
+
+func validateEmailStatus(status string) error {
+	switch status {
+	case models.EmailQueued, models.EmailSent, models.EmailFailed:
+		return nil
+	default:
+		return fmt.Errorf("invalid email status: %s", status)
+	}
+}
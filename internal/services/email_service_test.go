@@ -0,0 +1,201 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+type fakeEmailRepository struct {
+	emails    []*models.Email
+	donations map[string]*models.Donation
+}
+
+func (r *fakeEmailRepository) EnqueueEmail(email *models.Email) error {
+	email.ID = int64(len(r.emails) + 1)
+	r.emails = append(r.emails, email)
+	return nil
+}
+
+func (r *fakeEmailRepository) GetDueEmails(now int64, limit int) (emails []*models.Email, err error) {
+	for _, email := range r.emails {
+		if email.Status == models.EmailQueued && email.NextAttempt.Int64 <= now && len(emails) < limit {
+			copied := *email
+			emails = append(emails, &copied)
+		}
+	}
+	return
+}
+
+func (r *fakeEmailRepository) UpdateEmail(email *models.Email) error {
+	r.emails[email.ID-1] = email
+	return nil
+}
+
+func (r *fakeEmailRepository) GetEmails(status string) (emails []*models.Email, err error) {
+	for _, email := range r.emails {
+		if email.Status == status {
+			emails = append(emails, email)
+		}
+	}
+	return
+}
+
+func (r *fakeEmailRepository) GetDonation(id string) (*models.Donation, error) {
+	if donation, ok := r.donations[id]; ok {
+		return donation, nil
+	}
+	return nil, fmt.Errorf("donation not found")
+}
+
+func (r *fakeEmailRepository) GetOrganisation() (*models.Organisation, error) {
+	return &models.Organisation{Name: "Asociația Hintermann", Email: "contact@example.com"}, nil
+}
+
+// fakeDonationRecorder is the webhook repository as enqueueInvoiceEmail uses it.
+type fakeDonationRecorder struct {
+	WebhookRepository
+	emails *fakeEmailRepository
+}
+
+func (r fakeDonationRecorder) EnqueueEmail(email *models.Email) error {
+	return r.emails.EnqueueEmail(email)
+}
+
+type fakeInvoiceArchive struct{}
+
+func (fakeInvoiceArchive) Document(documentType, reference, lang, user string) (*dto.ArchivedDocument, []byte, error) {
+	return &dto.ArchivedDocument{}, []byte("%PDF-" + reference), nil
+}
+
+// fakeMailer keeps what it sends, and fails for the recipients in failing.
+type fakeMailer struct {
+	sent    []*dto.EmailMessage
+	failing map[string]bool
+}
+
+func (m *fakeMailer) ComposeInvoice(data *dto.InvoiceEmailData, lang string) (*dto.EmailMessage, error) {
+	return dto.NewEmailMessage(data.Recipient, data.InvoiceNumber, lang, ""), nil
+}
+
+func (m *fakeMailer) ComposePortalLink(data *dto.PortalLinkEmailData, lang string) (*dto.EmailMessage, error) {
+	return dto.NewEmailMessage(data.Recipient, data.Link, lang, ""), nil
+}
+
+func (m *fakeMailer) Send(message *dto.EmailMessage) error {
+	if m.failing[message.To] {
+		return errors.New("connection refused")
+	}
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+func TestSendDueEmails(t *testing.T) {
+	event := sql.NullString{String: "evt_1", Valid: true}
+	repo := &fakeEmailRepository{donations: map[string]*models.Donation{
+		"txn_1": {ID: "txn_1", ClientEmail: "ion@example.com", Locale: sql.NullString{String: "en-GB", Valid: true}, InvoiceSeries: sql.NullString{String: "HNT", Valid: true}, InvoiceYear: sql.NullInt64{Int64: 2024, Valid: true}, InvoiceNumber: sql.NullInt64{Int64: 1, Valid: true}, EventID: event},
+		"txn_2": {ID: "txn_2", ClientEmail: "maria@example.com", EventID: event},
+		"txn_3": {ID: "txn_3", EventID: event},
+		"txn_4": {ID: "txn_4", ClientEmail: "ana@example.com"},
+	}}
+	mailer := &fakeMailer{failing: map[string]bool{"maria@example.com": true}}
+	service := NewEmailService(repo, fakeInvoiceArchive{}, mailer, 2, time.UTC)
+
+	for _, id := range []string{"txn_1", "txn_2", "txn_3", "txn_4"} {
+		if err := enqueueInvoiceEmail(fakeDonationRecorder{emails: repo}, repo.donations[id]); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	if len(repo.emails) != 2 {
+		t.Fatalf("Expected donations without an email or an event to be left out, got %d emails", len(repo.emails))
+	}
+
+	if err := service.SendDueEmails(10); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].Text != "en" || len(mailer.sent[0].Attachments) != 1 ||
+		mailer.sent[0].Attachments[0].Name != "HNT-2024-000001.pdf" || string(mailer.sent[0].Attachments[0].Content) != "%PDF-txn_1" {
+		t.Fatalf("Expected the invoice emailed in English with its PDF, got %+v", mailer.sent)
+	}
+	sent, failed := repo.emails[0], repo.emails[1]
+	if sent.Status != models.EmailSent || !sent.Sent.Valid || sent.Attempts != 1 {
+		t.Errorf("Expected first email sent, got %+v", sent)
+	}
+	if failed.Status != models.EmailQueued || failed.Error.String != "connection refused" || failed.NextAttempt.Int64 <= time.Now().Unix() {
+		t.Errorf("Expected second email queued for a later retry, got %+v", failed)
+	}
+
+	// The retry is due only after the backoff, so move it forward.
+	repo.emails[1].NextAttempt.Int64 = time.Now().Unix()
+	if err := service.SendDueEmails(10); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if failed = repo.emails[1]; failed.Status != models.EmailFailed || failed.Attempts != 2 {
+		t.Errorf("Expected second email failed after 2 attempts, got %+v", failed)
+	}
+	if len(mailer.sent) != 1 {
+		t.Errorf("Expected the sent email not to be sent again, got %d", len(mailer.sent))
+	}
+}
+
+func TestResendInvoice(t *testing.T) {
+	repo := &fakeEmailRepository{donations: map[string]*models.Donation{
+		"txn_1": {ID: "txn_1", ClientEmail: "ion@example.com"},
+		"txn_2": {ID: "txn_2"},
+	}}
+	service := NewEmailService(repo, fakeInvoiceArchive{}, &fakeMailer{}, 2, time.UTC)
+
+	if err := service.ResendInvoice("txn_1", "admin"); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(repo.emails) != 1 || repo.emails[0].RequestedBy != "admin" || repo.emails[0].Status != models.EmailQueued {
+		t.Errorf("Expected a queued email requested by admin, got %+v", repo.emails)
+	}
+
+	var validationError *custom_errors.ValidationError
+	if err := service.ResendInvoice("txn_2", "admin"); !errors.As(err, &validationError) {
+		t.Errorf("Expected a validation error for a donation without email, got %v", err)
+	}
+	if err := service.ResendInvoice("txn_missing", "admin"); err == nil {
+		t.Errorf("Expected error for an unknown donation, but got none")
+	}
+}
+
+func TestTransformCompletedEmailDTOToModel(t *testing.T) {
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		attempts            uint32
+		sendErr             error
+		expectedStatus      string
+		expectedNextAttempt int64
+	}{
+		"sent":      {attempts: 0, expectedStatus: models.EmailSent},
+		"retry":     {attempts: 0, sendErr: errors.New("timeout"), expectedStatus: models.EmailQueued, expectedNextAttempt: now.Add(eventRetryBaseDelay).Unix()},
+		"backoff":   {attempts: 2, sendErr: errors.New("timeout"), expectedStatus: models.EmailQueued, expectedNextAttempt: now.Add(4 * eventRetryBaseDelay).Unix()},
+		"lastRetry": {attempts: 4, sendErr: errors.New("timeout"), expectedStatus: models.EmailFailed},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			email := &models.Email{ID: 1, Status: models.EmailQueued, Attempts: tc.attempts}
+			result := transformCompletedEmailDTOToModel(email, 5, now, tc.sendErr)
+
+			if result.Status != tc.expectedStatus || result.NextAttempt.Int64 != tc.expectedNextAttempt {
+				t.Errorf("Expected %v next at %v, got %v next at %v", tc.expectedStatus, tc.expectedNextAttempt, result.Status, result.NextAttempt.Int64)
+			}
+			if result.Attempts != tc.attempts+1 {
+				t.Errorf("Expected attempt %d, got %d", tc.attempts+1, result.Attempts)
+			}
+			if email.Attempts != tc.attempts {
+				t.Errorf("Expected the queued email to be left unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	emailPollInterval = 10 * time.Second
+	emailBatchSize    = 20
+)
+
+// EmailWorker sends queued emails in the background, one at a time so the SMTP server
+// is not flooded when a payout records many donations at once.
+type EmailWorker struct {
+	emails *EmailService
+}
+
+func NewEmailWorker(emails *EmailService) *EmailWorker {
+	return &EmailWorker{emails: emails}
+}
+
+// Run sends due emails every emailPollInterval until ctx is cancelled.
+func (w *EmailWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(emailPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.emails.SendDueEmails(emailBatchSize); err != nil {
+			log.Printf("Email worker error: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
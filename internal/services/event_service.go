@@ -0,0 +1,237 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+const (
+	eventRetryBaseDelay = 30 * time.Second
+	eventRetryMaxDelay  = 6 * time.Hour
+)
+
+type EventRepository interface {
+	EnqueueStripeEvent(event *models.StripeEvent) (bool, error)
+	ClaimStripeEvent(id string) (bool, error)
+	UpdateStripeEvent(event *models.StripeEvent) error
+	RetryStripeEvent(event *models.StripeEvent) (bool, error)
+	ResetProcessingStripeEvents() error
+	GetDueStripeEvents(now int64, limit int) ([]*models.StripeEvent, error)
+	GetStripeEvents(status string) ([]*models.StripeEvent, error)
+}
+
+type EventService struct {
+	repo        EventRepository
+	maxAttempts uint32
+	location    *time.Location
+}
+
+func NewEventService(repo EventRepository, maxAttempts uint32, location *time.Location) *EventService {
+	return &EventService{
+		repo:        repo,
+		maxAttempts: maxAttempts,
+		location:    location,
+	}
+}
+
+// EnqueueEvent stores a verified webhook event for background processing and reports
+// whether it was queued. Events already queued or processed are not queued again.
+func (s *EventService) EnqueueEvent(id, eventType string, payload []byte) (queued bool, err error) {
+	event := transformReceivedEventDTOToModel(id, eventType, string(payload), time.Now().Unix())
+	queued, err = s.repo.EnqueueStripeEvent(event)
+	if err != nil {
+		return false, fmt.Errorf("database event insertion failed: %w", err)
+	}
+	return
+}
+
+// DueEvents returns up to limit queued events whose next attempt is due.
+func (s *EventService) DueEvents(limit int) ([]*models.StripeEvent, error) {
+	events, err := s.repo.GetDueStripeEvents(time.Now().Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("database due events fetch failed: %w", err)
+	}
+	return events, nil
+}
+
+// ClaimEvent marks an event as being processed and counts the attempt on it.
+func (s *EventService) ClaimEvent(event *models.StripeEvent) (bool, error) {
+	claimed, err := s.repo.ClaimStripeEvent(event.ID)
+	if err != nil {
+		return false, fmt.Errorf("database event claim failed: %w", err)
+	}
+	if claimed {
+		event.Attempts++
+	}
+	return claimed, nil
+}
+
+// CompleteEvent stores the outcome of an attempt. A failed event is scheduled again with
+// exponential backoff until it runs out of attempts and is moved to the dead-letter state.
+func (s *EventService) CompleteEvent(event *models.StripeEvent, processErr error) error {
+	completed := transformCompletedEventDTOToModel(event.ID, event.Attempts, s.maxAttempts, time.Now(), processErr)
+	if err := s.repo.UpdateStripeEvent(completed); err != nil {
+		return fmt.Errorf("database event update failed: %w", err)
+	}
+	return nil
+}
+
+// RequeueInterruptedEvents puts back events that were being processed when the server stopped.
+func (s *EventService) RequeueInterruptedEvents() error {
+	if err := s.repo.ResetProcessingStripeEvents(); err != nil {
+		return fmt.Errorf("database event reset failed: %w", err)
+	}
+	return nil
+}
+
+func (s *EventService) ListEvents(status string) ([]*dto.FormattedStripeEvent, error) {
+	if err := validateEventStatus(status); err != nil {
+		return nil, err
+	}
+	eventModels, err := s.repo.GetStripeEvents(status)
+	if err != nil {
+		return nil, fmt.Errorf("database events fetch failed: %w", err)
+	}
+	return transformEventModelsToDTOs(eventModels, s.location), nil
+}
+
+func (s *EventService) RetryEvent(id string) error {
+	event := transformRetriedEventDTOToModel(id, time.Now().Unix())
+	retried, err := s.repo.RetryStripeEvent(event)
+	if err != nil {
+		return fmt.Errorf("database event retry failed: %w", err)
+	}
+	if !retried {
+		return fmt.Errorf("event %s is not failed or dead", id)
+	}
+	return nil
+}
+
+func transformReceivedEventDTOToModel(id, eventType, payload string, received int64) *models.StripeEvent {
+	return models.NewStripeEvent(
+		id,
+		eventType,
+		payload,
+		uint64(received),
+		sql.NullInt64{Valid: false},
+		models.StripeEventReceived,
+		sql.NullString{Valid: false},
+		0,
+		sql.NullInt64{Int64: received, Valid: true},
+	)
+}
+
+func transformCompletedEventDTOToModel(id string, attempts, maxAttempts uint32, processed time.Time, processErr error) *models.StripeEvent {
+	if processErr == nil {
+		return models.NewStripeEvent(
+			id,
+			"",
+			"",
+			0,
+			sql.NullInt64{Int64: processed.Unix(), Valid: true},
+			models.StripeEventProcessed,
+			sql.NullString{Valid: false},
+			attempts,
+			sql.NullInt64{Valid: false},
+		)
+	}
+	if attempts >= maxAttempts {
+		return models.NewStripeEvent(
+			id,
+			"",
+			"",
+			0,
+			sql.NullInt64{Int64: processed.Unix(), Valid: true},
+			models.StripeEventDead,
+			sql.NullString{String: processErr.Error(), Valid: true},
+			attempts,
+			sql.NullInt64{Valid: false},
+		)
+	}
+	nextAttempt := processed.Add(retryDelay(attempts))
+	return models.NewStripeEvent(
+		id,
+		"",
+		"",
+		0,
+		sql.NullInt64{Int64: processed.Unix(), Valid: true},
+		models.StripeEventFailed,
+		sql.NullString{String: processErr.Error(), Valid: true},
+		attempts,
+		sql.NullInt64{Int64: nextAttempt.Unix(), Valid: true},
+	)
+}
+
+func transformRetriedEventDTOToModel(id string, nextAttempt int64) *models.StripeEvent {
+	return models.NewStripeEvent(
+		id,
+		"",
+		"",
+		0,
+		sql.NullInt64{Valid: false},
+		models.StripeEventReceived,
+		sql.NullString{Valid: false},
+		0,
+		sql.NullInt64{Int64: nextAttempt, Valid: true},
+	)
+}
+
+func transformEventModelsToDTOs(eventModels []*models.StripeEvent, location *time.Location) (events []*dto.FormattedStripeEvent) {
+	for _, eventModel := range eventModels {
+		events = append(events, transformEventModelToDTO(eventModel, location))
+	}
+	return
+}
+
+func transformEventModelToDTO(event *models.StripeEvent, location *time.Location) *dto.FormattedStripeEvent {
+	var processed, nextAttempt string
+	if event.Processed.Valid {
+		processed = time.Unix(event.Processed.Int64, 0).In(location).Format("02 Jan 2006 15:04")
+	}
+	if event.NextAttempt.Valid {
+		nextAttempt = time.Unix(event.NextAttempt.Int64, 0).In(location).Format("02 Jan 2006 15:04")
+	}
+	return dto.NewFormattedStripeEvent(
+		event.ID,
+		event.Type,
+		time.Unix(int64(event.Received), 0).In(location).Format("02 Jan 2006 15:04"),
+		processed,
+		event.Status,
+		event.Error.String,
+		fmt.Sprintf("%d", event.Attempts),
+		nextAttempt,
+	)
+}
+
+// retryDelay doubles the wait after every failed attempt, starting from eventRetryBaseDelay.
+func retryDelay(attempts uint32) time.Duration {
+	delay := eventRetryBaseDelay
+	for i := uint32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= eventRetryMaxDelay {
+			return eventRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+func validateEventStatus(status string) error {
+	switch status {
+	case models.StripeEventReceived, models.StripeEventProcessing, models.StripeEventProcessed, models.StripeEventFailed, models.StripeEventDead:
+		return nil
+	default:
+		return fmt.Errorf("invalid event status: %s", status)
+	}
+}
+
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func toNullFloat64(f float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: f, Valid: f != 0}
+}
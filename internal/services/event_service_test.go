@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/internal/models"
+)
+
+func TestTransformReceivedEventDTOToModel(t *testing.T) {
+	result := transformReceivedEventDTOToModel("evt_123456", "charge.updated", `{"id":"evt_123456"}`, 1700000000)
+
+	if result.ID != "evt_123456" {
+		t.Errorf("Expected ID %v, got %v", "evt_123456", result.ID)
+	}
+	if result.Type != "charge.updated" {
+		t.Errorf("Expected Type %v, got %v", "charge.updated", result.Type)
+	}
+	if result.Payload != `{"id":"evt_123456"}` {
+		t.Errorf("Expected Payload %v, got %v", `{"id":"evt_123456"}`, result.Payload)
+	}
+	if result.Received != 1700000000 {
+		t.Errorf("Expected Received %v, got %v", 1700000000, result.Received)
+	}
+	if result.Processed.Valid {
+		t.Errorf("Expected Processed to be NULL, got %v", result.Processed)
+	}
+	if result.Status != models.StripeEventReceived {
+		t.Errorf("Expected Status %v, got %v", models.StripeEventReceived, result.Status)
+	}
+	if result.Error.Valid {
+		t.Errorf("Expected Error to be NULL, got %v", result.Error)
+	}
+	if result.Attempts != 0 {
+		t.Errorf("Expected Attempts %v, got %v", 0, result.Attempts)
+	}
+	if !result.NextAttempt.Valid || result.NextAttempt.Int64 != 1700000000 {
+		t.Errorf("Expected NextAttempt %v, got %v", 1700000000, result.NextAttempt)
+	}
+}
+
+func TestTransformCompletedEventDTOToModel(t *testing.T) {
+	processed := time.Unix(1700000100, 0)
+
+	testCases := map[string]struct {
+		attempts            uint32
+		processErr          error
+		expectedStatus      string
+		expectedError       string
+		expectedNextAttempt int64
+	}{
+		"processed":       {attempts: 1, processErr: nil, expectedStatus: models.StripeEventProcessed, expectedError: "", expectedNextAttempt: 0},
+		"failedFirst":     {attempts: 1, processErr: errors.New("database payout insertion failed"), expectedStatus: models.StripeEventFailed, expectedError: "database payout insertion failed", expectedNextAttempt: 1700000130},
+		"failedThird":     {attempts: 3, processErr: errors.New("database payout insertion failed"), expectedStatus: models.StripeEventFailed, expectedError: "database payout insertion failed", expectedNextAttempt: 1700000220},
+		"deadLastAttempt": {attempts: 5, processErr: errors.New("database payout insertion failed"), expectedStatus: models.StripeEventDead, expectedError: "database payout insertion failed", expectedNextAttempt: 0},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := transformCompletedEventDTOToModel("evt_123456", tc.attempts, 5, processed, tc.processErr)
+
+			if result.ID != "evt_123456" {
+				t.Errorf("Expected ID %v, got %v", "evt_123456", result.ID)
+			}
+			if !result.Processed.Valid || result.Processed.Int64 != 1700000100 {
+				t.Errorf("Expected Processed %v, got %v", 1700000100, result.Processed)
+			}
+			if result.Status != tc.expectedStatus {
+				t.Errorf("Expected Status %v, got %v", tc.expectedStatus, result.Status)
+			}
+			if result.Error.String != tc.expectedError || result.Error.Valid != (tc.expectedError != "") {
+				t.Errorf("Expected Error %q, got %v", tc.expectedError, result.Error)
+			}
+			if result.NextAttempt.Int64 != tc.expectedNextAttempt || result.NextAttempt.Valid != (tc.expectedNextAttempt != 0) {
+				t.Errorf("Expected NextAttempt %v, got %v", tc.expectedNextAttempt, result.NextAttempt)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	testCases := map[string]struct {
+		attempts uint32
+		expected time.Duration
+	}{
+		"firstAttempt":  {attempts: 1, expected: 30 * time.Second},
+		"secondAttempt": {attempts: 2, expected: time.Minute},
+		"fifthAttempt":  {attempts: 5, expected: 8 * time.Minute},
+		"capped":        {attempts: 20, expected: 6 * time.Hour},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := retryDelay(tc.attempts); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestValidateEventStatus(t *testing.T) {
+	testCases := map[string]struct {
+		status      string
+		expectError bool
+	}{
+		"dead":    {status: models.StripeEventDead, expectError: false},
+		"failed":  {status: models.StripeEventFailed, expectError: false},
+		"unknown": {status: "lost", expectError: true},
+		"empty":   {status: "", expectError: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateEventStatus(tc.status)
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error, but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, but got: %v", err)
+			}
+		})
+	}
+}
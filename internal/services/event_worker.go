@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/stripe/stripe-go/v79"
+)
+
+const eventPollInterval = 2 * time.Second
+
+type EventProcessor interface {
+	ProcessEvent(event *stripe.Event) error
+}
+
+// EventWorker processes queued webhook events in the background with a fixed pool of workers.
+type EventWorker struct {
+	events       *EventService
+	newProcessor func() EventProcessor
+	workers      int
+}
+
+func NewEventWorker(events *EventService, newProcessor func() EventProcessor, workers int) *EventWorker {
+	return &EventWorker{
+		events:       events,
+		newProcessor: newProcessor,
+		workers:      workers,
+	}
+}
+
+// Run polls for due events and hands them to the workers until ctx is cancelled.
+func (w *EventWorker) Run(ctx context.Context) error {
+	if err := w.events.RequeueInterruptedEvents(); err != nil {
+		return fmt.Errorf("requeue interrupted events failed: %w", err)
+	}
+
+	jobs := make(chan *models.StripeEvent)
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func(processor EventProcessor) {
+			defer wg.Done()
+			for event := range jobs {
+				w.process(processor, event)
+			}
+		}(w.newProcessor())
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.dispatch(ctx, jobs); err != nil {
+			log.Printf("Event worker error: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *EventWorker) dispatch(ctx context.Context, jobs chan<- *models.StripeEvent) error {
+	events, err := w.events.DueEvents(w.workers)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		claimed, err := w.events.ClaimEvent(event)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		select {
+		case jobs <- event:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+func (w *EventWorker) process(processor EventProcessor, event *models.StripeEvent) {
+	var stripeEvent stripe.Event
+	processErr := json.Unmarshal([]byte(event.Payload), &stripeEvent)
+	if processErr == nil {
+		processErr = safeProcessEvent(processor, &stripeEvent)
+	}
+	if processErr != nil {
+		log.Printf("Event %s attempt %d failed: %v\n", event.ID, event.Attempts, processErr)
+	}
+	if err := w.events.CompleteEvent(event, processErr); err != nil {
+		log.Printf("Event service error: %v\n", err)
+	}
+}
+
+func safeProcessEvent(processor EventProcessor, event *stripe.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic occurred: %v", r)
+		}
+	}()
+	return processor.ProcessEvent(event)
+}
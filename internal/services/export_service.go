@@ -0,0 +1,317 @@
+package services
+
+import (
+	"archive/zip"
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
+	"github.com/signintech/gopdf"
+)
+
+type ExportRepository interface {
+	GetDonationsCreatedBetween(start, end int64) ([]*models.Donation, error)
+	GetRefundsCreatedBetween(start, end int64) ([]*models.Refund, error)
+	GetMonthlyPayouts(monthStart, monthEnd int64) ([]*models.Payout, error)
+	GetDonorEmails(start, end int64) ([]string, error)
+}
+
+// ExportArchive serves the issued original of a document, issuing it when there is none.
+type ExportArchive interface {
+	Document(documentType, reference, lang, user string) (*dto.ArchivedDocument, []byte, error)
+}
+
+// StatementGenerator renders donor statements, which are generated on request and not
+// archived.
+type StatementGenerator interface {
+	GenerateDonorStatement(email, year, lang string) (*gopdf.GoPdf, error)
+}
+
+// ExportService bundles the documents of a period into a ZIP for the accountant.
+type ExportService struct {
+	repo       ExportRepository
+	archive    ExportArchive
+	statements StatementGenerator
+	location   *time.Location
+	workers    int
+}
+
+// NewExportService reads up to workers documents at a time.
+func NewExportService(repo ExportRepository, archive ExportArchive, statements StatementGenerator, location *time.Location, workers int) *ExportService {
+	return &ExportService{
+		repo:       repo,
+		archive:    archive,
+		statements: statements,
+		location:   location,
+		workers:    max(workers, 1),
+	}
+}
+
+type exportDocument struct {
+	documentType string
+	reference    string
+	number       string
+	date         string
+	amount       string
+	file         string
+	failure      string
+	content      func() ([]byte, error)
+}
+
+type exportResult struct {
+	content []byte
+	err     error
+}
+
+// Export streams a ZIP of the invoices, credit notes and payout reports created in the
+// period, the monthly report of every month with payouts in it, and a manifest.csv. The
+// documents are the archived originals; those never issued are issued by user first. The
+// period is a month (YYYY-MM) or a from and to date (YYYY-MM-DD), both included. Nothing is
+// written until the documents are listed, so an invalid period or a failed lookup can still
+// be reported to the client; after that, a document that fails to render is recorded in the
+// manifest instead of cutting the ZIP short.
+func (s *ExportService) Export(w io.Writer, month, from, to, lang, user string) (*dto.ExportSummary, error) {
+	start, end, err := parseExportPeriod(month, from, to, s.location)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := s.documents(start, end, lang, user)
+	if err != nil {
+		return nil, err
+	}
+	return s.write(w, documents)
+}
+
+// ExportDonorStatements streams a ZIP with the annual statement of every donor who gave
+// in the year, one per donor however many emails they gave from, and a manifest.csv, like
+// Export.
+func (s *ExportService) ExportDonorStatements(w io.Writer, year, lang string) (*dto.ExportSummary, error) {
+	start, err := parseStatementYear(year, s.location)
+	if err != nil {
+		return nil, err
+	}
+	emails, err := s.repo.GetDonorEmails(start.Unix(), start.AddDate(1, 0, 0).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("fetch donor emails failed: %w", err)
+	}
+
+	var documents []*exportDocument
+	for _, email := range emails {
+		documents = append(documents, &exportDocument{
+			documentType: "statement",
+			reference:    email,
+			number:       year,
+			file:         "statements/" + year + "/" + statementFileName(email) + ".pdf",
+			content: func() ([]byte, error) {
+				pdf, err := s.statements.GenerateDonorStatement(email, year, lang)
+				if err != nil {
+					return nil, err
+				}
+				return pdf.GetBytesPdfReturnErr()
+			},
+		})
+	}
+	return s.write(w, documents)
+}
+
+func (s *ExportService) write(w io.Writer, documents []*exportDocument) (*dto.ExportSummary, error) {
+	archive := zip.NewWriter(w)
+	summary, err := s.writeDocuments(archive, documents)
+	if err != nil {
+		return nil, err
+	}
+	if err = writeExportManifest(archive, documents); err != nil {
+		return nil, err
+	}
+	if err = archive.Close(); err != nil {
+		return nil, fmt.Errorf("finish export failed: %w", err)
+	}
+	return summary, nil
+}
+
+// documents lists what goes into the export, in the order it is written.
+func (s *ExportService) documents(start, end time.Time, lang, user string) ([]*exportDocument, error) {
+	donationModels, err := s.repo.GetDonationsCreatedBetween(start.Unix(), end.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("fetch donations failed: %w", err)
+	}
+	refundModels, err := s.repo.GetRefundsCreatedBetween(start.Unix(), end.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("fetch refunds failed: %w", err)
+	}
+	payoutModels, err := s.repo.GetMonthlyPayouts(start.Unix(), end.Unix()-1)
+	if err != nil {
+		return nil, fmt.Errorf("fetch payouts failed: %w", err)
+	}
+	slices.SortFunc(payoutModels, func(a, b *models.Payout) int {
+		return cmp.Compare(a.Created, b.Created)
+	})
+
+	var documents []*exportDocument
+	for _, donation := range donationModels {
+		number := formatInvoiceNumber(donation)
+		documents = append(documents, &exportDocument{
+			documentType: "donation",
+			reference:    donation.ID,
+			number:       number,
+			date:         formatDate(donation.Created, s.location),
+			amount:       money.New(donation.Gross, donation.Currency).String(),
+			file:         "invoices/" + number + ".pdf",
+			content:      s.original("donation", donation.ID, lang, user),
+		})
+	}
+	for _, refund := range refundModels {
+		documents = append(documents, &exportDocument{
+			documentType: "refund",
+			reference:    refund.ID,
+			number:       refund.ID,
+			date:         formatDate(refund.Created, s.location),
+			amount:       "-" + money.New(refund.Gross, refund.Currency).String(),
+			file:         "credit-notes/" + refund.ID + ".pdf",
+			content:      s.original("refund", refund.ID, lang, user),
+		})
+	}
+	var months []string
+	for _, payout := range payoutModels {
+		documents = append(documents, &exportDocument{
+			documentType: "payout",
+			reference:    payout.ID,
+			number:       payout.ID,
+			date:         formatDate(payout.Created, s.location),
+			amount:       money.New(payout.Net, payout.Currency).String(),
+			file:         "payouts/" + payout.ID + ".pdf",
+			content:      s.original("payout", payout.ID, lang, user),
+		})
+		month := time.Unix(int64(payout.Created), 0).In(s.location).Format("2006-01")
+		if !slices.Contains(months, month) {
+			months = append(months, month)
+		}
+	}
+	for _, month := range months {
+		documents = append(documents, &exportDocument{
+			documentType: "monthly",
+			reference:    month,
+			number:       month,
+			file:         "monthly/" + month + ".pdf",
+			content:      s.original("monthly", month, lang, user),
+		})
+	}
+	return documents, nil
+}
+
+// original reads the archived original of a document.
+func (s *ExportService) original(documentType, reference, lang, user string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		_, content, err := s.archive.Document(documentType, reference, lang, user)
+		return content, err
+	}
+}
+
+// writeDocuments renders the documents on the worker pool and adds them to the archive in
+// order. At most workers documents are held in memory at once.
+func (s *ExportService) writeDocuments(archive *zip.Writer, documents []*exportDocument) (*dto.ExportSummary, error) {
+	results := make([]chan exportResult, len(documents))
+	for i := range results {
+		results[i] = make(chan exportResult, 1)
+	}
+	slots := make(chan struct{}, s.workers)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for i, document := range documents {
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				return
+			}
+			go func() {
+				content, err := document.content()
+				results[i] <- exportResult{content: content, err: err}
+			}()
+		}
+	}()
+
+	summary := &dto.ExportSummary{}
+	for i, document := range documents {
+		result := <-results[i]
+		<-slots
+		if result.err != nil {
+			document.failure = result.err.Error()
+			summary.Failed++
+			summary.Failures = append(summary.Failures, fmt.Sprintf("%s %s: %v", document.documentType, document.reference, result.err))
+			continue
+		}
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: document.file, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return nil, fmt.Errorf("add %s to export failed: %w", document.file, err)
+		}
+		if _, err = entry.Write(result.content); err != nil {
+			return nil, fmt.Errorf("write %s to export failed: %w", document.file, err)
+		}
+		summary.Documents++
+	}
+	return summary, nil
+}
+
+// writeExportManifest lists every document of the export. Documents that failed to render
+// have no file and carry the error instead.
+func writeExportManifest(archive *zip.Writer, documents []*exportDocument) error {
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: "manifest.csv", Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("add manifest to export failed: %w", err)
+	}
+	manifest := csv.NewWriter(entry)
+	manifest.Write([]string{"type", "reference", "number", "date", "amount", "file", "error"})
+	for _, document := range documents {
+		file := document.file
+		if document.failure != "" {
+			file = ""
+		}
+		manifest.Write([]string{document.documentType, document.reference, document.number, document.date, document.amount, file, document.failure})
+	}
+	manifest.Flush()
+	if err = manifest.Error(); err != nil {
+		return fmt.Errorf("write manifest failed: %w", err)
+	}
+	return nil
+}
+
+// parseExportPeriod returns the local days of the period as [start, end).
+func parseExportPeriod(month, from, to string, location *time.Location) (start, end time.Time, err error) {
+	if month != "" {
+		if start, err = time.ParseInLocation("2006-01", month, location); err != nil {
+			return time.Time{}, time.Time{}, custom_errors.NewValidationError(constants.ErrExportMonthInvalid, month)
+		}
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	if from == "" || to == "" {
+		return time.Time{}, time.Time{}, custom_errors.NewValidationError(constants.ErrExportPeriodMissing)
+	}
+	if start, err = time.ParseInLocation("2006-01-02", from, location); err != nil {
+		return time.Time{}, time.Time{}, custom_errors.NewValidationError(constants.ErrExportDateInvalid, from)
+	}
+	if end, err = time.ParseInLocation("2006-01-02", to, location); err != nil {
+		return time.Time{}, time.Time{}, custom_errors.NewValidationError(constants.ErrExportDateInvalid, to)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, custom_errors.NewValidationError(constants.ErrExportRangeInvalid)
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+var unsafeFileName = regexp.MustCompile(`[^A-Za-z0-9@._+-]`)
+
+// statementFileName keeps a donor email usable as a file name in any unzip tool.
+func statementFileName(email string) string {
+	return unsafeFileName.ReplaceAllString(email, "_")
+}
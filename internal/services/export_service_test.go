@@ -0,0 +1,195 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/signintech/gopdf"
+)
+
+func TestParseExportPeriod(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+
+	testCases := map[string]struct {
+		month, from, to string
+		expectedStart   time.Time
+		expectedEnd     time.Time
+		expectError     bool
+	}{
+		"month":        {month: "2024-10", expectedStart: time.Date(2024, 10, 1, 0, 0, 0, 0, location), expectedEnd: time.Date(2024, 11, 1, 0, 0, 0, 0, location)},
+		"range":        {from: "2024-07-01", to: "2024-09-30", expectedStart: time.Date(2024, 7, 1, 0, 0, 0, 0, location), expectedEnd: time.Date(2024, 10, 1, 0, 0, 0, 0, location)},
+		"singleDay":    {from: "2024-09-15", to: "2024-09-15", expectedStart: time.Date(2024, 9, 15, 0, 0, 0, 0, location), expectedEnd: time.Date(2024, 9, 16, 0, 0, 0, 0, location)},
+		"badMonth":     {month: "2024-13", expectError: true},
+		"missingTo":    {from: "2024-07-01", expectError: true},
+		"badDate":      {from: "2024-07-01", to: "30.09.2024", expectError: true},
+		"reversed":     {from: "2024-09-30", to: "2024-07-01", expectError: true},
+		"nothingGiven": {expectError: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			start, end, err := parseExportPeriod(tc.month, tc.from, tc.to, location)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if !start.Equal(tc.expectedStart) || !end.Equal(tc.expectedEnd) {
+				t.Errorf("Expected [%v, %v), got [%v, %v)", tc.expectedStart, tc.expectedEnd, start, end)
+			}
+		})
+	}
+}
+
+type fakeExportRepository struct{}
+
+func (fakeExportRepository) GetDonationsCreatedBetween(start, end int64) ([]*models.Donation, error) {
+	return []*models.Donation{
+		{ID: "txn_1", Created: 1727000000, Gross: 10000, Currency: "ron"},
+		{ID: "txn_2", Created: 1727100000, Gross: 5000, Currency: "ron"},
+	}, nil
+}
+
+func (fakeExportRepository) GetRefundsCreatedBetween(start, end int64) ([]*models.Refund, error) {
+	return []*models.Refund{{ID: "txn_3", Created: 1727150000, Gross: 5000, Currency: "ron"}}, nil
+}
+
+func (fakeExportRepository) GetMonthlyPayouts(monthStart, monthEnd int64) ([]*models.Payout, error) {
+	return []*models.Payout{
+		{ID: "txn_payout_2", Created: 1727300000, Net: 4000, Currency: "ron"},
+		{ID: "txn_payout_1", Created: 1727200000, Net: 6000, Currency: "ron"},
+	}, nil
+}
+
+func (fakeExportRepository) GetDonorEmails(start, end int64) ([]string, error) {
+	return []string{"ana@example.com", "ion/popescu@example.com"}, nil
+}
+
+// fakeDocumentGenerator renders blank pages and fails for the references in failing.
+type fakeDocumentGenerator struct {
+	failing map[string]bool
+}
+
+func (g fakeDocumentGenerator) render(reference string) (*gopdf.GoPdf, error) {
+	if g.failing[reference] {
+		return nil, fmt.Errorf("render %s failed", reference)
+	}
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+	pdf.AddPage()
+	return pdf, nil
+}
+
+func (g fakeDocumentGenerator) GenerateInvoice(id, lang string) (*gopdf.GoPdf, error) {
+	return g.render(id)
+}
+
+func (g fakeDocumentGenerator) GenerateDonorStatement(email, year, lang string) (*gopdf.GoPdf, error) {
+	return g.render(email)
+}
+
+// fakeExportArchive serves a fixed original and fails for the references in failing.
+type fakeExportArchive struct {
+	failing map[string]bool
+}
+
+func (a fakeExportArchive) Document(documentType, reference, lang, user string) (*dto.ArchivedDocument, []byte, error) {
+	if a.failing[reference] {
+		return nil, nil, fmt.Errorf("issue %s failed", reference)
+	}
+	return dto.NewArchivedDocument(1, documentType, reference, lang, 1, "", 0, "", user, ""), []byte("%PDF-1.4 " + reference), nil
+}
+
+func TestExport(t *testing.T) {
+	originals := fakeExportArchive{failing: map[string]bool{"txn_2": true}}
+	service := NewExportService(fakeExportRepository{}, originals, fakeDocumentGenerator{}, time.UTC, 3)
+
+	var buffer bytes.Buffer
+	summary, err := service.Export(&buffer, "2024-09", "", "", "", "admin")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if summary.Documents != 5 || summary.Failed != 1 {
+		t.Errorf("Expected 5 documents and 1 failure, got %d and %d", summary.Documents, summary.Failed)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("Expected a valid ZIP, got: %v", err)
+	}
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	expectedNames := []string{"invoices/txn_1.pdf", "credit-notes/txn_3.pdf", "payouts/txn_payout_1.pdf", "payouts/txn_payout_2.pdf", "monthly/2024-09.pdf", "manifest.csv"}
+	if fmt.Sprint(names) != fmt.Sprint(expectedNames) {
+		t.Errorf("Expected files %v, got %v", expectedNames, names)
+	}
+	invoice, err := archive.Open("invoices/txn_1.pdf")
+	if err != nil {
+		t.Fatalf("Expected the invoice, got: %v", err)
+	}
+	if content, _ := io.ReadAll(invoice); string(content) != "%PDF-1.4 txn_1" {
+		t.Errorf("Expected the archived original, got %q", content)
+	}
+
+	manifestFile, err := archive.Open("manifest.csv")
+	if err != nil {
+		t.Fatalf("Expected a manifest, got: %v", err)
+	}
+	rows, err := csv.NewReader(manifestFile).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected a valid manifest, got: %v", err)
+	}
+	if len(rows) != 7 {
+		t.Fatalf("Expected a header and 6 rows, got %d rows", len(rows))
+	}
+	if failed := rows[2]; failed[1] != "txn_2" || failed[5] != "" || failed[6] == "" {
+		t.Errorf("Expected the failed invoice without a file and with an error, got %v", failed)
+	}
+	if refund := rows[3]; refund[4] != "-50,00 lei" {
+		t.Errorf("Expected the refund amount to be negative, got %v", refund[4])
+	}
+}
+
+func TestExportDonorStatements(t *testing.T) {
+	service := NewExportService(fakeExportRepository{}, fakeExportArchive{}, fakeDocumentGenerator{}, time.UTC, 2)
+
+	var buffer bytes.Buffer
+	summary, err := service.ExportDonorStatements(&buffer, "2024", "")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if summary.Documents != 2 {
+		t.Errorf("Expected 2 statements, got %d", summary.Documents)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("Expected a valid ZIP, got: %v", err)
+	}
+	for _, name := range []string{"statements/2024/ana@example.com.pdf", "statements/2024/ion_popescu@example.com.pdf", "manifest.csv"} {
+		if _, err = archive.Open(name); err != nil {
+			t.Errorf("Expected export to contain %v", name)
+		}
+	}
+
+	if _, err = service.ExportDonorStatements(&buffer, "24", ""); err == nil {
+		t.Errorf("Expected an invalid year to be rejected")
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diother/go-invoices/internal/models"
+)
+
+// InvoiceNumbering gives every recorded donation the next number of its invoice series.
+// Series restart from one each calendar year of the organisation timezone.
+type InvoiceNumbering struct {
+	defaultSeries  string
+	currencySeries map[string]string
+	location       *time.Location
+}
+
+// NewInvoiceNumbering uses the currency series for donations settled in that currency
+// and the default series for everything else.
+func NewInvoiceNumbering(defaultSeries string, currencySeries map[string]string, location *time.Location) *InvoiceNumbering {
+	return &InvoiceNumbering{
+		defaultSeries:  defaultSeries,
+		currencySeries: currencySeries,
+		location:       location,
+	}
+}
+
+func (n *InvoiceNumbering) Series(currency string) string {
+	if series, ok := n.currencySeries[strings.ToLower(currency)]; ok {
+		return series
+	}
+	return n.defaultSeries
+}
+
+// Assign reserves the number within the repository's open transaction, so the donation
+// must be inserted in that same transaction. Concurrent workers commit in any order, so a
+// donation can be numbered after a later one; it is then issued on that later date, which
+// keeps each series chronological.
+func (n *InvoiceNumbering) Assign(repo WebhookRepository, donation *models.Donation) error {
+	series := n.Series(donation.Currency)
+	year := time.Unix(int64(donation.Created), 0).In(n.location).Year()
+
+	number, issued, err := repo.NextInvoiceNumber(series, year, int64(donation.Created))
+	if err != nil {
+		return fmt.Errorf("invoice number reservation failed: %w", err)
+	}
+	donation.InvoiceSeries = toNullString(series)
+	donation.InvoiceYear = sql.NullInt64{Int64: int64(year), Valid: true}
+	donation.InvoiceNumber = sql.NullInt64{Int64: number, Valid: true}
+	donation.InvoiceIssued = sql.NullInt64{Int64: issued, Valid: true}
+	return nil
+}
+
+// invoiceIssued is the date the invoice of a donation was issued. Donations recorded before
+// invoices were numbered were issued when they were created.
+func invoiceIssued(donation *models.Donation) uint64 {
+	if !donation.InvoiceIssued.Valid {
+		return donation.Created
+	}
+	return uint64(donation.InvoiceIssued.Int64)
+}
+
+// formatInvoiceNumber prints the series and number as HNT-2026-000123. Donations recorded
+// before invoices were numbered keep their transaction ID.
+func formatInvoiceNumber(donation *models.Donation) string {
+	if !donation.InvoiceNumber.Valid {
+		return donation.ID
+	}
+	return fmt.Sprintf("%s-%d-%06d", donation.InvoiceSeries.String, donation.InvoiceYear.Int64, donation.InvoiceNumber.Int64)
+}
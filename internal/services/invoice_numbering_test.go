@@ -0,0 +1,92 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/internal/models"
+)
+
+func TestInvoiceNumberingSeries(t *testing.T) {
+	numbering := NewInvoiceNumbering("HNT", map[string]string{"eur": "HNTE"}, time.UTC)
+
+	testCases := map[string]struct {
+		currency string
+		expected string
+	}{
+		"defaultSeries":  {currency: "ron", expected: "HNT"},
+		"currencySeries": {currency: "eur", expected: "HNTE"},
+		"upperCase":      {currency: "EUR", expected: "HNTE"},
+		"unknown":        {currency: "usd", expected: "HNT"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := numbering.Series(tc.currency)
+
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestFormatInvoiceNumber(t *testing.T) {
+	testCases := map[string]struct {
+		input    *models.Donation
+		expected string
+	}{
+		"numbered": {
+			input: &models.Donation{
+				ID:            "txn_123456",
+				InvoiceSeries: sql.NullString{String: "HNT", Valid: true},
+				InvoiceYear:   sql.NullInt64{Int64: 2026, Valid: true},
+				InvoiceNumber: sql.NullInt64{Int64: 123, Valid: true},
+			},
+			expected: "HNT-2026-000123",
+		},
+		"widerThanPadding": {
+			input: &models.Donation{
+				ID:            "txn_123456",
+				InvoiceSeries: sql.NullString{String: "HNTE", Valid: true},
+				InvoiceYear:   sql.NullInt64{Int64: 2026, Valid: true},
+				InvoiceNumber: sql.NullInt64{Int64: 1234567, Valid: true},
+			},
+			expected: "HNTE-2026-1234567",
+		},
+		"unnumbered": {
+			input:    &models.Donation{ID: "txn_123456"},
+			expected: "txn_123456",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := formatInvoiceNumber(tc.input)
+
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestInvoiceIssued(t *testing.T) {
+	testCases := map[string]struct {
+		input    *models.Donation
+		expected uint64
+	}{
+		"issuedWhenCreated": {input: &models.Donation{Created: 100, InvoiceIssued: sql.NullInt64{Int64: 100, Valid: true}}, expected: 100},
+		"issuedLater":       {input: &models.Donation{Created: 100, InvoiceIssued: sql.NullInt64{Int64: 300, Valid: true}}, expected: 300},
+		"unnumbered":        {input: &models.Donation{Created: 100}, expected: 100},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if result := invoiceIssued(tc.input); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
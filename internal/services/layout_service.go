@@ -0,0 +1,121 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/signintech/gopdf"
+)
+
+type LayoutRepository interface {
+	AuditRepository
+	GetOrganisation() (*models.Organisation, error)
+	GetDocumentLayout(name string) (*models.DocumentLayout, error)
+	UpsertDocumentLayout(layout *models.DocumentLayout) error
+	DeleteDocumentLayout(name string) error
+}
+
+type LayoutRenderer interface {
+	LayoutNames() []string
+	DefaultLayout(name string) ([]byte, error)
+	PreviewLayout(name string, layout []byte, organisation *dto.Organisation, lang string) (*gopdf.GoPdf, error)
+}
+
+type LayoutService struct {
+	repo     LayoutRepository
+	renderer LayoutRenderer
+}
+
+func NewLayoutService(repo LayoutRepository, renderer LayoutRenderer) *LayoutService {
+	return &LayoutService{
+		repo:     repo,
+		renderer: renderer,
+	}
+}
+
+func (s *LayoutService) LayoutNames() []string {
+	return s.renderer.LayoutNames()
+}
+
+// GetLayout returns the layout documents are rendered with: the saved one, or the default.
+func (s *LayoutService) GetLayout(name string) (*dto.DocumentLayout, error) {
+	if !slices.Contains(s.renderer.LayoutNames(), name) {
+		return nil, fmt.Errorf(constants.ErrLayoutUnknown, name)
+	}
+	layoutModel, err := s.repo.GetDocumentLayout(name)
+	if err != nil {
+		return nil, fmt.Errorf("fetch document layout failed: %w", err)
+	}
+	if layoutModel != nil {
+		return dto.NewDocumentLayout(name, layoutModel.Definition, true), nil
+	}
+
+	definition, err := s.renderer.DefaultLayout(name)
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewDocumentLayout(name, string(definition), false), nil
+}
+
+// PreviewLayout renders the layout in lang with sample data. Errors in the layout are
+// returned as validation errors.
+func (s *LayoutService) PreviewLayout(name, definition, lang string) (*gopdf.GoPdf, error) {
+	if !slices.Contains(s.renderer.LayoutNames(), name) {
+		return nil, fmt.Errorf(constants.ErrLayoutUnknown, name)
+	}
+	organisationModel, err := s.repo.GetOrganisation()
+	if err != nil {
+		return nil, fmt.Errorf("fetch organisation failed: %w", err)
+	}
+
+	pdf, err := s.renderer.PreviewLayout(name, []byte(definition), transformOrganisationModelToDTO(organisationModel), i18n.Resolve(lang))
+	if err != nil {
+		return nil, custom_errors.NewValidationError("%v", err)
+	}
+	return pdf, nil
+}
+
+// SaveLayout stores the layout once it renders, so a broken layout never reaches documents.
+func (s *LayoutService) SaveLayout(actor models.Actor, name, definition string) error {
+	if _, err := s.PreviewLayout(name, definition, i18n.Default); err != nil {
+		return err
+	}
+	current, err := s.repo.GetDocumentLayout(name)
+	if err != nil {
+		return fmt.Errorf("fetch document layout failed: %w", err)
+	}
+
+	layoutModel := models.NewDocumentLayout(name, definition, time.Now().Unix())
+	if err = s.repo.UpsertDocumentLayout(layoutModel); err != nil {
+		return fmt.Errorf("save document layout failed: %w", err)
+	}
+	action := models.AuditUpdate
+	if current == nil {
+		action = models.AuditInsert
+	}
+	return recordAudit(s.repo, actor, action, "document_layout", name, current, layoutModel)
+}
+
+// ResetLayout goes back to the default layout.
+func (s *LayoutService) ResetLayout(actor models.Actor, name string) error {
+	if !slices.Contains(s.renderer.LayoutNames(), name) {
+		return fmt.Errorf(constants.ErrLayoutUnknown, name)
+	}
+	current, err := s.repo.GetDocumentLayout(name)
+	if err != nil {
+		return fmt.Errorf("fetch document layout failed: %w", err)
+	}
+	if current == nil {
+		return nil
+	}
+	if err = s.repo.DeleteDocumentLayout(name); err != nil {
+		return fmt.Errorf("reset document layout failed: %w", err)
+	}
+	return recordAudit(s.repo, actor, models.AuditDelete, "document_layout", name, current, nil)
+}
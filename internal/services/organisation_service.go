@@ -0,0 +1,214 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+type OrganisationRepository interface {
+	AuditRepository
+	GetOrganisation() (*models.Organisation, error)
+	UpdateOrganisation(organisation *models.Organisation) error
+}
+
+type OrganisationService struct {
+	repo    OrganisationRepository
+	logoDir string
+}
+
+// NewOrganisationService stores uploaded logos in logoDir, which must be readable by the
+// document renderer.
+func NewOrganisationService(repo OrganisationRepository, logoDir string) *OrganisationService {
+	return &OrganisationService{
+		repo:    repo,
+		logoDir: logoDir,
+	}
+}
+
+func (s *OrganisationService) GetOrganisation() (*dto.Organisation, error) {
+	organisationModel, err := s.repo.GetOrganisation()
+	if err != nil {
+		return nil, fmt.Errorf("fetch organisation failed: %w", err)
+	}
+	return transformOrganisationModelToDTO(organisationModel), nil
+}
+
+// UpdateOrganisation validates the profile and saves it. Logos are optional; when one
+// is not uploaded the current file is kept.
+func (s *OrganisationService) UpdateOrganisation(actor models.Actor, organisation *dto.Organisation, logo, smallLogo []byte) error {
+	normaliseOrganisation(organisation)
+	if err := validateOrganisation(organisation); err != nil {
+		return err
+	}
+
+	current, err := s.repo.GetOrganisation()
+	if err != nil {
+		return fmt.Errorf("fetch organisation failed: %w", err)
+	}
+	updated := time.Now().Unix()
+
+	logoPath := current.LogoPath
+	if len(logo) > 0 {
+		if logoPath, err = s.saveLogo("logo", logo, updated); err != nil {
+			return err
+		}
+	}
+	smallLogoPath := current.SmallLogoPath
+	if len(smallLogo) > 0 {
+		if smallLogoPath, err = s.saveLogo("logo-small", smallLogo, updated); err != nil {
+			return err
+		}
+	}
+
+	address := organisation.Address
+	organisationModel := models.NewOrganisation(
+		organisation.Name,
+		organisation.TaxID,
+		organisation.RegistrationNumber,
+		address.Street,
+		address.AdditionalStreet,
+		address.City,
+		address.County,
+		address.PostalCode,
+		address.Country,
+		organisation.IBAN,
+		organisation.Bank,
+		organisation.Email,
+		organisation.Phone,
+		organisation.StripeAccount,
+		logoPath,
+		smallLogoPath,
+		updated,
+	)
+	if err = s.repo.UpdateOrganisation(organisationModel); err != nil {
+		return fmt.Errorf("update organisation failed: %w", err)
+	}
+	return recordAudit(s.repo, actor, models.AuditUpdate, "organisation", strconv.FormatInt(organisationModel.ID, 10), current, organisationModel)
+}
+
+var logoExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+}
+
+// saveLogo writes the image under a name that changes with every upload, so an old
+// logo is never served from a cache after it is replaced.
+func (s *OrganisationService) saveLogo(name string, image []byte, updated int64) (string, error) {
+	extension, ok := logoExtensions[http.DetectContentType(image)]
+	if !ok {
+		return "", custom_errors.NewValidationError(constants.ErrOrganisationLogoInvalid)
+	}
+	if err := os.MkdirAll(s.logoDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed creating logo directory: %w", err)
+	}
+	path := filepath.Join(s.logoDir, fmt.Sprintf("%s-%d%s", name, updated, extension))
+	if err := os.WriteFile(path, image, 0o644); err != nil {
+		return "", fmt.Errorf("failed saving logo: %w", err)
+	}
+	return path, nil
+}
+
+func normaliseOrganisation(organisation *dto.Organisation) {
+	if organisation.Address == nil {
+		organisation.Address = &dto.Address{}
+	}
+	organisation.TaxID = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(organisation.TaxID), " ", ""))
+	organisation.IBAN = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(organisation.IBAN), " ", ""))
+	organisation.StripeAccount = strings.TrimSpace(organisation.StripeAccount)
+	organisation.Address.Country = strings.ToUpper(strings.TrimSpace(organisation.Address.Country))
+}
+
+var (
+	countryPattern       = regexp.MustCompile(`^[A-Z]{2}$`)
+	stripeAccountPattern = regexp.MustCompile(`^acct_[A-Za-z0-9]+$`)
+)
+
+func validateOrganisation(organisation *dto.Organisation) error {
+	required := []struct {
+		field string
+		value string
+	}{
+		{"name", organisation.Name},
+		{"CUI", organisation.TaxID},
+		{"street", organisation.Address.Street},
+		{"city", organisation.Address.City},
+		{"country", organisation.Address.Country},
+		{"email", organisation.Email},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			return custom_errors.NewValidationError(constants.ErrOrganisationFieldMissing, r.field)
+		}
+	}
+	if !validRomanianTaxID(organisation.TaxID) {
+		return custom_errors.NewValidationError(constants.ErrOrganisationTaxIDInvalid)
+	}
+	if organisation.IBAN != "" && !validIBAN(organisation.IBAN) {
+		return custom_errors.NewValidationError(constants.ErrOrganisationIBANInvalid)
+	}
+	if _, err := mail.ParseAddress(organisation.Email); err != nil {
+		return custom_errors.NewValidationError(constants.ErrOrganisationEmailInvalid)
+	}
+	if !countryPattern.MatchString(organisation.Address.Country) {
+		return custom_errors.NewValidationError(constants.ErrOrganisationCountryInvalid)
+	}
+	if organisation.StripeAccount != "" && !stripeAccountPattern.MatchString(organisation.StripeAccount) {
+		return custom_errors.NewValidationError(constants.ErrOrganisationStripeAccountInvalid)
+	}
+	return nil
+}
+
+var taxIDPattern = regexp.MustCompile(`^(?:RO)?([0-9]{2,10})$`)
+
+// validRomanianTaxID checks the CUI control digit, with or without the RO VAT prefix.
+func validRomanianTaxID(taxID string) bool {
+	match := taxIDPattern.FindStringSubmatch(taxID)
+	if match == nil {
+		return false
+	}
+	digits := match[1]
+	body := strings.Repeat("0", 10-len(digits)) + digits[:len(digits)-1]
+	const key = "753217532"
+
+	sum := 0
+	for i := range key {
+		sum += int(body[i]-'0') * int(key[i]-'0')
+	}
+	control := sum * 10 % 11
+	if control == 10 {
+		control = 0
+	}
+	return int(digits[len(digits)-1]-'0') == control
+}
+
+var ibanPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+
+// validIBAN checks the ISO 13616 mod-97 checksum.
+func validIBAN(iban string) bool {
+	if !ibanPattern.MatchString(iban) {
+		return false
+	}
+	var numeric strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' && r <= 'Z' {
+			fmt.Fprintf(&numeric, "%d", r-'A'+10)
+		} else {
+			numeric.WriteRune(r)
+		}
+	}
+	value, ok := new(big.Int).SetString(numeric.String(), 10)
+	return ok && new(big.Int).Mod(value, big.NewInt(97)).Int64() == 1
+}
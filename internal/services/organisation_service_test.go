@@ -0,0 +1,106 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/diother/go-invoices/internal/dto"
+)
+
+func TestValidRomanianTaxID(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		expected bool
+	}{
+		"valid":           {input: "12345674", expected: true},
+		"validWithPrefix": {input: "RO12345674", expected: true},
+		"wrongControl":    {input: "12345678", expected: false},
+		"controlTen":      {input: "60", expected: true},
+		"letters":         {input: "12A45674", expected: false},
+		"tooLong":         {input: "123456789012", expected: false},
+		"empty":           {input: "", expected: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := validRomanianTaxID(tc.input)
+
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestValidIBAN(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		expected bool
+	}{
+		"romanian":      {input: "RO49AAAA1B31007593840000", expected: true},
+		"german":        {input: "DE89370400440532013000", expected: true},
+		"wrongChecksum": {input: "RO48AAAA1B31007593840000", expected: false},
+		"tooShort":      {input: "RO49AAAA", expected: false},
+		"lowerCase":     {input: "ro49aaaa1b31007593840000", expected: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := validIBAN(tc.input)
+
+			if result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestValidateOrganisation(t *testing.T) {
+	newOrganisation := func() *dto.Organisation {
+		return dto.NewOrganisation(
+			"Asociația de Caritate Hintermann",
+			"RO 12345674",
+			"",
+			"ro49 aaaa 1b31 0075 9384 0000",
+			"",
+			"contact@hintermann.ro",
+			"",
+			"acct_1PVfUvDXCtuWOFq8",
+			"",
+			"",
+			dto.NewAddress("Strada Spicului, Nr. 12", "", "Brașov", "RO-BV", "500460", "ro"),
+		)
+	}
+
+	testCases := map[string]struct {
+		modify      func(organisation *dto.Organisation)
+		expectError bool
+	}{
+		"valid":          {modify: func(organisation *dto.Organisation) {}},
+		"withoutIBAN":    {modify: func(organisation *dto.Organisation) { organisation.IBAN = "" }},
+		"missingName":    {modify: func(organisation *dto.Organisation) { organisation.Name = " " }, expectError: true},
+		"missingAddress": {modify: func(organisation *dto.Organisation) { organisation.Address = nil }, expectError: true},
+		"invalidTaxID":   {modify: func(organisation *dto.Organisation) { organisation.TaxID = "12345678" }, expectError: true},
+		"invalidIBAN":    {modify: func(organisation *dto.Organisation) { organisation.IBAN = "RO00AAAA1B31007593840000" }, expectError: true},
+		"invalidEmail":   {modify: func(organisation *dto.Organisation) { organisation.Email = "contact" }, expectError: true},
+		"invalidCountry": {modify: func(organisation *dto.Organisation) { organisation.Address.Country = "Romania" }, expectError: true},
+		"withoutStripe":  {modify: func(organisation *dto.Organisation) { organisation.StripeAccount = "" }},
+		"invalidStripe":  {modify: func(organisation *dto.Organisation) { organisation.StripeAccount = "1PVfUvDXCtuWOFq8" }, expectError: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			organisation := newOrganisation()
+			tc.modify(organisation)
+
+			normaliseOrganisation(organisation)
+			err := validateOrganisation(organisation)
+
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error, but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, but got: %v", err)
+			}
+		})
+	}
+}
@@ -3,49 +3,100 @@ package services
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/diother/go-invoices/internal/constants"
 	"github.com/diother/go-invoices/internal/models"
 	"github.com/stripe/stripe-go/v79"
-	"github.com/stripe/stripe-go/v79/balancetransaction"
-	"github.com/stripe/stripe-go/v79/charge"
 )
 
 type WebhookRepository interface {
-	InsertDonation(donation *models.Donation) error
+	DonationExists(id string) (bool, error)
+	InsertDonation(donation *models.Donation) (bool, error)
+	UpsertDonor(donor *models.Donor) error
+	EnqueueEmail(email *models.Email) error
 	InsertFee(fee *models.Fee) error
 	InsertPayout(payout *models.Payout) error
+	InsertRefund(refund *models.Refund) error
+	UpsertDispute(dispute *models.Dispute) error
+	InsertDisputeAdjustment(adjustment *models.DisputeAdjustment) error
 	UpdateRelatedPayout(donation *models.Donation) (bool, error)
+	UpdateRefundPayout(refund *models.Refund) (bool, error)
+	UpdateDisputeAdjustmentPayout(adjustment *models.DisputeAdjustment) (bool, error)
+	UpdatePayoutStatus(payout *models.Payout) (bool, error)
+	InsertPayoutStatusChange(change *models.PayoutStatusChange) error
+	ReleasePayoutTransactions(payoutID string) error
+	RelinkFailedPayoutTransactions(failedPayoutID, payoutID string) error
+	GetFailedPayout(failureTransactionID string) (*models.Payout, error)
+	NextInvoiceNumber(series string, year int, created int64) (number, issued int64, err error)
 	BeginTransaction() error
 	Rollback() error
 	Commit() error
 }
 
+// StripeGateway fetches the Stripe objects a webhook event refers to.
+type StripeGateway interface {
+	GetBalanceTransaction(id string) (*stripe.BalanceTransaction, error)
+	ListPayoutTransactions(payoutID string) ([]*stripe.BalanceTransaction, error)
+	GetCharge(id string) (*stripe.Charge, error)
+	ListCharges(created *stripe.RangeQueryParams) ([]*stripe.Charge, error)
+	GetRefund(id string) (*stripe.Refund, error)
+	ListChargeRefunds(chargeID string) ([]*stripe.Refund, error)
+	GetDispute(id string) (*stripe.Dispute, error)
+	ListPayouts(created *stripe.RangeQueryParams) ([]*stripe.Payout, error)
+}
+
 type PayoutService struct {
-	repo WebhookRepository
+	repo      WebhookRepository
+	gateway   StripeGateway
+	numbering *InvoiceNumbering
 }
 
-func NewPayoutService(repo WebhookRepository) *PayoutService {
-	return &PayoutService{repo: repo}
+func NewPayoutService(repo WebhookRepository, gateway StripeGateway, numbering *InvoiceNumbering) *PayoutService {
+	return &PayoutService{repo: repo, gateway: gateway, numbering: numbering}
 }
 
-func (s *PayoutService) ProcessPayout(payout *stripe.Payout) (err error) {
+func (s *PayoutService) ProcessPayout(payout *stripe.Payout, eventID string) (err error) {
 	if err = validatePayout(payout); err != nil {
 		return fmt.Errorf("payout validation error: %w", err)
 	}
 
-	transactions, err := fetchRelatedTransactions(payout.ID)
+	transactions, payoutGross, payoutFee, payoutNet, err := s.fetchPayoutTransactions(payout.ID)
 	if err != nil {
-		return fmt.Errorf("related transactions fetch failed: %w", err)
+		return err
 	}
 
-	if err = validateRelatedTransactions(transactions); err != nil {
-		return fmt.Errorf("related transactions validation failed: %w", err)
+	if err = s.repo.BeginTransaction(); err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
+	defer func() {
+		if r := recover(); r != nil {
+			s.repo.Rollback()
+			err = fmt.Errorf("panic occurred: %v", r)
+		} else if err != nil {
+			s.repo.Rollback()
+		} else {
+			s.repo.Commit()
+		}
+	}()
 
-	payoutGross, payoutFee, payoutNet, err := validateMatchingSums(transactions)
-	if err != nil {
-		return fmt.Errorf("matching sum validation failed: %w", err)
+	payoutModel := transformPayoutDTOToModel(transactions[0], payoutGross, payoutFee, payoutNet, models.PayoutPaid, "", eventID)
+	if err = s.persistPayout(payoutModel, transactions[1:], eventID); err != nil {
+		return err
+	}
+
+	statusModel := transformPayoutStatusDTOToModel(payout, payoutModel.ID, time.Now().Unix(), eventID)
+	if err = s.repo.InsertPayoutStatusChange(statusModel); err != nil {
+		return fmt.Errorf("database payout status insertion failed: %w", err)
+	}
+	return
+}
+
+// ProcessFailedPayout marks a bounced or cancelled payout and releases its transactions
+// back to the unpaid balance. Payouts that fail before being reconciled are recorded first.
+func (s *PayoutService) ProcessFailedPayout(payout *stripe.Payout, eventID string) (err error) {
+	if err = validateFailedPayout(payout); err != nil {
+		return fmt.Errorf("failed payout validation error: %w", err)
 	}
 
 	if err = s.repo.BeginTransaction(); err != nil {
@@ -62,53 +113,129 @@ func (s *PayoutService) ProcessPayout(payout *stripe.Payout) (err error) {
 		}
 	}()
 
-	payoutModel := transformPayoutDTOToModel(transactions[0], payoutGross, payoutFee, payoutNet)
-	if err = s.repo.InsertPayout(payoutModel); err != nil {
-		return fmt.Errorf("database payout insertion failed: %w", err)
+	payoutModel := transformFailedPayoutDTOToModel(payout)
+	updated, err := s.repo.UpdatePayoutStatus(payoutModel)
+	if err != nil {
+		return fmt.Errorf("update payout status failed: %w", err)
 	}
-
-	for _, transaction := range transactions[1:] {
-		if err = s.PersistRelatedTransaction(transaction, payoutModel.ID); err != nil {
-			return fmt.Errorf("related transaction persistence failed: %w", err)
+	if !updated {
+		if err = s.InsertFailedPayout(payout, eventID); err != nil {
+			return fmt.Errorf("insert failed payout failed: %w", err)
 		}
 	}
+
+	statusModel := transformPayoutStatusDTOToModel(payout, payoutModel.ID, time.Now().Unix(), eventID)
+	if err = s.repo.InsertPayoutStatusChange(statusModel); err != nil {
+		return fmt.Errorf("database payout status insertion failed: %w", err)
+	}
+	if err = s.repo.ReleasePayoutTransactions(payoutModel.ID); err != nil {
+		return fmt.Errorf("database payout release failed: %w", err)
+	}
 	return
 }
 
-func fetchRelatedTransactions(id string) ([]*stripe.BalanceTransaction, error) {
-	params := &stripe.BalanceTransactionListParams{}
-	params.Payout = &id
+func (s *PayoutService) InsertFailedPayout(payout *stripe.Payout, eventID string) error {
+	transactions, payoutGross, payoutFee, payoutNet, err := s.fetchPayoutTransactions(payout.ID)
+	if err != nil {
+		return err
+	}
+
+	failureTransactionID := ""
+	if payout.FailureBalanceTransaction != nil {
+		failureTransactionID = payout.FailureBalanceTransaction.ID
+	}
+	payoutModel := transformPayoutDTOToModel(transactions[0], payoutGross, payoutFee, payoutNet, string(payout.Status), failureTransactionID, eventID)
+	return s.persistPayout(payoutModel, transactions[1:], eventID)
+}
+
+func (s *PayoutService) fetchPayoutTransactions(payoutID string) (transactions []*stripe.BalanceTransaction, payoutGross, payoutFee, payoutNet int64, err error) {
+	transactions, err = s.gateway.ListPayoutTransactions(payoutID)
+	if err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("related transactions fetch failed: %w", err)
+	}
+
+	if err = validateRelatedTransactions(transactions); err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("related transactions validation failed: %w", err)
+	}
 
-	iter := balancetransaction.List(params)
+	failedPayouts, err := s.fetchFailedPayouts(transactions)
+	if err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("failed payouts fetch failed: %w", err)
+	}
 
-	var transactions []*stripe.BalanceTransaction
-	for iter.Next() {
-		transactions = append(transactions, iter.BalanceTransaction())
+	payoutGross, payoutFee, payoutNet, err = validateMatchingSums(transactions, failedPayouts)
+	if err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("matching sum validation failed: %w", err)
 	}
+	return
+}
+
+func (s *PayoutService) persistPayout(payoutModel *models.Payout, transactions []*stripe.BalanceTransaction, eventID string) (err error) {
+	if err = s.repo.InsertPayout(payoutModel); err != nil {
+		return fmt.Errorf("database payout insertion failed: %w", err)
+	}
+
+	for _, transaction := range transactions {
+		if err = s.PersistRelatedTransaction(transaction, payoutModel.ID, eventID); err != nil {
+			return fmt.Errorf("related transaction persistence failed: %w", err)
+		}
+	}
+	return
+}
 
-	if err := iter.Err(); err != nil {
-		return nil, err
+// fetchFailedPayouts looks up the failed payouts whose funds are returned by the
+// payout_failure and payout_cancel transactions, keyed by the returning transaction ID.
+func (s *PayoutService) fetchFailedPayouts(transactions []*stripe.BalanceTransaction) (map[string]*models.Payout, error) {
+	failedPayouts := make(map[string]*models.Payout)
+	for _, transaction := range transactions[1:] {
+		if !isPayoutFailureTransaction(transaction) {
+			continue
+		}
+		failedPayout, err := s.repo.GetFailedPayout(transaction.ID)
+		if err != nil {
+			return nil, err
+		}
+		failedPayouts[transaction.ID] = failedPayout
 	}
-	return transactions, nil
+	return failedPayouts, nil
 }
 
-func (s *PayoutService) PersistRelatedTransaction(transaction *stripe.BalanceTransaction, payoutID string) (err error) {
+func (s *PayoutService) PersistRelatedTransaction(transaction *stripe.BalanceTransaction, payoutID, eventID string) (err error) {
 	switch transaction.Type {
 	case "charge":
-		if err = s.UpsertDonation(transaction, payoutID); err != nil {
+		if err = s.UpsertDonation(transaction, payoutID, eventID); err != nil {
 			return fmt.Errorf("upsert donation failed for %s: %w", transaction.ID, err)
 		}
 
 	case "stripe_fee":
-		feeModel := transformFeeDTOToModel(transaction, payoutID)
+		feeModel := transformFeeDTOToModel(transaction, payoutID, eventID)
 		if err = s.repo.InsertFee(feeModel); err != nil {
 			return fmt.Errorf("database donation insertion failed: %w", err)
 		}
+
+	case "refund":
+		if err = s.UpsertRefund(transaction, payoutID, eventID); err != nil {
+			return fmt.Errorf("upsert refund failed for %s: %w", transaction.ID, err)
+		}
+
+	case "adjustment":
+		if err = s.UpsertDisputeAdjustment(transaction, payoutID, eventID); err != nil {
+			return fmt.Errorf("upsert dispute adjustment failed for %s: %w", transaction.ID, err)
+		}
+
+	case "payout_failure", "payout_cancel":
+		failedPayout, err := s.repo.GetFailedPayout(transaction.ID)
+		if err != nil {
+			return fmt.Errorf("failed payout fetch failed for %s: %w", transaction.ID, err)
+		}
+		if err = s.repo.RelinkFailedPayoutTransactions(failedPayout.ID, payoutID); err != nil {
+			return fmt.Errorf("database failed payout relink failed: %w", err)
+		}
 	}
 	return
 }
 
-func (s *PayoutService) UpsertDonation(transaction *stripe.BalanceTransaction, payoutID string) (err error) {
+func (s *PayoutService) UpsertDonation(transaction *stripe.BalanceTransaction, payoutID, eventID string) (err error) {
 	donationModel := transformUpdateDonationDTOToModel(transaction.ID, payoutID)
 	updated, err := s.repo.UpdateRelatedPayout(donationModel)
 	if err != nil {
@@ -118,7 +245,7 @@ func (s *PayoutService) UpsertDonation(transaction *stripe.BalanceTransaction, p
 		return
 	}
 
-	charge, err := fetchRelatedCharge(transaction)
+	charge, err := s.gateway.GetCharge(transaction.Source.ID)
 	if err != nil {
 		return fmt.Errorf("related charge fetch failed: %w", err)
 	}
@@ -126,59 +253,163 @@ func (s *PayoutService) UpsertDonation(transaction *stripe.BalanceTransaction, p
 		return fmt.Errorf("related charge validation failed: %w", err)
 	}
 
-	donationModel = transformDonationDTOToModel(transaction, charge, payoutID)
-	if err = s.repo.InsertDonation(donationModel); err != nil {
+	donationModel = transformDonationDTOToModel(transaction, charge, payoutID, eventID)
+	if err = s.numbering.Assign(s.repo, donationModel); err != nil {
+		return err
+	}
+	if err = assignDonor(s.repo, donationModel); err != nil {
+		return err
+	}
+	inserted, err := s.repo.InsertDonation(donationModel)
+	if err != nil {
 		return fmt.Errorf("database donation insertion failed: %w", err)
 	}
+	if !inserted {
+		// Its charge event stored it meanwhile; rolling back gives the invoice number back,
+		// and the retry links the stored donation instead.
+		return fmt.Errorf("donation %s was stored concurrently", donationModel.ID)
+	}
+	return enqueueInvoiceEmail(s.repo, donationModel)
+}
+
+func (s *PayoutService) UpsertRefund(transaction *stripe.BalanceTransaction, payoutID, eventID string) (err error) {
+	refundModel := transformUpdateRefundDTOToModel(transaction.ID, payoutID)
+	updated, err := s.repo.UpdateRefundPayout(refundModel)
+	if err != nil {
+		return fmt.Errorf("update refund payout failed: %w", err)
+	}
+	if updated {
+		return
+	}
+
+	refund, err := s.gateway.GetRefund(transaction.Source.ID)
+	if err != nil {
+		return fmt.Errorf("related refund fetch failed: %w", err)
+	}
+	if err = validateRelatedRefund(refund); err != nil {
+		return fmt.Errorf("related refund validation failed: %w", err)
+	}
+
+	refundModel = transformRefundDTOToModel(transaction, refund.Charge.BalanceTransaction.ID, payoutID, eventID)
+	if err = s.repo.InsertRefund(refundModel); err != nil {
+		return fmt.Errorf("database refund insertion failed: %w", err)
+	}
+	return
+}
+
+func (s *PayoutService) UpsertDisputeAdjustment(transaction *stripe.BalanceTransaction, payoutID, eventID string) (err error) {
+	adjustmentModel := transformUpdateDisputeAdjustmentDTOToModel(transaction.ID, payoutID)
+	updated, err := s.repo.UpdateDisputeAdjustmentPayout(adjustmentModel)
+	if err != nil {
+		return fmt.Errorf("update dispute adjustment payout failed: %w", err)
+	}
+	if updated {
+		return
+	}
+
+	dispute, err := s.gateway.GetDispute(transaction.Source.ID)
+	if err != nil {
+		return fmt.Errorf("related dispute fetch failed: %w", err)
+	}
+	if err = validateDispute(dispute); err != nil {
+		return fmt.Errorf("related dispute validation failed: %w", err)
+	}
+	charge, err := s.gateway.GetCharge(dispute.Charge.ID)
+	if err != nil {
+		return fmt.Errorf("disputed charge fetch failed: %w", err)
+	}
+	if err = validateCharge(charge); err != nil {
+		return fmt.Errorf("disputed charge validation failed: %w", err)
+	}
+
+	disputeModel := transformDisputeDTOToModel(dispute, charge.BalanceTransaction.ID, eventID)
+	if err = s.repo.UpsertDispute(disputeModel); err != nil {
+		return fmt.Errorf("database dispute upsert failed: %w", err)
+	}
+	adjustmentModel = transformDisputeAdjustmentDTOToModel(transaction, dispute.ID, payoutID, eventID)
+	if err = s.repo.InsertDisputeAdjustment(adjustmentModel); err != nil {
+		return fmt.Errorf("database dispute adjustment insertion failed: %w", err)
+	}
 	return
 }
 
-func transformPayoutDTOToModel(transaction *stripe.BalanceTransaction, gross, fee, net int64) *models.Payout {
+func transformPayoutDTOToModel(transaction *stripe.BalanceTransaction, gross, fee, net int64, status, failureTransactionID, eventID string) *models.Payout {
 	return models.NewPayout(
 		transaction.ID,
 		uint64(transaction.Created),
-		uint32(gross),
-		uint32(fee),
-		uint32(net),
+		gross,
+		fee,
+		net,
+		string(transaction.Currency),
+		status,
+		toNullString(failureTransactionID),
+		toNullString(eventID),
+	)
+}
+
+func transformFailedPayoutDTOToModel(payout *stripe.Payout) *models.Payout {
+	failureTransactionID := ""
+	if payout.FailureBalanceTransaction != nil {
+		failureTransactionID = payout.FailureBalanceTransaction.ID
+	}
+	return models.NewPayout(
+		payout.BalanceTransaction.ID,
+		0, 0, 0, 0,
+		"",
+		string(payout.Status),
+		toNullString(failureTransactionID),
+		sql.NullString{Valid: false},
+	)
+}
+
+func transformPayoutStatusDTOToModel(payout *stripe.Payout, payoutID string, created int64, eventID string) *models.PayoutStatusChange {
+	return models.NewPayoutStatusChange(
+		payoutID,
+		string(payout.Status),
+		uint64(created),
+		toNullString(string(payout.FailureCode)),
+		toNullString(payout.FailureMessage),
+		toNullString(eventID),
 	)
 }
 
 func transformUpdateDonationDTOToModel(transactionID, payoutID string) *models.Donation {
-	return models.NewDonation(transactionID, 0, 0, 0, 0, "", "", sql.NullString{String: payoutID, Valid: true})
+	return models.NewDonation(transactionID, 0, 0, 0, 0, "", "", "", sql.NullInt64{Valid: false}, sql.NullString{Valid: false}, sql.NullFloat64{Valid: false}, sql.NullString{String: payoutID, Valid: true}, sql.NullString{Valid: false})
 }
 
-func transformDonationDTOToModel(transaction *stripe.BalanceTransaction, charge *stripe.Charge, payoutID string) *models.Donation {
-	return models.NewDonation(
+func transformDonationDTOToModel(transaction *stripe.BalanceTransaction, charge *stripe.Charge, payoutID, eventID string) *models.Donation {
+	donation := models.NewDonation(
 		transaction.ID,
 		uint64(transaction.Created),
-		uint32(transaction.Amount),
-		uint32(transaction.Fee),
-		uint32(transaction.Net),
+		transaction.Amount,
+		transaction.Fee,
+		transaction.Net,
+		string(transaction.Currency),
 		charge.BillingDetails.Name,
 		charge.BillingDetails.Email,
+		sql.NullInt64{Int64: charge.Amount, Valid: true},
+		toNullString(string(charge.Currency)),
+		toNullFloat64(transaction.ExchangeRate),
 		sql.NullString{String: payoutID, Valid: true},
+		toNullString(eventID),
 	)
+	applyBillingAddress(donation, charge.BillingDetails.Address)
+	applyCustomerLocale(donation, charge.Customer)
+	return donation
 }
 
-func transformFeeDTOToModel(transaction *stripe.BalanceTransaction, payoutID string) *models.Fee {
+func transformFeeDTOToModel(transaction *stripe.BalanceTransaction, payoutID, eventID string) *models.Fee {
 	return models.NewFee(
 		transaction.ID,
 		transaction.Description,
 		uint64(transaction.Created),
-		uint32(-transaction.Amount),
+		-transaction.Amount,
+		string(transaction.Currency),
 		sql.NullString{String: payoutID, Valid: true},
+		toNullString(eventID),
 	)
 }
 
-func fetchRelatedCharge(transaction *stripe.BalanceTransaction) (*stripe.Charge, error) {
-	params := &stripe.ChargeParams{}
-	charge, err := charge.Get(transaction.Source.ID, params)
-	if err != nil {
-		return nil, err
-	}
-	return charge, nil
-}
-
 func validateRelatedTransactions(transactions []*stripe.BalanceTransaction) error {
 	if len(transactions) < 2 {
 		return fmt.Errorf(constants.ErrPayoutListInsufficientTransactions)
@@ -190,6 +421,9 @@ func validateRelatedTransactions(transactions []*stripe.BalanceTransaction) erro
 		if err := validateRelatedTransaction(transaction); err != nil {
 			return fmt.Errorf(constants.ErrPayoutListRelatedTransactionInvalid+": %w", err)
 		}
+		if transaction.Currency != transactions[0].Currency {
+			return fmt.Errorf(constants.ErrPayoutListCurrencyMismatch+": %s", transaction.ID)
+		}
 	}
 	return nil
 }
@@ -200,12 +434,20 @@ func validateRelatedTransaction(transaction *stripe.BalanceTransaction) error {
 		return validateChargeTransaction(transaction)
 	case "stripe_fee":
 		return validateFeeTransaction(transaction)
+	case "refund":
+		return validateRefundTransaction(transaction)
+	case "adjustment":
+		return validateDisputeTransaction(transaction)
+	case "payout_failure", "payout_cancel":
+		return validatePayoutFailureTransaction(transaction)
 	default:
 		return fmt.Errorf(constants.ErrPayoutListUnexpectedTransaction+": %s", transaction.Type)
 	}
 }
 
-func validateMatchingSums(transactions []*stripe.BalanceTransaction) (payoutGross, payoutFee, payoutNet int64, err error) {
+// validateMatchingSums checks the payout amount against its transactions. Funds returned by a
+// failed payout count with the gross and fee of the transactions that payout contained.
+func validateMatchingSums(transactions []*stripe.BalanceTransaction, failedPayouts map[string]*models.Payout) (payoutGross, payoutFee, payoutNet int64, err error) {
 	for _, transaction := range transactions[1:] {
 		switch transaction.Type {
 		case "charge":
@@ -214,6 +456,21 @@ func validateMatchingSums(transactions []*stripe.BalanceTransaction) (payoutGros
 
 		case "stripe_fee":
 			payoutFee -= transaction.Amount
+
+		case "refund", "adjustment":
+			payoutGross += transaction.Amount
+			payoutFee += transaction.Fee
+
+		case "payout_failure", "payout_cancel":
+			failedPayout, ok := failedPayouts[transaction.ID]
+			if !ok {
+				return 0, 0, 0, fmt.Errorf(constants.ErrPayoutListFailedPayoutMissing+": %s", transaction.ID)
+			}
+			if failedPayout.Net != transaction.Amount {
+				return 0, 0, 0, fmt.Errorf(constants.ErrPayoutListFailedPayoutMismatch+". amount %v != net %v", transaction.Amount, failedPayout.Net)
+			}
+			payoutGross += failedPayout.Gross
+			payoutFee += failedPayout.Fee
 		}
 	}
 	payoutNet = payoutGross - payoutFee
@@ -238,6 +495,22 @@ func validatePayout(payout *stripe.Payout) error {
 	return nil
 }
 
+func validateFailedPayout(payout *stripe.Payout) error {
+	if payout == nil {
+		return fmt.Errorf(constants.ErrPayoutMissing)
+	}
+	if payout.Status != "failed" && payout.Status != "canceled" {
+		return fmt.Errorf(constants.ErrPayoutFailedStatusInvalid)
+	}
+	if payout.ID == "" {
+		return fmt.Errorf(constants.ErrPayoutIDMissing)
+	}
+	if payout.BalanceTransaction == nil || payout.BalanceTransaction.ID == "" {
+		return fmt.Errorf(constants.ErrPayoutBalanceTransactionMissing)
+	}
+	return nil
+}
+
 func validateCharge(charge *stripe.Charge) error {
 	if charge == nil {
 		return fmt.Errorf(constants.ErrChargeMissing)
@@ -288,6 +561,35 @@ func validatePayoutTransaction(transaction *stripe.BalanceTransaction) error {
 	return nil
 }
 
+func validatePayoutFailureTransaction(transaction *stripe.BalanceTransaction) error {
+	if transaction == nil {
+		return fmt.Errorf(constants.ErrTransactionMissing)
+	}
+	if !isPayoutFailureTransaction(transaction) {
+		return fmt.Errorf(constants.ErrPayoutFailureTransactionTypeInvalid)
+	}
+	if transaction.ID == "" {
+		return fmt.Errorf(constants.ErrTransactionIDMissing)
+	}
+	if transaction.Created <= 0 {
+		return fmt.Errorf(constants.ErrTransactionCreatedInvalid)
+	}
+	if transaction.Amount <= 0 {
+		return fmt.Errorf(constants.ErrPayoutFailureTransactionAmountInvalid)
+	}
+	if transaction.Fee != 0 {
+		return fmt.Errorf(constants.ErrPayoutFailureTransactionFeeInvalid)
+	}
+	if transaction.Net <= 0 {
+		return fmt.Errorf(constants.ErrPayoutFailureTransactionNetInvalid)
+	}
+	return nil
+}
+
+func isPayoutFailureTransaction(transaction *stripe.BalanceTransaction) bool {
+	return transaction.Type == "payout_failure" || transaction.Type == "payout_cancel"
+}
+
 func validateChargeTransaction(transaction *stripe.BalanceTransaction) error {
 	if transaction == nil {
 		return fmt.Errorf(constants.ErrTransactionMissing)
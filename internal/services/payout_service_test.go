@@ -34,6 +34,35 @@ func TestValidatePayout(t *testing.T) {
 	}
 }
 
+func TestValidateFailedPayout(t *testing.T) {
+	balanceTransaction := &stripe.BalanceTransaction{ID: "txn_payout_123456"}
+
+	testCases := map[string]struct {
+		input    *stripe.Payout
+		expected string
+	}{
+		"validFailedPayout":           {&stripe.Payout{ID: "po_123456789", Status: "failed", BalanceTransaction: balanceTransaction}, ""},
+		"validCanceledPayout":         {&stripe.Payout{ID: "po_123456789", Status: "canceled", BalanceTransaction: balanceTransaction}, ""},
+		"payoutMissing":               {nil, constants.ErrPayoutMissing},
+		"statusInvalid":               {&stripe.Payout{ID: "po_123456789", Status: "paid"}, constants.ErrPayoutFailedStatusInvalid},
+		"IDMissing":                   {&stripe.Payout{Status: "failed"}, constants.ErrPayoutIDMissing},
+		"balanceTransactionMissing":   {&stripe.Payout{ID: "po_123456789", Status: "failed"}, constants.ErrPayoutBalanceTransactionMissing},
+		"balanceTransactionIDMissing": {&stripe.Payout{ID: "po_123456789", Status: "failed", BalanceTransaction: &stripe.BalanceTransaction{}}, constants.ErrPayoutBalanceTransactionMissing},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateFailedPayout(tc.input)
+			if tc.expected == "" && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+			if tc.expected != "" && (err == nil || err.Error() != tc.expected) {
+				t.Errorf("Expected error: %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
 func TestValidateCharge(t *testing.T) {
 	testCases := map[string]struct {
 		input    *stripe.Charge
@@ -219,10 +248,40 @@ func TestValidateFeeTransaction(t *testing.T) {
 	}
 }
 
+func TestValidatePayoutFailureTransaction(t *testing.T) {
+	testCases := map[string]struct {
+		input    *stripe.BalanceTransaction
+		expected string
+	}{
+		"validFailure":       {&stripe.BalanceTransaction{ID: "txn_failure_123456", Type: "payout_failure", Created: 1234567890, Amount: 1000, Fee: 0, Net: 1000}, ""},
+		"validCancel":        {&stripe.BalanceTransaction{ID: "txn_cancel_123456", Type: "payout_cancel", Created: 1234567890, Amount: 1000, Fee: 0, Net: 1000}, ""},
+		"transactionMissing": {nil, constants.ErrTransactionMissing},
+		"typeInvalid":        {&stripe.BalanceTransaction{ID: "txn_failure_123456", Type: "payout"}, constants.ErrPayoutFailureTransactionTypeInvalid},
+		"IDMissing":          {&stripe.BalanceTransaction{Type: "payout_failure"}, constants.ErrTransactionIDMissing},
+		"createdInvalid":     {&stripe.BalanceTransaction{ID: "txn_failure_123456", Type: "payout_failure"}, constants.ErrTransactionCreatedInvalid},
+		"amountInvalid":      {&stripe.BalanceTransaction{ID: "txn_failure_123456", Type: "payout_failure", Created: 1234567890, Amount: -1000}, constants.ErrPayoutFailureTransactionAmountInvalid},
+		"feeInvalid":         {&stripe.BalanceTransaction{ID: "txn_failure_123456", Type: "payout_failure", Created: 1234567890, Amount: 1000, Fee: 1}, constants.ErrPayoutFailureTransactionFeeInvalid},
+		"netInvalid":         {&stripe.BalanceTransaction{ID: "txn_failure_123456", Type: "payout_failure", Created: 1234567890, Amount: 1000, Fee: 0, Net: 0}, constants.ErrPayoutFailureTransactionNetInvalid},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validatePayoutFailureTransaction(tc.input)
+			if tc.expected == "" && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+			if tc.expected != "" && (err == nil || err.Error() != tc.expected) {
+				t.Errorf("Expected error: %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
 func TestValidateRelatedTransactions(t *testing.T) {
 	validPayout := &stripe.BalanceTransaction{ID: "txn_123456", Type: "payout", Created: 1234567890, Amount: -1000, Fee: 0, Net: -1000}
 	validCharge := &stripe.BalanceTransaction{ID: "txn_123456", Type: "charge", Created: 1234567890, Amount: 1000, Fee: 100, Net: 900, Source: &stripe.BalanceTransactionSource{ID: "src_123456"}}
 	validFee := &stripe.BalanceTransaction{ID: "txn_fee_123456", Type: "stripe_fee", Description: "Billing", Created: 1234567890, Amount: -100, Fee: 0, Net: -100}
+	validRefund := &stripe.BalanceTransaction{ID: "txn_refund_123456", Type: "refund", Created: 1234567890, Amount: -500, Fee: 0, Net: -500, Source: &stripe.BalanceTransactionSource{ID: "re_123456"}}
 
 	testCases := map[string]struct {
 		input    []*stripe.BalanceTransaction
@@ -232,6 +291,10 @@ func TestValidateRelatedTransactions(t *testing.T) {
 			input:    []*stripe.BalanceTransaction{validPayout, validCharge, validFee},
 			expected: "",
 		},
+		"validTransactionsWithRefund": {
+			input:    []*stripe.BalanceTransaction{validPayout, validCharge, validRefund, validFee},
+			expected: "",
+		},
 		"insufficientTransactions": {
 			input:    []*stripe.BalanceTransaction{validPayout},
 			expected: constants.ErrPayoutListInsufficientTransactions,
@@ -248,6 +311,10 @@ func TestValidateRelatedTransactions(t *testing.T) {
 			input:    []*stripe.BalanceTransaction{validPayout, {Type: "unexpected"}},
 			expected: constants.ErrPayoutListUnexpectedTransaction,
 		},
+		"currencyMismatch": {
+			input:    []*stripe.BalanceTransaction{validPayout, {ID: "txn_eur_123456", Type: "charge", Created: 1234567890, Amount: 1000, Fee: 100, Net: 900, Currency: "eur", Source: &stripe.BalanceTransactionSource{ID: "src_123456"}}},
+			expected: constants.ErrPayoutListCurrencyMismatch,
+		},
 	}
 
 	for name, tc := range testCases {
@@ -267,9 +334,17 @@ func TestValidateMatchingSums(t *testing.T) {
 	validPayout := &stripe.BalanceTransaction{ID: "txn_123456", Type: "payout", Created: 1234567890, Amount: -800, Fee: 0, Net: -800}
 	validCharge := &stripe.BalanceTransaction{ID: "txn_123456", Type: "charge", Created: 1234567890, Amount: 1000, Fee: 100, Net: 900, Source: &stripe.BalanceTransactionSource{ID: "src_123456"}}
 	validFee := &stripe.BalanceTransaction{ID: "txn_fee_123456", Type: "stripe_fee", Description: "Billing", Created: 1234567890, Amount: -100, Fee: 0, Net: -100}
+	validRefund := &stripe.BalanceTransaction{ID: "txn_refund_123456", Type: "refund", Created: 1234567890, Amount: -500, Fee: 0, Net: -500, Source: &stripe.BalanceTransactionSource{ID: "re_123456"}}
+	refundedPayout := &stripe.BalanceTransaction{ID: "txn_654321", Type: "payout", Created: 1234567890, Amount: -300, Fee: 0, Net: -300}
+	returnedPayout := &stripe.BalanceTransaction{ID: "txn_987654", Type: "payout", Created: 1234567890, Amount: -1500, Fee: 0, Net: -1500}
+	validFailure := &stripe.BalanceTransaction{ID: "txn_failure_123456", Type: "payout_failure", Created: 1234567890, Amount: 700, Fee: 0, Net: 700}
+	failedPayouts := map[string]*models.Payout{
+		"txn_failure_123456": {ID: "txn_failed_123456", Gross: 1000, Fee: 300, Net: 700, Status: models.PayoutFailed},
+	}
 
 	testCases := map[string]struct {
 		input         []*stripe.BalanceTransaction
+		failedPayouts map[string]*models.Payout
 		expectedErr   string
 		expectedGross int64
 		expectedFee   int64
@@ -282,15 +357,41 @@ func TestValidateMatchingSums(t *testing.T) {
 			expectedFee:   200,
 			expectedNet:   800,
 		},
+		"validRelatedTransactionsWithRefund": {
+			input:         []*stripe.BalanceTransaction{refundedPayout, validCharge, validRefund, validFee},
+			expectedErr:   "",
+			expectedGross: 500,
+			expectedFee:   200,
+			expectedNet:   300,
+		},
+		"validRelatedTransactionsWithFailure": {
+			input:         []*stripe.BalanceTransaction{returnedPayout, validCharge, validFailure, validFee},
+			failedPayouts: failedPayouts,
+			expectedErr:   "",
+			expectedGross: 2000,
+			expectedFee:   500,
+			expectedNet:   1500,
+		},
 		"payoutMismatch": {
 			input:       []*stripe.BalanceTransaction{validPayout, validCharge, validFee, validFee},
 			expectedErr: constants.ErrPayoutListSumMismatch,
 		},
+		"failedPayoutMissing": {
+			input:       []*stripe.BalanceTransaction{returnedPayout, validCharge, validFailure, validFee},
+			expectedErr: constants.ErrPayoutListFailedPayoutMissing,
+		},
+		"failedPayoutMismatch": {
+			input: []*stripe.BalanceTransaction{returnedPayout, validCharge, validFailure, validFee},
+			failedPayouts: map[string]*models.Payout{
+				"txn_failure_123456": {ID: "txn_failed_123456", Gross: 1000, Fee: 100, Net: 900, Status: models.PayoutFailed},
+			},
+			expectedErr: constants.ErrPayoutListFailedPayoutMismatch,
+		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			gross, fee, net, err := validateMatchingSums(tc.input)
+			gross, fee, net, err := validateMatchingSums(tc.input, tc.failedPayouts)
 			if tc.expectedErr == "" && err != nil {
 				t.Errorf("Expected no error, got: %v", err)
 			}
@@ -320,13 +421,17 @@ func TestTransformNoPayoutDonationDTOToModel(t *testing.T) {
 	}{
 		"validData": {
 			transaction: &stripe.BalanceTransaction{
-				ID:      "txn_123456",
-				Created: 1234567890,
-				Amount:  2500,
-				Fee:     500,
-				Net:     2000,
+				ID:           "txn_123456",
+				Created:      1234567890,
+				Amount:       2500,
+				Fee:          500,
+				Net:          2000,
+				Currency:     "ron",
+				ExchangeRate: 4.97,
 			},
 			charge: &stripe.Charge{
+				Amount:   503,
+				Currency: "eur",
 				BillingDetails: &stripe.ChargeBillingDetails{
 					Name:  "John Doe",
 					Email: "john.doe@example.com",
@@ -335,19 +440,24 @@ func TestTransformNoPayoutDonationDTOToModel(t *testing.T) {
 			expected: models.NewDonation(
 				"txn_123456",
 				uint64(1234567890),
-				uint32(2500),
-				uint32(500),
-				uint32(2000),
+				int64(2500),
+				int64(500),
+				int64(2000),
+				"ron",
 				"John Doe",
 				"john.doe@example.com",
+				sql.NullInt64{Int64: 503, Valid: true},
+				sql.NullString{String: "eur", Valid: true},
+				sql.NullFloat64{Float64: 4.97, Valid: true},
 				sql.NullString{Valid: false},
+				sql.NullString{String: "evt_123456", Valid: true},
 			),
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformNoPayoutDonationDTOToModel(tc.transaction, tc.charge)
+			result := transformNoPayoutDonationDTOToModel(tc.transaction, tc.charge, "evt_123456")
 
 			if result.ID != tc.expected.ID {
 				t.Errorf("Expected ID %v, got %v", tc.expected.ID, result.ID)
@@ -370,9 +480,24 @@ func TestTransformNoPayoutDonationDTOToModel(t *testing.T) {
 			if result.ClientEmail != tc.expected.ClientEmail {
 				t.Errorf("Expected ClientEmail %v, got %v", tc.expected.ClientEmail, result.ClientEmail)
 			}
+			if result.Currency != tc.expected.Currency {
+				t.Errorf("Expected Currency %v, got %v", tc.expected.Currency, result.Currency)
+			}
+			if result.OriginalAmount != tc.expected.OriginalAmount {
+				t.Errorf("Expected OriginalAmount %v, got %v", tc.expected.OriginalAmount, result.OriginalAmount)
+			}
+			if result.OriginalCurrency != tc.expected.OriginalCurrency {
+				t.Errorf("Expected OriginalCurrency %v, got %v", tc.expected.OriginalCurrency, result.OriginalCurrency)
+			}
+			if result.ExchangeRate != tc.expected.ExchangeRate {
+				t.Errorf("Expected ExchangeRate %v, got %v", tc.expected.ExchangeRate, result.ExchangeRate)
+			}
 			if result.PayoutID != tc.expected.PayoutID {
 				t.Errorf("Expected PayoutID %v, got %v", tc.expected.PayoutID, result.PayoutID)
 			}
+			if result.EventID != tc.expected.EventID {
+				t.Errorf("Expected EventID %v, got %v", tc.expected.EventID, result.EventID)
+			}
 		})
 	}
 }
@@ -388,8 +513,12 @@ func TestTransformUpdateDonationDTOToModel(t *testing.T) {
 			payoutID:      "txn_789456",
 			expected: models.NewDonation(
 				"txn_7894561",
-				0, 0, 0, 0, "", "",
+				0, 0, 0, 0, "", "", "",
+				sql.NullInt64{Valid: false},
+				sql.NullString{Valid: false},
+				sql.NullFloat64{Valid: false},
 				sql.NullString{String: "txn_789456", Valid: true},
+				sql.NullString{Valid: false},
 			),
 		},
 	}
@@ -404,6 +533,9 @@ func TestTransformUpdateDonationDTOToModel(t *testing.T) {
 			if result.PayoutID != tc.expected.PayoutID {
 				t.Errorf("Expected PayoutID %v, got %v", tc.expected.PayoutID, result.PayoutID)
 			}
+			if result.EventID != tc.expected.EventID {
+				t.Errorf("Expected EventID %v, got %v", tc.expected.EventID, result.EventID)
+			}
 			if result.Created != tc.expected.Created {
 				t.Errorf("Expected Created %v, got %v", tc.expected.Created, result.Created)
 			}
@@ -435,13 +567,16 @@ func TestTransformDonationDTOToModel(t *testing.T) {
 	}{
 		"validData": {
 			transaction: &stripe.BalanceTransaction{
-				ID:      "txn_987654",
-				Created: 1627849100,
-				Amount:  5000,
-				Fee:     1000,
-				Net:     4000,
+				ID:       "txn_987654",
+				Created:  1627849100,
+				Amount:   5000,
+				Fee:      1000,
+				Net:      4000,
+				Currency: "ron",
 			},
 			charge: &stripe.Charge{
+				Amount:   5000,
+				Currency: "ron",
 				BillingDetails: &stripe.ChargeBillingDetails{
 					Name:  "Jane Doe",
 					Email: "jane.doe@example.com",
@@ -451,19 +586,24 @@ func TestTransformDonationDTOToModel(t *testing.T) {
 			expected: models.NewDonation(
 				"txn_987654",
 				uint64(1627849100),
-				uint32(5000),
-				uint32(1000),
-				uint32(4000),
+				int64(5000),
+				int64(1000),
+				int64(4000),
+				"ron",
 				"Jane Doe",
 				"jane.doe@example.com",
+				sql.NullInt64{Int64: 5000, Valid: true},
+				sql.NullString{String: "ron", Valid: true},
+				sql.NullFloat64{Valid: false},
 				sql.NullString{String: "po_321654", Valid: true},
+				sql.NullString{String: "evt_987654", Valid: true},
 			),
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformDonationDTOToModel(tc.transaction, tc.charge, tc.payoutID)
+			result := transformDonationDTOToModel(tc.transaction, tc.charge, tc.payoutID, "evt_987654")
 
 			if result.ID != tc.expected.ID {
 				t.Errorf("Expected ID %v, got %v", tc.expected.ID, result.ID)
@@ -486,9 +626,24 @@ func TestTransformDonationDTOToModel(t *testing.T) {
 			if result.ClientEmail != tc.expected.ClientEmail {
 				t.Errorf("Expected ClientEmail %v, got %v", tc.expected.ClientEmail, result.ClientEmail)
 			}
+			if result.Currency != tc.expected.Currency {
+				t.Errorf("Expected Currency %v, got %v", tc.expected.Currency, result.Currency)
+			}
+			if result.OriginalAmount != tc.expected.OriginalAmount {
+				t.Errorf("Expected OriginalAmount %v, got %v", tc.expected.OriginalAmount, result.OriginalAmount)
+			}
+			if result.OriginalCurrency != tc.expected.OriginalCurrency {
+				t.Errorf("Expected OriginalCurrency %v, got %v", tc.expected.OriginalCurrency, result.OriginalCurrency)
+			}
+			if result.ExchangeRate != tc.expected.ExchangeRate {
+				t.Errorf("Expected ExchangeRate %v, got %v", tc.expected.ExchangeRate, result.ExchangeRate)
+			}
 			if result.PayoutID != tc.expected.PayoutID {
 				t.Errorf("Expected PayoutID %v, got %v", tc.expected.PayoutID, result.PayoutID)
 			}
+			if result.EventID != tc.expected.EventID {
+				t.Errorf("Expected EventID %v, got %v", tc.expected.EventID, result.EventID)
+			}
 		})
 	}
 }
@@ -503,8 +658,9 @@ func TestTransformPayoutDTOToModel(t *testing.T) {
 	}{
 		"validData": {
 			transaction: &stripe.BalanceTransaction{
-				ID:      "txn_payout_123456",
-				Created: 1627849100,
+				ID:       "txn_payout_123456",
+				Created:  1627849100,
+				Currency: "ron",
 			},
 			gross: 10000,
 			fee:   500,
@@ -512,16 +668,20 @@ func TestTransformPayoutDTOToModel(t *testing.T) {
 			expected: models.NewPayout(
 				"txn_payout_123456",
 				uint64(1627849100),
-				uint32(10000),
-				uint32(500),
-				uint32(9500),
+				int64(10000),
+				int64(500),
+				int64(9500),
+				"ron",
+				models.PayoutPaid,
+				sql.NullString{Valid: false},
+				sql.NullString{String: "evt_payout_123456", Valid: true},
 			),
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformPayoutDTOToModel(tc.transaction, tc.gross, tc.fee, tc.net)
+			result := transformPayoutDTOToModel(tc.transaction, tc.gross, tc.fee, tc.net, models.PayoutPaid, "", "evt_payout_123456")
 
 			if result.ID != tc.expected.ID {
 				t.Errorf("Expected ID %v, got %v", tc.expected.ID, result.ID)
@@ -538,6 +698,67 @@ func TestTransformPayoutDTOToModel(t *testing.T) {
 			if result.Net != tc.expected.Net {
 				t.Errorf("Expected Net %v, got %v", tc.expected.Net, result.Net)
 			}
+			if result.Currency != tc.expected.Currency {
+				t.Errorf("Expected Currency %v, got %v", tc.expected.Currency, result.Currency)
+			}
+			if result.Status != tc.expected.Status {
+				t.Errorf("Expected Status %v, got %v", tc.expected.Status, result.Status)
+			}
+			if result.FailureTransactionID != tc.expected.FailureTransactionID {
+				t.Errorf("Expected FailureTransactionID %v, got %v", tc.expected.FailureTransactionID, result.FailureTransactionID)
+			}
+			if result.EventID != tc.expected.EventID {
+				t.Errorf("Expected EventID %v, got %v", tc.expected.EventID, result.EventID)
+			}
+		})
+	}
+}
+
+func TestTransformFailedPayoutDTOToModel(t *testing.T) {
+	testCases := map[string]struct {
+		payout   *stripe.Payout
+		expected *models.Payout
+	}{
+		"failedPayout": {
+			payout: &stripe.Payout{
+				ID:                        "po_123456",
+				Status:                    "failed",
+				BalanceTransaction:        &stripe.BalanceTransaction{ID: "txn_payout_123456"},
+				FailureBalanceTransaction: &stripe.BalanceTransaction{ID: "txn_failure_123456"},
+			},
+			expected: models.NewPayout(
+				"txn_payout_123456",
+				0, 0, 0, 0,
+				"",
+				models.PayoutFailed,
+				sql.NullString{String: "txn_failure_123456", Valid: true},
+				sql.NullString{Valid: false},
+			),
+		},
+		"canceledPayout": {
+			payout: &stripe.Payout{
+				ID:                 "po_654321",
+				Status:             "canceled",
+				BalanceTransaction: &stripe.BalanceTransaction{ID: "txn_payout_654321"},
+			},
+			expected: models.NewPayout(
+				"txn_payout_654321",
+				0, 0, 0, 0,
+				"",
+				models.PayoutCanceled,
+				sql.NullString{Valid: false},
+				sql.NullString{Valid: false},
+			),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := transformFailedPayoutDTOToModel(tc.payout)
+
+			if *result != *tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
 		})
 	}
 }
@@ -554,21 +775,24 @@ func TestTransformFeeDTOToModel(t *testing.T) {
 				Description: "billing",
 				Created:     1627849100,
 				Amount:      -1000,
+				Currency:    "ron",
 			},
 			payoutID: "po_321654",
 			expected: models.NewFee(
 				"txn_fee_789456",
 				"billing",
 				uint64(1627849100),
-				uint32(1000),
+				int64(1000),
+				"ron",
 				sql.NullString{String: "po_321654", Valid: true},
+				sql.NullString{String: "evt_fee_789456", Valid: true},
 			),
 		},
 	}
 
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
-			result := transformFeeDTOToModel(tc.transaction, tc.payoutID)
+			result := transformFeeDTOToModel(tc.transaction, tc.payoutID, "evt_fee_789456")
 
 			if result.ID != tc.expected.ID {
 				t.Errorf("Expected ID %v, got %v", tc.expected.ID, result.ID)
@@ -579,9 +803,15 @@ func TestTransformFeeDTOToModel(t *testing.T) {
 			if result.Fee != tc.expected.Fee {
 				t.Errorf("Expected Fee %v, got %v", tc.expected.Fee, result.Fee)
 			}
+			if result.Currency != tc.expected.Currency {
+				t.Errorf("Expected Currency %v, got %v", tc.expected.Currency, result.Currency)
+			}
 			if result.PayoutID != tc.expected.PayoutID {
 				t.Errorf("Expected PayoutID %v, got %v", tc.expected.PayoutID, result.PayoutID)
 			}
+			if result.EventID != tc.expected.EventID {
+				t.Errorf("Expected EventID %v, got %v", tc.expected.EventID, result.EventID)
+			}
 		})
 	}
 }
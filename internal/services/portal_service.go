@@ -0,0 +1,205 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/mail"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
+	"github.com/signintech/gopdf"
+)
+
+type PortalRepository interface {
+	AuditRepository
+	GetDonorDonations(email string, start, end int64) ([]*models.Donation, error)
+	GetOrganisation() (*models.Organisation, error)
+}
+
+// DonorDocuments generates the documents a donor can download from the portal.
+type DonorDocuments interface {
+	GenerateInvoice(id, lang string) (*gopdf.GoPdf, error)
+	GenerateDonorStatement(email, year, lang string) (*gopdf.GoPdf, error)
+}
+
+type PortalMailer interface {
+	ComposePortalLink(data *dto.PortalLinkEmailData, lang string) (*dto.EmailMessage, error)
+	Send(message *dto.EmailMessage) error
+}
+
+// PortalService lets donors download their own documents without an account. A donor
+// asks for a magic link by email; the signed link is all the portal trusts afterwards.
+type PortalService struct {
+	repo      PortalRepository
+	documents DonorDocuments
+	mailer    PortalMailer
+	secret    []byte
+	baseURL   string
+	ttl       time.Duration
+	location  *time.Location
+	// pending counts the links still being sent.
+	pending sync.WaitGroup
+}
+
+func NewPortalService(repo PortalRepository, documents DonorDocuments, mailer PortalMailer, secret []byte, baseURL string, ttl time.Duration, location *time.Location) *PortalService {
+	return &PortalService{
+		repo:      repo,
+		documents: documents,
+		mailer:    mailer,
+		secret:    secret,
+		baseURL:   baseURL,
+		ttl:       ttl,
+		location:  location,
+	}
+}
+
+// RequestLink emails a magic link to a donor, in lang. Only the email is checked before
+// it returns; looking the donor up and sending happen in the background, so the caller
+// cannot tell from the answer or its timing whether the email ever donated, and the
+// portal does not reveal who our donors are.
+func (s *PortalService) RequestLink(email, lang string) error {
+	email, err := normalisePortalEmail(email)
+	if err != nil {
+		return err
+	}
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if err := s.sendLink(email, i18n.Resolve(lang)); err != nil {
+			log.Printf("Portal link error: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// sendLink emails the magic link if the email belongs to a donor, and does nothing otherwise.
+func (s *PortalService) sendLink(email, lang string) error {
+	donations, err := s.repo.GetDonorDonations(email, 0, math.MaxInt64)
+	if err != nil {
+		return fmt.Errorf("fetch donor donations failed: %w", err)
+	}
+	if len(donations) == 0 {
+		return nil
+	}
+	organisation, err := s.repo.GetOrganisation()
+	if err != nil {
+		return fmt.Errorf("fetch organisation failed: %w", err)
+	}
+
+	expires := time.Now().Add(s.ttl)
+	link := s.baseURL + "/portal/documents?token=" + url.QueryEscape(signPortalToken(s.secret, email, expires))
+	data := dto.NewPortalLinkEmailData(email, link, expires.In(s.location).Format("02 Jan 2006 15:04"), organisation.Name, organisation.Email)
+	message, err := s.mailer.ComposePortalLink(data, lang)
+	if err != nil {
+		return fmt.Errorf("compose email failed: %w", err)
+	}
+	if err = s.mailer.Send(message); err != nil {
+		return fmt.Errorf("send portal link failed: %w", err)
+	}
+	return nil
+}
+
+// Documents lists the invoices and statement years of the donor a token was signed for.
+func (s *PortalService) Documents(token string) (*dto.PortalDocuments, error) {
+	email, err := verifyPortalToken(s.secret, token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	donations, err := s.repo.GetDonorDonations(email, 0, math.MaxInt64)
+	if err != nil {
+		return nil, fmt.Errorf("fetch donor donations failed: %w", err)
+	}
+	return transformToPortalDocuments(email, donations, s.location), nil
+}
+
+// Invoice renders the invoice of a donation, if it is one Documents lists for the donor
+// the token was signed for, made from any of their merged emails. The download is audited
+// for the donor's email and IP.
+func (s *PortalService) Invoice(token, donationID, lang, ip string) (*gopdf.GoPdf, error) {
+	email, err := verifyPortalToken(s.secret, token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	donations, err := s.repo.GetDonorDonations(email, 0, math.MaxInt64)
+	if err != nil {
+		return nil, fmt.Errorf("fetch donor donations failed: %w", err)
+	}
+	if !slices.ContainsFunc(donations, func(donation *models.Donation) bool { return donation.ID == donationID }) {
+		return nil, custom_errors.NewValidationError(constants.ErrPortalDocumentNotFound, donationID)
+	}
+	pdf, err := s.documents.GenerateInvoice(donationID, lang)
+	if err != nil {
+		return nil, err
+	}
+	if err = recordAudit(s.repo, models.Actor{Username: email, IP: ip}, models.AuditDownload, "donation", donationID, nil, nil); err != nil {
+		return nil, err
+	}
+	return pdf, nil
+}
+
+// Statement renders the annual statement of the donor the token was signed for, auditing
+// the download as Invoice does.
+func (s *PortalService) Statement(token, year, lang, ip string) (*gopdf.GoPdf, error) {
+	email, err := verifyPortalToken(s.secret, token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	pdf, err := s.documents.GenerateDonorStatement(email, year, lang)
+	if err != nil {
+		return nil, err
+	}
+	if err = recordAudit(s.repo, models.Actor{Username: email, IP: ip}, models.AuditDownload, "statement", statementReference(year, email), nil, nil); err != nil {
+		return nil, err
+	}
+	return pdf, nil
+}
+
+// statementReference identifies a donor's statement of a year in the audit log.
+func statementReference(year, email string) string {
+	return year + "/" + email
+}
+
+// normalisePortalEmail returns the bare, lowercased address, which tokens are signed for.
+func normalisePortalEmail(email string) (string, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return "", custom_errors.NewValidationError(constants.ErrPortalEmailInvalid, email)
+	}
+	return strings.ToLower(address.Address), nil
+}
+
+// transformToPortalDocuments lists invoices newest first, and the years with donations as
+// the statements available.
+func transformToPortalDocuments(email string, donationModels []*models.Donation, location *time.Location) *dto.PortalDocuments {
+	var invoices []*dto.PortalInvoice
+	years := make(map[string]bool)
+	for i := len(donationModels) - 1; i >= 0; i-- {
+		donation := donationModels[i]
+		years[strconv.Itoa(time.Unix(int64(donation.Created), 0).In(location).Year())] = true
+		invoices = append(invoices, dto.NewPortalInvoice(
+			donation.ID,
+			formatInvoiceNumber(donation),
+			formatDate(donation.Created, location),
+			money.New(donation.Gross, donation.Currency).String(),
+		))
+	}
+
+	statementYears := make([]string, 0, len(years))
+	for year := range years {
+		statementYears = append(statementYears, year)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(statementYears)))
+	return dto.NewPortalDocuments(email, invoices, statementYears)
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+func TestVerifyPortalToken(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+	token := signPortalToken(secret, "ion@example.com", now.Add(time.Hour))
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := signPortalToken(secret, "maria@example.com", now.Add(time.Hour))
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	testCases := map[string]struct {
+		token         string
+		secret        []byte
+		now           time.Time
+		expectedEmail string
+		expectedErr   string
+	}{
+		"valid":           {token: token, secret: secret, now: now, expectedEmail: "ion@example.com"},
+		"expired":         {token: token, secret: secret, now: now.Add(time.Hour), expectedErr: constants.ErrPortalLinkExpired},
+		"otherSecret":     {token: token, secret: []byte("fedcba9876543210fedcba9876543210"), now: now, expectedErr: constants.ErrPortalLinkInvalid},
+		"swappedEmail":    {token: forgedPayload + "." + signature, secret: secret, now: now, expectedErr: constants.ErrPortalLinkInvalid},
+		"tamperedPayload": {token: payload + "A." + signature, secret: secret, now: now, expectedErr: constants.ErrPortalLinkInvalid},
+		"noSignature":     {token: payload, secret: secret, now: now, expectedErr: constants.ErrPortalLinkInvalid},
+		"empty":           {token: "", secret: secret, now: now, expectedErr: constants.ErrPortalLinkInvalid},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			email, err := verifyPortalToken(tc.secret, tc.token, tc.now)
+			if tc.expectedErr != "" {
+				var validationError *custom_errors.ValidationError
+				if !errors.As(err, &validationError) || err.Error() != tc.expectedErr {
+					t.Fatalf("Expected validation error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if email != tc.expectedEmail {
+				t.Errorf("Expected email %v, got %v", tc.expectedEmail, email)
+			}
+		})
+	}
+}
+
+type fakePortalRepository struct {
+	donations []*models.Donation
+}
+
+func (r fakePortalRepository) GetDonorDonations(email string, start, end int64) (donations []*models.Donation, err error) {
+	for _, donation := range r.donations {
+		if strings.EqualFold(donation.ClientEmail, email) && int64(donation.Created) >= start && int64(donation.Created) < end {
+			donations = append(donations, donation)
+		}
+	}
+	return
+}
+
+func (r fakePortalRepository) InsertAuditEntry(entry *models.AuditEntry, before, after interface{}) error {
+	return nil
+}
+
+func (r fakePortalRepository) GetOrganisation() (*models.Organisation, error) {
+	return &models.Organisation{Name: "Asociația Hintermann"}, nil
+}
+
+func TestPortalService(t *testing.T) {
+	repo := fakePortalRepository{donations: []*models.Donation{
+		{ID: "txn_1", Created: 1700000000, Gross: 10000, Currency: "ron", ClientEmail: "Ion@Example.com"},
+		{ID: "txn_2", Created: 1727000000, Gross: 5000, Currency: "ron", ClientEmail: "ion@example.com"},
+		{ID: "txn_3", Created: 1727100000, Gross: 2000, Currency: "ron", ClientEmail: "maria@example.com"},
+	}}
+	mailer := &fakeMailer{}
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	service := NewPortalService(repo, fakeDocumentGenerator{}, mailer, secret, "https://app.example.org", time.Hour, time.UTC)
+
+	var validationError *custom_errors.ValidationError
+	if err := service.RequestLink("not an email", "ro"); !errors.As(err, &validationError) {
+		t.Errorf("Expected a validation error for an invalid email, got %v", err)
+	}
+	err := service.RequestLink("nobody@example.com", "ro")
+	service.pending.Wait()
+	if err != nil || len(mailer.sent) != 0 {
+		t.Errorf("Expected no link sent to an email that never donated, got %v and %d emails", err, len(mailer.sent))
+	}
+	if err = service.RequestLink(" ION@example.com ", "en"); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	service.pending.Wait()
+	if len(mailer.sent) != 1 || mailer.sent[0].To != "ion@example.com" || mailer.sent[0].Text != "en" {
+		t.Fatalf("Expected one link sent to ion@example.com in English, got %+v", mailer.sent)
+	}
+
+	link, err := url.Parse(mailer.sent[0].Subject)
+	if err != nil || link.Host != "app.example.org" || link.Path != "/portal/documents" {
+		t.Fatalf("Expected a link to the portal, got %v", mailer.sent[0].Subject)
+	}
+	token := link.Query().Get("token")
+
+	documents, err := service.Documents(token)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(documents.Invoices) != 2 || documents.Invoices[0].ID != "txn_2" || documents.Invoices[1].ID != "txn_1" {
+		t.Errorf("Expected the donor's invoices newest first, got %+v", documents.Invoices)
+	}
+	if strings.Join(documents.Years, ",") != "2024,2023" {
+		t.Errorf("Expected statements for 2024 and 2023, got %v", documents.Years)
+	}
+
+	if _, err = service.Invoice(token, "txn_1", "", "192.0.2.1"); err != nil {
+		t.Errorf("Expected the donor's invoice, got %v", err)
+	}
+	if _, err = service.Invoice(token, "txn_3", "", "192.0.2.1"); !errors.As(err, &validationError) {
+		t.Errorf("Expected another donor's invoice to be refused, got %v", err)
+	}
+	if _, err = service.Invoice(token, "txn_missing", "", "192.0.2.1"); !errors.As(err, &validationError) {
+		t.Errorf("Expected an unknown invoice to be refused, got %v", err)
+	}
+	if _, err = service.Statement(token, "2024", "", "192.0.2.1"); err != nil {
+		t.Errorf("Expected the donor's statement, got %v", err)
+	}
+	if _, err = service.Statement("forged."+token, "2024", "", "192.0.2.1"); !errors.As(err, &validationError) {
+		t.Errorf("Expected a forged token to be refused, got %v", err)
+	}
+}
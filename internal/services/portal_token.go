@@ -0,0 +1,54 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+)
+
+// signPortalToken returns a magic link token for email, valid until expires. The token is
+// the email and expiry, base64url encoded, followed by their HMAC-SHA256 under secret, so
+// nothing is stored and a token cannot be altered or extended.
+func signPortalToken(secret []byte, email string, expires time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(email + "\n" + strconv.FormatInt(expires.Unix(), 10)))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(portalSignature(secret, payload))
+}
+
+// verifyPortalToken returns the email a token was signed for, if it is authentic and has
+// not expired at now.
+func verifyPortalToken(secret []byte, token string, now time.Time) (string, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return "", custom_errors.NewValidationError(constants.ErrPortalLinkInvalid)
+	}
+	decodedSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decodedSignature, portalSignature(secret, payload)) {
+		return "", custom_errors.NewValidationError(constants.ErrPortalLinkInvalid)
+	}
+
+	decodedPayload, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", custom_errors.NewValidationError(constants.ErrPortalLinkInvalid)
+	}
+	email, expires, found := strings.Cut(string(decodedPayload), "\n")
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if !found || err != nil || email == "" {
+		return "", custom_errors.NewValidationError(constants.ErrPortalLinkInvalid)
+	}
+	if now.Unix() >= expiresUnix {
+		return "", custom_errors.NewValidationError(constants.ErrPortalLinkExpired)
+	}
+	return email, nil
+}
+
+func portalSignature(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/stripe/stripe-go/v79"
+)
+
+type RefundService struct {
+	repo    WebhookRepository
+	gateway StripeGateway
+}
+
+func NewRefundService(repo WebhookRepository, gateway StripeGateway) *RefundService {
+	return &RefundService{repo: repo, gateway: gateway}
+}
+
+func (s *RefundService) ProcessRefund(charge *stripe.Charge, eventID string) (err error) {
+	if err = validateRefundedCharge(charge); err != nil {
+		return fmt.Errorf("refunded charge validation error: %w", err)
+	}
+
+	refunds, err := s.gateway.ListChargeRefunds(charge.ID)
+	if err != nil {
+		return fmt.Errorf("charge refunds fetch failed: %w", err)
+	}
+
+	for _, refund := range refunds {
+		if refund.Status != "succeeded" {
+			continue
+		}
+		if err = validateRefundTransaction(refund.BalanceTransaction); err != nil {
+			return fmt.Errorf("refund transaction validation failed for %s: %w", refund.ID, err)
+		}
+
+		refundModel := transformRefundDTOToModel(refund.BalanceTransaction, charge.BalanceTransaction.ID, "", eventID)
+		if err = s.repo.InsertRefund(refundModel); err != nil {
+			return fmt.Errorf("database refund insertion failed: %w", err)
+		}
+	}
+	return
+}
+
+func transformRefundDTOToModel(transaction *stripe.BalanceTransaction, donationID, payoutID, eventID string) *models.Refund {
+	return models.NewRefund(
+		transaction.ID,
+		uint64(transaction.Created),
+		-transaction.Amount,
+		-transaction.Fee,
+		-transaction.Net,
+		string(transaction.Currency),
+		donationID,
+		toNullString(payoutID),
+		toNullString(eventID),
+	)
+}
+
+func transformUpdateRefundDTOToModel(transactionID, payoutID string) *models.Refund {
+	return models.NewRefund(transactionID, 0, 0, 0, 0, "", "", sql.NullString{String: payoutID, Valid: true}, sql.NullString{Valid: false})
+}
+
+func validateRefundedCharge(charge *stripe.Charge) error {
+	if charge == nil {
+		return fmt.Errorf(constants.ErrChargeMissing)
+	}
+	if charge.AmountRefunded <= 0 {
+		return fmt.Errorf(constants.ErrRefundedChargeNotRefunded)
+	}
+	if charge.BalanceTransaction == nil {
+		return fmt.Errorf(constants.ErrTransactionMissing)
+	}
+	if charge.BalanceTransaction.ID == "" {
+		return fmt.Errorf(constants.ErrTransactionIDMissing)
+	}
+	return nil
+}
+
+func validateRelatedRefund(refund *stripe.Refund) error {
+	if refund == nil {
+		return fmt.Errorf(constants.ErrRefundMissing)
+	}
+	if refund.Charge == nil {
+		return fmt.Errorf(constants.ErrRefundChargeMissing)
+	}
+	if refund.Charge.BalanceTransaction == nil {
+		return fmt.Errorf(constants.ErrTransactionMissing)
+	}
+	if refund.Charge.BalanceTransaction.ID == "" {
+		return fmt.Errorf(constants.ErrTransactionIDMissing)
+	}
+	return nil
+}
+
+func validateRefundTransaction(transaction *stripe.BalanceTransaction) error {
+	if transaction == nil {
+		return fmt.Errorf(constants.ErrTransactionMissing)
+	}
+	if transaction.Type != "refund" {
+		return fmt.Errorf(constants.ErrRefundTransactionTypeInvalid)
+	}
+	if transaction.ID == "" {
+		return fmt.Errorf(constants.ErrTransactionIDMissing)
+	}
+	if transaction.Created <= 0 {
+		return fmt.Errorf(constants.ErrTransactionCreatedInvalid)
+	}
+	if transaction.Amount >= 0 {
+		return fmt.Errorf(constants.ErrRefundTransactionAmountInvalid)
+	}
+	if transaction.Fee > 0 {
+		return fmt.Errorf(constants.ErrRefundTransactionFeeInvalid)
+	}
+	if transaction.Net >= 0 {
+		return fmt.Errorf(constants.ErrRefundTransactionNetInvalid)
+	}
+	if transaction.Source == nil {
+		return fmt.Errorf(constants.ErrRefundTransactionSourceMissing)
+	}
+	if transaction.Source.ID == "" {
+		return fmt.Errorf(constants.ErrRefundTransactionSourceIDMissing)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/stripe/stripe-go/v79"
+)
+
+func TestValidateRefundedCharge(t *testing.T) {
+	testCases := map[string]struct {
+		input    *stripe.Charge
+		expected string
+	}{
+		"validCharge": {
+			&stripe.Charge{
+				ID:                 "ch_123456789",
+				AmountRefunded:     1000,
+				BalanceTransaction: &stripe.BalanceTransaction{ID: "txn_123456"},
+			},
+			"",
+		},
+		"chargeMissing":        {nil, constants.ErrChargeMissing},
+		"notRefunded":          {&stripe.Charge{ID: "ch_123456789"}, constants.ErrRefundedChargeNotRefunded},
+		"transactionMissing":   {&stripe.Charge{ID: "ch_123456789", AmountRefunded: 1000}, constants.ErrTransactionMissing},
+		"transactionIDMissing": {&stripe.Charge{ID: "ch_123456789", AmountRefunded: 1000, BalanceTransaction: &stripe.BalanceTransaction{}}, constants.ErrTransactionIDMissing},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateRefundedCharge(tc.input)
+			if tc.expected == "" && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+			if tc.expected != "" && (err == nil || err.Error() != tc.expected) {
+				t.Errorf("Expected error: %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateRelatedRefund(t *testing.T) {
+	testCases := map[string]struct {
+		input    *stripe.Refund
+		expected string
+	}{
+		"validRefund": {
+			&stripe.Refund{
+				ID:     "re_123456",
+				Charge: &stripe.Charge{ID: "ch_123456789", BalanceTransaction: &stripe.BalanceTransaction{ID: "txn_123456"}},
+			},
+			"",
+		},
+		"refundMissing":        {nil, constants.ErrRefundMissing},
+		"chargeMissing":        {&stripe.Refund{ID: "re_123456"}, constants.ErrRefundChargeMissing},
+		"transactionMissing":   {&stripe.Refund{ID: "re_123456", Charge: &stripe.Charge{ID: "ch_123456789"}}, constants.ErrTransactionMissing},
+		"transactionIDMissing": {&stripe.Refund{ID: "re_123456", Charge: &stripe.Charge{ID: "ch_123456789", BalanceTransaction: &stripe.BalanceTransaction{}}}, constants.ErrTransactionIDMissing},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateRelatedRefund(tc.input)
+			if tc.expected == "" && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+			if tc.expected != "" && (err == nil || err.Error() != tc.expected) {
+				t.Errorf("Expected error: %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateRefundTransaction(t *testing.T) {
+	testCases := map[string]struct {
+		input    *stripe.BalanceTransaction
+		expected string
+	}{
+		"validTransaction": {
+			&stripe.BalanceTransaction{
+				ID:      "txn_refund_123456",
+				Type:    "refund",
+				Created: 1234567890,
+				Amount:  -1000,
+				Fee:     0,
+				Net:     -1000,
+				Source:  &stripe.BalanceTransactionSource{ID: "re_123456"},
+			},
+			"",
+		},
+		"transactionMissing": {nil, constants.ErrTransactionMissing},
+		"typeInvalid":        {&stripe.BalanceTransaction{ID: "txn_refund_123456", Type: "charge"}, constants.ErrRefundTransactionTypeInvalid},
+		"IDMissing":          {&stripe.BalanceTransaction{Type: "refund"}, constants.ErrTransactionIDMissing},
+		"createdInvalid":     {&stripe.BalanceTransaction{ID: "txn_refund_123456", Type: "refund"}, constants.ErrTransactionCreatedInvalid},
+		"amountInvalid":      {&stripe.BalanceTransaction{ID: "txn_refund_123456", Type: "refund", Created: 1234567890, Amount: 1000}, constants.ErrRefundTransactionAmountInvalid},
+		"feeInvalid":         {&stripe.BalanceTransaction{ID: "txn_refund_123456", Type: "refund", Created: 1234567890, Amount: -1000, Fee: 100}, constants.ErrRefundTransactionFeeInvalid},
+		"netInvalid":         {&stripe.BalanceTransaction{ID: "txn_refund_123456", Type: "refund", Created: 1234567890, Amount: -1000, Net: 0}, constants.ErrRefundTransactionNetInvalid},
+		"sourceMissing":      {&stripe.BalanceTransaction{ID: "txn_refund_123456", Type: "refund", Created: 1234567890, Amount: -1000, Net: -1000}, constants.ErrRefundTransactionSourceMissing},
+		"sourceIDMissing":    {&stripe.BalanceTransaction{ID: "txn_refund_123456", Type: "refund", Created: 1234567890, Amount: -1000, Net: -1000, Source: &stripe.BalanceTransactionSource{}}, constants.ErrRefundTransactionSourceIDMissing},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validateRefundTransaction(tc.input)
+			if tc.expected == "" && err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+			if tc.expected != "" && (err == nil || err.Error() != tc.expected) {
+				t.Errorf("Expected error: %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestTransformRefundDTOToModel(t *testing.T) {
+	testCases := map[string]struct {
+		transaction *stripe.BalanceTransaction
+		donationID  string
+		payoutID    string
+		expected    *models.Refund
+	}{
+		"refundWithoutPayout": {
+			transaction: &stripe.BalanceTransaction{
+				ID:       "txn_refund_123456",
+				Created:  1627849100,
+				Amount:   -2500,
+				Fee:      0,
+				Net:      -2500,
+				Currency: "ron",
+			},
+			donationID: "txn_123456",
+			payoutID:   "",
+			expected: models.NewRefund(
+				"txn_refund_123456",
+				uint64(1627849100),
+				int64(2500),
+				int64(0),
+				int64(2500),
+				"ron",
+				"txn_123456",
+				sql.NullString{Valid: false},
+				sql.NullString{String: "evt_123456", Valid: true},
+			),
+		},
+		"refundWithPayout": {
+			transaction: &stripe.BalanceTransaction{
+				ID:       "txn_refund_654321",
+				Created:  1627849100,
+				Amount:   -2500,
+				Fee:      -100,
+				Net:      -2400,
+				Currency: "ron",
+			},
+			donationID: "txn_123456",
+			payoutID:   "po_321654",
+			expected: models.NewRefund(
+				"txn_refund_654321",
+				uint64(1627849100),
+				int64(2500),
+				int64(100),
+				int64(2400),
+				"ron",
+				"txn_123456",
+				sql.NullString{String: "po_321654", Valid: true},
+				sql.NullString{String: "evt_123456", Valid: true},
+			),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := transformRefundDTOToModel(tc.transaction, tc.donationID, tc.payoutID, "evt_123456")
+
+			if *result != *tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
+)
+
+const (
+	periodMonth   = "month"
+	periodQuarter = "quarter"
+	periodYear    = "year"
+	periodRange   = "range"
+)
+
+var (
+	quarterPattern = regexp.MustCompile(`^(\d{4})-Q([1-4])$`)
+	yearPattern    = regexp.MustCompile(`^\d{4}$`)
+	rangePattern   = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})_(\d{4}-\d{2}-\d{2})$`)
+)
+
+// reportPeriod is the span of local days a report covers, from start up to end.
+type reportPeriod struct {
+	kind  string
+	start time.Time
+	end   time.Time
+}
+
+// reportMonth holds the payouts of one month of a report and their subtotals.
+type reportMonth struct {
+	date    time.Time
+	gross   money.Money
+	fee     money.Money
+	net     money.Money
+	payouts []*models.Payout
+}
+
+// parseReportPeriod reads a month (2024-09), a quarter (2024-Q3), a year (2024) or an
+// inclusive range of days (2024-07-01_2024-09-30).
+func parseReportPeriod(period string, location *time.Location) (*reportPeriod, error) {
+	if date, err := validateMonthString(period); err == nil {
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, location)
+		return &reportPeriod{kind: periodMonth, start: start, end: start.AddDate(0, 1, 0)}, nil
+	}
+	if matches := quarterPattern.FindStringSubmatch(period); matches != nil {
+		year, _ := strconv.Atoi(matches[1])
+		quarter, _ := strconv.Atoi(matches[2])
+		start := time.Date(year, time.Month(quarter*3-2), 1, 0, 0, 0, 0, location)
+		return &reportPeriod{kind: periodQuarter, start: start, end: start.AddDate(0, 3, 0)}, nil
+	}
+	if yearPattern.MatchString(period) {
+		year, _ := strconv.Atoi(period)
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, location)
+		return &reportPeriod{kind: periodYear, start: start, end: start.AddDate(1, 0, 0)}, nil
+	}
+	if matches := rangePattern.FindStringSubmatch(period); matches != nil {
+		start, err := time.ParseInLocation("2006-01-02", matches[1], location)
+		if err != nil {
+			return nil, custom_errors.NewValidationError(constants.ErrReportPeriodInvalid, period)
+		}
+		last, err := time.ParseInLocation("2006-01-02", matches[2], location)
+		if err != nil {
+			return nil, custom_errors.NewValidationError(constants.ErrReportPeriodInvalid, period)
+		}
+		if last.Before(start) {
+			return nil, custom_errors.NewValidationError(constants.ErrReportRangeInvalid)
+		}
+		return &reportPeriod{kind: periodRange, start: start, end: last.AddDate(0, 0, 1)}, nil
+	}
+	return nil, custom_errors.NewValidationError(constants.ErrReportPeriodInvalid, period)
+}
+
+// getUnixTimestampsForPeriod bounds the period inclusively, like getUnixTimestampsForMonth.
+func getUnixTimestampsForPeriod(period *reportPeriod) (periodStart, periodEnd int64) {
+	return period.start.Unix(), period.end.Add(-time.Second).Unix()
+}
+
+// getPeriodDates formats the first and last day of the period and the day after it,
+// when the report is issued.
+func getPeriodDates(period *reportPeriod) (periodStart, periodEnd, emissionDate string) {
+	periodStart = period.start.Format("2 Jan, 2006")
+	periodEnd = period.end.Add(-time.Second).Format("2 Jan, 2006")
+	emissionDate = period.end.Format("2 Jan, 2006")
+	return
+}
+
+// splitReportMonths groups the payouts by the local month they were created in and adds
+// up each month. Months without payouts are left out.
+func splitReportMonths(period *reportPeriod, payoutModels []*models.Payout, location *time.Location) ([]*reportMonth, error) {
+	var months []*reportMonth
+	first := time.Date(period.start.Year(), period.start.Month(), 1, 0, 0, 0, 0, location)
+	for date := first; date.Before(period.end); date = date.AddDate(0, 1, 0) {
+		monthStart, monthEnd := getUnixTimestampsForMonth(date, location)
+		var payouts []*models.Payout
+		for _, payout := range payoutModels {
+			if int64(payout.Created) >= monthStart && int64(payout.Created) <= monthEnd {
+				payouts = append(payouts, payout)
+			}
+		}
+		if len(payouts) == 0 {
+			continue
+		}
+
+		gross, fee, net, err := monthlyReportSum(payouts)
+		if err != nil {
+			return nil, fmt.Errorf("%s subtotal failed: %w", date.Format("2006-01"), err)
+		}
+		months = append(months, &reportMonth{date: date, gross: gross, fee: fee, net: net, payouts: payouts})
+	}
+	return months, nil
+}
+
+// formatReportPeriod names the period in lang, e.g. "September 2024", "Q3 2024" or a
+// range of days.
+func formatReportPeriod(period *reportPeriod, lang string) string {
+	switch period.kind {
+	case periodMonth:
+		return formatReportMonth(period.start, lang)
+	case periodQuarter:
+		return i18n.T(lang, "report.quarter", (int(period.start.Month())+2)/3, period.start.Year())
+	case periodYear:
+		return period.start.Format("2006")
+	}
+	periodStart, periodEnd, _ := getPeriodDates(period)
+	return periodStart + " - " + periodEnd
+}
+
+func formatReportMonth(date time.Time, lang string) string {
+	return i18n.T(lang, "ui.month."+date.Format("01")) + " " + date.Format("2006")
+}
+
+// reportTitle keeps the monthly report title for single months.
+func reportTitle(period *reportPeriod, lang string) string {
+	if period.kind == periodMonth {
+		return i18n.T(lang, "document.monthlyReport.title")
+	}
+	return i18n.T(lang, "document.periodReport.title")
+}
+
+func transformToReportMonth(month *reportMonth, payouts []*dto.FormattedPayout, lang string) *dto.ReportMonth {
+	return dto.NewReportMonth(
+		formatReportMonth(month.date, lang),
+		month.gross.String(),
+		month.fee.String(),
+		month.net.String(),
+		payouts,
+	)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/internal/i18n"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+func TestParseReportPeriod(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+
+	testCases := map[string]struct {
+		input         string
+		expectedKind  string
+		expectedStart time.Time
+		expectedEnd   time.Time
+		expectError   bool
+	}{
+		"month":       {input: "2024-09", expectedKind: periodMonth, expectedStart: time.Date(2024, 9, 1, 0, 0, 0, 0, location), expectedEnd: time.Date(2024, 10, 1, 0, 0, 0, 0, location)},
+		"quarter":     {input: "2024-Q3", expectedKind: periodQuarter, expectedStart: time.Date(2024, 7, 1, 0, 0, 0, 0, location), expectedEnd: time.Date(2024, 10, 1, 0, 0, 0, 0, location)},
+		"lastQuarter": {input: "2024-Q4", expectedKind: periodQuarter, expectedStart: time.Date(2024, 10, 1, 0, 0, 0, 0, location), expectedEnd: time.Date(2025, 1, 1, 0, 0, 0, 0, location)},
+		"year":        {input: "2024", expectedKind: periodYear, expectedStart: time.Date(2024, 1, 1, 0, 0, 0, 0, location), expectedEnd: time.Date(2025, 1, 1, 0, 0, 0, 0, location)},
+		"range":       {input: "2024-07-15_2024-09-14", expectedKind: periodRange, expectedStart: time.Date(2024, 7, 15, 0, 0, 0, 0, location), expectedEnd: time.Date(2024, 9, 15, 0, 0, 0, 0, location)},
+		"singleDay":   {input: "2024-09-15_2024-09-15", expectedKind: periodRange, expectedStart: time.Date(2024, 9, 15, 0, 0, 0, 0, location), expectedEnd: time.Date(2024, 9, 16, 0, 0, 0, 0, location)},
+		"badMonth":    {input: "2024-13", expectError: true},
+		"badQuarter":  {input: "2024-Q5", expectError: true},
+		"badDay":      {input: "2024-02-30_2024-03-01", expectError: true},
+		"reversed":    {input: "2024-09-30_2024-07-01", expectError: true},
+		"empty":       {input: "", expectError: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			period, err := parseReportPeriod(tc.input, location)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if period.kind != tc.expectedKind || !period.start.Equal(tc.expectedStart) || !period.end.Equal(tc.expectedEnd) {
+				t.Errorf("Expected %v [%v, %v), got %v [%v, %v)", tc.expectedKind, tc.expectedStart, tc.expectedEnd, period.kind, period.start, period.end)
+			}
+		})
+	}
+}
+
+func TestSplitReportMonths(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+	period, err := parseReportPeriod("2024-Q3", location)
+	if err != nil {
+		t.Fatalf("Expected valid period, got %v", err)
+	}
+
+	payouts := []*models.Payout{
+		{ID: "payout1", Created: uint64(time.Date(2024, 7, 10, 12, 0, 0, 0, location).Unix()), Gross: 10000, Fee: 210, Net: 9790, Currency: "ron"},
+		{ID: "payout2", Created: uint64(time.Date(2024, 7, 31, 23, 30, 0, 0, location).Unix()), Gross: 5000, Fee: 105, Net: 4895, Currency: "ron"},
+		{ID: "payout3", Created: uint64(time.Date(2024, 9, 1, 0, 30, 0, 0, location).Unix()), Gross: 20000, Fee: 420, Net: 19580, Currency: "ron"},
+		{ID: "payout4", Created: uint64(time.Date(2024, 9, 20, 12, 0, 0, 0, location).Unix()), Gross: 3000, Fee: 63, Net: 2937, Currency: "ron", Status: models.PayoutFailed},
+	}
+
+	months, err := splitReportMonths(period, payouts, location)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	expected := []struct {
+		month   string
+		gross   string
+		net     string
+		payouts int
+	}{
+		{month: "2024-07", gross: "150,00 lei", net: "146,85 lei", payouts: 2},
+		{month: "2024-09", gross: "200,00 lei", net: "195,80 lei", payouts: 2},
+	}
+	if len(months) != len(expected) {
+		t.Fatalf("Expected %d months, got %d", len(expected), len(months))
+	}
+	for i, month := range months {
+		if month.date.Format("2006-01") != expected[i].month || month.gross.String() != expected[i].gross ||
+			month.net.String() != expected[i].net || len(month.payouts) != expected[i].payouts {
+			t.Errorf("Expected %v, got %v %v %v with %d payouts", expected[i], month.date.Format("2006-01"), month.gross, month.net, len(month.payouts))
+		}
+	}
+}
+
+func TestFormatReportPeriod(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		lang     string
+		expected string
+	}{
+		"month":   {input: "2024-09", lang: i18n.English, expected: "September 2024"},
+		"quarter": {input: "2024-Q3", lang: i18n.Romanian, expected: "Trimestrul 3 2024"},
+		"year":    {input: "2024", lang: i18n.English, expected: "2024"},
+		"range":   {input: "2024-07-15_2024-09-14", lang: i18n.English, expected: "15 Jul, 2024 - 14 Sep, 2024"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			period, err := parseReportPeriod(tc.input, time.UTC)
+			if err != nil {
+				t.Fatalf("Expected valid period, got %v", err)
+			}
+			if result := formatReportPeriod(period, tc.lang); result != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/dto"
+	"github.com/diother/go-invoices/internal/models"
+	"github.com/diother/go-invoices/internal/money"
+)
+
+// searchResultLimit caps the results; more terms or filters narrow them down.
+const searchResultLimit = 100
+
+type SearchRepository interface {
+	Search(filter models.SearchFilter, limit int) ([]*models.SearchResult, error)
+}
+
+// SearchService finds donations, fees and payouts by donor, email, ID, invoice number or
+// fee description, within an amount and date range.
+type SearchService struct {
+	repo     SearchRepository
+	location *time.Location
+}
+
+func NewSearchService(repo SearchRepository, location *time.Location) *SearchService {
+	return &SearchService{repo: repo, location: location}
+}
+
+// Search returns the newest results matching every word of the query. Amounts are in major
+// units and dates are local days, both bounds included. Nothing to search for finds
+// nothing.
+func (s *SearchService) Search(query, minAmount, maxAmount, from, to string) ([]*dto.FormattedSearchResult, error) {
+	filter, err := parseSearchFilter(query, minAmount, maxAmount, from, to, s.location)
+	if err != nil {
+		return nil, err
+	}
+	if len(filter.Terms) == 0 && !filter.Start.Valid && !filter.End.Valid && !filter.MinAmount.Valid && !filter.MaxAmount.Valid {
+		return nil, nil
+	}
+
+	resultModels, err := s.repo.Search(filter, searchResultLimit)
+	if err != nil {
+		return nil, fmt.Errorf("database search failed: %w", err)
+	}
+	return transformSearchResultModelsToDTOs(resultModels, s.location), nil
+}
+
+func parseSearchFilter(query, minAmount, maxAmount, from, to string, location *time.Location) (filter models.SearchFilter, err error) {
+	filter.Terms = strings.Fields(query)
+
+	if filter.MinAmount, err = parseSearchAmount(minAmount); err != nil {
+		return models.SearchFilter{}, err
+	}
+	if filter.MaxAmount, err = parseSearchAmount(maxAmount); err != nil {
+		return models.SearchFilter{}, err
+	}
+	if filter.MinAmount.Valid && filter.MaxAmount.Valid && filter.MinAmount.Int64 > filter.MaxAmount.Int64 {
+		return models.SearchFilter{}, custom_errors.NewValidationError(constants.ErrSearchRangeInvalid)
+	}
+
+	if filter.Start, err = parseSearchDate(from, location, 0); err != nil {
+		return models.SearchFilter{}, err
+	}
+	if filter.End, err = parseSearchDate(to, location, 1); err != nil {
+		return models.SearchFilter{}, err
+	}
+	if filter.Start.Valid && filter.End.Valid && filter.Start.Int64 >= filter.End.Int64 {
+		return models.SearchFilter{}, custom_errors.NewValidationError(constants.ErrSearchRangeInvalid)
+	}
+	return filter, nil
+}
+
+var searchAmountPattern = regexp.MustCompile(`^(\d{1,12})(?:[.,](\d{1,2}))?$`)
+
+// parseSearchAmount reads 150, 150,5 or 150.50 into minor units. Searched amounts have two
+// decimals, as the currencies the organisation receives do.
+func parseSearchAmount(value string) (sql.NullInt64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return sql.NullInt64{}, nil
+	}
+	parts := searchAmountPattern.FindStringSubmatch(value)
+	if parts == nil {
+		return sql.NullInt64{}, custom_errors.NewValidationError(constants.ErrSearchAmountInvalid, value)
+	}
+	whole, _ := strconv.ParseInt(parts[1], 10, 64)
+	cents, _ := strconv.ParseInt((parts[2] + "00")[:2], 10, 64)
+	return sql.NullInt64{Int64: whole*100 + cents, Valid: true}, nil
+}
+
+// parseSearchDate returns the start of the local day, or of the day days later.
+func parseSearchDate(value string, location *time.Location, days int) (sql.NullInt64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return sql.NullInt64{}, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, location)
+	if err != nil {
+		return sql.NullInt64{}, custom_errors.NewValidationError(constants.ErrSearchDateInvalid, value)
+	}
+	return sql.NullInt64{Int64: date.AddDate(0, 0, days).Unix(), Valid: true}, nil
+}
+
+func transformSearchResultModelsToDTOs(resultModels []*models.SearchResult, location *time.Location) (results []*dto.FormattedSearchResult) {
+	for _, result := range resultModels {
+		results = append(results, dto.NewFormattedSearchResult(
+			result.Kind,
+			result.Reference,
+			result.PayoutID.String,
+			result.Title,
+			formatDate(result.Created, location),
+			money.New(result.Amount, result.Currency).String(),
+		))
+	}
+	return
+}
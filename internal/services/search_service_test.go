@@ -0,0 +1,66 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diother/go-invoices/internal/constants"
+	"github.com/diother/go-invoices/internal/custom_errors"
+	"github.com/diother/go-invoices/internal/models"
+)
+
+func TestParseSearchFilter(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Bucharest")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+	valid := func(n int64) sql.NullInt64 { return sql.NullInt64{Int64: n, Valid: true} }
+
+	testCases := map[string]struct {
+		query, minAmount, maxAmount, from, to string
+		expected                              models.SearchFilter
+		expectedErr                           string
+	}{
+		"empty":       {},
+		"terms":       {query: "  Ion   HNT-2024-000007 ", expected: models.SearchFilter{Terms: []string{"Ion", "HNT-2024-000007"}}},
+		"amounts":     {minAmount: "150", maxAmount: "1200,5", expected: models.SearchFilter{MinAmount: valid(15000), MaxAmount: valid(120050)}},
+		"pointAmount": {minAmount: "0.05", expected: models.SearchFilter{MinAmount: valid(5)}},
+		"dates": {from: "2024-09-01", to: "2024-09-30", expected: models.SearchFilter{
+			Start: valid(time.Date(2024, 9, 1, 0, 0, 0, 0, location).Unix()),
+			End:   valid(time.Date(2024, 10, 1, 0, 0, 0, 0, location).Unix()),
+		}},
+		"sameDay":           {from: "2024-09-01", to: "2024-09-01", expected: models.SearchFilter{Start: valid(1725138000), End: valid(1725224400)}},
+		"invalidAmount":     {minAmount: "12,345", expectedErr: `amount "12,345" is not valid, use e.g. 150 or 150,50`},
+		"negativeAmount":    {maxAmount: "-5", expectedErr: `amount "-5" is not valid, use e.g. 150 or 150,50`},
+		"invertedAmounts":   {minAmount: "100", maxAmount: "50", expectedErr: constants.ErrSearchRangeInvalid},
+		"invalidDate":       {from: "01.09.2024", expectedErr: `date "01.09.2024" is not valid, use YYYY-MM-DD`},
+		"invertedDateRange": {from: "2024-09-02", to: "2024-09-01", expectedErr: constants.ErrSearchRangeInvalid},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			filter, err := parseSearchFilter(tc.query, tc.minAmount, tc.maxAmount, tc.from, tc.to, location)
+			if tc.expectedErr != "" {
+				var validationError *custom_errors.ValidationError
+				if !errors.As(err, &validationError) || err.Error() != tc.expectedErr {
+					t.Fatalf("Expected validation error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if strings.Join(filter.Terms, " ") != strings.Join(tc.expected.Terms, " ") {
+				t.Errorf("Expected terms %q, got %q", tc.expected.Terms, filter.Terms)
+			}
+			filter.Terms, tc.expected.Terms = nil, nil
+			if !reflect.DeepEqual(filter, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, filter)
+			}
+		})
+	}
+}
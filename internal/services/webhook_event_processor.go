@@ -0,0 +1,70 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/stripe/stripe-go/v79"
+)
+
+// WebhookEventProcessor routes a queued Stripe event to the service that records it.
+// Its services share one repository, so every worker needs its own processor.
+type WebhookEventProcessor struct {
+	donation *DonationService
+	payout   *PayoutService
+	refund   *RefundService
+	dispute  *DisputeService
+}
+
+func NewWebhookEventProcessor(repo WebhookRepository, gateway StripeGateway, numbering *InvoiceNumbering) *WebhookEventProcessor {
+	return &WebhookEventProcessor{
+		donation: NewDonationService(repo, gateway, numbering),
+		payout:   NewPayoutService(repo, gateway, numbering),
+		refund:   NewRefundService(repo, gateway),
+		dispute:  NewDisputeService(repo, gateway),
+	}
+}
+
+func (p *WebhookEventProcessor) ProcessEvent(event *stripe.Event) error {
+	switch event.Type {
+	case "charge.updated":
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return fmt.Errorf("invalid charge JSON: %w", err)
+		}
+		return p.donation.ProcessDonation(&charge, event.ID)
+
+	case "charge.refunded":
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return fmt.Errorf("invalid charge JSON: %w", err)
+		}
+		return p.refund.ProcessRefund(&charge, event.ID)
+
+	case "charge.dispute.created", "charge.dispute.funds_withdrawn", "charge.dispute.funds_reinstated", "charge.dispute.closed":
+		var dispute stripe.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return fmt.Errorf("invalid dispute JSON: %w", err)
+		}
+		return p.dispute.ProcessDispute(&dispute, event.ID)
+
+	case "payout.reconciliation_completed":
+		var payout stripe.Payout
+		if err := json.Unmarshal(event.Data.Raw, &payout); err != nil {
+			return fmt.Errorf("invalid payout JSON: %w", err)
+		}
+		return p.payout.ProcessPayout(&payout, event.ID)
+
+	case "payout.failed", "payout.canceled":
+		var payout stripe.Payout
+		if err := json.Unmarshal(event.Data.Raw, &payout); err != nil {
+			return fmt.Errorf("invalid payout JSON: %w", err)
+		}
+		return p.payout.ProcessFailedPayout(&payout, event.ID)
+
+	default:
+		log.Println("Unsupported event type:", event.Type)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/diother/go-invoices/internal/constants"
+)
+
+// FileStorage keeps documents as files under a root directory. Files are written once:
+// storing different content under an existing key fails.
+type FileStorage struct {
+	root string
+}
+
+func NewFileStorage(root string) *FileStorage {
+	return &FileStorage{root: root}
+}
+
+// Put stores content under key. Storing the same content again succeeds, so an issue
+// interrupted after the file was written can be retried.
+func (s *FileStorage) Put(key string, content []byte) (err error) {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o444)
+	if errors.Is(err, fs.ErrExist) {
+		existing, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read stored file: %w", err)
+		}
+		if !bytes.Equal(existing, content) {
+			return fmt.Errorf(constants.ErrStorageExists, key)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	if _, err = file.Write(content); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return file.Sync()
+}
+
+func (s *FileStorage) Get(key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored file: %w", err)
+	}
+	return content, nil
+}
+
+// path keeps keys inside the root, so a key can never name a file elsewhere.
+func (s *FileStorage) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf(constants.ErrStorageKeyInvalid, key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFileStorage(t *testing.T) {
+	storage := NewFileStorage(t.TempDir())
+	content := []byte("%PDF-1.4 document")
+
+	if err := storage.Put("donation/txn_1/v1.pdf", content); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	stored, err := storage.Get("donation/txn_1/v1.pdf")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !bytes.Equal(stored, content) {
+		t.Errorf("Expected %q, got %q", content, stored)
+	}
+
+	if err = storage.Put("donation/txn_1/v1.pdf", content); err != nil {
+		t.Errorf("Expected storing the same content again to succeed, got: %v", err)
+	}
+	if err = storage.Put("donation/txn_1/v1.pdf", []byte("changed")); err == nil {
+		t.Errorf("Expected overwriting with different content to fail")
+	}
+	if stored, _ = storage.Get("donation/txn_1/v1.pdf"); !bytes.Equal(stored, content) {
+		t.Errorf("Expected stored content to stay %q, got %q", content, stored)
+	}
+}
+
+func TestFileStorageKeys(t *testing.T) {
+	testCases := map[string]struct {
+		key         string
+		expectError bool
+	}{
+		"nested":   {key: "payout/txn_1/v1.pdf"},
+		"parent":   {key: "../v1.pdf", expectError: true},
+		"absolute": {key: "/etc/passwd", expectError: true},
+		"empty":    {key: "", expectError: true},
+	}
+
+	storage := NewFileStorage(t.TempDir())
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := storage.Put(tc.key, []byte("content"))
+
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error, but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Expected no error, but got: %v", err)
+			}
+		})
+	}
+}